@@ -3,16 +3,19 @@ package app
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/jonathanwamsley/banking/dto"
 	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
 	"github.com/jonathanwamsley/banking/service"
 )
 
 // AccountHandler connects account routing options to account services
 type AccountHandler struct {
 	service service.AccountService
+	stepUp  StepUp
 }
 
 // CreateAccount returns a new account for a customer on success
@@ -28,7 +31,7 @@ func (ah *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request)
 
 	result, err := ah.service.CreateAccount(accountRequest)
 	if err != nil {
-		writeResponse(w, err.Code, err.Message)
+		writeResponse(w, err.Code, err.AsMessage())
 		return
 	}
 	writeResponse(w, http.StatusCreated, result)
@@ -46,6 +49,19 @@ func (ah *AccountHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
 	writeResponse(w, http.StatusOK, result)
 }
 
+// GetAccountByID returns one account of a customer with its ETag
+func (ah *AccountHandler) GetAccountByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	account, err := ah.service.GetAccountByID(vars["customer_id"], vars["account_id"])
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	setETag(w, account.Version)
+	writeResponse(w, http.StatusOK, account)
+}
+
 // DeleteAccount uses a account type query and a customer_id to delete an account
 func (ah *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["customer_id"]
@@ -57,7 +73,13 @@ func (ah *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := ah.service.DeleteAccount(id, accountType)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+
+	err = ah.service.DeleteAccount(id, accountType, version)
 	if err != nil {
 		writeResponse(w, err.Code, err.Message)
 		return
@@ -66,6 +88,7 @@ func (ah *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request)
 }
 
 // MakeTransaction creates a transaction for a customer/account. Then the account is updated returning the new account balance.
+// Amounts above the step-up threshold need a one time password in the X-OTP header.
 func (ah AccountHandler) MakeTransaction(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	accountID := vars["account_id"]
@@ -79,6 +102,15 @@ func (ah AccountHandler) MakeTransaction(w http.ResponseWriter, r *http.Request)
 		//build the request object
 		request.AccountID = accountID
 		request.CustomerID = customerID
+		version, appError := ifMatchVersion(r)
+		if appError != nil {
+			writeResponse(w, appError.Code, appError.AsMessage())
+			return
+		}
+		request.Version = version
+		if !ah.stepUp.allows(w, r, request.Amount) {
+			return
+		}
 
 		// make transaction
 		account, appError := ah.service.MakeTransaction(request)
@@ -91,6 +123,145 @@ func (ah AccountHandler) MakeTransaction(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// MakeTransfer moves money from the account in the route to another account, returning both new balances.
+// Amounts above the step-up threshold need a one time password in the X-OTP header.
+func (ah AccountHandler) MakeTransfer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var request dto.TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	request.FromAccountID = vars["account_id"]
+	request.CustomerID = vars["customer_id"]
+	version, appError := ifMatchVersion(r)
+	if appError != nil {
+		writeResponse(w, appError.Code, appError.AsMessage())
+		return
+	}
+	request.Version = version
+	if !ah.stepUp.allows(w, r, request.Amount) {
+		return
+	}
+
+	transfer, appError := ah.service.Transfer(request)
+	if appError != nil {
+		writeResponse(w, appError.Code, appError.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusCreated, transfer)
+}
+
+// GetTransactions returns a page of an account's transaction history.
+//
+// Query parameters: cursor, limit, from, to (dates like 2021-03-31), type, min_amount, max_amount and sort (asc or desc)
+func (ah AccountHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	request := dto.TransactionHistoryRequest{
+		AccountID:       vars["account_id"],
+		CustomerID:      vars["customer_id"],
+		Cursor:          query.Get("cursor"),
+		Limit:           dto.DEFAULT_PAGE_SIZE,
+		From:            query.Get("from"),
+		To:              query.Get("to"),
+		TransactionType: query.Get("type"),
+		Sort:            dto.SORT_DESC,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			appError := errs.NewBadRequestError("limit must be a number")
+			writeResponse(w, appError.Code, appError.AsMessage())
+			return
+		}
+		request.Limit = parsed
+	}
+	if sort := query.Get("sort"); sort != "" {
+		request.Sort = sort
+	}
+	var appError *errs.AppError
+	if request.MinAmount, appError = amountParam(query.Get("min_amount"), "min_amount"); appError != nil {
+		writeResponse(w, appError.Code, appError.AsMessage())
+		return
+	}
+	if request.MaxAmount, appError = amountParam(query.Get("max_amount"), "max_amount"); appError != nil {
+		writeResponse(w, appError.Code, appError.AsMessage())
+		return
+	}
+
+	history, appError := ah.service.GetTransactionHistory(request)
+	if appError != nil {
+		writeResponse(w, appError.Code, appError.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, history)
+}
+
+// UpdateAccountStatus changes the status of a customer's account, it is an admin route
+func (ah AccountHandler) UpdateAccountStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var request dto.UpdateStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	version, appError := ifMatchVersion(r)
+	if appError != nil {
+		writeResponse(w, appError.Code, appError.AsMessage())
+		return
+	}
+	request.Version = version
+
+	account, appError := ah.service.UpdateAccountStatus(vars["customer_id"], vars["account_id"], request)
+	if appError != nil {
+		writeResponse(w, appError.Code, appError.AsMessage())
+		return
+	}
+	setETag(w, account.Version)
+	writeResponse(w, http.StatusOK, account)
+}
+
+// UpdateOverdraft sets the overdraft limit and sweep of a customer's account, it is an admin route
+func (ah AccountHandler) UpdateOverdraft(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var request dto.UpdateOverdraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	version, appError := ifMatchVersion(r)
+	if appError != nil {
+		writeResponse(w, appError.Code, appError.AsMessage())
+		return
+	}
+	request.Version = version
+
+	account, appError := ah.service.UpdateOverdraft(vars["customer_id"], vars["account_id"], request)
+	if appError != nil {
+		writeResponse(w, appError.Code, appError.AsMessage())
+		return
+	}
+	setETag(w, account.Version)
+	writeResponse(w, http.StatusOK, account)
+}
+
+// amountParam reads an optional amount query parameter
+func amountParam(value string, name string) (*money.Money, *errs.AppError) {
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := money.Parse(value)
+	if err != nil {
+		return nil, errs.NewBadRequestError(name + " must be an amount like 10.50")
+	}
+	return &amount, nil
+}
+
 func badAccountType(accountType string) bool {
 	return accountType != "checking" && accountType != "saving"
 }
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// APIKeyHandler connects the admin api key routes to the APIKeyService
+type APIKeyHandler struct {
+	service service.APIKeyService
+}
+
+// GetAPIKeys returns every api key without its secret
+func (kh APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := kh.service.GetAPIKeys()
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, keys)
+}
+
+// CreateAPIKey creates an api key and returns it, this is the only time the key is shown
+func (kh APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	createdBy, appErr := tokenUsername(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	var request dto.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	key, appErr := kh.service.CreateAPIKey(request, createdBy)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeResponse(w, http.StatusCreated, key)
+}
+
+// RevokeAPIKey revokes an api key
+func (kh APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if appErr := kh.service.RevokeAPIKey(mux.Vars(r)["key_id"]); appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
@@ -11,9 +11,12 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
+	"github.com/jonathanwamsley/banking/calendar"
 	"github.com/jonathanwamsley/banking/config"
 	"github.com/jonathanwamsley/banking/domain"
 	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/jonathanwamsley/banking/policy"
 	"github.com/jonathanwamsley/banking/service"
 )
 
@@ -37,6 +40,105 @@ func getDbClient(connectionInfo string) *sqlx.DB {
 	return client
 }
 
+// newAuthRepository picks how tokens are verified. Local mode loads the signing keys once and watches the policy file,
+// and a misconfigured local mode stops the server instead of rejecting every request.
+func newAuthRepository(c config.AuthConfig, issuer *domain.TokenIssuer) domain.AuthRepository {
+	if c.Mode != config.AUTH_LOCAL {
+		return domain.NewRemoteAuthRepository(c.RemoteAddress)
+	}
+
+	keys := domain.NewVerificationKeys()
+	if c.HMACSecret != "" {
+		keys.AddHMAC("", []byte(c.HMACSecret))
+	}
+	if issuer != nil {
+		if kid, key := issuer.PublicKey(); key != nil {
+			keys.AddRSA(kid, key)
+		}
+	}
+	if c.RSAPublicKeyFile != "" {
+		if err := keys.AddRSAPEMFile("", c.RSAPublicKeyFile); err != nil {
+			panic(err)
+		}
+	}
+	if c.JWKSFile != "" {
+		if err := keys.AddJWKSFile(c.JWKSFile); err != nil {
+			panic(err)
+		}
+	}
+	if keys.IsEmpty() {
+		panic("auth_mode is local but no auth_hmac_secret, auth_rsa_public_key_file, auth_jwks_file or auth_rsa_private_key_file is set")
+	}
+
+	engine, err := policy.NewFileEngine(c.PolicyFile)
+	if err != nil {
+		panic(err)
+	}
+	if c.PolicyReload > 0 {
+		go engine.Watch(c.PolicyReload, nil, func(reloaded bool, err error) {
+			if err != nil {
+				logger.Error("policy not reloaded, keeping the previous policy: " + err.Error())
+				return
+			}
+			logger.Info("policy reloaded from " + c.PolicyFile)
+		})
+	}
+	return domain.NewLocalAuthRepository(keys, c.Issuer, c.Audience, c.Leeway, engine)
+}
+
+// newTokenIssuer creates the signer of the login endpoint, with the RSA private key when one is set and
+// the HMAC secret otherwise. Without either key there is no login endpoint and nil is returned.
+func newTokenIssuer(c config.AuthConfig) *domain.TokenIssuer {
+	l := c.Login
+	if l.RSAPrivateKeyFile != "" {
+		issuer, err := domain.NewRSATokenIssuer(l.RSAPrivateKeyFile, l.KeyID, c.Issuer, c.Audience, l.AccessTTL, l.RefreshTTL)
+		if err != nil {
+			panic(err)
+		}
+		return issuer
+	}
+	if c.HMACSecret != "" {
+		issuer := domain.NewHMACTokenIssuer([]byte(c.HMACSecret), c.Issuer, c.Audience, l.AccessTTL, l.RefreshTTL)
+		return &issuer
+	}
+	return nil
+}
+
+// newStepUp reads the step-up amount, an empty amount turns step-up off and a malformed one stops the server
+func newStepUp(c config.MFAConfig, mfa service.MFAService) StepUp {
+	if c.StepUpAmount == "" {
+		return StepUp{}
+	}
+	threshold, err := money.Parse(c.StepUpAmount)
+	if err != nil {
+		panic(fmt.Sprintf("mfa_step_up_amount %q: %v", c.StepUpAmount, err))
+	}
+	return StepUp{service: mfa, threshold: threshold}
+}
+
+// newCalendar loads the bank's calendar, a calendar that does not load stops the server
+func newCalendar(c config.CalendarConfig) calendar.Calendar {
+	cal, err := calendar.Load(c.Timezone, c.CutOff, c.HolidayFile)
+	if err != nil {
+		panic(err)
+	}
+	return cal
+}
+
+// purgeExpiredSessions removes expired sessions and revoked tokens every interval, it is meant to be run in its own goroutine
+func purgeExpiredSessions(repo domain.SessionRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		deleted, appErr := repo.DeleteExpired(time.Now().Format(dbTSLayout))
+		if appErr != nil {
+			logger.Error("unable to purge expired sessions: " + appErr.Message)
+			continue
+		}
+		logger.Info(fmt.Sprintf("purged %d expired sessions and revoked tokens", deleted))
+	}
+}
+
 // Start helps decouples from running the whole entire application
 // it connects the handlers, starts the server, and any other configuration setup
 func Start() {
@@ -45,26 +147,157 @@ func Start() {
 		panic(err)
 	}
 	config := config.NewConfig()
+	bankCalendar := newCalendar(config.Calendar)
 	dbClient := getDbClient(config.GetMySQLInfo())
 	serverInfo := config.GetServerInfo()
 
 	router := mux.NewRouter()
-	ch := CustomerHandler{service.NewCustomerService(domain.NewCustomerRepositoryDB(dbClient))}
-	ah := AccountHandler{service.NewAccountService(domain.NewAccountRepositoryDB(dbClient))}
+	customerRepository := domain.NewCustomerRepositoryDB(dbClient)
+	ch := CustomerHandler{service.NewCustomerService(customerRepository, bankCalendar)}
+	lh := LedgerHandler{service.NewLedgerService(domain.NewLedgerRepositoryDB(dbClient))}
+	userRepository := domain.NewUserRepositoryDB(dbClient)
+	sessionRepository := domain.NewSessionRepositoryDB(dbClient)
+	uh := UserHandler{service.NewUserService(userRepository, sessionRepository)}
+	lockout := domain.LoginLockout{MaxAttempts: config.Auth.Login.LockoutAttempts, Duration: config.Auth.Login.LockoutDuration}
+	mfaService := service.NewMFAService(userRepository, config.Auth.MFA.Issuer, lockout)
+	mh := MFAHandler{mfaService}
+	accountRepository := domain.NewAccountRepositoryDB(dbClient)
+	accountService := service.NewAccountService(accountRepository, bankCalendar)
+	stepUp := newStepUp(config.Auth.MFA, mfaService)
+	ah := AccountHandler{
+		service: accountService,
+		stepUp:  stepUp,
+	}
+	eh := TransactionExportHandler{service: accountService, bankID: config.Exports.BankID}
 
 	router.HandleFunc("/customers", ch.GetAllCustomers).Methods(http.MethodGet).Name("GetCustomers")
 	router.HandleFunc("/customers", ch.CreateCustomer).Methods(http.MethodPost).Name("CreateCustomer")
 	router.HandleFunc("/customers/{customer_id:[0-9]+}", ch.GetCustomer).Methods(http.MethodGet).Name("GetCustomer")
 	router.HandleFunc("/customers/{customer_id:[0-9]+}", ch.DeleteCustomer).Methods(http.MethodDelete).Name("DeleteCustomer")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}", ch.UpdateCustomer).Methods(http.MethodPut).Name("UpdateCustomer")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}", ch.PatchCustomer).Methods(http.MethodPatch).Name("PatchCustomer")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/status", ch.UpdateCustomerStatus).Methods(http.MethodPut).Name("UpdateCustomerStatus")
 
 	router.HandleFunc("/customers/{customer_id:[0-9]+}/account", ah.GetAccount).Methods(http.MethodGet).Name("GetAccount")
 	router.HandleFunc("/customers/{customer_id:[0-9]+}/account", ah.CreateAccount).Methods(http.MethodPost).Name("CreateAccount")
 	router.HandleFunc("/customers/{customer_id:[0-9]+}/account", ah.DeleteAccount).Methods(http.MethodDelete).Name("DeleteAccount")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}", ah.GetAccountByID).Methods(http.MethodGet).Name("GetAccountByID")
 	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}", ah.MakeTransaction).Methods(http.MethodPost).Name("NewTransaction")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/transfers", ah.MakeTransfer).Methods(http.MethodPost).Name("NewTransfer")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/transactions", ah.GetTransactions).Methods(http.MethodGet).Name("GetTransactions")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/transactions/export", eh.ExportTransactions).Methods(http.MethodGet).Name("ExportTransactions")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/status", ah.UpdateAccountStatus).Methods(http.MethodPut).Name("UpdateAccountStatus")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/overdraft", ah.UpdateOverdraft).Methods(http.MethodPut).Name("UpdateOverdraft")
+
+	// reversals are for admins only, resources/policy.yaml grants ReverseTransaction to no other role
+	rh := ReversalHandler{service.NewReversalService(domain.NewReversalRepositoryDB(dbClient), accountRepository, bankCalendar)}
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/transactions/{transaction_id:[0-9]+}/reverse", rh.ReverseTransaction).Methods(http.MethodPost).Name("ReverseTransaction")
 
-	am := AuthMiddleware{domain.NewAuthRepository()}
+	router.HandleFunc("/ledger/check", lh.CheckLedger).Methods(http.MethodGet).Name("CheckLedger")
+
+	jobRunRepository := domain.NewJobRunRepositoryDB(dbClient)
+	interestService := service.NewInterestService(domain.NewInterestRepositoryDB(dbClient), jobRunRepository, bankCalendar)
+	ih := InterestHandler{service: interestService, calendar: bankCalendar}
+	router.HandleFunc("/interest/products", ih.GetInterestProducts).Methods(http.MethodGet).Name("GetInterestProducts")
+	router.HandleFunc("/interest/products/{product_code:[a-z0-9_]+}", ih.SaveInterestProduct).Methods(http.MethodPut).Name("SaveInterestProduct")
+	router.HandleFunc("/interest/accruals", ih.RunInterestAccrual).Methods(http.MethodPost).Name("RunInterestAccrual")
+	router.HandleFunc("/interest/postings", ih.RunInterestPosting).Methods(http.MethodPost).Name("RunInterestPosting")
+	if config.Interest.RunInterval > 0 {
+		go runInterest(interestService, config.Interest.RunInterval)
+	}
+
+	feeService := service.NewFeeService(domain.NewFeeRepositoryDB(dbClient), accountRepository, jobRunRepository, bankCalendar)
+	fh := FeeHandler{service: feeService, calendar: bankCalendar}
+	router.HandleFunc("/fees/schedules", fh.GetFeeSchedules).Methods(http.MethodGet).Name("GetFeeSchedules")
+	router.HandleFunc("/fees/schedules/{account_type:[a-z]+}", fh.SaveFeeSchedule).Methods(http.MethodPut).Name("SaveFeeSchedule")
+	router.HandleFunc("/fees/maintenance", fh.RunMaintenanceFees).Methods(http.MethodPost).Name("RunMaintenanceFees")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/fee-waivers", fh.GetFeeWaivers).Methods(http.MethodGet).Name("GetFeeWaivers")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/fee-waivers", fh.CreateFeeWaiver).Methods(http.MethodPost).Name("CreateFeeWaiver")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/fee-waivers/{waiver_id:[0-9]+}", fh.EndFeeWaiver).Methods(http.MethodDelete).Name("EndFeeWaiver")
+	if config.Fees.RunInterval > 0 {
+		go runFees(feeService, config.Fees.RunInterval)
+	}
+
+	holdService := service.NewHoldService(domain.NewHoldRepositoryDB(dbClient), accountRepository, config.Holds.TTL, bankCalendar)
+	hh := HoldHandler{holdService}
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/holds", hh.GetHolds).Methods(http.MethodGet).Name("GetHolds")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/holds", hh.CreateHold).Methods(http.MethodPost).Name("CreateHold")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/holds/{hold_id:[0-9]+}/capture", hh.CaptureHold).Methods(http.MethodPost).Name("CaptureHold")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/holds/{hold_id:[0-9]+}/void", hh.VoidHold).Methods(http.MethodPost).Name("VoidHold")
+	if config.Holds.ExpiryInterval > 0 {
+		go runHoldExpiry(holdService, config.Holds.ExpiryInterval)
+	}
+
+	paymentService := service.NewScheduledPaymentService(domain.NewScheduledPaymentRepositoryDB(dbClient), accountRepository, accountService, bankCalendar)
+	ph := ScheduledPaymentHandler{service: paymentService, stepUp: stepUp}
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/scheduled-payments", ph.GetScheduledPayments).Methods(http.MethodGet).Name("GetScheduledPayments")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/scheduled-payments", ph.CreateScheduledPayment).Methods(http.MethodPost).Name("CreateScheduledPayment")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/scheduled-payments/{payment_id:[0-9]+}", ph.GetScheduledPayment).Methods(http.MethodGet).Name("GetScheduledPayment")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/scheduled-payments/{payment_id:[0-9]+}", ph.UpdateScheduledPayment).Methods(http.MethodPut).Name("UpdateScheduledPayment")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/scheduled-payments/{payment_id:[0-9]+}", ph.CancelScheduledPayment).Methods(http.MethodDelete).Name("CancelScheduledPayment")
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/scheduled-payments/{payment_id:[0-9]+}/runs", ph.GetScheduledPaymentRuns).Methods(http.MethodGet).Name("GetScheduledPaymentRuns")
+	router.HandleFunc("/scheduled-payments/runs", ph.RunScheduledPayments).Methods(http.MethodPost).Name("RunScheduledPayments")
+	if config.Payments.RunInterval > 0 {
+		go runScheduledPayments(paymentService, config.Payments.RunInterval)
+	}
+
+	balanceRepository := domain.NewBalanceRepositoryDB(dbClient)
+	balanceService := service.NewBalanceService(balanceRepository, accountRepository, jobRunRepository, bankCalendar)
+	bh := BalanceHandler{service: balanceService, calendar: bankCalendar}
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/balance", bh.GetBalance).Methods(http.MethodGet).Name("GetBalance")
+	router.HandleFunc("/balances/snapshots", bh.RunBalanceSnapshots).Methods(http.MethodPost).Name("RunBalanceSnapshots")
+	router.HandleFunc("/balances/snapshots/check", bh.CheckBalanceSnapshots).Methods(http.MethodGet).Name("CheckBalanceSnapshots")
+	if config.Balances.SnapshotInterval > 0 {
+		go runBalanceSnapshots(balanceService, config.Balances.SnapshotInterval)
+	}
+
+	statementService := service.NewStatementService(domain.NewStatementRepositoryDB(dbClient), accountRepository, customerRepository, balanceRepository, jobRunRepository, bankCalendar)
+	sth := StatementHandler{service: statementService, calendar: bankCalendar}
+	router.HandleFunc("/customers/{customer_id:[0-9]+}/account/{account_id:[0-9]+}/statements/{period:[0-9]{4}-[0-9]{2}}", sth.GetStatement).Methods(http.MethodGet).Name("GetStatement")
+	router.HandleFunc("/statements", sth.RunStatements).Methods(http.MethodPost).Name("RunStatements")
+	if config.Statements.RunInterval > 0 {
+		go runStatements(statementService, config.Statements.RunInterval)
+	}
+
+	router.HandleFunc("/users", uh.GetAllUsers).Methods(http.MethodGet).Name("GetUsers")
+	router.HandleFunc("/users", uh.CreateUser).Methods(http.MethodPost).Name("CreateUser")
+	router.HandleFunc("/users/{username}", uh.GetUser).Methods(http.MethodGet).Name("GetUser")
+	router.HandleFunc("/users/{username}", uh.UpdateUser).Methods(http.MethodPut).Name("UpdateUser")
+	router.HandleFunc("/users/{username}", uh.DeleteUser).Methods(http.MethodDelete).Name("DeleteUser")
+	router.HandleFunc("/users/{username}/unlock", uh.UnlockUser).Methods(http.MethodPost).Name("UnlockUser")
+	router.HandleFunc("/users/{username}/mfa", mh.ResetMFA).Methods(http.MethodDelete).Name("ResetMFA")
+	router.HandleFunc("/auth/mfa", mh.EnrollMFA).Methods(http.MethodPost).Name("EnrollMFA")
+	router.HandleFunc("/auth/mfa/confirm", mh.ConfirmMFA).Methods(http.MethodPost).Name("ConfirmMFA")
+
+	// scopes of new keys are checked against the named routes when the key is created, after every route is registered
+	apiKeyService := service.NewAPIKeyService(domain.NewAPIKeyRepositoryDB(dbClient), func(name string) bool { return router.Get(name) != nil })
+	kh := APIKeyHandler{apiKeyService}
+	router.HandleFunc("/api-keys", kh.GetAPIKeys).Methods(http.MethodGet).Name("GetAPIKeys")
+	router.HandleFunc("/api-keys", kh.CreateAPIKey).Methods(http.MethodPost).Name("CreateAPIKey")
+	router.HandleFunc("/api-keys/{key_id:[0-9a-f]+}", kh.RevokeAPIKey).Methods(http.MethodDelete).Name("RevokeAPIKey")
+
+	issuer := newTokenIssuer(config.Auth)
+	if issuer != nil {
+		auth := AuthHandler{service.NewAuthService(userRepository, sessionRepository, *issuer, lockout)}
+		sh := SessionHandler{service.NewSessionService(sessionRepository, *issuer)}
+		router.HandleFunc("/auth/login", auth.Login).Methods(http.MethodPost).Name("Login")
+		router.HandleFunc("/auth/refresh", auth.Refresh).Methods(http.MethodPost).Name("Refresh")
+		router.HandleFunc("/auth/revoke", sh.RevokeToken).Methods(http.MethodPost).Name("RevokeToken")
+		router.HandleFunc("/sessions", sh.GetSessions).Methods(http.MethodGet).Name("GetSessions")
+		router.HandleFunc("/sessions/{session_id:[0-9a-f]+}", sh.RevokeSession).Methods(http.MethodDelete).Name("RevokeSession")
+		go purgeExpiredSessions(sessionRepository, time.Hour)
+	} else {
+		logger.Info("no auth_hmac_secret or auth_rsa_private_key_file is set, /auth/login and /sessions are turned off")
+	}
+
+	am := AuthMiddleware{repo: newAuthRepository(config.Auth, issuer), revocations: sessionRepository, apiKeys: apiKeyService}
 	router.Use(am.authorizationHandler())
 
+	// runs after authorization, so a rejected request never reserves an Idempotency-Key
+	im := NewIdempotencyMiddleware(domain.NewIdempotencyRepositoryDB(dbClient), config.Idempotency.Retention)
+	router.Use(im.idempotencyHandler())
+	go im.purgeExpired(time.Hour)
+
 	logger.Info(fmt.Sprintf("Starting server on %s ...", serverInfo))
 	log.Fatal(http.ListenAndServe(serverInfo, router))
 }
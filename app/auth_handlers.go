@@ -0,0 +1,59 @@
+package app
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// AuthHandler connects the login and refresh routes to the AuthService
+type AuthHandler struct {
+	service service.AuthService
+}
+
+// Login returns an access token and a refresh token for valid credentials. A user with mfa that sent no
+// otp gets a 401 with an OTP challenge.
+func (ah AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var request dto.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	request.UserAgent = r.UserAgent()
+	request.IPAddress = clientIP(r)
+	tokens, err := ah.service.Login(request)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeResponse(w, http.StatusOK, tokens)
+}
+
+// Refresh trades a refresh token for a new access token and refresh token, the old refresh token stops working
+func (ah AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var request dto.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	tokens, err := ah.service.Refresh(request)
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeResponse(w, http.StatusOK, tokens)
+}
+
+// clientIP is the address the request came from, forwarded headers are not trusted since any client can set them
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
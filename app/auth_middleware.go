@@ -1,30 +1,93 @@
 package app
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/service"
 )
 
+// publicRoutes are the route names that are called without a token
+var publicRoutes = map[string]bool{
+	"Login":   true,
+	"Refresh": true,
+}
+
+// claimsKey is the request context key of the claims of an authorized token
+type claimsKey struct{}
+
+// claimsFrom returns the claims of the token a request was authorized with, or nil on public routes
+func claimsFrom(r *http.Request) *domain.AccessClaims {
+	claims, _ := r.Context().Value(claimsKey{}).(*domain.AccessClaims)
+	return claims
+}
+
+// apiKeyHeader carries an api key, the Authorization header can carry one with the ApiKey scheme too
+const (
+	apiKeyHeader = "X-API-Key"
+	apiKeyScheme = "ApiKey"
+)
+
+// AuthMiddleware checks the token of every request that is not public. With revocations set, a token
+// whose jti or session was revoked is refused even though it is still valid. With apiKeys set, requests
+// can also be made with an api key, its scopes decide which routes it may call instead of the policy.
 type AuthMiddleware struct {
-	repo domain.AuthRepository
+	repo        domain.AuthRepository
+	revocations domain.RevocationList
+	apiKeys     service.APIKeyService
 }
 
 func (a AuthMiddleware) authorizationHandler() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			currentRoute := mux.CurrentRoute(r)
+			if currentRoute != nil && publicRoutes[currentRoute.GetName()] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if key := apiKeyFromRequest(r); key != "" && a.apiKeys != nil {
+				claims, appErr := a.apiKeys.Authorize(key, currentRoute.GetName())
+				if appErr != nil {
+					writeResponse(w, appErr.Code, appErr.AsMessage())
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
+				return
+			}
 			currentRouteVars := mux.Vars(r)
 			authHeader := r.Header.Get("Authorization")
 
 			if authHeader != "" {
-				token := getTokenFromHeader(authHeader)
-
-				isAuthorized := a.repo.IsAuthorized(token, currentRoute.GetName(), currentRouteVars)
+				body, err := readJSONBody(r)
+				if err != nil {
+					writeResponse(w, http.StatusBadRequest, "unable to read request body")
+					return
+				}
+				claims, isAuthorized := a.repo.IsAuthorized(domain.AuthRequest{
+					Token:     getTokenFromHeader(authHeader),
+					RouteName: currentRoute.GetName(),
+					Vars:      currentRouteVars,
+					Body:      body,
+				})
+				if isAuthorized && a.revocations != nil {
+					revoked, appErr := a.revocations.IsRevoked(claims.ID, claims.SessionID)
+					if appErr != nil {
+						writeResponse(w, appErr.Code, appErr.AsMessage())
+						return
+					}
+					if revoked {
+						writeResponse(w, http.StatusUnauthorized, "token was revoked")
+						return
+					}
+				}
 				if isAuthorized {
-					next.ServeHTTP(w, r)
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
 				} else {
 					writeResponse(w, http.StatusForbidden, "Unauthorized")
 				}
@@ -36,6 +99,18 @@ func (a AuthMiddleware) authorizationHandler() func(http.Handler) http.Handler {
 	}
 }
 
+// apiKeyFromRequest returns the key of the X-API-Key header, or of an Authorization header with the ApiKey scheme
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return strings.TrimSpace(key)
+	}
+	fields := strings.Fields(r.Header.Get("Authorization"))
+	if len(fields) == 2 && strings.EqualFold(fields[0], apiKeyScheme) {
+		return fields[1]
+	}
+	return ""
+}
+
 func getTokenFromHeader(header string) string {
 	splitToken := strings.Split(header, "Bearer")
 	if len(splitToken) == 2 {
@@ -43,3 +118,25 @@ func getTokenFromHeader(header string) string {
 	}
 	return ""
 }
+
+// readJSONBody decodes a json object body for the policy and puts the body back for the handler.
+// Numbers are kept as written, so an amount like 9999.99 is compared exactly. A body that is not
+// a json object is nil, and the handler reports it.
+func readJSONBody(r *http.Request) (map[string]interface{}, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var body map[string]interface{}
+	if decoder.Decode(&body) != nil {
+		return nil, nil
+	}
+	return body, nil
+}
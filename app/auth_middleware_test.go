@@ -0,0 +1,132 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingAuthRepository allows every request and keeps the last one it was asked about
+type recordingAuthRepository struct {
+	last *domain.AuthRequest
+}
+
+func (r *recordingAuthRepository) IsAuthorized(req domain.AuthRequest) (*domain.AccessClaims, bool) {
+	r.last = &req
+	return &domain.AccessClaims{Username: "2001"}, true
+}
+
+func TestAuthMiddlewarePassesBodyToPolicyAndHandler(t *testing.T) {
+	repo := &recordingAuthRepository{}
+	var handlerBody []byte
+	var handlerClaims *domain.AccessClaims
+	router := mux.NewRouter()
+	router.HandleFunc("/customers/{customer_id}/account/{account_id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerBody, _ = ioutil.ReadAll(r.Body)
+		handlerClaims = claimsFrom(r)
+	}).Name("NewTransaction")
+	router.Use(AuthMiddleware{repo: repo}.authorizationHandler())
+
+	body := `{"amount": 9999.99, "transaction_type": "deposit"}`
+	request, _ := http.NewRequest(http.MethodPost, "/customers/2001/account/95472", bytes.NewReader([]byte(body)))
+	request.Header.Set("Authorization", "Bearer abc")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	assert.Equal(t, "abc", repo.last.Token)
+	assert.Equal(t, "NewTransaction", repo.last.RouteName)
+	assert.Equal(t, "95472", repo.last.Vars["account_id"])
+	assert.Equal(t, json.Number("9999.99"), repo.last.Body["amount"])
+	assert.Equal(t, body, string(handlerBody))
+	assert.Equal(t, "2001", handlerClaims.Username)
+}
+
+func TestAuthMiddlewareIgnoresBodyThatIsNotAnObject(t *testing.T) {
+	repo := &recordingAuthRepository{}
+	router := mux.NewRouter()
+	router.HandleFunc("/customers", func(w http.ResponseWriter, r *http.Request) {}).Name("CreateCustomer")
+	router.Use(AuthMiddleware{repo: repo}.authorizationHandler())
+
+	request, _ := http.NewRequest(http.MethodPost, "/customers", bytes.NewReader([]byte(`["not", "an", "object"]`)))
+	request.Header.Set("Authorization", "Bearer abc")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	assert.Nil(t, repo.last.Body)
+}
+
+// revokedSessions is a revocation list of session ids
+type revokedSessions map[string]bool
+
+func (l revokedSessions) IsRevoked(jti string, sessionID string) (bool, *errs.AppError) {
+	return l[sessionID], nil
+}
+
+func TestAuthMiddlewareRefusesRevokedSession(t *testing.T) {
+	repo := &sessionAuthRepository{}
+	router := mux.NewRouter()
+	router.HandleFunc("/customers", func(w http.ResponseWriter, r *http.Request) {}).Name("GetCustomers")
+	router.Use(AuthMiddleware{repo: repo, revocations: revokedSessions{"s1": true}}.authorizationHandler())
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/customers", nil)
+	request.Header.Set("Authorization", "Bearer s1")
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request.Header.Set("Authorization", "Bearer s2")
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+// sessionAuthRepository allows every request, with the token as the session id
+type sessionAuthRepository struct{}
+
+func (sessionAuthRepository) IsAuthorized(req domain.AuthRequest) (*domain.AccessClaims, bool) {
+	return &domain.AccessClaims{Username: "2001", SessionID: req.Token}, true
+}
+
+func TestAuthMiddlewareAcceptsAPIKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	keys := service.NewMockAPIKeyService(ctrl)
+	claims := &domain.AccessClaims{Role: domain.ROLE_SERVICE}
+	keys.EXPECT().Authorize("bk_0a1b2c3d4e5f_00ff", "GetCustomers").Return(claims, nil).Times(2)
+	keys.EXPECT().Authorize("bk_0a1b2c3d4e5f_00ff", "CreateCustomer").Return(nil, errs.NewForbiddenError("api key is not scoped for CreateCustomer"))
+
+	var handlerClaims *domain.AccessClaims
+	router := mux.NewRouter()
+	router.HandleFunc("/customers", func(w http.ResponseWriter, r *http.Request) {
+		handlerClaims = claimsFrom(r)
+	}).Methods(http.MethodGet).Name("GetCustomers")
+	router.HandleFunc("/customers", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodPost).Name("CreateCustomer")
+	router.Use(AuthMiddleware{repo: &recordingAuthRepository{}, apiKeys: keys}.authorizationHandler())
+
+	request, _ := http.NewRequest(http.MethodGet, "/customers", nil)
+	request.Header.Set(apiKeyHeader, "bk_0a1b2c3d4e5f_00ff")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, claims, handlerClaims)
+
+	request, _ = http.NewRequest(http.MethodGet, "/customers", nil)
+	request.Header.Set("Authorization", "ApiKey bk_0a1b2c3d4e5f_00ff")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	request, _ = http.NewRequest(http.MethodPost, "/customers", nil)
+	request.Header.Set(apiKeyHeader, "bk_0a1b2c3d4e5f_00ff")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// BalanceHandler connects the balance routes to the BalanceService
+type BalanceHandler struct {
+	service  service.BalanceService
+	calendar calendar.Calendar
+}
+
+// GetBalance returns the balance of an account at the end of the as_of date, today when it is left out
+func (bh BalanceHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	balance, appErr := bh.service.GetBalance(vars["customer_id"], vars["account_id"], r.URL.Query().Get("as_of"))
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, balance)
+}
+
+// RunBalanceSnapshots takes the balance snapshots of a date, yesterday when the body names no date
+func (bh BalanceHandler) RunBalanceSnapshots(w http.ResponseWriter, r *http.Request) {
+	request, ok := decodeRunRequest(w, r, bh.calendar.Today(time.Now()).AddDate(0, 0, -1))
+	if !ok {
+		return
+	}
+	result, appErr := bh.service.TakeSnapshots(request.Date)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, result)
+}
+
+// CheckBalanceSnapshots compares the balance snapshots with the account balances
+func (bh BalanceHandler) CheckBalanceSnapshots(w http.ResponseWriter, r *http.Request) {
+	result, appErr := bh.service.CheckSnapshots()
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	if !result.Consistent {
+		logger.Error("balance snapshot check found snapshots that do not add up to the account balances")
+	}
+	writeResponse(w, http.StatusOK, result)
+}
+
+// runBalanceSnapshots takes the balance snapshots that are due every interval, it is meant to be run in its own goroutine
+func runBalanceSnapshots(s service.BalanceService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if appErr := s.RunDue(); appErr != nil {
+			logger.Error("balance snapshot run did not finish: " + appErr.Message)
+		}
+	}
+}
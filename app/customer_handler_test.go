@@ -35,8 +35,8 @@ func TestGetCustomersNoError(t *testing.T) {
 	defer teardown()
 
 	dummyCustomers := []dto.CustomerResponse{
-		{"1001", "Ashish", "New Delhi", "110011", "2000-01-01", "1"},
-		{"1002", "Rob", "New Delhi", "110011", "2000-01-01", "1"},
+		{ID: "1001", Name: "Ashish", City: "New Delhi", Zipcode: "110011", DateofBirth: "2000-01-01", Status: "active"},
+		{ID: "1002", Name: "Rob", City: "New Delhi", Zipcode: "110011", DateofBirth: "2000-01-01", Status: "active"},
 	}
 	mockService.EXPECT().GetAllCustomers().Return(dummyCustomers, nil)
 	router.HandleFunc("/customers", ch.GetAllCustomers)
@@ -93,7 +93,7 @@ func TestGetCustomerNoError(t *testing.T) {
 	defer teardown()
 
 	dummyCustomers := &dto.CustomerResponse{
-		"1001", "Ashish", "New Delhi", "110011", "2000-01-01", "1",
+		ID: "1001", Name: "Ashish", City: "New Delhi", Zipcode: "110011", DateofBirth: "2000-01-01", Status: "active",
 	}
 	mockService.EXPECT().GetCustomer("").Return(dummyCustomers, nil)
 	router.HandleFunc("/customer", ch.GetCustomer)
@@ -114,7 +114,7 @@ func TestDeleteCustomerError(t *testing.T) {
 	teardown := setup(t)
 	defer teardown()
 
-	mockService.EXPECT().DeleteCustomer("").Return(errs.NewUnexpectedError("some database error"))
+	mockService.EXPECT().DeleteCustomer("", 0).Return(errs.NewUnexpectedError("some database error"))
 	router.HandleFunc("/customer", ch.DeleteCustomer)
 	request, _ := http.NewRequest(http.MethodDelete, "/customer", nil)
 
@@ -133,7 +133,7 @@ func TestDeleteCustomerNoError(t *testing.T) {
 	teardown := setup(t)
 	defer teardown()
 
-	mockService.EXPECT().DeleteCustomer("").Return(nil)
+	mockService.EXPECT().DeleteCustomer("", 0).Return(nil)
 	router.HandleFunc("/customer", ch.DeleteCustomer)
 	request, _ := http.NewRequest(http.MethodDelete, "/customer", nil)
 
@@ -215,3 +215,85 @@ func TestCreateCustomerServerError(t *testing.T) {
 		t.Error("Failed while testing the status code")
 	}
 }
+
+func TestPatchCustomerNoError(t *testing.T) {
+	// Arrange
+	teardown := setup(t)
+	defer teardown()
+
+	patch := dto.CustomerPatch{"city": []byte(`"Beacon, NY"`)}
+	resp := &dto.UpdateCustomerResponse{
+		CustomerResponse: dto.CustomerResponse{ID: "2001", Name: "Arian", City: "Beacon, NY", Zipcode: "12550", DateofBirth: "1988-05-21", Status: "active"},
+		ChangedFields:    []string{"city"},
+	}
+	mockService.EXPECT().PatchCustomer("2001", 0, patch).Return(resp, nil)
+	router.HandleFunc("/customers/{customer_id}", ch.PatchCustomer)
+	request, _ := http.NewRequest(http.MethodPatch, "/customers/2001", bytes.NewReader([]byte(`{"city":"Beacon, NY"}`)))
+
+	// Act
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	// Assert
+	if recorder.Code != http.StatusOK {
+		t.Error("Failed while testing the status code")
+	}
+}
+
+func TestPatchCustomerInvalidJSON(t *testing.T) {
+	// Arrange
+	teardown := setup(t)
+	defer teardown()
+
+	router.HandleFunc("/customers/{customer_id}", ch.PatchCustomer)
+	request, _ := http.NewRequest(http.MethodPatch, "/customers/2001", bytes.NewReader([]byte(`["city"]`)))
+
+	// Act
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	// Assert
+	if recorder.Code != http.StatusBadRequest {
+		t.Error("Failed while testing the status code")
+	}
+}
+
+func TestGetCustomerSetsETag(t *testing.T) {
+	// Arrange
+	teardown := setup(t)
+	defer teardown()
+
+	resp := &dto.CustomerResponse{ID: "2001", Name: "Arian", Status: "active", Version: 4}
+	mockService.EXPECT().GetCustomer("2001").Return(resp, nil)
+	router.HandleFunc("/customers/{customer_id}", ch.GetCustomer)
+	request, _ := http.NewRequest(http.MethodGet, "/customers/2001", nil)
+
+	// Act
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	// Assert
+	if recorder.Header().Get("ETag") != `"4"` {
+		t.Error("Failed while testing the ETag")
+	}
+}
+
+func TestPatchCustomerStaleIfMatch(t *testing.T) {
+	// Arrange
+	teardown := setup(t)
+	defer teardown()
+
+	mockService.EXPECT().PatchCustomer("2001", 3, gomock.Any()).Return(nil, errs.NewPreconditionFailedError("The customer was changed by another request"))
+	router.HandleFunc("/customers/{customer_id}", ch.PatchCustomer)
+	request, _ := http.NewRequest(http.MethodPatch, "/customers/2001", bytes.NewReader([]byte(`{"city":"Beacon, NY"}`)))
+	request.Header.Set("If-Match", `"3"`)
+
+	// Act
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	// Assert
+	if recorder.Code != http.StatusPreconditionFailed {
+		t.Error("Failed while testing the status code")
+	}
+}
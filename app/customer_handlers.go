@@ -50,14 +50,20 @@ func (ch *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
 		writeResponse(w, err.Code, err.AsMessage())
 		return
 	}
+	setETag(w, customer.Version)
 	writeResponse(w, http.StatusOK, customer)
 }
 
 // DeleteCustomer returns a confirmation status deleted if success
 func (ch *CustomerHandler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
 	customerID := mux.Vars(r)["customer_id"]
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
 
-	err := ch.service.DeleteCustomer(customerID)
+	err = ch.service.DeleteCustomer(customerID, version)
 	if err != nil {
 		writeResponse(w, err.Code, err.AsMessage())
 		return
@@ -65,6 +71,79 @@ func (ch *CustomerHandler) DeleteCustomer(w http.ResponseWriter, r *http.Request
 	writeResponse(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
+// UpdateCustomer replaces a customer's information, every field has to be sent
+func (ch *CustomerHandler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["customer_id"]
+
+	version, appErr := ifMatchVersion(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+
+	var customerRequest dto.CustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&customerRequest); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	customer, err := ch.service.UpdateCustomer(customerID, version, customerRequest)
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	setETag(w, customer.Version)
+	writeResponse(w, http.StatusOK, customer)
+}
+
+// PatchCustomer changes only the fields in the json merge patch body
+func (ch *CustomerHandler) PatchCustomer(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["customer_id"]
+
+	version, appErr := ifMatchVersion(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+
+	var patch dto.CustomerPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	customer, err := ch.service.PatchCustomer(customerID, version, patch)
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	setETag(w, customer.Version)
+	writeResponse(w, http.StatusOK, customer)
+}
+
+// UpdateCustomerStatus changes the status of a customer, it is an admin route
+func (ch *CustomerHandler) UpdateCustomerStatus(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["customer_id"]
+
+	var request dto.UpdateStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	request.Version = version
+
+	customer, err := ch.service.UpdateCustomerStatus(customerID, request)
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	setETag(w, customer.Version)
+	writeResponse(w, http.StatusOK, customer)
+}
+
 // writeResponse returns the header with an encoded json data as a response
 func writeResponse(w http.ResponseWriter, code int, data interface{}) {
 	w.Header().Add("Content-Type", "application/json")
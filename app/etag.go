@@ -0,0 +1,44 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// the headers used for optimistic concurrency, the ETag of a customer or an account is its quoted version
+const (
+	etagHeader    = "ETag"
+	ifMatchHeader = "If-Match"
+)
+
+// setETag sets the ETag of a response from the version of the customer or account it returns
+func setETag(w http.ResponseWriter, version int) {
+	if version > 0 {
+		w.Header().Set(etagHeader, strconv.Quote(strconv.Itoa(version)))
+	}
+}
+
+// ifMatchVersion returns the version in the If-Match header, or domain.ANY_VERSION when it is missing or *.
+// Weak ETags never match, since If-Match needs a strong comparison.
+func ifMatchVersion(r *http.Request) (int, *errs.AppError) {
+	ifMatch := strings.TrimSpace(r.Header.Get(ifMatchHeader))
+	if ifMatch == "" || ifMatch == "*" {
+		return domain.ANY_VERSION, nil
+	}
+	if strings.HasPrefix(ifMatch, "W/") {
+		return 0, errs.NewPreconditionFailedError("If-Match needs a strong ETag").WithReason("version_mismatch")
+	}
+	unquoted, err := strconv.Unquote(ifMatch)
+	if err != nil {
+		return 0, errs.NewBadRequestError(`If-Match must be an ETag like "3"`)
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return 0, errs.NewPreconditionFailedError("If-Match does not match any version").WithReason("version_mismatch")
+	}
+	return version, nil
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func ifMatchRequest(ifMatch string) *http.Request {
+	request, _ := http.NewRequest(http.MethodPut, "/customers/2001", nil)
+	if ifMatch != "" {
+		request.Header.Set(ifMatchHeader, ifMatch)
+	}
+	return request
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	version, err := ifMatchVersion(ifMatchRequest(`"3"`))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, version)
+
+	for _, any := range []string{"", "*"} {
+		version, err = ifMatchVersion(ifMatchRequest(any))
+		assert.Nil(t, err)
+		assert.Equal(t, domain.ANY_VERSION, version)
+	}
+}
+
+func TestIfMatchVersionNeverMatches(t *testing.T) {
+	for _, ifMatch := range []string{`W/"3"`, `"abc"`, `"0"`} {
+		_, err := ifMatchVersion(ifMatchRequest(ifMatch))
+		assert.EqualValues(t, http.StatusPreconditionFailed, err.Code, ifMatch)
+	}
+}
+
+func TestIfMatchVersionMalformed(t *testing.T) {
+	_, err := ifMatchVersion(ifMatchRequest("3"))
+	assert.EqualValues(t, http.StatusBadRequest, err.Code)
+}
+
+func TestSetETag(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	setETag(recorder, 7)
+	assert.Equal(t, `"7"`, recorder.Header().Get(etagHeader))
+
+	recorder = httptest.NewRecorder()
+	setETag(recorder, 0)
+	assert.Equal(t, "", recorder.Header().Get(etagHeader))
+}
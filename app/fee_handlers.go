@@ -0,0 +1,116 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// FeeHandler connects the fee routes to the FeeService
+type FeeHandler struct {
+	service  service.FeeService
+	calendar calendar.Calendar
+}
+
+// GetFeeSchedules returns the fee schedule of every account type
+func (fh FeeHandler) GetFeeSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := fh.service.GetFeeSchedules()
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, schedules)
+}
+
+// SaveFeeSchedule creates or replaces the fee schedule of the account type in the route
+func (fh FeeHandler) SaveFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	var request dto.FeeScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	schedule, appErr := fh.service.SaveFeeSchedule(mux.Vars(r)["account_type"], request)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, schedule)
+}
+
+// GetFeeWaivers returns the fee waivers of an account
+func (fh FeeHandler) GetFeeWaivers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	waivers, appErr := fh.service.GetFeeWaivers(vars["customer_id"], vars["account_id"])
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, waivers)
+}
+
+// CreateFeeWaiver waives a fee of an account, the waiver records who created it
+func (fh FeeHandler) CreateFeeWaiver(w http.ResponseWriter, r *http.Request) {
+	createdBy, appErr := tokenUsername(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	var request dto.FeeWaiverRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	vars := mux.Vars(r)
+	waiver, appErr := fh.service.CreateFeeWaiver(vars["customer_id"], vars["account_id"], request, createdBy)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusCreated, waiver)
+}
+
+// EndFeeWaiver ends a fee waiver today
+func (fh FeeHandler) EndFeeWaiver(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if appErr := fh.service.EndFeeWaiver(vars["customer_id"], vars["account_id"], vars["waiver_id"]); appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, map[string]string{"status": "ended"})
+}
+
+// RunMaintenanceFees charges the maintenance fees of a month, last month when the body names no period
+func (fh FeeHandler) RunMaintenanceFees(w http.ResponseWriter, r *http.Request) {
+	var request dto.FeeRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if request.Period == "" {
+		request.Period = lastMonth(fh.calendar, time.Now())
+	}
+	result, appErr := fh.service.ChargeMaintenanceFees(request.Period)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, result)
+}
+
+// runFees charges the maintenance fees that are due every interval, it is meant to be run in its own goroutine
+func runFees(s service.FeeService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if appErr := s.RunDue(); appErr != nil {
+			logger.Error("maintenance fee run did not finish: " + appErr.Message)
+		}
+	}
+}
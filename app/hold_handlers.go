@@ -0,0 +1,88 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// HoldHandler connects the hold routes to the HoldService
+type HoldHandler struct {
+	service service.HoldService
+}
+
+// GetHolds returns the holds of an account
+func (hh HoldHandler) GetHolds(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	holds, appErr := hh.service.GetHolds(vars["customer_id"], vars["account_id"])
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, holds)
+}
+
+// CreateHold reserves an amount of an account, the hold records who created it
+func (hh HoldHandler) CreateHold(w http.ResponseWriter, r *http.Request) {
+	createdBy, appErr := tokenUsername(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	var request dto.CreateHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	vars := mux.Vars(r)
+	hold, appErr := hh.service.CreateHold(vars["customer_id"], vars["account_id"], request, createdBy)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusCreated, hold)
+}
+
+// CaptureHold settles a hold as a withdrawal, the whole hold when the body names no amount
+func (hh HoldHandler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	var request dto.CaptureHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	vars := mux.Vars(r)
+	hold, appErr := hh.service.CaptureHold(vars["customer_id"], vars["account_id"], vars["hold_id"], request)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, hold)
+}
+
+// VoidHold releases a hold
+func (hh HoldHandler) VoidHold(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hold, appErr := hh.service.VoidHold(vars["customer_id"], vars["account_id"], vars["hold_id"])
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, hold)
+}
+
+// runHoldExpiry releases the expired holds every interval, it is meant to be run in its own goroutine
+func runHoldExpiry(s service.HoldService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if appErr := s.ExpireHolds(); appErr != nil {
+			logger.Error("hold expiry did not finish: " + appErr.Message)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// a time layout that the db is using
+const dbTSLayout = "2006-01-02 15:04:05"
+
+// the header clients use to make a request safe to retry, and the header set on replayed responses
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// idempotentRoutes are the route names that honour the Idempotency-Key header
+var idempotentRoutes = map[string]bool{
+	"CreateCustomer": true,
+	"CreateAccount":  true,
+	"NewTransaction": true,
+	"NewTransfer":    true,
+	"CreateHold":     true,
+	"CaptureHold":    true,
+	// a retried partial reversal would otherwise reverse the amount again
+	"ReverseTransaction": true,
+	// a retried create would otherwise schedule every payment twice
+	"CreateScheduledPayment": true,
+}
+
+// IdempotencyMiddleware replays the stored response when a mutating request is retried with the same Idempotency-Key
+type IdempotencyMiddleware struct {
+	repo      domain.IdempotencyRepository
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewIdempotencyMiddleware creates an IdempotencyMiddleware that keeps responses for the retention window
+func NewIdempotencyMiddleware(repo domain.IdempotencyRepository, retention time.Duration) IdempotencyMiddleware {
+	return IdempotencyMiddleware{repo: repo, retention: retention, now: time.Now}
+}
+
+func (im IdempotencyMiddleware) idempotencyHandler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			route := mux.CurrentRoute(r)
+			if key == "" || route == nil || !idempotentRoutes[route.GetName()] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				writeResponse(w, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength))
+				return
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				writeResponse(w, http.StatusBadRequest, "unable to read request body")
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			fingerprint := requestFingerprint(r, body)
+			key = scopedKey(claimsFrom(r), key)
+
+			stored, appErr := im.repo.FindKey(key)
+			if appErr != nil && appErr.Code != http.StatusNotFound {
+				writeResponse(w, appErr.Code, appErr.AsMessage())
+				return
+			}
+			if stored != nil && stored.IsExpired(im.now()) {
+				if appErr = im.repo.Release(key); appErr != nil {
+					writeResponse(w, appErr.Code, appErr.AsMessage())
+					return
+				}
+				stored = nil
+			}
+			if stored != nil {
+				im.replay(w, *stored, fingerprint)
+				return
+			}
+
+			if appErr = im.repo.Reserve(domain.NewIdempotencyKey(key, fingerprint, im.now(), im.retention)); appErr != nil {
+				writeResponse(w, appErr.Code, appErr.AsMessage())
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			// server errors and one time password challenges are not stored, so the client can retry them
+			// with the same key, a challenge with the X-OTP header it asked for
+			if recorder.statusCode >= http.StatusInternalServerError || recorder.statusCode == http.StatusUnauthorized {
+				if appErr = im.repo.Release(key); appErr != nil {
+					logger.Error("unable to release idempotency key " + key + ": " + appErr.Message)
+				}
+				return
+			}
+			if appErr = im.repo.Complete(key, recorder.statusCode, recorder.body.String()); appErr != nil {
+				logger.Error("unable to store response for idempotency key " + key + ": " + appErr.Message)
+			}
+		})
+	}
+}
+
+// replay answers a retried request with the stored response, as long as it is the same request
+func (im IdempotencyMiddleware) replay(w http.ResponseWriter, stored domain.IdempotencyKey, fingerprint string) {
+	if !stored.Matches(fingerprint) {
+		writeResponse(w, http.StatusUnprocessableEntity, fmt.Sprintf("%s was already used with a different request", idempotencyKeyHeader))
+		return
+	}
+	if stored.IsPending() {
+		writeResponse(w, http.StatusConflict, fmt.Sprintf("A request with this %s is already in progress", idempotencyKeyHeader))
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.Header().Add(idempotentReplayedHeader, "true")
+	w.WriteHeader(stored.StatusCode)
+	w.Write([]byte(stored.ResponseBody))
+}
+
+// purgeExpired removes expired keys every interval, it is meant to be run in its own goroutine
+func (im IdempotencyMiddleware) purgeExpired(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		deleted, appErr := im.repo.DeleteExpired(im.now().Format(dbTSLayout))
+		if appErr != nil {
+			logger.Error("unable to purge expired idempotency keys: " + appErr.Message)
+			continue
+		}
+		logger.Info(fmt.Sprintf("purged %d expired idempotency keys", deleted))
+	}
+}
+
+// scopedKey stores a key under the caller that sent it, so two callers allowed on the same route that happen to
+// send the same key and body never get each other's response. The caller is the token subject, an api key's
+// subject names the key, and tokens of the auth api without a subject fall back to the username. The caller is
+// hashed so the stored key has a fixed length prefix whatever the subject looks like.
+func scopedKey(claims *domain.AccessClaims, key string) string {
+	caller := ""
+	if claims != nil {
+		caller = claims.Subject
+		if caller == "" {
+			caller = claims.Username
+		}
+	}
+	sum := sha256.Sum256([]byte(caller))
+	return hex.EncodeToString(sum[:]) + ":" + key
+}
+
+// requestFingerprint identifies a request by its method, path and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	rr.statusCode = code
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.statusCode == 0 {
+		rr.statusCode = http.StatusOK
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryIdempotencyRepository keeps keys in a map so the middleware can be tested without a db
+type memoryIdempotencyRepository struct {
+	mu   sync.Mutex
+	keys map[string]domain.IdempotencyKey
+}
+
+func (m *memoryIdempotencyRepository) FindKey(key string) (*domain.IdempotencyKey, *errs.AppError) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, ok := m.keys[key]
+	if !ok {
+		return nil, errs.NewNotFoundError("Idempotency key not found")
+	}
+	return &k, nil
+}
+
+func (m *memoryIdempotencyRepository) Reserve(k domain.IdempotencyKey) *errs.AppError {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[k.Key]; ok {
+		return errs.NewConflictError("A request with this Idempotency-Key is already in progress")
+	}
+	m.keys[k.Key] = k
+	return nil
+}
+
+func (m *memoryIdempotencyRepository) Complete(key string, statusCode int, responseBody string) *errs.AppError {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := m.keys[key]
+	k.StatusCode = statusCode
+	k.ResponseBody = responseBody
+	m.keys[key] = k
+	return nil
+}
+
+func (m *memoryIdempotencyRepository) Release(key string) *errs.AppError {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, key)
+	return nil
+}
+
+func (m *memoryIdempotencyRepository) DeleteExpired(now string) (int64, *errs.AppError) {
+	return 0, nil
+}
+
+// idempotentRouter counts how often the wrapped handler really runs
+func idempotentRouter(status int, now func() time.Time) (*mux.Router, *int) {
+	calls := 0
+	im := NewIdempotencyMiddleware(&memoryIdempotencyRepository{keys: map[string]domain.IdempotencyKey{}}, time.Hour)
+	im.now = now
+	r := mux.NewRouter()
+	r.HandleFunc("/customers/{customer_id}/account/{account_id}", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeResponse(w, status, map[string]int{"call": calls})
+	}).Methods(http.MethodPost).Name("NewTransaction")
+	r.HandleFunc("/customers/{customer_id}", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeResponse(w, http.StatusOK, map[string]int{"call": calls})
+	}).Methods(http.MethodDelete).Name("DeleteCustomer")
+	r.Use(im.idempotencyHandler())
+	return r, &calls
+}
+
+func sendIdempotent(r *mux.Router, method string, path string, key string, body string) *httptest.ResponseRecorder {
+	return sendIdempotentAs(r, nil, method, path, key, body)
+}
+
+// sendIdempotentAs sends a request the way the authorization middleware passes it on, with the caller's claims
+func sendIdempotentAs(r *mux.Router, claims *domain.AccessClaims, method string, path string, key string, body string) *httptest.ResponseRecorder {
+	request, _ := http.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		request.Header.Set(idempotencyKeyHeader, key)
+	}
+	if claims != nil {
+		request = request.WithContext(context.WithValue(request.Context(), claimsKey{}, claims))
+	}
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	r, calls := idempotentRouter(http.StatusOK, time.Now)
+	body := `{"transaction_type":"deposit","amount":"10.00"}`
+
+	first := sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "key-1", body)
+	second := sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "key-1", body)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(idempotentReplayedHeader))
+	assert.Equal(t, "", first.Header().Get(idempotentReplayedHeader))
+}
+
+func TestIdempotencyRejectsKeyReusedWithDifferentBody(t *testing.T) {
+	r, calls := idempotentRouter(http.StatusOK, time.Now)
+
+	sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "key-1", `{"amount":"10.00"}`)
+	second := sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "key-1", `{"amount":"20.00"}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, second.Code)
+}
+
+func TestIdempotencyKeysAreScopedToTheCaller(t *testing.T) {
+	r, calls := idempotentRouter(http.StatusOK, time.Now)
+	body := `{"transaction_type":"deposit","amount":"10.00"}`
+	teller := &domain.AccessClaims{Username: "teller1", Role: "teller"}
+	admin := &domain.AccessClaims{Username: "admin", Role: "admin"}
+	admin.Subject = "admin"
+
+	sendIdempotentAs(r, teller, http.MethodPost, "/customers/2001/account/95472", "key-1", body)
+	other := sendIdempotentAs(r, admin, http.MethodPost, "/customers/2001/account/95472", "key-1", body)
+	retry := sendIdempotentAs(r, teller, http.MethodPost, "/customers/2001/account/95472", "key-1", body)
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, "", other.Header().Get(idempotentReplayedHeader))
+	assert.Equal(t, "true", retry.Header().Get(idempotentReplayedHeader))
+}
+
+func TestIdempotencyWithoutKeyAlwaysRuns(t *testing.T) {
+	r, calls := idempotentRouter(http.StatusOK, time.Now)
+
+	sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "", `{}`)
+	sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "", `{}`)
+
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyIgnoresOtherRoutes(t *testing.T) {
+	r, calls := idempotentRouter(http.StatusOK, time.Now)
+
+	sendIdempotent(r, http.MethodDelete, "/customers/2001", "key-1", ``)
+	sendIdempotent(r, http.MethodDelete, "/customers/2001", "key-1", ``)
+
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	r, calls := idempotentRouter(http.StatusInternalServerError, time.Now)
+
+	sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "key-1", `{}`)
+	sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "key-1", `{}`)
+
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyDoesNotStoreOTPChallenges(t *testing.T) {
+	r, calls := idempotentRouter(http.StatusUnauthorized, time.Now)
+
+	sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "key-1", `{}`)
+	sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "key-1", `{}`)
+
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyKeyExpiresAfterRetention(t *testing.T) {
+	now := time.Date(2021, 3, 10, 9, 0, 0, 0, time.Local)
+	r, calls := idempotentRouter(http.StatusOK, func() time.Time { return now })
+
+	sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "key-1", `{}`)
+	now = now.Add(2 * time.Hour)
+	second := sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", "key-1", `{}`)
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, "", second.Header().Get(idempotentReplayedHeader))
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	r, calls := idempotentRouter(http.StatusOK, time.Now)
+
+	resp := sendIdempotent(r, http.MethodPost, "/customers/2001/account/95472", strings.Repeat("k", 256), `{}`)
+
+	assert.Equal(t, 0, *calls)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
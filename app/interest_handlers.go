@@ -0,0 +1,104 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// InterestHandler connects the interest routes to the InterestService
+type InterestHandler struct {
+	service  service.InterestService
+	calendar calendar.Calendar
+}
+
+// GetInterestProducts returns every interest product
+func (ih InterestHandler) GetInterestProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := ih.service.GetInterestProducts()
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, products)
+}
+
+// SaveInterestProduct creates or replaces the product named in the route
+func (ih InterestHandler) SaveInterestProduct(w http.ResponseWriter, r *http.Request) {
+	var request dto.InterestProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	product, appErr := ih.service.SaveInterestProduct(mux.Vars(r)["product_code"], request)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, product)
+}
+
+// RunInterestAccrual accrues one day of interest, yesterday when the body names no date
+func (ih InterestHandler) RunInterestAccrual(w http.ResponseWriter, r *http.Request) {
+	request, ok := decodeRunRequest(w, r, ih.calendar.Today(time.Now()).AddDate(0, 0, -1))
+	if !ok {
+		return
+	}
+	result, appErr := ih.service.AccrueInterest(request.Date)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, result)
+}
+
+// RunInterestPosting posts the interest of the periods that ended before a date, today when the body names no date
+func (ih InterestHandler) RunInterestPosting(w http.ResponseWriter, r *http.Request) {
+	request, ok := decodeRunRequest(w, r, ih.calendar.Today(time.Now()))
+	if !ok {
+		return
+	}
+	result, appErr := ih.service.PostInterest(request.Date)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, result)
+}
+
+// decodeRunRequest reads an optional run request, an empty body runs for the default day
+func decodeRunRequest(w http.ResponseWriter, r *http.Request, defaultDay time.Time) (dto.InterestRunRequest, bool) {
+	var request dto.InterestRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return request, false
+	}
+	if request.Date == "" {
+		request.Date = domain.FormatRunDate(defaultDay)
+	}
+	return request, true
+}
+
+// lastMonth is the fee month before the bank's month of now
+func lastMonth(cal calendar.Calendar, now time.Time) string {
+	today := cal.Today(now)
+	return domain.FormatFeePeriod(today.AddDate(0, 0, -today.Day()))
+}
+
+// runInterest runs the interest jobs that are due every interval, it is meant to be run in its own goroutine
+func runInterest(s service.InterestService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if appErr := s.RunDue(); appErr != nil {
+			logger.Error("interest run did not finish: " + appErr.Message)
+		}
+	}
+}
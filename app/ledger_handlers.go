@@ -0,0 +1,26 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// LedgerHandler connects ledger routing options to ledger services
+type LedgerHandler struct {
+	service service.LedgerService
+}
+
+// CheckLedger returns the trial balance and every invariant the ledger breaks
+func (lh LedgerHandler) CheckLedger(w http.ResponseWriter, r *http.Request) {
+	result, err := lh.service.CheckLedger()
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	if !result.Balanced {
+		logger.Error("ledger check found the books out of balance")
+	}
+	writeResponse(w, http.StatusOK, result)
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// the header a client sends a one time password in, and the challenge that asks for it
+const (
+	otpHeader       = "X-OTP"
+	challengeHeader = "WWW-Authenticate"
+	otpChallenge    = `OTP realm="banking", header="X-OTP"`
+)
+
+// MFAHandler connects the mfa routes to the MFAService
+type MFAHandler struct {
+	service service.MFAService
+}
+
+// EnrollMFA starts totp enrollment for the user of the token
+func (mh MFAHandler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	username, appErr := tokenUsername(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	enrollment, appErr := mh.service.Enroll(username)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeResponse(w, http.StatusOK, enrollment)
+}
+
+// ConfirmMFA turns mfa on with the first code of the authenticator app and returns the recovery codes
+func (mh MFAHandler) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	username, appErr := tokenUsername(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	var request dto.MFAConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	codes, appErr := mh.service.Confirm(username, request)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeResponse(w, http.StatusOK, codes)
+}
+
+// ResetMFA turns mfa off for a user, it is an admin route
+func (mh MFAHandler) ResetMFA(w http.ResponseWriter, r *http.Request) {
+	if appErr := mh.service.Reset(mux.Vars(r)["username"]); appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, map[string]string{"status": "mfa reset"})
+}
+
+// StepUp asks for a one time password before a transaction or transfer above the threshold.
+// A StepUp without a service never asks.
+type StepUp struct {
+	service   service.MFAService
+	threshold money.Money
+}
+
+// allows checks the X-OTP header when the amount is above the threshold. When it returns false
+// the response was written, a 401 with an OTP challenge when the password is missing or wrong.
+func (s StepUp) allows(w http.ResponseWriter, r *http.Request, amount money.Money) bool {
+	if s.service == nil || !amount.GreaterThan(s.threshold) {
+		return true
+	}
+	username := ""
+	if claims := claimsFrom(r); claims != nil {
+		// an api key was scoped to the route by an admin and has no one to ask for a password
+		if claims.Role == domain.ROLE_SERVICE {
+			return true
+		}
+		username = claims.Username
+	}
+	if appErr := s.service.StepUp(username, r.Header.Get(otpHeader)); appErr != nil {
+		writeAuthError(w, appErr)
+		return false
+	}
+	return true
+}
+
+// writeAuthError writes an error, with the OTP challenge when the error asks for a one time password
+func writeAuthError(w http.ResponseWriter, appErr *errs.AppError) {
+	if appErr.Reason == service.REASON_MFA_REQUIRED || appErr.Reason == service.REASON_INVALID_OTP {
+		w.Header().Set(challengeHeader, otpChallenge)
+	}
+	writeResponse(w, appErr.Code, appErr.AsMessage())
+}
+
+// tokenUsername returns the username of the token a request was authorized with
+func tokenUsername(r *http.Request) (string, *errs.AppError) {
+	claims := claimsFrom(r)
+	if claims == nil || claims.Username == "" {
+		return "", errs.NewForbiddenError("the token has no username")
+	}
+	return claims.Username, nil
+}
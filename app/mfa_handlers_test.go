@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/service"
+	"github.com/jonathanwamsley/banking/money"
+	realservice "github.com/jonathanwamsley/banking/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func stepUpRequest(otp string) *http.Request {
+	request, _ := http.NewRequest(http.MethodPost, "/customers/2001/account/95472", nil)
+	if otp != "" {
+		request.Header.Set(otpHeader, otp)
+	}
+	claims := &domain.AccessClaims{Username: "2001"}
+	return request.WithContext(context.WithValue(request.Context(), claimsKey{}, claims))
+}
+
+func TestStepUpOnlyAsksAboveThreshold(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mfa := service.NewMockMFAService(ctrl)
+	stepUp := StepUp{service: mfa, threshold: money.MustParse("5000.00")}
+
+	assert.True(t, stepUp.allows(httptest.NewRecorder(), stepUpRequest(""), money.MustParse("5000.00")))
+	assert.True(t, StepUp{}.allows(httptest.NewRecorder(), stepUpRequest(""), money.MustParse("90000.00")))
+}
+
+func TestStepUpChallengesWithoutOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mfa := service.NewMockMFAService(ctrl)
+	mfa.EXPECT().StepUp("2001", "").Return(errs.NewUnauthorizedError("a one time password is required").WithReason(realservice.REASON_MFA_REQUIRED))
+	stepUp := StepUp{service: mfa, threshold: money.MustParse("5000.00")}
+
+	recorder := httptest.NewRecorder()
+	assert.False(t, stepUp.allows(recorder, stepUpRequest(""), money.MustParse("5000.01")))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, otpChallenge, recorder.Header().Get(challengeHeader))
+}
+
+func TestStepUpAcceptsOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mfa := service.NewMockMFAService(ctrl)
+	mfa.EXPECT().StepUp("2001", "287082").Return(nil)
+	stepUp := StepUp{service: mfa, threshold: money.MustParse("5000.00")}
+
+	assert.True(t, stepUp.allows(httptest.NewRecorder(), stepUpRequest("287082"), money.MustParse("7500.00")))
+}
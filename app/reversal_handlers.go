@@ -0,0 +1,36 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// ReversalHandler connects the reversal route to the ReversalService
+type ReversalHandler struct {
+	service service.ReversalService
+}
+
+// ReverseTransaction posts a compensating transaction for a transaction, the reversal records who made it
+func (rh ReversalHandler) ReverseTransaction(w http.ResponseWriter, r *http.Request) {
+	createdBy, appErr := tokenUsername(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	var request dto.ReverseTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	vars := mux.Vars(r)
+	reversal, appErr := rh.service.ReverseTransaction(vars["customer_id"], vars["account_id"], vars["transaction_id"], request, createdBy)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusCreated, reversal)
+}
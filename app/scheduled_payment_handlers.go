@@ -0,0 +1,137 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// ScheduledPaymentHandler connects the scheduled payment routes to the ScheduledPaymentService. A schedule pays
+// without anyone there to confirm it, so setting an amount above the step-up threshold asks for a one time password.
+type ScheduledPaymentHandler struct {
+	service service.ScheduledPaymentService
+	stepUp  StepUp
+}
+
+// GetScheduledPayments returns the scheduled payments of a customer
+func (ph ScheduledPaymentHandler) GetScheduledPayments(w http.ResponseWriter, r *http.Request) {
+	payments, appErr := ph.service.GetScheduledPayments(mux.Vars(r)["customer_id"])
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, payments)
+}
+
+// GetScheduledPayment returns a scheduled payment with its version as the ETag
+func (ph ScheduledPaymentHandler) GetScheduledPayment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	payment, appErr := ph.service.GetScheduledPayment(vars["customer_id"], vars["payment_id"])
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	setETag(w, payment.Version)
+	writeResponse(w, http.StatusOK, payment)
+}
+
+// CreateScheduledPayment schedules a payment from an account of the customer
+func (ph ScheduledPaymentHandler) CreateScheduledPayment(w http.ResponseWriter, r *http.Request) {
+	var request dto.CreateScheduledPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	request.CustomerID = mux.Vars(r)["customer_id"]
+	if !ph.stepUp.allows(w, r, request.Amount) {
+		return
+	}
+	payment, appErr := ph.service.CreateScheduledPayment(request)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	setETag(w, payment.Version)
+	writeResponse(w, http.StatusCreated, payment)
+}
+
+// UpdateScheduledPayment replaces what can change of a scheduled payment, every field has to be sent
+func (ph ScheduledPaymentHandler) UpdateScheduledPayment(w http.ResponseWriter, r *http.Request) {
+	version, appErr := ifMatchVersion(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	var request dto.UpdateScheduledPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	request.Version = version
+	if !ph.stepUp.allows(w, r, request.Amount) {
+		return
+	}
+	vars := mux.Vars(r)
+	payment, appErr := ph.service.UpdateScheduledPayment(vars["customer_id"], vars["payment_id"], request)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	setETag(w, payment.Version)
+	writeResponse(w, http.StatusOK, payment)
+}
+
+// CancelScheduledPayment ends a scheduled payment, it stays in the list with its run history
+func (ph ScheduledPaymentHandler) CancelScheduledPayment(w http.ResponseWriter, r *http.Request) {
+	version, appErr := ifMatchVersion(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	vars := mux.Vars(r)
+	payment, appErr := ph.service.CancelScheduledPayment(vars["customer_id"], vars["payment_id"], version)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	setETag(w, payment.Version)
+	writeResponse(w, http.StatusOK, payment)
+}
+
+// GetScheduledPaymentRuns returns the run history of a scheduled payment
+func (ph ScheduledPaymentHandler) GetScheduledPaymentRuns(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	runs, appErr := ph.service.GetScheduledPaymentRuns(vars["customer_id"], vars["payment_id"])
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, runs)
+}
+
+// RunScheduledPayments makes the scheduled payments that are due now, without waiting for the scheduler
+func (ph ScheduledPaymentHandler) RunScheduledPayments(w http.ResponseWriter, r *http.Request) {
+	result, appErr := ph.service.RunDuePayments()
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, result)
+}
+
+// runScheduledPayments makes the scheduled payments that are due every interval, it is meant to be run in its
+// own goroutine
+func runScheduledPayments(s service.ScheduledPaymentService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, appErr := s.RunDuePayments(); appErr != nil {
+			logger.Error("scheduled payment run did not finish: " + appErr.Message)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/service"
+	"github.com/jonathanwamsley/banking/money"
+	realservice "github.com/jonathanwamsley/banking/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func servePaymentRequest(ph ScheduledPaymentHandler, method string, path string, body string, otp string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc("/customers/{customer_id}/scheduled-payments", ph.CreateScheduledPayment).Methods(http.MethodPost)
+	router.HandleFunc("/customers/{customer_id}/scheduled-payments/{payment_id}", ph.UpdateScheduledPayment).Methods(http.MethodPut)
+
+	request, _ := http.NewRequest(method, path, strings.NewReader(body))
+	if otp != "" {
+		request.Header.Set(otpHeader, otp)
+	}
+	claims := &domain.AccessClaims{Username: "2001"}
+	request = request.WithContext(context.WithValue(request.Context(), claimsKey{}, claims))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCreateScheduledPaymentAboveThresholdIsChallenged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mfa := service.NewMockMFAService(ctrl)
+	mfa.EXPECT().StepUp("2001", "").Return(errs.NewUnauthorizedError("a one time password is required").WithReason(realservice.REASON_MFA_REQUIRED))
+	ph := ScheduledPaymentHandler{service: service.NewMockScheduledPaymentService(ctrl), stepUp: StepUp{service: mfa, threshold: money.MustParse("5000.00")}}
+
+	recorder := servePaymentRequest(ph, http.MethodPost, "/customers/2001/scheduled-payments", `{"account_id":"95472","amount":"6000.00","frequency":"monthly"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, otpChallenge, recorder.Header().Get(challengeHeader))
+}
+
+func TestCreateScheduledPaymentAboveThresholdWithOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mfa := service.NewMockMFAService(ctrl)
+	mfa.EXPECT().StepUp("2001", "287082").Return(nil)
+	payments := service.NewMockScheduledPaymentService(ctrl)
+	payments.EXPECT().CreateScheduledPayment(gomock.Any()).Return(&dto.ScheduledPaymentResponse{PaymentID: "1", Version: 1}, nil)
+	ph := ScheduledPaymentHandler{service: payments, stepUp: StepUp{service: mfa, threshold: money.MustParse("5000.00")}}
+
+	recorder := servePaymentRequest(ph, http.MethodPost, "/customers/2001/scheduled-payments", `{"account_id":"95472","amount":"6000.00","frequency":"monthly"}`, "287082")
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+}
+
+func TestUpdateScheduledPaymentAboveThresholdIsChallenged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mfa := service.NewMockMFAService(ctrl)
+	mfa.EXPECT().StepUp("2001", "000000").Return(errs.NewUnauthorizedError("invalid one time password").WithReason(realservice.REASON_INVALID_OTP))
+	ph := ScheduledPaymentHandler{service: service.NewMockScheduledPaymentService(ctrl), stepUp: StepUp{service: mfa, threshold: money.MustParse("5000.00")}}
+
+	recorder := servePaymentRequest(ph, http.MethodPut, "/customers/2001/scheduled-payments/1", `{"amount":"9000.00","retry_policy":"retry","status":"active"}`, "000000")
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, otpChallenge, recorder.Header().Get(challengeHeader))
+}
+
+func TestUpdateScheduledPaymentBelowThresholdIsNotChallenged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	payments := service.NewMockScheduledPaymentService(ctrl)
+	payments.EXPECT().UpdateScheduledPayment("2001", "1", gomock.Any()).Return(&dto.ScheduledPaymentResponse{PaymentID: "1", Version: 2}, nil)
+	ph := ScheduledPaymentHandler{service: payments, stepUp: StepUp{service: service.NewMockMFAService(ctrl), threshold: money.MustParse("5000.00")}}
+
+	recorder := servePaymentRequest(ph, http.MethodPut, "/customers/2001/scheduled-payments/1", `{"amount":"50.00","retry_policy":"retry","status":"active"}`, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// SessionHandler connects the session routes to the SessionService
+type SessionHandler struct {
+	service service.SessionService
+}
+
+// GetSessions returns the active sessions of the user of the token. An admin can ask for another user's
+// sessions with ?username=.
+func (sh SessionHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	username, appErr := tokenUsername(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	claims := claimsFrom(r)
+	if other := r.URL.Query().Get("username"); other != "" && other != username {
+		if claims.Role != domain.ROLE_ADMIN {
+			writeResponse(w, http.StatusForbidden, "only an admin can list the sessions of another user")
+			return
+		}
+		username = other
+	}
+	sessions, appErr := sh.service.GetSessions(username, claims.SessionID)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, sessions)
+}
+
+// RevokeSession logs a session out, its tokens are refused from the next request on. Users can revoke
+// their own sessions, an admin any session.
+func (sh SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	username, appErr := tokenUsername(r)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	isAdmin := claimsFrom(r).Role == domain.ROLE_ADMIN
+	if appErr = sh.service.RevokeSession(mux.Vars(r)["session_id"], username, isAdmin); appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+// RevokeToken puts a compromised token on the revocation list, it is an admin route
+func (sh SessionHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	var request dto.RevokeTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if appErr := sh.service.RevokeToken(request); appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/jonathanwamsley/banking/pdf"
+)
+
+// writeStatementCSV writes the summary of a statement as field, value rows, an empty row, then one row per
+// transaction under a header row
+func writeStatementCSV(w io.Writer, s dto.StatementResponse) error {
+	out := csv.NewWriter(w)
+	summary := [][]string{
+		{"account_id", s.AccountID},
+		{"account_type", s.AccountType},
+		{"customer_id", s.Customer.CustomerID},
+		{"full_name", s.Customer.Name},
+		{"city", s.Customer.City},
+		{"zipcode", s.Customer.Zipcode},
+		{"period_start", s.PeriodStart},
+		{"period_end", s.PeriodEnd},
+		{"currency", s.OpeningBalance.Currency()},
+		{"opening_balance", s.OpeningBalance.String()},
+		{"total_credits", s.TotalCredits.String()},
+		{"total_debits", s.TotalDebits.String()},
+		{"fees_charged", s.FeesCharged.String()},
+		{"interest_paid", s.InterestPaid.String()},
+		{"interest_charged", s.InterestCharged.String()},
+		{"closing_balance", s.ClosingBalance.String()},
+		{"generated_at", s.GeneratedAt},
+		{"checksum", s.Checksum},
+		{},
+		{"transaction_date", "value_date", "transaction_id", "transaction_type", "description", "amount", "balance"},
+	}
+	if err := out.WriteAll(summary); err != nil {
+		return err
+	}
+	for _, t := range s.Transactions {
+		row := []string{t.TransactionDate, t.ValueDate, t.TransactionID, t.TransactionType, t.Description, t.Amount.String(), t.Balance.String()}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// the layout of a pdf statement in points. The first page also holds the address and the summary, so it has
+// room for fewer transactions.
+const (
+	pdfMargin        = 50.0
+	pdfRowHeight     = 13.0
+	pdfFirstPageRows = 30
+	pdfPageRows      = 48
+)
+
+// the right edge of the amount and balance columns of the transaction table
+const (
+	pdfAmountRight  = 470.0
+	pdfBalanceRight = pdf.LetterWidth - pdfMargin
+)
+
+// statementPDF draws a statement on letter pages: the customer's name and address, the account and month, the
+// summary, then the transactions with their running balance. Every page has a footer with its number and the
+// checksum of the statement.
+func statementPDF(s dto.StatementResponse) []byte {
+	pages := 1
+	if rest := len(s.Transactions) - pdfFirstPageRows; rest > 0 {
+		pages += (rest + pdfPageRows - 1) / pdfPageRows
+	}
+
+	doc := pdf.New()
+	page := doc.AddPage()
+	top := pdf.LetterHeight - pdfMargin
+	page.Text(pdfMargin, top, pdf.HelveticaBold, 16, "Account statement")
+	page.Text(pdfMargin, top-28, pdf.HelveticaBold, 11, s.Customer.Name)
+	page.Text(pdfMargin, top-42, pdf.Helvetica, 10, s.Customer.City+" "+s.Customer.Zipcode)
+	page.Text(330, top-28, pdf.Helvetica, 10, "Account "+s.AccountID+" ("+s.AccountType+")")
+	page.Text(330, top-42, pdf.Helvetica, 10, "Period "+s.PeriodStart+" to "+s.PeriodEnd)
+	page.Text(330, top-56, pdf.Helvetica, 10, "Amounts in "+s.OpeningBalance.Currency())
+
+	summary := []struct {
+		label  string
+		amount money.Money
+	}{
+		{"Opening balance", s.OpeningBalance},
+		{"Money in", s.TotalCredits},
+		{"Money out", s.TotalDebits},
+		{"Fees charged", s.FeesCharged},
+		{"Interest paid", s.InterestPaid},
+		{"Interest charged", s.InterestCharged},
+		{"Closing balance", s.ClosingBalance},
+	}
+	y := top - 90
+	for _, line := range summary {
+		font := pdf.Helvetica
+		if line.label == "Closing balance" {
+			font = pdf.HelveticaBold
+		}
+		page.Text(pdfMargin, y, font, 10, line.label)
+		page.TextRight(250, y, 10, line.amount.String())
+		y -= 14
+	}
+
+	number := 1
+	y = statementTableHeader(page, y-16)
+	for i, t := range s.Transactions {
+		if i == pdfFirstPageRows || (i > pdfFirstPageRows && (i-pdfFirstPageRows)%pdfPageRows == 0) {
+			statementFooter(page, number, pages, s.Checksum)
+			page = doc.AddPage()
+			number++
+			y = statementTableHeader(page, top)
+		}
+		page.Text(pdfMargin, y, pdf.Courier, 8, t.TransactionDate)
+		page.Text(155, y, pdf.Courier, 8, t.ValueDate)
+		page.Text(210, y, pdf.Courier, 8, truncate(t.Description, 32))
+		page.TextRight(pdfAmountRight, y, 8, t.Amount.String())
+		page.TextRight(pdfBalanceRight, y, 8, t.Balance.String())
+		y -= pdfRowHeight
+	}
+	statementFooter(page, number, pages, s.Checksum)
+	return doc.Bytes()
+}
+
+// statementTableHeader draws the column titles of the transaction table at y and returns where the first row goes
+func statementTableHeader(page *pdf.Page, y float64) float64 {
+	page.Text(pdfMargin, y, pdf.HelveticaBold, 9, "Date")
+	page.Text(155, y, pdf.HelveticaBold, 9, "Value date")
+	page.Text(210, y, pdf.HelveticaBold, 9, "Description")
+	page.Text(pdfAmountRight-40, y, pdf.HelveticaBold, 9, "Amount")
+	page.Text(pdfBalanceRight-40, y, pdf.HelveticaBold, 9, "Balance")
+	page.Line(pdfMargin, y-4, pdfBalanceRight, y-4)
+	return y - 4 - pdfRowHeight
+}
+
+// statementFooter draws the page number and the checksum at the bottom of a page
+func statementFooter(page *pdf.Page, number int, pages int, checksum string) {
+	page.Line(pdfMargin, 42, pdfBalanceRight, 42)
+	page.Text(pdfMargin, 30, pdf.Helvetica, 7, "SHA-256 "+checksum)
+	page.Text(pdfBalanceRight-50, 30, pdf.Helvetica, 7, fmt.Sprintf("Page %d of %d", number, pages))
+}
+
+// truncate shortens text to at most n characters, marking it with a trailing ellipsis of dots
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:n-3])) + "..."
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// StatementHandler connects the statement routes to the StatementService
+type StatementHandler struct {
+	service  service.StatementService
+	calendar calendar.Calendar
+}
+
+// GetStatement returns the statement of an account for a month as json, csv or pdf, json when format is left out.
+// Every format sends the X-Statement-Checksum header, the sha256 of the stored json document the csv and pdf are
+// rendered from, not of the file that is downloaded.
+func (sh StatementHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = dto.STATEMENT_JSON
+	}
+	if format != dto.STATEMENT_JSON && format != dto.STATEMENT_CSV && format != dto.STATEMENT_PDF {
+		appErr := errs.NewValidationError("format should be json, csv or pdf")
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+
+	vars := mux.Vars(r)
+	statement, appErr := sh.service.GetStatement(vars["customer_id"], vars["account_id"], vars["period"])
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	w.Header().Set("X-Statement-Checksum", statement.Checksum)
+
+	filename := "statement-" + statement.AccountID + "-" + statement.Period + "." + format
+	switch format {
+	case dto.STATEMENT_CSV:
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.WriteHeader(http.StatusOK)
+		if err := writeStatementCSV(w, *statement); err != nil {
+			logger.Error("Error while writing the csv statement " + err.Error())
+		}
+	case dto.STATEMENT_PDF:
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(statementPDF(*statement)); err != nil {
+			logger.Error("Error while writing the pdf statement " + err.Error())
+		}
+	default:
+		writeResponse(w, http.StatusOK, statement)
+	}
+}
+
+// RunStatements generates the statements of a month, last month when the body names no period
+func (sh StatementHandler) RunStatements(w http.ResponseWriter, r *http.Request) {
+	var request dto.StatementRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if request.Period == "" {
+		request.Period = lastMonth(sh.calendar, time.Now())
+	}
+	result, appErr := sh.service.GenerateStatements(request.Period)
+	if appErr != nil {
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, result)
+}
+
+// runStatements generates last month's statements when they are due every interval, it is meant to be run in its own goroutine
+func runStatements(s service.StatementService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if appErr := s.RunDue(); appErr != nil {
+			logger.Error("statement run did not finish: " + appErr.Message)
+		}
+	}
+}
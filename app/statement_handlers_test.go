@@ -0,0 +1,99 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/mocks/service"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func testStatement(transactions int) *dto.StatementResponse {
+	s := &dto.StatementResponse{
+		AccountID:      "95470",
+		AccountType:    "checking",
+		Period:         "2021-03",
+		PeriodStart:    "2021-03-01",
+		PeriodEnd:      "2021-03-31",
+		Customer:       dto.StatementCustomer{CustomerID: "2000", Name: "Steve", City: "Delhi", Zipcode: "110075"},
+		OpeningBalance: money.MustParse("100.00"),
+		ClosingBalance: money.MustParse("100.00"),
+		Checksum:       "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8",
+	}
+	for i := 0; i < transactions; i++ {
+		s.Transactions = append(s.Transactions, dto.StatementLine{
+			TransactionID:   fmt.Sprint(i + 1),
+			TransactionType: "deposit",
+			Description:     "deposit",
+			TransactionDate: "2021-03-02 10:00:00",
+			ValueDate:       "2021-03-02",
+			Amount:          money.MustParse("1.00"),
+			Balance:         money.MustParse("101.00"),
+		})
+	}
+	return s
+}
+
+func serveStatement(t *testing.T, format string, statement *dto.StatementResponse) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	statements := service.NewMockStatementService(ctrl)
+	if statement != nil {
+		statements.EXPECT().GetStatement("2000", "95470", "2021-03").Return(statement, nil)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/customers/{customer_id}/account/{account_id}/statements/{period}", StatementHandler{service: statements}.GetStatement)
+	request, _ := http.NewRequest(http.MethodGet, "/customers/2000/account/95470/statements/2021-03?format="+format, nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestGetStatementAsCSV(t *testing.T) {
+	statement := testStatement(2)
+	recorder := serveStatement(t, "csv", statement)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, statement.Checksum, recorder.Header().Get("X-Statement-Checksum"))
+	reader := csv.NewReader(recorder.Body)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"full_name", "Steve"}, rows[3])
+	assert.Equal(t, []string{"2021-03-02 10:00:00", "2021-03-02", "2", "deposit", "deposit", "1.00", "101.00"}, rows[len(rows)-1])
+}
+
+func TestGetStatementAsPDFPagesTheTransactions(t *testing.T) {
+	recorder := serveStatement(t, "pdf", testStatement(pdfFirstPageRows+pdfPageRows+1))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/pdf", recorder.Header().Get("Content-Type"))
+	document := recorder.Body.Bytes()
+	assert.True(t, bytes.HasPrefix(document, []byte("%PDF-1.4")))
+	assert.Contains(t, string(document), "/Count 3")
+	assert.Contains(t, string(document), "(Page 3 of 3)")
+}
+
+func TestLastMonthIsTheMonthBeforeTheBanksDay(t *testing.T) {
+	bank, _ := calendar.New(time.FixedZone("UTC+14", 14*60*60), 0, calendar.File{})
+	// still March 31 in UTC, already April 1 at the bank
+	now := time.Date(2021, time.March, 31, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2021-03", lastMonth(bank, now))
+}
+
+func TestGetStatementRefusesUnknownFormats(t *testing.T) {
+	recorder := serveStatement(t, "xlsx", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+}
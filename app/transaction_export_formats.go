@@ -0,0 +1,217 @@
+package app
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jonathanwamsley/banking/dto"
+)
+
+// exportFormat writes the parts of a transaction export in one file format
+type exportFormat interface {
+	contentType() string
+	begin(out *bufio.Writer, e dto.TransactionExport) error
+	write(out *bufio.Writer, t dto.TransactionExportItem) error
+	end(out *bufio.Writer) error
+}
+
+// transactionWriter streams an export to the response through a buffer, so rows reach the client as the buffer
+// fills instead of when the whole export is read. The headers are only sent once the export begins, until then
+// an error can still be sent as json.
+type transactionWriter struct {
+	w         http.ResponseWriter
+	out       *bufio.Writer
+	format    exportFormat
+	extension string
+	started   bool
+}
+
+// newTransactionWriter writes the format of an export request. The request is validated before anything is
+// written, so an unknown format never gets past Begin.
+func newTransactionWriter(w http.ResponseWriter, format string, bankID string) *transactionWriter {
+	t := &transactionWriter{w: w, out: bufio.NewWriter(w), extension: format}
+	switch format {
+	case dto.EXPORT_QIF:
+		t.format = qifFormat{}
+	case dto.EXPORT_CSV:
+		t.format = &csvFormat{}
+	default:
+		t.format = &ofxFormat{bankID: bankID}
+	}
+	return t
+}
+
+// Begin sends the headers of the download and the start of the file
+func (t *transactionWriter) Begin(e dto.TransactionExport) error {
+	filename := "transactions-" + e.AccountID + "-" + e.From + "-" + e.To + "." + t.extension
+	t.w.Header().Set("Content-Type", t.format.contentType())
+	t.w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	t.w.WriteHeader(http.StatusOK)
+	t.started = true
+	return t.format.begin(t.out, e)
+}
+
+// Write adds a transaction to the file
+func (t *transactionWriter) Write(item dto.TransactionExportItem) error {
+	return t.format.write(t.out, item)
+}
+
+// End writes the end of the file and sends what is left in the buffer
+func (t *transactionWriter) End() error {
+	if err := t.format.end(t.out); err != nil {
+		return err
+	}
+	return t.out.Flush()
+}
+
+// ofxTransactionTypes maps transaction types to the OFX TRNTYPE finance tools categorize them by
+var ofxTransactionTypes = map[string]string{
+	dto.DEPOSIT:            "DEP",
+	dto.WITHDRAWAL:         "CASH",
+	dto.TRANSFER_IN:        "XFER",
+	dto.TRANSFER_OUT:       "XFER",
+	dto.SWEEP_IN:           "XFER",
+	dto.SWEEP_OUT:          "XFER",
+	dto.INTEREST:           "INT",
+	dto.OVERDRAFT_INTEREST: "INT",
+	dto.FEE:                "FEE",
+	dto.REVERSAL_IN:        "CREDIT",
+	dto.REVERSAL_OUT:       "DEBIT",
+}
+
+// ofxAccountTypes maps account types to the OFX ACCTTYPE
+var ofxAccountTypes = map[string]string{
+	"checking": "CHECKING",
+	"saving":   "SAVINGS",
+}
+
+// ofxFormat writes an OFX 1.02 bank statement, the SGML version every finance tool imports. The FITID of a
+// transaction is its transaction_id, so importing an overlapping range again does not duplicate it.
+type ofxFormat struct {
+	bankID string
+	export dto.TransactionExport
+}
+
+func (f *ofxFormat) contentType() string {
+	return "application/x-ofx"
+}
+
+func (f *ofxFormat) begin(out *bufio.Writer, e dto.TransactionExport) error {
+	f.export = e
+	accountType, ok := ofxAccountTypes[e.AccountType]
+	if !ok {
+		accountType = "CHECKING"
+	}
+	_, err := fmt.Fprintf(out, "OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\nSECURITY:NONE\r\nENCODING:USASCII\r\nCHARSET:1252\r\n"+
+		"COMPRESSION:NONE\r\nOLDFILEUID:NONE\r\nNEWFILEUID:NONE\r\n\r\n"+
+		"<OFX>\r\n<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS><DTSERVER>%s<LANGUAGE>ENG</SONRS></SIGNONMSGSRSV1>\r\n"+
+		"<BANKMSGSRSV1><STMTTRNRS><TRNUID>0<STATUS><CODE>0<SEVERITY>INFO</STATUS>\r\n"+
+		"<STMTRS><CURDEF>%s<BANKACCTFROM><BANKID>%s<ACCTID>%s<ACCTTYPE>%s</BANKACCTFROM>\r\n"+
+		"<BANKTRANLIST><DTSTART>%s<DTEND>%s\r\n",
+		ofxDate(e.ExportedAt), e.Currency, ofxText(f.bankID), e.AccountID, accountType, ofxDate(e.From), ofxDate(e.To))
+	return err
+}
+
+func (f *ofxFormat) write(out *bufio.Writer, t dto.TransactionExportItem) error {
+	transactionType, ok := ofxTransactionTypes[t.TransactionType]
+	if !ok {
+		transactionType = "OTHER"
+	}
+	memo := ""
+	if t.RelatedTransactionID != "" {
+		memo = "<MEMO>" + relatedMemo(t)
+	}
+	_, err := fmt.Fprintf(out, "<STMTTRN><TRNTYPE>%s<DTPOSTED>%s<DTAVAIL>%s<TRNAMT>%s<FITID>%s<NAME>%s%s</STMTTRN>\r\n",
+		transactionType, ofxDate(t.TransactionDate), ofxDate(t.ValueDate), t.Amount.String(), t.TransactionID, transactionName(t.TransactionType), memo)
+	return err
+}
+
+func (f *ofxFormat) end(out *bufio.Writer) error {
+	_, err := fmt.Fprintf(out, "</BANKTRANLIST>\r\n<LEDGERBAL><BALAMT>%s<DTASOF>%s</LEDGERBAL>\r\n</STMTRS></STMTTRNRS></BANKMSGSRSV1>\r\n</OFX>\r\n",
+		f.export.Balance.String(), ofxDate(f.export.ExportedAt))
+	return err
+}
+
+// ofxDate writes a date like 2021-03-31 or a datetime like 2021-03-31 09:30:00 as 20210331 or 20210331093000
+func ofxDate(date string) string {
+	return strings.NewReplacer("-", "", " ", "", ":", "").Replace(date)
+}
+
+// ofxText escapes the characters SGML reads as markup
+func ofxText(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// qifFormat writes a QIF bank account. QIF has no transaction id field, the transaction_id goes in the number
+// field, which the finance tools that deduplicate QIF imports compare.
+type qifFormat struct{}
+
+func (qifFormat) contentType() string {
+	return "application/qif"
+}
+
+func (qifFormat) begin(out *bufio.Writer, e dto.TransactionExport) error {
+	_, err := out.WriteString("!Type:Bank\n")
+	return err
+}
+
+func (qifFormat) write(out *bufio.Writer, t dto.TransactionExportItem) error {
+	date := t.BookingDate
+	if len(date) == len(dto.DATE_LAYOUT) {
+		date = date[5:7] + "/" + date[8:10] + "/" + date[0:4]
+	}
+	if _, err := fmt.Fprintf(out, "D%s\nT%s\nN%s\nP%s\n", date, t.Amount.String(), t.TransactionID, transactionName(t.TransactionType)); err != nil {
+		return err
+	}
+	if t.RelatedTransactionID != "" {
+		if _, err := out.WriteString("M" + relatedMemo(t) + "\n"); err != nil {
+			return err
+		}
+	}
+	_, err := out.WriteString("^\n")
+	return err
+}
+
+func (qifFormat) end(out *bufio.Writer) error {
+	return nil
+}
+
+// csvFormat writes a header row and one row per transaction
+type csvFormat struct {
+	out *csv.Writer
+}
+
+func (f *csvFormat) contentType() string {
+	return "text/csv"
+}
+
+func (f *csvFormat) begin(out *bufio.Writer, e dto.TransactionExport) error {
+	f.out = csv.NewWriter(out)
+	return f.out.Write([]string{"transaction_id", "transaction_date", "booking_date", "value_date", "transaction_type", "amount", "balance", "related_transaction_id"})
+}
+
+func (f *csvFormat) write(out *bufio.Writer, t dto.TransactionExportItem) error {
+	return f.out.Write([]string{t.TransactionID, t.TransactionDate, t.BookingDate, t.ValueDate, t.TransactionType, t.Amount.String(), t.Balance.String(), t.RelatedTransactionID})
+}
+
+func (f *csvFormat) end(out *bufio.Writer) error {
+	f.out.Flush()
+	return f.out.Error()
+}
+
+// transactionName is the payee finance tools show for a transaction, like Transfer out
+func transactionName(transactionType string) string {
+	name := strings.ReplaceAll(transactionType, "_", " ")
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// relatedMemo names the transaction a fee was charged for or a reversal reverses
+func relatedMemo(t dto.TransactionExportItem) string {
+	return "Related to transaction " + t.RelatedTransactionID
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// TransactionExportHandler connects the transaction export route to the AccountService. BankID is the routing
+// number OFX files name the bank with.
+type TransactionExportHandler struct {
+	service service.AccountService
+	bankID  string
+}
+
+// ExportTransactions streams the transactions of an account as an ofx, qif or csv download, ofx when format is
+// left out.
+//
+// Query parameters: format, from and to (dates like 2021-03-31)
+func (th TransactionExportHandler) ExportTransactions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	request := dto.TransactionExportRequest{
+		AccountID:  vars["account_id"],
+		CustomerID: vars["customer_id"],
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+		Format:     query.Get("format"),
+	}
+	if request.Format == "" {
+		request.Format = dto.EXPORT_OFX
+	}
+
+	out := newTransactionWriter(w, request.Format, th.bankID)
+	if appErr := th.service.ExportTransactions(request, out); appErr != nil {
+		// once the download started its status was sent, the client only sees the file end early
+		if out.started {
+			logger.Error("transaction export of account " + request.AccountID + " was cut short: " + appErr.Message)
+			return
+		}
+		writeResponse(w, appErr.Code, appErr.AsMessage())
+	}
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/service"
+	"github.com/jonathanwamsley/banking/money"
+	realservice "github.com/jonathanwamsley/banking/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// exportTransactions writes a deposit and a withdrawal with its fee to the export writer
+func exportTransactions(request dto.TransactionExportRequest, out realservice.TransactionWriter) *errs.AppError {
+	out.Begin(dto.TransactionExport{
+		AccountID: "95470", AccountType: "checking", Currency: "USD", From: "2021-03-01", To: "2021-03-31",
+		Balance: money.MustParse("6561.23"), ExportedAt: "2021-04-01 08:00:00",
+	})
+	out.Write(dto.TransactionExportItem{TransactionID: "8", TransactionType: dto.DEPOSIT, TransactionDate: "2021-03-02 10:00:00",
+		BookingDate: "2021-03-02", ValueDate: "2021-03-02", Amount: money.MustParse("50.00"), Balance: money.MustParse("6873.23")})
+	out.Write(dto.TransactionExportItem{TransactionID: "9", TransactionType: dto.WITHDRAWAL, TransactionDate: "2021-03-31 09:30:00",
+		BookingDate: "2021-03-31", ValueDate: "2021-03-31", Amount: money.MustParse("-300.00"), Balance: money.MustParse("6573.23")})
+	out.Write(dto.TransactionExportItem{TransactionID: "10", TransactionType: dto.FEE, TransactionDate: "2021-03-31 09:30:00",
+		BookingDate: "2021-03-31", ValueDate: "2021-03-31", Amount: money.MustParse("-12.00"), Balance: money.MustParse("6561.23"), RelatedTransactionID: "9"})
+	out.End()
+	return nil
+}
+
+func serveExport(t *testing.T, query string, export func(dto.TransactionExportRequest, realservice.TransactionWriter) *errs.AppError) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	accounts := service.NewMockAccountService(ctrl)
+	accounts.EXPECT().ExportTransactions(gomock.Any(), gomock.Any()).DoAndReturn(export)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/customers/{customer_id}/account/{account_id}/transactions/export", TransactionExportHandler{accounts, "021000021"}.ExportTransactions)
+	request, _ := http.NewRequest(http.MethodGet, "/customers/2000/account/95470/transactions/export"+query, nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestExportTransactionsAsOFX(t *testing.T) {
+	recorder := serveExport(t, "?from=2021-03-01&to=2021-03-31", func(request dto.TransactionExportRequest, out realservice.TransactionWriter) *errs.AppError {
+		assert.Equal(t, dto.EXPORT_OFX, request.Format)
+		assert.Equal(t, "2021-03-01", request.From)
+		return exportTransactions(request, out)
+	})
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/x-ofx", recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+	assert.True(t, strings.HasPrefix(body, "OFXHEADER:100\r\n"))
+	assert.Contains(t, body, "<BANKID>021000021<ACCTID>95470<ACCTTYPE>CHECKING</BANKACCTFROM>")
+	assert.Contains(t, body, "<STMTTRN><TRNTYPE>DEP<DTPOSTED>20210302100000<DTAVAIL>20210302<TRNAMT>50.00<FITID>8<NAME>Deposit</STMTTRN>")
+	assert.Contains(t, body, "<TRNTYPE>CASH<DTPOSTED>20210331093000<DTAVAIL>20210331<TRNAMT>-300.00<FITID>9<NAME>Withdrawal</STMTTRN>")
+	assert.Contains(t, body, "<TRNTYPE>FEE")
+	assert.Contains(t, body, "<LEDGERBAL><BALAMT>6561.23<DTASOF>20210401080000</LEDGERBAL>")
+	assert.True(t, strings.HasSuffix(body, "</OFX>\r\n"))
+}
+
+func TestExportTransactionsAsQIF(t *testing.T) {
+	recorder := serveExport(t, "?format=qif", exportTransactions)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `attachment; filename="transactions-95470-2021-03-01-2021-03-31.qif"`, recorder.Header().Get("Content-Disposition"))
+	assert.Equal(t, "!Type:Bank\n"+
+		"D03/02/2021\nT50.00\nN8\nPDeposit\n^\n"+
+		"D03/31/2021\nT-300.00\nN9\nPWithdrawal\n^\n"+
+		"D03/31/2021\nT-12.00\nN10\nPFee\nMRelated to transaction 9\n^\n", recorder.Body.String())
+}
+
+func TestExportTransactionsAsCSV(t *testing.T) {
+	recorder := serveExport(t, "?format=csv", exportTransactions)
+
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	assert.Equal(t, 4, len(lines))
+	assert.Equal(t, "transaction_id,transaction_date,booking_date,value_date,transaction_type,amount,balance,related_transaction_id", lines[0])
+	assert.Equal(t, "10,2021-03-31 09:30:00,2021-03-31,2021-03-31,fee,-12.00,6561.23,9", lines[3])
+}
+
+func TestExportTransactionsErrorBeforeItBeginsIsJSON(t *testing.T) {
+	recorder := serveExport(t, "?format=xls", func(dto.TransactionExportRequest, realservice.TransactionWriter) *errs.AppError {
+		return errs.NewValidationError("format must be ofx, qif or csv")
+	})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+}
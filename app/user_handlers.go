@@ -0,0 +1,84 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/service"
+)
+
+// UserHandler connects the admin user routes to the UserService
+type UserHandler struct {
+	service service.UserService
+}
+
+// GetAllUsers returns every user
+func (uh UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := uh.service.GetAllUsers()
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, users)
+}
+
+// GetUser returns a user by username
+func (uh UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := uh.service.GetUser(mux.Vars(r)["username"])
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, user)
+}
+
+// CreateUser creates a user and returns it without the password
+func (uh UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var request dto.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	user, err := uh.service.CreateUser(request)
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusCreated, user)
+}
+
+// UpdateUser changes the role, customer or password of a user
+func (uh UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	var request dto.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	user, err := uh.service.UpdateUser(mux.Vars(r)["username"], request)
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, user)
+}
+
+// DeleteUser returns a confirmation status deleted if success
+func (uh UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := uh.service.DeleteUser(mux.Vars(r)["username"]); err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// UnlockUser lets a locked out user log in again
+func (uh UserHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	user, err := uh.service.UnlockUser(mux.Vars(r)["username"])
+	if err != nil {
+		writeResponse(w, err.Code, err.AsMessage())
+		return
+	}
+	writeResponse(w, http.StatusOK, user)
+}
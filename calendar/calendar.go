@@ -0,0 +1,184 @@
+// Package calendar knows the business days of the bank: its time zone, its weekend and holidays, and the
+// cut-off time after which a payment is only valued on the next business day.
+package calendar
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// the layouts of the dates and timestamps the bank stores, wall-clock times of the bank's time zone
+const (
+	DateLayout      = "2006-01-02"
+	TimestampLayout = "2006-01-02 15:04:05"
+	cutOffLayout    = "15:04"
+)
+
+// Holiday is a date the bank is closed
+type Holiday struct {
+	Date string `yaml:"date"`
+	Name string `yaml:"name"`
+}
+
+// File is a holiday file. Weekend lists the weekdays the bank is closed, saturday and sunday when left out.
+type File struct {
+	Weekend  []string  `yaml:"weekend"`
+	Holidays []Holiday `yaml:"holidays"`
+}
+
+// Calendar answers which days are business days and how dates are booked and valued. A zero cut-off values
+// every payment of a business day on that day.
+type Calendar struct {
+	location *time.Location
+	cutOff   time.Duration
+	weekend  map[time.Weekday]bool
+	holidays map[string]string
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// New returns a calendar of a time zone with a cut-off after midnight, closed on the weekend and the holidays
+// of f
+func New(location *time.Location, cutOff time.Duration, f File) (Calendar, error) {
+	c := Calendar{location: location, cutOff: cutOff, weekend: map[time.Weekday]bool{}, holidays: map[string]string{}}
+	if len(f.Weekend) == 0 {
+		f.Weekend = []string{"saturday", "sunday"}
+	}
+	for _, name := range f.Weekend {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return Calendar{}, fmt.Errorf("weekend day %q is not a day of the week", name)
+		}
+		c.weekend[day] = true
+	}
+	if len(c.weekend) == len(weekdays) {
+		return Calendar{}, fmt.Errorf("the bank has to be open on a day of the week")
+	}
+	for _, h := range f.Holidays {
+		if _, err := time.Parse(DateLayout, h.Date); err != nil {
+			return Calendar{}, fmt.Errorf("holiday %q: date should look like %s", h.Name, DateLayout)
+		}
+		c.holidays[h.Date] = h.Name
+	}
+	return c, nil
+}
+
+// Default is a calendar of the local time zone, closed on the weekend, without holidays or a cut-off
+func Default() Calendar {
+	c, _ := New(time.Local, 0, File{})
+	return c
+}
+
+// Load builds the calendar of a bank from an IANA time zone like America/New_York, or Local, a cut-off like
+// 17:00 and a holiday file. An empty cut-off or holiday file leaves them out.
+func Load(timezone string, cutOff string, holidayFile string) (Calendar, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Calendar{}, fmt.Errorf("time zone %q: %v", timezone, err)
+	}
+	var after time.Duration
+	if cutOff != "" {
+		at, err := time.Parse(cutOffLayout, cutOff)
+		if err != nil {
+			return Calendar{}, fmt.Errorf("cut-off %q should look like 17:00", cutOff)
+		}
+		after = time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+	}
+	var f File
+	if holidayFile != "" {
+		data, err := ioutil.ReadFile(holidayFile)
+		if err != nil {
+			return Calendar{}, err
+		}
+		if err = yaml.Unmarshal(data, &f); err != nil {
+			return Calendar{}, fmt.Errorf("%s: %v", holidayFile, err)
+		}
+	}
+	return New(location, after, f)
+}
+
+// Location is the time zone of the bank
+func (c Calendar) Location() *time.Location {
+	return c.location
+}
+
+// Timestamp writes now as a wall-clock time of the bank
+func (c Calendar) Timestamp(now time.Time) string {
+	return now.In(c.location).Format(TimestampLayout)
+}
+
+// Today is midnight of the bank's day of now
+func (c Calendar) Today(now time.Time) time.Time {
+	year, month, day := now.In(c.location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, c.location)
+}
+
+// BookingDate is the bank's date of now, the day a transaction is booked on whether or not the bank is open
+func (c Calendar) BookingDate(now time.Time) string {
+	return FormatDate(c.Today(now))
+}
+
+// ValueDate is the day a payment made at now is valued on: today on a business day before the cut-off, and
+// the next business day after the cut-off or when the bank is closed
+func (c Calendar) ValueDate(now time.Time) string {
+	today := c.Today(now)
+	if c.IsBusinessDay(today) && !c.PastCutOff(now) {
+		return FormatDate(today)
+	}
+	return FormatDate(c.NextBusinessDay(today))
+}
+
+// PastCutOff checks if now is at or after the cut-off of its day
+func (c Calendar) PastCutOff(now time.Time) bool {
+	hour, minute, second := now.In(c.location).Clock()
+	clock := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+	return c.cutOff > 0 && clock >= c.cutOff
+}
+
+// IsBusinessDay checks the bank is open on the date of day
+func (c Calendar) IsBusinessDay(day time.Time) bool {
+	day = day.In(c.location)
+	if c.weekend[day.Weekday()] {
+		return false
+	}
+	_, holiday := c.holidays[day.Format(DateLayout)]
+	return !holiday
+}
+
+// Holiday returns the name of the holiday on the date of day
+func (c Calendar) Holiday(day time.Time) (string, bool) {
+	name, ok := c.holidays[day.In(c.location).Format(DateLayout)]
+	return name, ok
+}
+
+// NextBusinessDay is the first business day after day
+func (c Calendar) NextBusinessDay(day time.Time) time.Time {
+	return c.OnOrAfter(c.Today(day).AddDate(0, 0, 1))
+}
+
+// OnOrAfter is day when it is a business day, or the first business day after it. The search stops after a
+// year, in case a holiday file closes every day.
+func (c Calendar) OnOrAfter(day time.Time) time.Time {
+	day = c.Today(day)
+	for i := 0; i < 366 && !c.IsBusinessDay(day); i++ {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// FormatDate writes the date of a day
+func FormatDate(day time.Time) string {
+	return day.Format(DateLayout)
+}
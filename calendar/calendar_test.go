@@ -0,0 +1,65 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadHolidayFile(t *testing.T) {
+	c, err := Load("America/New_York", "17:00", "../resources/holidays.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, ok := c.Holiday(time.Date(2021, time.July, 5, 12, 0, 0, 0, c.Location()))
+	assert.True(t, ok)
+	assert.Equal(t, "Independence Day (observed)", name)
+	assert.False(t, c.IsBusinessDay(time.Date(2021, time.July, 5, 12, 0, 0, 0, c.Location())))
+	assert.True(t, c.IsBusinessDay(time.Date(2021, time.July, 6, 12, 0, 0, 0, c.Location())))
+}
+
+func TestLoadRejectsBadSettings(t *testing.T) {
+	_, err := Load("Mars/Olympus_Mons", "", "")
+	assert.Error(t, err)
+	_, err = Load("UTC", "5pm", "")
+	assert.Error(t, err)
+	_, err = New(time.UTC, 0, File{Weekend: []string{"caturday"}})
+	assert.Error(t, err)
+	_, err = New(time.UTC, 0, File{Holidays: []Holiday{{Date: "01/01/2021", Name: "New Year's Day"}}})
+	assert.Error(t, err)
+}
+
+func TestBookingDateIsInTheBanksTimeZone(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	c, _ := New(tokyo, 0, File{})
+	now := time.Date(2021, time.March, 10, 20, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2021-03-11", c.BookingDate(now))
+	assert.Equal(t, "2021-03-11 05:00:00", c.Timestamp(now))
+}
+
+func TestValueDateRollsAfterTheCutOff(t *testing.T) {
+	c, _ := New(time.UTC, 17*time.Hour, File{Holidays: []Holiday{{Date: "2021-04-05", Name: "Easter Monday"}}})
+
+	// wednesday before and at the cut-off
+	assert.Equal(t, "2021-03-10", c.ValueDate(time.Date(2021, time.March, 10, 16, 59, 59, 0, time.UTC)))
+	assert.Equal(t, "2021-03-11", c.ValueDate(time.Date(2021, time.March, 10, 17, 0, 0, 0, time.UTC)))
+	// friday after the cut-off and saturday roll to monday
+	assert.Equal(t, "2021-03-15", c.ValueDate(time.Date(2021, time.March, 12, 18, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2021-03-15", c.ValueDate(time.Date(2021, time.March, 13, 9, 0, 0, 0, time.UTC)))
+	// a holiday monday rolls to tuesday
+	assert.Equal(t, "2021-04-06", c.ValueDate(time.Date(2021, time.April, 2, 18, 0, 0, 0, time.UTC)))
+}
+
+func TestWithoutACutOffABusinessDayValuesToday(t *testing.T) {
+	c := Default()
+	assert.False(t, c.PastCutOff(time.Date(2021, time.March, 10, 23, 59, 0, 0, time.Local)))
+	assert.Equal(t, "2021-03-10", c.ValueDate(time.Date(2021, time.March, 10, 23, 59, 0, 0, time.Local)))
+}
+
+func TestCustomWeekend(t *testing.T) {
+	c, _ := New(time.UTC, 0, File{Weekend: []string{"Friday", "Saturday"}})
+	assert.False(t, c.IsBusinessDay(time.Date(2021, time.March, 12, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, c.IsBusinessDay(time.Date(2021, time.March, 14, 9, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2021-03-14", FormatDate(c.NextBusinessDay(time.Date(2021, time.March, 11, 9, 0, 0, 0, time.UTC))))
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 // MySQLConfig holds env variables
@@ -19,10 +21,119 @@ type ServerConfig struct {
 	Port    string
 }
 
+// IdempotencyConfig holds how long a stored response is replayed for a retried Idempotency-Key
+type IdempotencyConfig struct {
+	Retention time.Duration
+}
+
+// InterestConfig holds how often the interest scheduler checks for accrual days and postings that are due,
+// zero turns the scheduler off and leaves the jobs to the interest routes
+type InterestConfig struct {
+	RunInterval time.Duration
+}
+
+// FeeConfig holds how often the fee scheduler checks if last month's maintenance fees were charged,
+// zero turns the scheduler off and leaves them to the fee routes
+type FeeConfig struct {
+	RunInterval time.Duration
+}
+
+// HoldConfig holds how long a hold reserves funds before it expires, and how often the hold sweeper releases
+// expired holds, zero turns the sweeper off and an expired hold is then only refused when captured or voided
+type HoldConfig struct {
+	TTL            time.Duration
+	ExpiryInterval time.Duration
+}
+
+// PaymentConfig holds how often the scheduler makes the scheduled payments that are due, zero turns it off
+// and leaves them to the scheduled payment run route
+type PaymentConfig struct {
+	RunInterval time.Duration
+}
+
+// BalanceConfig holds how often the scheduler takes the end of day balance snapshots that are due, zero turns
+// it off and leaves them to the balance snapshot route
+type BalanceConfig struct {
+	SnapshotInterval time.Duration
+}
+
+// StatementConfig holds how often the scheduler checks if last month's statements were generated, zero turns
+// it off and leaves them to the statement run route, statements are then still generated when first asked for
+type StatementConfig struct {
+	RunInterval time.Duration
+}
+
+// ExportConfig holds the routing number OFX exports name the bank with. Finance tools tell accounts apart by it,
+// so it should not change once customers imported an export.
+type ExportConfig struct {
+	BankID string
+}
+
+// CalendarConfig holds the bank's IANA time zone, or Local, the cut-off like 17:00 after which a transfer is
+// valued on the next business day, and the file of the bank's weekend and holidays. An empty cut-off or
+// holiday file leaves them out.
+type CalendarConfig struct {
+	Timezone    string
+	CutOff      string
+	HolidayFile string
+}
+
+// auth modes, local verifies tokens in process and remote asks the banking auth api
+const (
+	AUTH_LOCAL  = "local"
+	AUTH_REMOTE = "remote"
+)
+
+// AuthConfig holds how tokens are verified. In local mode the HMAC secret, the RSA public key file
+// and the JWKS file are all optional, but at least one of them has to be set. The policy file decides
+// what each role may do and is checked for changes every PolicyReload, zero turns reloading off.
+type AuthConfig struct {
+	Mode             string
+	RemoteAddress    string
+	Issuer           string
+	Audience         string
+	HMACSecret       string
+	RSAPublicKeyFile string
+	JWKSFile         string
+	Leeway           time.Duration
+	PolicyFile       string
+	PolicyReload     time.Duration
+	Login            LoginConfig
+	MFA              MFAConfig
+}
+
+// MFAConfig holds the name authenticator apps show for the bank, and the transaction and transfer amount
+// above which a one time password is needed. An empty StepUpAmount turns step-up off.
+type MFAConfig struct {
+	Issuer       string
+	StepUpAmount string
+}
+
+// LoginConfig holds how the login endpoint signs tokens and locks out users. Tokens are signed with the RSA
+// private key file when it is set, and with the HMAC secret otherwise.
+type LoginConfig struct {
+	RSAPrivateKeyFile string
+	KeyID             string
+	AccessTTL         time.Duration
+	RefreshTTL        time.Duration
+	LockoutAttempts   int
+	LockoutDuration   time.Duration
+}
+
 // Config holds the MySQL Config that can be called from other files
 type Config struct {
-	MySQL  MySQLConfig
-	Server ServerConfig
+	MySQL       MySQLConfig
+	Server      ServerConfig
+	Idempotency IdempotencyConfig
+	Auth        AuthConfig
+	Interest    InterestConfig
+	Fees        FeeConfig
+	Holds       HoldConfig
+	Payments    PaymentConfig
+	Balances    BalanceConfig
+	Statements  StatementConfig
+	Exports     ExportConfig
+	Calendar    CalendarConfig
 }
 
 // NewConfig returns a new config that looks at a .env for environment variables
@@ -38,6 +149,60 @@ func NewConfig() *Config {
 			Address: getEnv("server_Address", "localhost"),
 			Port:    getEnv("server_port", "8000"),
 		},
+		Idempotency: IdempotencyConfig{
+			Retention: getEnvDuration("idempotency_retention", 24*time.Hour),
+		},
+		Auth: AuthConfig{
+			Mode:             getEnv("auth_mode", AUTH_REMOTE),
+			RemoteAddress:    getEnv("auth_remote_address", "localhost:8181"),
+			Issuer:           getEnv("auth_issuer", ""),
+			Audience:         getEnv("auth_audience", ""),
+			HMACSecret:       getEnv("auth_hmac_secret", ""),
+			RSAPublicKeyFile: getEnv("auth_rsa_public_key_file", ""),
+			JWKSFile:         getEnv("auth_jwks_file", ""),
+			Leeway:           getEnvDuration("auth_leeway", 30*time.Second),
+			PolicyFile:       getEnv("auth_policy_file", "resources/policy.yaml"),
+			PolicyReload:     getEnvDuration("auth_policy_reload", 30*time.Second),
+			Login: LoginConfig{
+				RSAPrivateKeyFile: getEnv("auth_rsa_private_key_file", ""),
+				KeyID:             getEnv("auth_key_id", ""),
+				AccessTTL:         getEnvDuration("auth_access_ttl", 15*time.Minute),
+				RefreshTTL:        getEnvDuration("auth_refresh_ttl", 7*24*time.Hour),
+				LockoutAttempts:   getEnvInt("auth_lockout_attempts", 5),
+				LockoutDuration:   getEnvDuration("auth_lockout_duration", 15*time.Minute),
+			},
+			MFA: MFAConfig{
+				Issuer:       getEnv("mfa_issuer", "Banking"),
+				StepUpAmount: getEnv("mfa_step_up_amount", "5000.00"),
+			},
+		},
+		Interest: InterestConfig{
+			RunInterval: getEnvDuration("interest_run_interval", time.Hour),
+		},
+		Fees: FeeConfig{
+			RunInterval: getEnvDuration("fee_run_interval", time.Hour),
+		},
+		Holds: HoldConfig{
+			TTL:            getEnvDuration("hold_ttl", 7*24*time.Hour),
+			ExpiryInterval: getEnvDuration("hold_expiry_interval", time.Minute),
+		},
+		Payments: PaymentConfig{
+			RunInterval: getEnvDuration("payment_run_interval", time.Minute),
+		},
+		Balances: BalanceConfig{
+			SnapshotInterval: getEnvDuration("balance_snapshot_interval", time.Hour),
+		},
+		Statements: StatementConfig{
+			RunInterval: getEnvDuration("statement_run_interval", time.Hour),
+		},
+		Exports: ExportConfig{
+			BankID: getEnv("ofx_bank_id", "000000000"),
+		},
+		Calendar: CalendarConfig{
+			Timezone:    getEnv("bank_timezone", "Local"),
+			CutOff:      getEnv("transfer_cut_off", "17:00"),
+			HolidayFile: getEnv("bank_holiday_file", "resources/holidays.yaml"),
+		},
 	}
 }
 
@@ -48,6 +213,26 @@ func getEnv(key string, defaultVal string) string {
 	return defaultVal
 }
 
+// getEnvDuration reads a duration like "24h" or "90m", the default is used when it is missing or malformed
+func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultVal
+}
+
+// getEnvInt reads a positive whole number, the default is used when it is missing or malformed
+func getEnvInt(key string, defaultVal int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultVal
+}
+
 // GetMySQLInfo returns string to connect to mysql db
 func (c Config) GetMySQLInfo() string {
 	return fmt.Sprintf("%s:%s@tcp(%s)/%s",
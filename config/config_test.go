@@ -1,7 +1,9 @@
 package config
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -28,3 +30,87 @@ func TestGetServerInfoNoError(t *testing.T) {
 	dbInfo := config.GetServerInfo()
 	assert.NotNil(t, dbInfo)
 }
+
+func TestGetEnvDuration(t *testing.T) {
+	os.Setenv("test_duration", "90m")
+	defer os.Unsetenv("test_duration")
+	assert.Equal(t, 90*time.Minute, getEnvDuration("test_duration", time.Hour))
+	assert.Equal(t, time.Hour, getEnvDuration("missing_duration", time.Hour))
+
+	os.Setenv("test_duration", "soon")
+	assert.Equal(t, time.Hour, getEnvDuration("test_duration", time.Hour))
+}
+
+func TestIdempotencyRetentionDefault(t *testing.T) {
+	config := NewConfig()
+	assert.True(t, config.Idempotency.Retention > 0)
+}
+
+func TestAuthConfigDefaultsToRemote(t *testing.T) {
+	os.Unsetenv("auth_mode")
+	config := NewConfig()
+	assert.Equal(t, AUTH_REMOTE, config.Auth.Mode)
+	assert.Equal(t, "localhost:8181", config.Auth.RemoteAddress)
+	assert.Equal(t, 30*time.Second, config.Auth.Leeway)
+	assert.Equal(t, "resources/policy.yaml", config.Auth.PolicyFile)
+	assert.Equal(t, 30*time.Second, config.Auth.PolicyReload)
+}
+
+func TestGetEnvInt(t *testing.T) {
+	os.Setenv("test_int", "3")
+	defer os.Unsetenv("test_int")
+	assert.Equal(t, 3, getEnvInt("test_int", 5))
+
+	os.Setenv("test_int", "-1")
+	assert.Equal(t, 5, getEnvInt("test_int", 5))
+	assert.Equal(t, 5, getEnvInt("missing_int", 5))
+}
+
+func TestLoginConfigDefaults(t *testing.T) {
+	config := NewConfig()
+	assert.Equal(t, 15*time.Minute, config.Auth.Login.AccessTTL)
+	assert.Equal(t, 5, config.Auth.Login.LockoutAttempts)
+}
+
+func TestInterestRunIntervalDefault(t *testing.T) {
+	config := NewConfig()
+	assert.Equal(t, time.Hour, config.Interest.RunInterval)
+}
+
+func TestFeeRunIntervalDefault(t *testing.T) {
+	config := NewConfig()
+	assert.Equal(t, time.Hour, config.Fees.RunInterval)
+}
+
+func TestHoldConfigDefaults(t *testing.T) {
+	config := NewConfig()
+	assert.Equal(t, 7*24*time.Hour, config.Holds.TTL)
+	assert.Equal(t, time.Minute, config.Holds.ExpiryInterval)
+}
+
+func TestPaymentRunIntervalDefault(t *testing.T) {
+	config := NewConfig()
+	assert.Equal(t, time.Minute, config.Payments.RunInterval)
+}
+
+func TestBalanceSnapshotIntervalDefault(t *testing.T) {
+	config := NewConfig()
+	assert.Equal(t, time.Hour, config.Balances.SnapshotInterval)
+}
+
+func TestStatementRunIntervalDefault(t *testing.T) {
+	config := NewConfig()
+	assert.Equal(t, time.Hour, config.Statements.RunInterval)
+}
+
+func TestCalendarConfigDefaults(t *testing.T) {
+	config := NewConfig()
+	assert.Equal(t, "Local", config.Calendar.Timezone)
+	assert.Equal(t, "17:00", config.Calendar.CutOff)
+	assert.Equal(t, "resources/holidays.yaml", config.Calendar.HolidayFile)
+}
+
+func TestOFXBankIDDefault(t *testing.T) {
+	config := NewConfig()
+	assert.Equal(t, "000000000", config.Exports.BankID)
+}
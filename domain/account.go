@@ -1,8 +1,12 @@
 package domain
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/jonathanwamsley/banking/dto"
 	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
 )
 
 // a time layout that the db is using
@@ -14,24 +18,46 @@ type Account struct {
 	CustomerID  string `db:"customer_id"`
 	OpeningDate string `db:"opening_date"`
 	AccountType string `db:"account_type"`
-	Amount      float64
+	Amount      money.Money
 	Status      string
+	Version     int
+	// InterestProduct is the product code the account earns interest with, empty when it earns none
+	InterestProduct sql.NullString `db:"interest_product"`
+	// OverdraftLimit is how far below zero customer debits may take the balance
+	OverdraftLimit money.Money `db:"overdraft_limit"`
+	// OverdraftSweep covers a checking debit from the customer's saving account before it overdraws
+	OverdraftSweep bool `db:"overdraft_sweep"`
+	// Held is the sum of the active holds, it is reserved but still part of the ledger balance in Amount
+	Held money.Money `db:"held"`
+	// CustomerStatus is only loaded when the account is locked for a transaction
+	CustomerStatus string `db:"customer_status"`
 }
 
 // AccountRepository implements:
 //
 // Save: creates a new account for a customer, and returns customer account id
 // ById: searches for accounts by a user_id
-// Delete: deletes an account using a customer id and account type
+// Delete: deletes the accounts of a type of a customer when the version matches and the balance is 0
 // SaveTransaction: makes a transaction in a bank account and returns new account total
 // FindBy: finds a specific account information
+// SaveTransfer: debits one account and credits another in a single db transaction, and returns both new totals
+// FindTransactions: returns the transactions of an account that match a filter, one page at a time
+// StreamTransactions: reads the transactions of an account that match a filter one row at a time, passing each to a
+// func, so a whole history is never held in memory
+// UpdateStatus: moves an account to a new status when the transition is allowed and the version matches
+// UpdateOverdraft: sets the overdraft limit and sweep of an account when the version matches
 // mockgen -destination=mocks/domain/mock_account_repository.go -package=domain github.com/jonathanwamsley/banking/domain AccountRepository
 type AccountRepository interface {
 	Save(Account) (*Account, *errs.AppError)
 	ByID(customerID string) ([]Account, *errs.AppError)
-	Delete(id string, accountType string) *errs.AppError
+	Delete(id string, accountType string, version int) *errs.AppError
 	SaveTransaction(transaction Transaction) (*Transaction, *errs.AppError)
 	FindBy(accountID string) (*Account, *errs.AppError)
+	SaveTransfer(transfer Transfer) (*Transfer, *errs.AppError)
+	FindTransactions(filter TransactionFilter) ([]Transaction, *errs.AppError)
+	StreamTransactions(filter TransactionFilter, each func(Transaction) error) *errs.AppError
+	UpdateStatus(accountID string, status string, version int) *errs.AppError
+	UpdateOverdraft(accountID string, o Overdraft, version int) *errs.AppError
 }
 
 // ToCreateAccountResponseDTO converts account from database to account response for user
@@ -42,29 +68,71 @@ func (a Account) ToCreateAccountResponseDTO() *dto.CreateAccountResponse {
 // ToGetAccountResponseDTO converts a account to a account response for the user
 func (a Account) ToGetAccountResponseDTO() dto.GetAccountResponse {
 	return dto.GetAccountResponse{
-		AccountID:   a.AccountID,
-		CustomerID:  a.CustomerID,
-		OpeningDate: a.OpeningDate,
-		AccountType: a.AccountType,
-		Amount:      a.Amount,
+		AccountID:        a.AccountID,
+		CustomerID:       a.CustomerID,
+		OpeningDate:      a.OpeningDate,
+		AccountType:      a.AccountType,
+		Amount:           a.Amount,
+		LedgerBalance:    a.Amount,
+		AvailableBalance: a.Available(),
+		Status:           a.Status,
+		OverdraftLimit:   a.OverdraftLimit,
+		OverdraftSweep:   a.OverdraftSweep,
+		Version:          a.Version,
 	}
 }
 
-// NewAccount converts account request from user to an account to be processed by db
-func NewAccount(a dto.CreateAccountRequest) Account {
-	return Account{
-		CustomerID:  a.CustomerID,
-		OpeningDate: dbTSLayout,
-		AccountType: a.AccountType,
-		Amount:      a.Amount,
-		Status:      "1",
+// NewAccount converts account request from user to an account to be processed by db, opened at openingDate.
+// Saving accounts earn interest with the default saving product.
+func NewAccount(a dto.CreateAccountRequest, openingDate string) Account {
+	account := Account{
+		CustomerID:     a.CustomerID,
+		OpeningDate:    openingDate,
+		AccountType:    a.AccountType,
+		Amount:         a.Amount,
+		Status:         STATUS_ACTIVE,
+		OverdraftLimit: money.New(0, a.Amount.Currency()),
+		Held:           money.New(0, a.Amount.Currency()),
+		Version:        1,
+	}
+	if a.AccountType == dto.SAVING {
+		account.InterestProduct = sql.NullString{String: DEFAULT_SAVING_PRODUCT, Valid: true}
 	}
+	return account
+}
+
+// OpenedBy checks the account was opened before the end of day
+func (a Account) OpenedBy(day time.Time) bool {
+	opened, err := time.ParseInLocation(dbTSLayout, a.OpeningDate, day.Location())
+	return err == nil && opened.Before(day.AddDate(0, 0, 1))
+}
+
+// CanPost checks that both the account and its customer are in a status that allows the transaction
+func (a Account) CanPost(t Transaction) *errs.AppError {
+	operation := operationFor(t)
+	if err := CheckStatus(SUBJECT_CUSTOMER, a.CustomerStatus, operation); err != nil {
+		return err
+	}
+	return CheckStatus(SUBJECT_ACCOUNT, a.Status, operation)
+}
+
+// CanWithdraw checks if an transaction can be made without going past the overdraft limit, held funds
+// can not be withdrawn
+func (a Account) CanWithdraw(amount money.Money) bool {
+	return !a.Unheld().Add(a.OverdraftLimit).LessThan(amount)
+}
+
+// Unheld is the ledger balance less the active holds
+func (a Account) Unheld() money.Money {
+	return a.Amount.Sub(a.Held)
 }
 
-// CanWithdraw checks if an transaction can be made
-func (a Account) CanWithdraw(amount float64) bool {
-	if a.Amount < amount {
-		return false
+// Available is what customer debits can still take out: the balance that is not held and the unused overdraft
+// limit. Charges can take an account past its limit, then nothing is available.
+func (a Account) Available() money.Money {
+	available := a.Unheld().Add(a.OverdraftLimit)
+	if available.IsNegative() {
+		return money.New(0, available.Currency())
 	}
-	return true
+	return available
 }
@@ -2,20 +2,39 @@ package domain
 
 import (
 	"database/sql"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/jonathanwamsley/banking/errs"
 	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
 )
 
+// selectHeld sums the active holds of the account aliased a
+const selectHeld = "COALESCE((SELECT SUM(h.amount) from holds h where h.account_id = a.account_id and h.status = 'active'), 0) as held"
+
 // The query statements
 const (
-	createAccount   = "insert into accounts(customer_id, opening_date, account_type, amount, status) values (?, ?, ?, ?, ?);"
-	getAccounts     = "select account_id, customer_id, opening_date, account_type, amount from accounts where customer_id = ?;"
-	deleteAccount   = "delete from accounts where customer_id = ? and account_type = ?;"
-	getAccount      = "SELECT account_id, customer_id, opening_date, account_type, amount from accounts where account_id = ?;"
-	makeTransaction = "INSERT INTO transactions (account_id, amount, transaction_type, transaction_date) values (?, ?, ?, ?);"
+	createAccount   = "insert into accounts(customer_id, opening_date, account_type, amount, status, interest_product) values (?, ?, ?, ?, ?, ?);"
+	getAccounts     = "select account_id, customer_id, opening_date, account_type, amount, status, version, overdraft_limit, overdraft_sweep, " + selectHeld + " from accounts a where customer_id = ?;"
+	deleteAccount   = "delete from accounts where account_id = ? and version = ? and amount = 0;"
+	getAccount      = "SELECT account_id, customer_id, opening_date, account_type, amount, status, version, overdraft_limit, overdraft_sweep, " + selectHeld + " from accounts a where account_id = ?;"
+	makeTransaction = "INSERT INTO transactions (account_id, amount, transaction_type, transaction_date, booking_date, value_date, balance, related_transaction_id) values (?, ?, ?, ?, ?, ?, ?, ?);"
+	lockAccount     = `SELECT a.account_id, a.customer_id, a.opening_date, a.account_type, a.amount, a.status, a.version, a.overdraft_limit, a.overdraft_sweep,
+		` + selectHeld + `, c.status as customer_status from accounts a join customers c on c.customer_id = a.customer_id where a.account_id = ? FOR UPDATE OF a;`
+	lockAccountRow     = "SELECT account_id from accounts where account_id = ? FOR UPDATE;"
+	lockAccountsOfType = "SELECT account_id, amount, version from accounts where customer_id = ? and account_type = ? FOR UPDATE;"
+	// the saving account of the same customer a checking account with sweep turned on covers its debits from
+	findSweepSource = `SELECT s.account_id from accounts a join accounts s on s.customer_id = a.customer_id and s.account_type = 'saving'
+		and s.account_id <> a.account_id where a.account_id = ? and a.account_type = 'checking' and a.overdraft_sweep = 1 order by s.account_id limit 1;`
+	lockCustomer     = "SELECT status from customers where customer_id = ? FOR SHARE;"
+	setAccountStatus = "UPDATE accounts SET status = ?, version = version + 1 where account_id = ? and version = ?;"
+	updateBalance    = "UPDATE accounts SET amount = ?, version = version + 1 where account_id = ? and version = ?;"
+	setOverdraft     = "UPDATE accounts SET overdraft_limit = ?, overdraft_sweep = ?, version = version + 1 where account_id = ? and version = ?;"
+	linkTransaction  = "UPDATE transactions SET related_transaction_id = ? where transaction_id = ?;"
+	getTransactions  = "SELECT transaction_id, account_id, amount, transaction_type, transaction_date, booking_date, value_date, balance, related_transaction_id from transactions where account_id = ?"
 )
 
 // AccountRepositoryDB holds the sql client connection
@@ -28,19 +47,55 @@ func NewAccountRepositoryDB(repo *sqlx.DB) AccountRepositoryDB {
 	return AccountRepositoryDB{repo}
 }
 
-// Save creates a new account for a customer. The account id is returned
+// Save creates a new account for a customer and posts its opening deposit to the ledger. The account id is returned.
+// The customer must be in a status that allows opening accounts.
 func (d AccountRepositoryDB) Save(a Account) (*Account, *errs.AppError) {
-	result, err := d.client.Exec(createAccount, a.CustomerID, a.OpeningDate, a.AccountType, a.Amount, a.Status)
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("error while starting a new transaction for a new account " + err.Error())
+		return nil, errs.NewUnexpectedError("unexpected error from database")
+	}
+
+	// the customer row is share locked, so the customer can not be frozen or closed while the account is opened
+	var customerStatus string
+	if err = tx.Get(&customerStatus, lockCustomer, a.CustomerID); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("Customer not found")
+		}
+		logger.Error("error while locking customer for a new account " + err.Error())
+		return nil, errs.NewUnexpectedError("unexpected error from database")
+	}
+	if appErr := CheckStatus(SUBJECT_CUSTOMER, customerStatus, OP_OPEN_ACCOUNT); appErr != nil {
+		tx.Rollback()
+		return nil, appErr
+	}
+
+	result, err := tx.Exec(createAccount, a.CustomerID, a.OpeningDate, a.AccountType, a.Amount, a.Status, a.InterestProduct)
 	if err != nil {
+		tx.Rollback()
 		logger.Error("error while creating new account " + err.Error())
 		return nil, errs.NewUnexpectedError("unexpected error from database")
 	}
 	id, err := result.LastInsertId()
 	if err != nil {
+		tx.Rollback()
 		logger.Error("error while getting last id from the new account " + err.Error())
 		return nil, errs.NewUnexpectedError("unexpected error from database")
 	}
 	a.AccountID = strconv.FormatInt(id, 10)
+
+	if !a.Amount.IsZero() {
+		if _, appErr := postJournalEntry(tx, OpeningEntry(a)); appErr != nil {
+			tx.Rollback()
+			return nil, appErr
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("error while commiting new account " + err.Error())
+		return nil, errs.NewUnexpectedError("unexpected error from database")
+	}
 	return &a, nil
 }
 
@@ -60,79 +115,500 @@ func (d AccountRepositoryDB) ByID(id string) ([]Account, *errs.AppError) {
 	return accounts, nil
 }
 
-// Delete returns nil for a customer of a given account type
-func (d AccountRepositoryDB) Delete(id string, accountType string) *errs.AppError {
-	result, err := d.client.Exec(deleteAccount, id, accountType)
+// Delete removes the accounts of a type of a customer. Every one of them must still be at the expected version
+// and have a balance of 0, the delete statement checks both again so a deposit can never slip in between.
+func (d AccountRepositoryDB) Delete(id string, accountType string, version int) *errs.AppError {
+	tx, err := d.client.Beginx()
 	if err != nil {
-		logger.Error("Error while trying to delete account " + err.Error())
-		return errs.NewUnexpectedError("Unexpected error from database")
+		logger.Error("Error while starting a new transaction to delete an account: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
 	}
 
-	rowsChanged, _ := result.RowsAffected()
-	if rowsChanged == 0 {
+	accounts := make([]Account, 0)
+	if err = tx.Select(&accounts, lockAccountsOfType, id, accountType); err != nil {
+		tx.Rollback()
+		logger.Error("Error while locking accounts to delete: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if len(accounts) == 0 {
+		tx.Rollback()
 		return errs.NewNotFoundError("Account not found")
 	}
+	for _, a := range accounts {
+		if appErr := CheckVersion(SUBJECT_ACCOUNT, a.Version, version); appErr != nil {
+			tx.Rollback()
+			return appErr
+		}
+		if !a.Amount.IsZero() {
+			tx.Rollback()
+			return errs.NewConflictError("Account must have a balance of 0 to close").WithReason("account_balance_not_zero")
+		}
+	}
+
+	for _, a := range accounts {
+		if appErr := execVersioned(tx, SUBJECT_ACCOUNT, deleteAccount, a.AccountID, a.Version); appErr != nil {
+			tx.Rollback()
+			return appErr
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting account delete: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
 	return nil
 }
 
 // SaveTransaction completes a withdrawal or deposit in a bank account. A new total will be returned.
+//
+// The account row is locked before its balance is checked, so two withdrawals arriving at the same
+// time are applied one after the other and can never go past the overdraft limit.
 func (d AccountRepositoryDB) SaveTransaction(t Transaction) (*Transaction, *errs.AppError) {
 	// starting the database transaction block
-	tx, err := d.client.Begin()
+	tx, err := d.client.Beginx()
 	if err != nil {
 		logger.Error("Error while starting a new transaction for bank account transaction: " + err.Error())
 		return nil, errs.NewUnexpectedError("Unexpected database error")
 	}
 
-	// inserting bank account transaction
-	result, _ := tx.Exec(makeTransaction, t.AccountID, t.Amount, t.TransactionType, t.TransactionDate)
-
-	// updating account balance
-	if t.IsWithdrawal() {
-		_, err = tx.Exec(`UPDATE accounts SET amount = amount - ? where account_id = ?`, t.Amount, t.AccountID)
-	} else {
-		_, err = tx.Exec(`UPDATE accounts SET amount = amount + ? where account_id = ?`, t.Amount, t.AccountID)
+	if IsCustomerDebit(t) {
+		if appErr := lockInOrder(tx, t.AccountID); appErr != nil {
+			tx.Rollback()
+			return nil, appErr
+		}
 	}
 
 	// in case of error Rollback, and changes from both the tables will be reverted
-	if err != nil {
+	if appErr := postTransaction(tx, &t); appErr != nil {
 		tx.Rollback()
-		logger.Error("Error while saving transaction: " + err.Error())
-		return nil, errs.NewUnexpectedError("Unexpected database error")
+		return nil, appErr
 	}
+
 	// commit the transaction when all is good
-	err = tx.Commit()
-	if err != nil {
+	if err = tx.Commit(); err != nil {
 		tx.Rollback()
 		logger.Error("Error while commiting transaction for bank account: " + err.Error())
 		return nil, errs.NewUnexpectedError("Unexpected database error")
 	}
+	return &t, nil
+}
+
+// FindBy returns a specific account information given the account id
+func (d AccountRepositoryDB) FindBy(accountID string) (*Account, *errs.AppError) {
+	var account Account
+	err := d.client.Get(&account, getAccount, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("Account not found")
+		}
+		logger.Error("Error while fetching account information: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &account, nil
+}
+
+// SaveTransfer posts both sides of a transfer inside one database transaction. Both account rows are
+// locked before the source balance is checked, and if the debit can not be made nothing is saved.
+func (d AccountRepositoryDB) SaveTransfer(t Transfer) (*Transfer, *errs.AppError) {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for bank transfer: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	// rows are always locked in account id order so two opposite transfers can not deadlock
+	if appErr := lockInOrder(tx, t.Debit.AccountID, t.Credit.AccountID); appErr != nil {
+		tx.Rollback()
+		return nil, appErr
+	}
+	legs := []*Transaction{&t.Debit, &t.Credit}
+	sort.Slice(legs, func(i, j int) bool {
+		return accountIDLess(legs[i].AccountID, legs[j].AccountID)
+	})
+	for _, leg := range legs {
+		if appErr := postTransaction(tx, leg); appErr != nil {
+			tx.Rollback()
+			return nil, appErr
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting transfer: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &t, nil
+}
+
+// postTransaction locks the account row, checks the version, the account and customer status and the balance
+// against the locked row, then inserts the transaction and posts its journal entry, all within tx. The lock is held until tx commits or rolls back.
+//
+// The ledger is the source of truth, accounts.amount is kept as its projection: the new balance is the
+// locked balance moved by what the journal entry posts to the customer deposit.
+// On success the transaction holds its new id and the account balance right after it was applied.
+//
+// A customer debit may go below zero down to the overdraft limit. Before it does, a checking account with sweep
+// turned on is topped up from the customer's saving account. Its fees are charged right after it in the same tx:
+// a withdrawal past the free ones of its month has to fit together with its fee, the overdraft fee of a debit
+// that leaves the balance negative is a charge and is taken even past the limit.
+func postTransaction(tx *sqlx.Tx, t *Transaction) *errs.AppError {
+	entry, appErr := JournalEntryFor(*t)
+	if appErr != nil {
+		return appErr
+	}
+
+	var account Account
+	if err := tx.Get(&account, lockAccount, t.AccountID); err != nil {
+		if err == sql.ErrNoRows {
+			return errs.NewNotFoundError("Account not found")
+		}
+		logger.Error("Error while locking account: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	if appErr = CheckVersion(SUBJECT_ACCOUNT, account.Version, t.ExpectedVersion); appErr != nil {
+		return appErr
+	}
+	if appErr = account.CanPost(*t); appErr != nil {
+		return appErr
+	}
+	var charges []Transaction
+	switch {
+	case IsCustomerDebit(*t):
+		if charges, appErr = prepareCustomerDebit(tx, &account, t); appErr != nil {
+			return appErr
+		}
+	case t.IsDebit() && !t.IsCharge():
+		if appErr = account.CheckFunds(t.Amount, money.Zero()); appErr != nil {
+			return appErr
+		}
+	}
+	balance := account.Amount.Add(entry.DepositChange(t.AccountID))
+
+	if appErr = execVersioned(tx, SUBJECT_ACCOUNT, updateBalance, balance, t.AccountID, account.Version); appErr != nil {
+		return appErr
+	}
+
+	// inserting bank account transaction
+	t.fillDates()
+	result, err := tx.Exec(makeTransaction, t.AccountID, t.Amount, t.TransactionType, t.TransactionDate, t.BookingDate, t.ValueDate, balance, t.RelatedTransactionID)
+	if err != nil {
+		logger.Error("Error while saving transaction: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
 	// getting the last transaction ID from the transaction table
 	transactionID, err := result.LastInsertId()
 	if err != nil {
 		logger.Error("Error while getting the last transaction id: " + err.Error())
-		return nil, errs.NewUnexpectedError("Unexpected database error")
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	t.TransactionID = strconv.FormatInt(transactionID, 10)
+	// updating the transaction struct with the latest balance
+	t.Balance = balance
+
+	entry.TransactionID = t.TransactionID
+	if _, appErr = postJournalEntry(tx, entry); appErr != nil {
+		return appErr
 	}
 
-	// Getting the latest account information from the accounts table
-	account, appErr := d.FindBy(t.AccountID)
+	if t.Sweep != nil {
+		for _, leg := range []*Transaction{&t.Sweep.Out, &t.Sweep.In} {
+			if _, err = tx.Exec(linkTransaction, t.TransactionID, leg.TransactionID); err != nil {
+				logger.Error("Error while linking sweep: " + err.Error())
+				return errs.NewUnexpectedError("Unexpected database error")
+			}
+			leg.RelatedTransactionID = sql.NullString{String: t.TransactionID, Valid: true}
+		}
+	}
+	for _, charge := range charges {
+		charge.RelatedTransactionID = sql.NullString{String: t.TransactionID, Valid: true}
+		if appErr = postTransaction(tx, &charge); appErr != nil {
+			return appErr
+		}
+		t.Fees = append(t.Fees, charge)
+	}
+	return nil
+}
+
+// prepareCustomerDebit sweeps from saving when the locked account can not cover the debit t and its withdrawal
+// fee, then checks the funds. It returns the fees to charge once t is posted. The account is read again after
+// a sweep, so it holds the balance and version t is posted against.
+func prepareCustomerDebit(tx *sqlx.Tx, account *Account, t *Transaction) ([]Transaction, *errs.AppError) {
+	schedule, appErr := findSchedule(tx, account.AccountType)
 	if appErr != nil {
 		return nil, appErr
 	}
-	t.TransactionID = strconv.FormatInt(transactionID, 10)
+	var charges []Transaction
+	withdrawal, appErr := withdrawalFee(tx, schedule, *account, *t)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if withdrawal.IsPositive() {
+		charges = append(charges, NewFeeCharge(*t, FEE_WITHDRAWAL, withdrawal))
+	}
 
-	// updating the transaction struct with the latest balance
-	t.Amount = account.Amount
-	return &t, nil
+	need := t.Amount.Add(withdrawal)
+	if account.OverdraftSweep && account.Unheld().LessThan(need) {
+		if appErr = sweepInto(tx, account, t, need); appErr != nil {
+			return nil, appErr
+		}
+	}
+	if appErr = account.CheckFunds(t.Amount, withdrawal); appErr != nil {
+		return nil, appErr
+	}
+
+	overdraft, appErr := overdraftFee(tx, schedule, *account, *t, account.Amount.Sub(need))
+	if appErr != nil {
+		return nil, appErr
+	}
+	if overdraft.IsPositive() {
+		charges = append(charges, NewFeeCharge(*t, FEE_OVERDRAFT, overdraft))
+	}
+	return charges, nil
 }
 
-// FindBy returns a specific account information given the account id
-func (d AccountRepositoryDB) FindBy(accountID string) (*Account, *errs.AppError) {
+// sweepInto moves what the account is short of need from the customer's saving account, as much as saving has
+// that is not held.
+// Nothing is swept when there is no saving account or it can not be withdrawn from, the debit then falls back
+// on the overdraft limit.
+func sweepInto(tx *sqlx.Tx, account *Account, t *Transaction, need money.Money) *errs.AppError {
+	var sourceID string
+	if err := tx.Get(&sourceID, findSweepSource, account.AccountID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		logger.Error("Error while finding the sweep account: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	var source Account
+	if err := tx.Get(&source, lockAccount, sourceID); err != nil {
+		logger.Error("Error while locking the sweep account: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	amount := SweepAmount(account.Unheld(), need, source.Unheld())
+	sweep := NewSweep(*t, sourceID, amount)
+	if !amount.IsPositive() || source.CanPost(sweep.Out) != nil {
+		return nil
+	}
+
+	for _, leg := range []*Transaction{&sweep.Out, &sweep.In} {
+		if appErr := postTransaction(tx, leg); appErr != nil {
+			return appErr
+		}
+	}
+	t.Sweep = &sweep
+	if err := tx.Get(account, lockAccount, account.AccountID); err != nil {
+		logger.Error("Error while reading the swept account: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// lockInOrder locks every account row a posting may touch in account id order before any of them changes, so
+// postings that share accounts wait for each other instead of deadlocking. A debit from checking with sweep
+// turned on may touch the customer's saving account too.
+func lockInOrder(tx *sqlx.Tx, debitAccountID string, otherAccountIDs ...string) *errs.AppError {
+	ids := append([]string{debitAccountID}, otherAccountIDs...)
+	var sourceID string
+	err := tx.Get(&sourceID, findSweepSource, debitAccountID)
+	switch {
+	case err == nil:
+		ids = append(ids, sourceID)
+	case err != sql.ErrNoRows:
+		logger.Error("Error while finding the sweep account: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return accountIDLess(ids[i], ids[j])
+	})
+	for i, id := range ids {
+		if i > 0 && id == ids[i-1] {
+			continue
+		}
+		// a missing account is reported by postTransaction
+		var locked []string
+		if err = tx.Select(&locked, lockAccountRow, id); err != nil {
+			logger.Error("Error while locking account: " + err.Error())
+			return errs.NewUnexpectedError("Unexpected database error")
+		}
+	}
+	return nil
+}
+
+// UpdateOverdraft sets the overdraft limit and sweep of an account that is still at the expected version.
+// A limit below what the account is already overdrawn is allowed, it only stops further debits.
+func (d AccountRepositoryDB) UpdateOverdraft(accountID string, o Overdraft, version int) *errs.AppError {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for an overdraft: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+
 	var account Account
-	err := d.client.Get(&account, getAccount, accountID)
+	if err = tx.Get(&account, lockAccount, accountID); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errs.NewNotFoundError("Account not found")
+		}
+		logger.Error("Error while locking account: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if appErr := CheckVersion(SUBJECT_ACCOUNT, account.Version, version); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+	if appErr := o.ValidateFor(account); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+
+	if appErr := execVersioned(tx, SUBJECT_ACCOUNT, setOverdraft, o.Limit, o.Sweep, accountID, account.Version); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting overdraft: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// UpdateStatus moves an account to a new status if the transition is allowed and the account is still at
+// the expected version. An account can only be closed once its balance is 0.
+func (d AccountRepositoryDB) UpdateStatus(accountID string, status string, version int) *errs.AppError {
+	tx, err := d.client.Beginx()
 	if err != nil {
-		logger.Error("Error while fetching account information: " + err.Error())
+		logger.Error("Error while starting a new transaction for an account status: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	var account Account
+	if err = tx.Get(&account, lockAccount, accountID); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errs.NewNotFoundError("Account not found")
+		}
+		logger.Error("Error while locking account: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if appErr := CheckVersion(SUBJECT_ACCOUNT, account.Version, version); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+	if appErr := CheckTransition(SUBJECT_ACCOUNT, account.Status, status); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+	if status == STATUS_CLOSED && !account.Amount.IsZero() {
+		tx.Rollback()
+		return errs.NewValidationError("Account must have a balance of 0 to close").WithReason("account_balance_not_zero")
+	}
+
+	if appErr := execVersioned(tx, SUBJECT_ACCOUNT, setAccountStatus, status, accountID, account.Version); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting account status: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// FindTransactions returns up to filter.Limit transactions of an account, ordered by date and then id
+func (d AccountRepositoryDB) FindTransactions(f TransactionFilter) ([]Transaction, *errs.AppError) {
+	query, args := buildTransactionsQuery(f)
+	transactions := make([]Transaction, 0)
+	if err := d.client.Select(&transactions, query, args...); err != nil {
+		logger.Error("Error while querying transactions table " + err.Error())
 		return nil, errs.NewUnexpectedError("Unexpected database error")
 	}
-	return &account, nil
+	return transactions, nil
+}
+
+// StreamTransactions reads the transactions of an account ordered by date and then id, and passes each row to
+// each as it is read. A filter without a limit reads them all. An error from each stops reading and is returned.
+func (d AccountRepositoryDB) StreamTransactions(f TransactionFilter, each func(Transaction) error) *errs.AppError {
+	query, args := buildTransactionsQuery(f)
+	rows, err := d.client.Queryx(query, args...)
+	if err != nil {
+		logger.Error("Error while querying transactions table " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t Transaction
+		if err = rows.StructScan(&t); err != nil {
+			logger.Error("Error while scanning transactions " + err.Error())
+			return errs.NewUnexpectedError("Unexpected database error")
+		}
+		if err = each(t); err != nil {
+			logger.Error("Error while streaming transactions " + err.Error())
+			return errs.NewUnexpectedError("Unexpected error while streaming the transactions")
+		}
+	}
+	if err = rows.Err(); err != nil {
+		logger.Error("Error while reading transactions " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// buildTransactionsQuery adds a condition for every filter that is set, and keyset pagination after the cursor.
+// A limit of 0 reads every matching transaction.
+func buildTransactionsQuery(f TransactionFilter) (string, []interface{}) {
+	var query strings.Builder
+	query.WriteString(getTransactions)
+	args := []interface{}{f.AccountID}
+
+	if f.From != "" {
+		query.WriteString(" and transaction_date >= ?")
+		args = append(args, f.From)
+	}
+	if f.To != "" {
+		query.WriteString(" and transaction_date < ?")
+		args = append(args, f.To)
+	}
+	if f.TransactionType != "" {
+		query.WriteString(" and transaction_type = ?")
+		args = append(args, f.TransactionType)
+	}
+	if f.MinAmount != nil {
+		query.WriteString(" and amount >= ?")
+		args = append(args, *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		query.WriteString(" and amount <= ?")
+		args = append(args, *f.MaxAmount)
+	}
+
+	direction, compare := "asc", ">"
+	if f.Descending {
+		direction, compare = "desc", "<"
+	}
+	if f.After != nil {
+		query.WriteString(" and (transaction_date " + compare + " ? or (transaction_date = ? and transaction_id " + compare + " ?))")
+		args = append(args, f.After.TransactionDate, f.After.TransactionDate, f.After.TransactionID)
+	}
+	query.WriteString(" order by transaction_date " + direction + ", transaction_id " + direction)
+	if f.Limit > 0 {
+		query.WriteString(" limit ?")
+		args = append(args, f.Limit)
+	}
+	query.WriteString(";")
+	return query.String(), args
+}
+
+// accountIDLess orders numeric account ids, falling back to string order for anything else
+func accountIDLess(a string, b string) bool {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return x < y
 }
@@ -0,0 +1,239 @@
+package domain
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDBClient connects to a mysql loaded with resources/database.sql. The tests that need it are skipped
+// unless mysql_test_dsn is set, for example:
+//
+// mysql_test_dsn="user:password@tcp(127.0.0.1:3306)/banking" go test ./domain
+func testDBClient(t *testing.T) *sqlx.DB {
+	dsn, ok := os.LookupEnv("mysql_test_dsn")
+	if !ok {
+		t.Skip("mysql_test_dsn is not set, skipping db test")
+	}
+	client, err := sqlx.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	client.SetMaxOpenConns(20)
+	return client
+}
+
+// testAccount opens a throwaway account for customer 2000 and removes it with its transactions afterwards
+func testAccount(t *testing.T, repo AccountRepositoryDB, amount string) *Account {
+	account, appErr := repo.Save(Account{
+		CustomerID:  "2000",
+		OpeningDate: "2021-03-10 09:00:00",
+		AccountType: "checking",
+		Amount:      money.MustParse(amount),
+		Status:      STATUS_ACTIVE,
+	})
+	if appErr != nil {
+		t.Fatal(appErr.Message)
+	}
+	t.Cleanup(func() {
+		repo.client.MustExec("delete from transactions where account_id = ?", account.AccountID)
+		repo.client.MustExec("delete from accounts where account_id = ?", account.AccountID)
+	})
+	return account
+}
+
+func TestConcurrentWithdrawalsNeverOverdraw(t *testing.T) {
+	client := testDBClient(t)
+	defer client.Close()
+	repo := NewAccountRepositoryDB(client)
+	account := testAccount(t, repo, "100.00")
+
+	const attempts = 50
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			saved, appErr := repo.SaveTransaction(Transaction{
+				AccountID:       account.AccountID,
+				Amount:          money.MustParse("10.00"),
+				TransactionType: WITHDRAWAL,
+				TransactionDate: "2021-03-10 09:02:44",
+			})
+			if appErr != nil {
+				assert.EqualValues(t, REASON_INSUFFICIENT_FUNDS, appErr.Reason)
+				return
+			}
+			assert.False(t, saved.Balance.IsNegative(), "balance went negative: %s", saved.Balance)
+			mu.Lock()
+			succeeded++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	after, appErr := repo.FindBy(account.AccountID)
+	assert.Nil(t, appErr)
+	assert.EqualValues(t, 10, succeeded)
+	assert.True(t, after.Amount.IsZero(), "expected a balance of 0.00, got %s", after.Amount)
+}
+
+func TestConcurrentOppositeTransfersKeepTotal(t *testing.T) {
+	client := testDBClient(t)
+	defer client.Close()
+	repo := NewAccountRepositoryDB(client)
+	a := testAccount(t, repo, "100.00")
+	b := testAccount(t, repo, "100.00")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		from, to := a, b
+		if i%2 == 0 {
+			from, to = b, a
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			transfer := Transfer{
+				Amount: money.MustParse("15.00"),
+				Debit:  Transaction{AccountID: from.AccountID, Amount: money.MustParse("15.00"), TransactionType: TRANSFER_OUT, TransactionDate: "2021-03-10 09:02:44"},
+				Credit: Transaction{AccountID: to.AccountID, Amount: money.MustParse("15.00"), TransactionType: TRANSFER_IN, TransactionDate: "2021-03-10 09:02:44"},
+			}
+			if _, appErr := repo.SaveTransfer(transfer); appErr != nil {
+				assert.EqualValues(t, REASON_INSUFFICIENT_FUNDS, appErr.Reason)
+			}
+		}()
+	}
+	wg.Wait()
+
+	afterA, _ := repo.FindBy(a.AccountID)
+	afterB, _ := repo.FindBy(b.AccountID)
+	assert.False(t, afterA.Amount.IsNegative())
+	assert.False(t, afterB.Amount.IsNegative())
+	assert.EqualValues(t, money.MustParse("200.00"), afterA.Amount.Add(afterB.Amount))
+}
+
+func TestWithdrawalIntoOverdraftIsChargedItsFee(t *testing.T) {
+	client := testDBClient(t)
+	defer client.Close()
+	repo := NewAccountRepositoryDB(client)
+	account := testAccount(t, repo, "100.00")
+	if appErr := repo.UpdateOverdraft(account.AccountID, Overdraft{Limit: money.MustParse("50.00")}, ANY_VERSION); appErr != nil {
+		t.Fatal(appErr.Message)
+	}
+
+	withdrawal := Transaction{AccountID: account.AccountID, Amount: money.MustParse("120.00"), TransactionType: WITHDRAWAL, TransactionDate: "2021-03-10 09:02:44"}
+	saved, appErr := repo.SaveTransaction(withdrawal)
+	assert.Nil(t, appErr)
+	assert.Equal(t, money.MustParse("-20.00"), saved.Balance)
+	// the checking schedule charges 25.00 for going overdrawn, a charge may go past the limit
+	assert.Len(t, saved.Fees, 1)
+	assert.Equal(t, FEE_OVERDRAFT, saved.Fees[0].FeeType)
+	assert.Equal(t, money.MustParse("-45.00"), saved.BalanceAfter())
+
+	withdrawal.Amount = money.MustParse("10.00")
+	_, appErr = repo.SaveTransaction(withdrawal)
+	assert.EqualValues(t, REASON_OVERDRAFT_LIMIT_EXCEEDED, appErr.Reason)
+}
+
+func TestReversingAWithdrawalInFullReversesItsFees(t *testing.T) {
+	client := testDBClient(t)
+	defer client.Close()
+	repo := NewAccountRepositoryDB(client)
+	account := testAccount(t, repo, "100.00")
+	t.Cleanup(func() {
+		client.MustExec("delete from reversals where account_id = ?", account.AccountID)
+	})
+	if appErr := repo.UpdateOverdraft(account.AccountID, Overdraft{Limit: money.MustParse("50.00")}, ANY_VERSION); appErr != nil {
+		t.Fatal(appErr.Message)
+	}
+	withdrawal, appErr := repo.SaveTransaction(Transaction{AccountID: account.AccountID, Amount: money.MustParse("120.00"), TransactionType: WITHDRAWAL, TransactionDate: "2021-03-10 09:02:44"})
+	if appErr != nil {
+		t.Fatal(appErr.Message)
+	}
+
+	reversals := NewReversalRepositoryDB(client)
+	partial := money.MustParse("20.00")
+	reversal := Reversal{TransactionID: withdrawal.TransactionID, AccountID: account.AccountID, ReasonCode: "fraud", CreatedBy: "admin", CreatedAt: "2021-03-10 10:00:00"}
+	reversal.Requested = &partial
+	saved, appErr := reversals.SaveReversal(reversal)
+	assert.Nil(t, appErr)
+	assert.Empty(t, saved.FeeReversals)
+
+	reversal.Requested = nil
+	saved, appErr = reversals.SaveReversal(reversal)
+	assert.Nil(t, appErr)
+	assert.Len(t, saved.FeeReversals, 1)
+	assert.Equal(t, withdrawal.Fees[0].TransactionID, saved.FeeReversals[0].TransactionID)
+	after, _ := repo.FindBy(account.AccountID)
+	assert.Equal(t, money.MustParse("100.00"), after.Amount)
+}
+
+func TestOneLegOfATransferIsNotReversed(t *testing.T) {
+	client := testDBClient(t)
+	defer client.Close()
+	repo := NewAccountRepositoryDB(client)
+	a := testAccount(t, repo, "100.00")
+	b := testAccount(t, repo, "100.00")
+	transfer, appErr := repo.SaveTransfer(Transfer{
+		Amount: money.MustParse("40.00"),
+		Debit:  Transaction{AccountID: a.AccountID, Amount: money.MustParse("40.00"), TransactionType: TRANSFER_OUT, TransactionDate: "2021-03-10 09:02:44"},
+		Credit: Transaction{AccountID: b.AccountID, Amount: money.MustParse("40.00"), TransactionType: TRANSFER_IN, TransactionDate: "2021-03-10 09:02:44"},
+	})
+	if appErr != nil {
+		t.Fatal(appErr.Message)
+	}
+
+	reversals := NewReversalRepositoryDB(client)
+	_, appErr = reversals.SaveReversal(Reversal{TransactionID: transfer.Debit.TransactionID, AccountID: a.AccountID, ReasonCode: "posting_error", CreatedBy: "admin", CreatedAt: "2021-03-10 10:00:00"})
+	assert.Equal(t, "transfer_not_reversible", appErr.Reason)
+	_, appErr = reversals.SaveReversal(Reversal{TransactionID: transfer.Credit.TransactionID, AccountID: b.AccountID, ReasonCode: "posting_error", CreatedBy: "admin", CreatedAt: "2021-03-10 10:00:00"})
+	assert.Equal(t, "transfer_not_reversible", appErr.Reason)
+}
+
+func TestBuildTransactionsQueryNoFilters(t *testing.T) {
+	query, args := buildTransactionsQuery(TransactionFilter{AccountID: "95470", Limit: 51})
+	assert.Equal(t, getTransactions+" order by transaction_date asc, transaction_id asc limit ?;", query)
+	assert.Equal(t, []interface{}{"95470", 51}, args)
+}
+
+func TestBuildTransactionsQueryWithoutLimitReadsEverything(t *testing.T) {
+	query, args := buildTransactionsQuery(TransactionFilter{AccountID: "95470", From: "2021-03-01 00:00:00"})
+	assert.Equal(t, getTransactions+" and transaction_date >= ? order by transaction_date asc, transaction_id asc;", query)
+	assert.Equal(t, []interface{}{"95470", "2021-03-01 00:00:00"}, args)
+}
+
+func TestBuildTransactionsQueryAllFilters(t *testing.T) {
+	min := money.MustParse("10.00")
+	max := money.MustParse("99.99")
+	f := TransactionFilter{
+		AccountID:       "95470",
+		From:            "2021-03-01 00:00:00",
+		To:              "2021-04-01 00:00:00",
+		TransactionType: DEPOSIT,
+		MinAmount:       &min,
+		MaxAmount:       &max,
+		Descending:      true,
+		After:           &TransactionCursor{TransactionDate: "2021-03-10 09:02:44", TransactionID: "42"},
+		Limit:           11,
+	}
+	query, args := buildTransactionsQuery(f)
+	assert.Equal(t, getTransactions+
+		" and transaction_date >= ? and transaction_date < ? and transaction_type = ? and amount >= ? and amount <= ?"+
+		" and (transaction_date < ? or (transaction_date = ? and transaction_id < ?))"+
+		" order by transaction_date desc, transaction_id desc limit ?;", query)
+	assert.Equal(t, []interface{}{
+		"95470", "2021-03-01 00:00:00", "2021-04-01 00:00:00", DEPOSIT, min, max,
+		"2021-03-10 09:02:44", "2021-03-10 09:02:44", "42", 11,
+	}, args)
+}
@@ -2,8 +2,10 @@ package domain
 
 import (
 	"testing"
+	"time"
 
 	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/money"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,34 +21,48 @@ func TestToGetAccountResponseDTO(t *testing.T) {
 		CustomerID:  "456",
 		OpeningDate: "11-11-1111",
 		AccountType: "checking",
-		Amount:      1000,
+		Amount:      money.MustParse("1000"),
 	}
 	resp := a.ToGetAccountResponseDTO()
 	assert.Equal(t, "123", resp.AccountID)
 	assert.Equal(t, "456", resp.CustomerID)
 	assert.Equal(t, "11-11-1111", resp.OpeningDate)
 	assert.Equal(t, "checking", resp.AccountType)
-	assert.Equal(t, 1000.0, resp.Amount)
+	assert.Equal(t, money.MustParse("1000.00"), resp.Amount)
 }
 
 func TestNewAccount(t *testing.T) {
 	a := dto.CreateAccountRequest{
 		CustomerID:  "456",
 		AccountType: "checking",
-		Amount:      1000,
+		Amount:      money.MustParse("1000"),
 	}
-	resp := NewAccount(a)
+	resp := NewAccount(a, "2021-03-10 09:00:00")
 	assert.Equal(t, "456", resp.CustomerID)
-	assert.NotNil(t, resp.OpeningDate)
+	assert.Equal(t, "2021-03-10 09:00:00", resp.OpeningDate)
 	assert.Equal(t, "checking", resp.AccountType)
-	assert.Equal(t, 1000.0, resp.Amount)
-	assert.Equal(t, "1", resp.Status)
+	assert.Equal(t, money.MustParse("1000.00"), resp.Amount)
+	assert.Equal(t, STATUS_ACTIVE, resp.Status)
 }
 
 func TestCanWithdraw(t *testing.T) {
-	a := Account{Amount: 1000.0}
-	assert.True(t, a.CanWithdraw(1000.0))
-	assert.True(t, a.CanWithdraw(900.0))
-	assert.False(t, a.CanWithdraw(2000.0))
+	a := Account{Amount: money.MustParse("1000.00")}
+	assert.True(t, a.CanWithdraw(money.MustParse("1000.00")))
+	assert.True(t, a.CanWithdraw(money.MustParse("900.00")))
+	assert.False(t, a.CanWithdraw(money.MustParse("1000.01")))
+	assert.False(t, a.CanWithdraw(money.MustParse("2000.00")))
+}
+
+func TestAccountOpenedBy(t *testing.T) {
+	a := Account{OpeningDate: "2021-03-10 23:59:59"}
+	assert.False(t, a.OpenedBy(time.Date(2021, time.March, 9, 0, 0, 0, 0, time.Local)))
+	assert.True(t, a.OpenedBy(time.Date(2021, time.March, 10, 0, 0, 0, 0, time.Local)))
+}
 
+func TestAccountOpenedByReadsTheOpeningDateInTheZoneOfDay(t *testing.T) {
+	// the opening date is a wall-clock time of the bank, whatever the zone of the server
+	bank := time.FixedZone("UTC+14", 14*60*60)
+	a := Account{OpeningDate: "2021-03-10 23:59:59"}
+	assert.False(t, a.OpenedBy(time.Date(2021, time.March, 9, 0, 0, 0, 0, bank)))
+	assert.True(t, a.OpenedBy(time.Date(2021, time.March, 10, 0, 0, 0, 0, bank)))
 }
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// API_KEY_PREFIX starts every api key, so a leaked key is easy to spot in logs and code
+const API_KEY_PREFIX = "bk_"
+
+// apiKeyTouchInterval is how stale last_used_at may get, a busy key is not written on every request
+const apiKeyTouchInterval = time.Minute
+
+// APIKey lets a batch job or partner service call the routes named in its scopes. The key is shown once,
+// only the sha256 of its secret is stored. A key looks like bk_<key id>_<secret>.
+type APIKey struct {
+	KeyID      string `db:"key_id"`
+	Name       string
+	SecretHash string `db:"secret_hash"`
+	// Scopes are the route names the key may call, comma separated
+	Scopes     string
+	CreatedBy  string         `db:"created_by"`
+	CreatedAt  string         `db:"created_at"`
+	ExpiresAt  sql.NullString `db:"expires_at"`
+	LastUsedAt sql.NullString `db:"last_used_at"`
+	RevokedAt  sql.NullString `db:"revoked_at"`
+}
+
+// APIKeyRepository implements:
+//
+// FindAll: returns every api key
+// ByID: returns an api key, or a not found error
+// Save: stores a new api key
+// Revoke: revokes an api key, a not found error is returned when there is no such key
+// Touch: records the last use of an api key
+// mockgen -destination=mocks/domain/mock_api_key_repository.go -package=domain github.com/jonathanwamsley/banking/domain APIKeyRepository
+type APIKeyRepository interface {
+	FindAll() ([]APIKey, *errs.AppError)
+	ByID(keyID string) (*APIKey, *errs.AppError)
+	Save(APIKey) *errs.AppError
+	Revoke(keyID string, now string) *errs.AppError
+	Touch(keyID string, now string) *errs.AppError
+}
+
+// NewAPIKey creates a key for the request and returns it with the key to hand out, which is never stored
+func NewAPIKey(req dto.CreateAPIKeyRequest, createdBy string, now time.Time) (APIKey, string, error) {
+	id := make([]byte, 6)
+	secret := make([]byte, 24)
+	if _, err := rand.Read(id); err != nil {
+		return APIKey{}, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return APIKey{}, "", err
+	}
+	k := APIKey{
+		KeyID:      hex.EncodeToString(id),
+		Name:       req.Name,
+		SecretHash: hashAPIKeySecret(hex.EncodeToString(secret)),
+		Scopes:     strings.Join(req.Scopes, ","),
+		CreatedBy:  createdBy,
+		CreatedAt:  now.Format(dbTSLayout),
+		ExpiresAt:  nullString(req.ExpiresAt),
+	}
+	return k, API_KEY_PREFIX + k.KeyID + "_" + hex.EncodeToString(secret), nil
+}
+
+// ParseAPIKey splits a key into its id and secret, ok is false when it is not shaped like a key
+func ParseAPIKey(key string) (keyID string, secret string, ok bool) {
+	if !strings.HasPrefix(key, API_KEY_PREFIX) {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(key, API_KEY_PREFIX), "_")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ScopeList returns the route names of the key
+func (k APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// Check makes sure the secret belongs to the key, the key is neither revoked nor expired, and routeName
+// is in its scopes. A wrong secret, a revoked key and an expired key are all 401, a route outside the scopes is 403.
+func (k APIKey) Check(secret string, routeName string, now time.Time) *errs.AppError {
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(k.SecretHash)) != 1 {
+		return errs.NewUnauthorizedError("invalid api key")
+	}
+	if k.RevokedAt.Valid {
+		return errs.NewUnauthorizedError("api key was revoked")
+	}
+	if k.ExpiresAt.Valid {
+		expiresAt, err := time.ParseInLocation(dbTSLayout, k.ExpiresAt.String, time.Local)
+		if err != nil || !now.Before(expiresAt) {
+			return errs.NewUnauthorizedError("api key expired")
+		}
+	}
+	for _, scope := range k.ScopeList() {
+		if scope == routeName {
+			return nil
+		}
+	}
+	return errs.NewForbiddenError("api key is not scoped for " + routeName)
+}
+
+// NeedsTouch checks if last_used_at is stale enough to be written again
+func (k APIKey) NeedsTouch(now time.Time) bool {
+	if !k.LastUsedAt.Valid {
+		return true
+	}
+	lastUsed, err := time.ParseInLocation(dbTSLayout, k.LastUsedAt.String, time.Local)
+	return err != nil || now.Sub(lastUsed) >= apiKeyTouchInterval
+}
+
+// AccessClaims are the claims handlers see for a request made with the key
+func (k APIKey) AccessClaims() *AccessClaims {
+	claims := &AccessClaims{Role: ROLE_SERVICE}
+	claims.Subject = "apikey:" + k.KeyID
+	return claims
+}
+
+// ToDTO converts an api key, without its secret hash
+func (k APIKey) ToDTO() dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		KeyID:      k.KeyID,
+		Name:       k.Name,
+		Scopes:     k.ScopeList(),
+		CreatedBy:  k.CreatedBy,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt.String,
+		LastUsedAt: k.LastUsedAt.String,
+		RevokedAt:  k.RevokedAt.String,
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"database/sql"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// The query statements
+const (
+	apiKeyColumns = "key_id, name, secret_hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at"
+	findAllKeys   = "SELECT " + apiKeyColumns + " from api_keys order by created_at, key_id;"
+	findKey       = "SELECT " + apiKeyColumns + " from api_keys where key_id = ?;"
+	insertKey     = "INSERT INTO api_keys (key_id, name, secret_hash, scopes, created_by, created_at, expires_at) values (?, ?, ?, ?, ?, ?, ?);"
+	revokeKey     = "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) where key_id = ?;"
+	touchKey      = "UPDATE api_keys SET last_used_at = ? where key_id = ?;"
+)
+
+// APIKeyRepositoryDB holds the sql client connection
+type APIKeyRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewAPIKeyRepositoryDB creates a new APIKeyRepositoryDB to call sql methods
+func NewAPIKeyRepositoryDB(client *sqlx.DB) APIKeyRepositoryDB {
+	return APIKeyRepositoryDB{client}
+}
+
+// FindAll returns every api key, revoked and expired keys included
+func (d APIKeyRepositoryDB) FindAll() ([]APIKey, *errs.AppError) {
+	keys := make([]APIKey, 0)
+	if err := d.client.Select(&keys, findAllKeys); err != nil {
+		logger.Error("Error while querying api_keys table " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return keys, nil
+}
+
+// ByID returns an api key
+func (d APIKeyRepositoryDB) ByID(keyID string) (*APIKey, *errs.AppError) {
+	var k APIKey
+	if err := d.client.Get(&k, findKey, keyID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("API key not found")
+		}
+		logger.Error("Error while scanning api key " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &k, nil
+}
+
+// Save stores a new api key
+func (d APIKeyRepositoryDB) Save(k APIKey) *errs.AppError {
+	_, err := d.client.Exec(insertKey, k.KeyID, k.Name, k.SecretHash, k.Scopes, k.CreatedBy, k.CreatedAt, k.ExpiresAt)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlDuplicateEntry {
+			return errs.NewConflictError("API key id is already taken, try again")
+		}
+		logger.Error("Error while saving api key " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// Revoke revokes an api key, revoking it again keeps the first revocation time
+func (d APIKeyRepositoryDB) Revoke(keyID string, now string) *errs.AppError {
+	if _, err := d.ByID(keyID); err != nil {
+		return err
+	}
+	if _, err := d.client.Exec(revokeKey, now, keyID); err != nil {
+		logger.Error("Error while revoking api key " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// Touch records when an api key was last used
+func (d APIKeyRepositoryDB) Touch(keyID string, now string) *errs.AppError {
+	if _, err := d.client.Exec(touchKey, now, keyID); err != nil {
+		logger.Error("Error while recording api key use " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
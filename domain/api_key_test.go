@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+var keyNow = time.Date(2021, 3, 10, 9, 0, 0, 0, time.Local)
+
+func TestNewAPIKeyOnlyStoresTheHashOfItsSecret(t *testing.T) {
+	k, key, err := NewAPIKey(dto.CreateAPIKeyRequest{Name: "nightly", Scopes: []string{"GetCustomers", "CheckLedger"}}, "admin", keyNow)
+
+	assert.Nil(t, err)
+	keyID, secret, ok := ParseAPIKey(key)
+	assert.True(t, ok)
+	assert.Equal(t, k.KeyID, keyID)
+	assert.NotContains(t, k.SecretHash, secret)
+	assert.Equal(t, []string{"GetCustomers", "CheckLedger"}, k.ScopeList())
+	assert.Nil(t, k.Check(secret, "CheckLedger", keyNow))
+	assert.EqualValues(t, 401, k.Check(secret+"0", "CheckLedger", keyNow).Code)
+	assert.EqualValues(t, 403, k.Check(secret, "DeleteCustomer", keyNow).Code)
+}
+
+func TestParseAPIKeyRejectsOtherShapes(t *testing.T) {
+	for _, key := range []string{"", "bk_", "bk_abc", "xx_abc_def", "bk_abc_def_ghi", "eyJhbGciOiJIUzI1NiJ9.e30.x"} {
+		_, _, ok := ParseAPIKey(key)
+		assert.False(t, ok, key)
+	}
+}
+
+func TestAPIKeyCheckExpiryAndRevocation(t *testing.T) {
+	k, key, _ := NewAPIKey(dto.CreateAPIKeyRequest{Name: "partner", Scopes: []string{"GetAccount"}, ExpiresAt: "2021-03-10 10:00:00"}, "admin", keyNow)
+	_, secret, _ := ParseAPIKey(key)
+
+	assert.Nil(t, k.Check(secret, "GetAccount", keyNow.Add(59*time.Minute)))
+	assert.EqualValues(t, 401, k.Check(secret, "GetAccount", keyNow.Add(time.Hour)).Code)
+
+	k.RevokedAt = sql.NullString{String: "2021-03-10 09:30:00", Valid: true}
+	assert.EqualValues(t, 401, k.Check(secret, "GetAccount", keyNow).Code)
+}
+
+func TestAPIKeyNeedsTouchOncePerMinute(t *testing.T) {
+	k := APIKey{}
+	assert.True(t, k.NeedsTouch(keyNow))
+
+	k.LastUsedAt = sql.NullString{String: "2021-03-10 08:59:30", Valid: true}
+	assert.False(t, k.NeedsTouch(keyNow))
+	assert.True(t, k.NeedsTouch(keyNow.Add(30*time.Second)))
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "github.com/golang-jwt/jwt/v4"
+
+// roles a token can carry, what each role may do is set by the policy file
+const (
+	ROLE_ADMIN    = "admin"
+	ROLE_CUSTOMER = "customer"
+	ROLE_USER     = "user"
+	ROLE_TELLER   = "teller"
+	ROLE_AUDITOR  = "auditor"
+	// ROLE_SERVICE is the role of a request made with an api key, the scopes of the key decide what it may do
+	ROLE_SERVICE = "service"
+)
+
+// AccessClaims are the claims of a token issued by the banking auth api
+type AccessClaims struct {
+	CustomerID string   `json:"customer_id"`
+	Accounts   []string `json:"accounts"`
+	Username   string   `json:"username"`
+	Role       string   `json:"role"`
+	// TokenUse is access or refresh, tokens of the auth api do not set it and are access tokens
+	TokenUse string `json:"token_use,omitempty"`
+	// SessionID is the login session the token belongs to, revoking the session revokes the token
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Attributes are the claims a policy condition can read as token.<name>
+func (c AccessClaims) Attributes() map[string]interface{} {
+	accounts := make([]interface{}, 0, len(c.Accounts))
+	for _, a := range c.Accounts {
+		accounts = append(accounts, a)
+	}
+	attrs := map[string]interface{}{"accounts": accounts}
+	for name, value := range map[string]string{
+		"sub": c.Subject, "iss": c.Issuer, "customer_id": c.CustomerID, "username": c.Username, "role": c.Role,
+	} {
+		// a claim the token does not carry is missing, not empty, so an exists condition fails on it
+		if value != "" {
+			attrs[name] = value
+		}
+	}
+	return attrs
+}
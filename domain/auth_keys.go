@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signing algorithms tokens are accepted with
+const (
+	ALG_HS256 = "HS256"
+	ALG_RS256 = "RS256"
+)
+
+// VerificationKeys holds the keys tokens can be verified with, by algorithm and key id.
+// A key added without an id is used for tokens without a kid header.
+type VerificationKeys struct {
+	keys map[string]map[string]interface{}
+}
+
+// NewVerificationKeys creates an empty set of keys
+func NewVerificationKeys() *VerificationKeys {
+	return &VerificationKeys{keys: map[string]map[string]interface{}{ALG_HS256: {}, ALG_RS256: {}}}
+}
+
+// AddHMAC adds a shared secret for HS256 tokens
+func (k *VerificationKeys) AddHMAC(kid string, secret []byte) {
+	k.keys[ALG_HS256][kid] = secret
+}
+
+// AddRSA adds a public key for RS256 tokens
+func (k *VerificationKeys) AddRSA(kid string, key *rsa.PublicKey) {
+	k.keys[ALG_RS256][kid] = key
+}
+
+// AddRSAPEMFile adds the PEM encoded RSA public key in a file
+func (k *VerificationKeys) AddRSAPEMFile(kid string, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	k.AddRSA(kid, key)
+	return nil
+}
+
+// jsonWebKey is the part of a JWK (RFC 7517) needed for RSA and symmetric signing keys
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// AddJWKSFile adds the RSA and symmetric signing keys of a JWKS file. Encryption keys and
+// other key types are skipped, but the file must hold at least one usable key.
+func (k *VerificationKeys) AddJWKSFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	added := 0
+	for _, jwk := range set.Keys {
+		if jwk.Use == "enc" {
+			continue
+		}
+		switch jwk.Kty {
+		case "RSA":
+			if jwk.Alg != "" && jwk.Alg != ALG_RS256 {
+				continue
+			}
+			key, err := jwk.rsaPublicKey()
+			if err != nil {
+				return fmt.Errorf("%s: key %s: %v", path, jwk.Kid, err)
+			}
+			k.AddRSA(jwk.Kid, key)
+		case "oct":
+			if jwk.Alg != "" && jwk.Alg != ALG_HS256 {
+				continue
+			}
+			secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+			if err != nil || len(secret) == 0 {
+				return fmt.Errorf("%s: key %s: invalid k", path, jwk.Kid)
+			}
+			k.AddHMAC(jwk.Kid, secret)
+		default:
+			continue
+		}
+		added++
+	}
+	if added == 0 {
+		return fmt.Errorf("%s: no RS256 or HS256 signing keys", path)
+	}
+	return nil
+}
+
+func (jwk jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil || len(n) == 0 {
+		return nil, errors.New("invalid n")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid e")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+// IsEmpty checks if no key was added
+func (k *VerificationKeys) IsEmpty() bool {
+	return len(k.keys[ALG_HS256]) == 0 && len(k.keys[ALG_RS256]) == 0
+}
+
+// keyFor returns the key a token has to be verified with. Keys are looked up by the token's alg,
+// so an RSA public key can never be used as an HMAC secret. A token without a kid may use the key
+// added without an id, or the only key there is for its alg.
+func (k *VerificationKeys) keyFor(t *jwt.Token) (interface{}, error) {
+	alg := t.Method.Alg()
+	keys, ok := k.keys[alg]
+	if !ok {
+		return nil, fmt.Errorf("unexpected signing method %s", alg)
+	}
+	kid, _ := t.Header["kid"].(string)
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s key for kid %q", alg, kid)
+}
@@ -5,45 +5,78 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/jonathanwamsley/banking/logger"
 )
 
+// AuthRepository implements:
+//
+// IsAuthorized: checks the token is valid and lets its owner make the request, the claims of an authorized token are returned
 type AuthRepository interface {
-	IsAuthorized(token string, routeName string, vars map[string]string) bool
+	IsAuthorized(r AuthRequest) (*AccessClaims, bool)
 }
 
-type RemoteAuthRepository struct{}
+// AuthRequest is what the auth middleware knows about a request: the bearer token, the route name,
+// the route variables and the json body, which is nil when the request has no json object body
+type AuthRequest struct {
+	Token     string
+	RouteName string
+	Vars      map[string]string
+	Body      map[string]interface{}
+}
+
+// RemoteAuthRepository asks the banking auth api to verify the token on every request
+type RemoteAuthRepository struct {
+	address string
+	client  *http.Client
+}
 
-func NewAuthRepository() RemoteAuthRepository {
-	return RemoteAuthRepository{}
+// NewRemoteAuthRepository creates a RemoteAuthRepository for the auth api at address, like localhost:8181
+func NewRemoteAuthRepository(address string) RemoteAuthRepository {
+	return RemoteAuthRepository{address: address, client: &http.Client{Timeout: 5 * time.Second}}
 }
 
-func (r RemoteAuthRepository) IsAuthorized(token string, routeName string, vars map[string]string) bool {
-	u := buildVerifyURL(token, routeName, vars)
+// IsAuthorized sends the token, route name and route variables to the auth api's /auth/verify,
+// the auth api does not see the body. The auth api already checked the signature of an authorized
+// token, so its claims are read without checking it again.
+func (r RemoteAuthRepository) IsAuthorized(req AuthRequest) (*AccessClaims, bool) {
+	u := buildVerifyURL(r.address, req.Token, req.RouteName, req.Vars)
 
-	if response, err := http.Get(u); err != nil {
+	response, err := r.client.Get(u)
+	if err != nil {
 		logger.Error("Error while sending..." + err.Error())
-		return false
-	} else {
-		logger.Info(fmt.Sprint("successfully sent a msg to auth api %s", response))
-		m := map[string]bool{}
-		if err = json.NewDecoder(response.Body).Decode(&m); err != nil {
-			logger.Error("Error while decoding response from auth server:" + err.Error())
-			return false
-		}
-		logger.Info("successfully received a message from auth api")
-		return m["isAuthorized"]
+		return nil, false
 	}
+	defer response.Body.Close()
 
+	logger.Info(fmt.Sprintf("successfully sent a msg to auth api %s", response.Status))
+	if response.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	m := map[string]bool{}
+	if err = json.NewDecoder(response.Body).Decode(&m); err != nil {
+		logger.Error("Error while decoding response from auth server:" + err.Error())
+		return nil, false
+	}
+	logger.Info("successfully received a message from auth api")
+	if !m["isAuthorized"] {
+		return nil, false
+	}
+	claims := &AccessClaims{}
+	if _, _, err = jwt.NewParser().ParseUnverified(req.Token, claims); err != nil {
+		logger.Error("Error while reading the claims of an authorized token " + err.Error())
+		return nil, false
+	}
+	return claims, true
 }
 
-func buildVerifyURL(token string, routeName string, vars map[string]string) string {
-	u := url.URL{Host: "localhost:8181", Path: "/auth/verify", Scheme: "http"}
+func buildVerifyURL(address string, token string, routeName string, vars map[string]string) string {
+	u := url.URL{Host: address, Path: "/auth/verify", Scheme: "http"}
 	q := u.Query()
 	q.Add("token", token)
 	q.Add("routeName", routeName)
-	logger.Info(fmt.Sprintf("token received %s", token))
 	for k, v := range vars {
 		q.Add(k, v)
 	}
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// what a token is used for, only access tokens are accepted on api requests
+const (
+	TOKEN_USE_ACCESS  = "access"
+	TOKEN_USE_REFRESH = "refresh"
+)
+
+// TokenIssuer signs the access and refresh tokens of a login with the same key the api verifies them with
+type TokenIssuer struct {
+	method     jwt.SigningMethod
+	key        interface{}
+	kid        string
+	issuer     string
+	audience   string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// TokenPair is the access token and the refresh token of a login, with the jti of the refresh token
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	RefreshID    string
+	AccessTTL    time.Duration
+	RefreshTTL   time.Duration
+}
+
+// NewHMACTokenIssuer creates a TokenIssuer that signs HS256 tokens with a shared secret
+func NewHMACTokenIssuer(secret []byte, issuer string, audience string, accessTTL time.Duration, refreshTTL time.Duration) TokenIssuer {
+	return TokenIssuer{
+		method: jwt.SigningMethodHS256, key: secret, issuer: issuer, audience: audience,
+		accessTTL: accessTTL, refreshTTL: refreshTTL, now: time.Now,
+	}
+}
+
+// NewRSATokenIssuer creates a TokenIssuer that signs RS256 tokens with the PEM encoded RSA private key in a file.
+// The kid is set on every token, so verifiers with a JWKS can pick the key.
+func NewRSATokenIssuer(path string, kid string, issuer string, audience string, accessTTL time.Duration, refreshTTL time.Duration) (*TokenIssuer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &TokenIssuer{
+		method: jwt.SigningMethodRS256, key: key, kid: kid, issuer: issuer, audience: audience,
+		accessTTL: accessTTL, refreshTTL: refreshTTL, now: time.Now,
+	}, nil
+}
+
+// PublicKey returns the RSA public key tokens are verified with, or nil for HS256 tokens
+func (i TokenIssuer) PublicKey() (string, *rsa.PublicKey) {
+	if key, ok := i.key.(*rsa.PrivateKey); ok {
+		return i.kid, &key.PublicKey
+	}
+	return "", nil
+}
+
+// Issue signs an access token and a refresh token for the claims of a user. Both get their own jti,
+// so each can be revoked on its own.
+func (i TokenIssuer) Issue(claims AccessClaims) (*TokenPair, error) {
+	now := i.now()
+	accessID, err := NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+	claims.ID = accessID
+	access, err := i.sign(claims, TOKEN_USE_ACCESS, now, i.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refreshID, err := NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+	refreshClaims := AccessClaims{Username: claims.Username, Role: claims.Role, SessionID: claims.SessionID}
+	refreshClaims.ID = refreshID
+	refreshClaims.Subject = claims.Subject
+	refresh, err := i.sign(refreshClaims, TOKEN_USE_REFRESH, now, i.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{AccessToken: access, RefreshToken: refresh, RefreshID: refreshID, AccessTTL: i.accessTTL, RefreshTTL: i.refreshTTL}, nil
+}
+
+func (i TokenIssuer) sign(claims AccessClaims, use string, now time.Time, ttl time.Duration) (string, error) {
+	claims.TokenUse = use
+	claims.Issuer = i.issuer
+	if i.audience != "" {
+		claims.Audience = jwt.ClaimStrings{i.audience}
+	}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+
+	token := jwt.NewWithClaims(i.method, claims)
+	if i.kid != "" {
+		token.Header["kid"] = i.kid
+	}
+	return token.SignedString(i.key)
+}
+
+// ParseRefreshToken checks a refresh token was signed by this issuer and has not expired, and returns its claims
+func (i TokenIssuer) ParseRefreshToken(token string) (*AccessClaims, error) {
+	claims, err := i.ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenUse != TOKEN_USE_REFRESH {
+		return nil, errors.New("token is not a refresh token")
+	}
+	if !claims.VerifyExpiresAt(i.now(), true) {
+		return nil, errors.New("token is expired")
+	}
+	return claims, nil
+}
+
+// ParseToken checks a token was signed by this issuer and returns its claims, even when it expired
+func (i TokenIssuer) ParseToken(token string) (*AccessClaims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{i.method.Alg()}), jwt.WithoutClaimsValidation())
+	claims := &AccessClaims{}
+	_, err := parser.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		if key, ok := i.key.(*rsa.PrivateKey); ok {
+			return &key.PublicKey, nil
+		}
+		return i.key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// NewTokenID returns a random id for a jti or a session
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
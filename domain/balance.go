@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// JOB_BALANCE_SNAPSHOTS takes the end of day balance snapshots, it runs once per date that has ended
+const JOB_BALANCE_SNAPSHOTS = "balance_snapshots"
+
+// BalanceSnapshot is the balance of an account at the end of a date, read from the ledger
+type BalanceSnapshot struct {
+	AccountID    string      `db:"account_id"`
+	SnapshotDate string      `db:"snapshot_date"`
+	Balance      money.Money `db:"balance"`
+	TakenAt      string      `db:"taken_at"`
+}
+
+// EndOfDayBalance is the balance of an account at the end of a date: the balance of the last snapshot before
+// it, moved by the ledger lines posted after that snapshot up to the end of the date. SnapshotDate is empty
+// when there was no snapshot, the movement then starts with the opening deposit.
+type EndOfDayBalance struct {
+	AccountID       string      `db:"account_id"`
+	Date            string      `db:"balance_date"`
+	SnapshotDate    string      `db:"snapshot_date"`
+	SnapshotBalance money.Money `db:"snapshot_balance"`
+	Movement        money.Money `db:"movement"`
+}
+
+// SnapshotComparison is the last snapshot of an account, the ledger lines posted since and its stored balance
+type SnapshotComparison struct {
+	AccountID       string      `db:"account_id"`
+	AccountBalance  money.Money `db:"amount"`
+	SnapshotDate    string      `db:"snapshot_date"`
+	SnapshotBalance money.Money `db:"snapshot_balance"`
+	Movement        money.Money `db:"movement"`
+}
+
+// BalanceRepository implements:
+//
+// BalanceAsOf: returns the balance of an account at the end of a date from the last snapshot before it
+// SnapshotCandidates: returns the open accounts, opened before the end of a date, that have no snapshot of it yet
+// with their balance at the end of the date
+// SaveSnapshot: stores the snapshot of an account for a date, false is returned when that date was already taken
+// FirstOpeningDate: returns the date the first account was opened, an empty string when there are none
+// CompareSnapshots: returns the last snapshot of every account that has one, with the ledger lines posted since
+// and the stored balance
+// mockgen -destination=mocks/domain/mock_balance_repository.go -package=domain github.com/jonathanwamsley/banking/domain BalanceRepository
+type BalanceRepository interface {
+	BalanceAsOf(accountID string, date string) (*EndOfDayBalance, *errs.AppError)
+	SnapshotCandidates(date string) ([]EndOfDayBalance, *errs.AppError)
+	SaveSnapshot(BalanceSnapshot) (bool, *errs.AppError)
+	FirstOpeningDate() (string, *errs.AppError)
+	CompareSnapshots() ([]SnapshotComparison, *errs.AppError)
+}
+
+// Balance is the balance at the end of the date
+func (b EndOfDayBalance) Balance() money.Money {
+	return b.SnapshotBalance.Add(b.Movement)
+}
+
+// Snapshot records the balance as the snapshot of its date
+func (b EndOfDayBalance) Snapshot(takenAt string) BalanceSnapshot {
+	return BalanceSnapshot{
+		AccountID:    b.AccountID,
+		SnapshotDate: b.Date,
+		Balance:      b.Balance(),
+		TakenAt:      takenAt,
+	}
+}
+
+// ToDTO converts the balance to the response for the user
+func (b EndOfDayBalance) ToDTO() dto.BalanceResponse {
+	return dto.BalanceResponse{
+		AccountID:    b.AccountID,
+		AsOf:         b.Date,
+		Balance:      b.Balance(),
+		SnapshotDate: b.SnapshotDate,
+	}
+}
+
+// Expected is the balance the account should have according to its last snapshot
+func (m SnapshotComparison) Expected() money.Money {
+	return m.SnapshotBalance.Add(m.Movement)
+}
+
+// IsConsistent checks the last snapshot moved by the ledger since comes to the stored balance
+func (m SnapshotComparison) IsConsistent() bool {
+	return m.Expected().Cmp(m.AccountBalance) == 0
+}
+
+// ToDTO converts a comparison that is not consistent to the mismatch returned to the user
+func (m SnapshotComparison) ToDTO() dto.SnapshotMismatch {
+	return dto.SnapshotMismatch{
+		AccountID:       m.AccountID,
+		AccountBalance:  m.AccountBalance,
+		SnapshotDate:    m.SnapshotDate,
+		SnapshotBalance: m.SnapshotBalance,
+		MovementSince:   m.Movement,
+		ExpectedBalance: m.Expected(),
+	}
+}
+
+// endOfDay is the start of the day after date, ledger lines posted before it belong to date or earlier
+func endOfDay(date string) (string, *errs.AppError) {
+	day, err := ParseRunDate(date, wallClock)
+	if err != nil {
+		return "", err
+	}
+	return day.AddDate(0, 0, 1).Format(dbTSLayout), nil
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"database/sql"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// beginningOfTime is the first datetime the db can store, the ledger of an account without a snapshot is
+// read from it
+const beginningOfTime = "1000-01-01 00:00:00"
+
+// The query statements
+const (
+	findSnapshotBefore = "SELECT snapshot_date, balance from balance_snapshots where account_id = ? and snapshot_date <= ? order by snapshot_date desc limit 1;"
+	sumMovement        = `SELECT COALESCE(SUM(l.credit - l.debit), 0) from journal_lines l join journal_entries e on e.entry_id = l.entry_id
+		where l.ledger_account = ? and l.account_id = ? and e.posted_at >= ? and e.posted_at < ?;`
+	// each account starts from its last snapshot before the date, so a day only reads the ledger lines posted
+	// since. Accounts that already have a snapshot of the date are left out, a rerun only takes the missing ones.
+	findSnapshotCandidates = `SELECT a.account_id, ? as balance_date, COALESCE(s.snapshot_date, '') as snapshot_date, COALESCE(s.balance, 0) as snapshot_balance,
+		COALESCE((SELECT SUM(l.credit - l.debit) from journal_lines l join journal_entries e on e.entry_id = l.entry_id
+			where l.ledger_account = ? and l.account_id = a.account_id and e.posted_at < ?
+			and (s.snapshot_date IS NULL or e.posted_at >= s.snapshot_date + INTERVAL 1 DAY)), 0) as movement
+		from accounts a left join balance_snapshots s on s.account_id = a.account_id
+			and s.snapshot_date = (SELECT MAX(p.snapshot_date) from balance_snapshots p where p.account_id = a.account_id and p.snapshot_date < ?)
+		where a.status <> 'closed' and a.opening_date < ?
+			and NOT EXISTS (SELECT 1 from balance_snapshots t where t.account_id = a.account_id and t.snapshot_date = ?)
+		order by a.account_id;`
+	insertSnapshot   = "INSERT INTO balance_snapshots (account_id, snapshot_date, balance, taken_at) values (?, ?, ?, ?);"
+	findFirstOpening = "SELECT COALESCE(DATE_FORMAT(MIN(opening_date), '%Y-%m-%d'), '') from accounts;"
+	compareSnapshots = `SELECT a.account_id, a.amount, s.snapshot_date, s.balance as snapshot_balance,
+		COALESCE((SELECT SUM(l.credit - l.debit) from journal_lines l join journal_entries e on e.entry_id = l.entry_id
+			where l.ledger_account = ? and l.account_id = a.account_id and e.posted_at >= s.snapshot_date + INTERVAL 1 DAY), 0) as movement
+		from accounts a join balance_snapshots s on s.account_id = a.account_id
+			and s.snapshot_date = (SELECT MAX(p.snapshot_date) from balance_snapshots p where p.account_id = a.account_id)
+		order by a.account_id;`
+)
+
+// BalanceRepositoryDB holds the sql client connection
+type BalanceRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewBalanceRepositoryDB creates a new BalanceRepositoryDB to call sql methods
+func NewBalanceRepositoryDB(client *sqlx.DB) BalanceRepositoryDB {
+	return BalanceRepositoryDB{client}
+}
+
+// BalanceAsOf reads the last snapshot of the account on or before date, and adds the ledger lines posted after
+// it up to the end of date. Without a snapshot the whole ledger of the account is read.
+func (d BalanceRepositoryDB) BalanceAsOf(accountID string, date string) (*EndOfDayBalance, *errs.AppError) {
+	end, appErr := endOfDay(date)
+	if appErr != nil {
+		return nil, appErr
+	}
+	b := EndOfDayBalance{AccountID: accountID, Date: date, SnapshotBalance: money.Zero()}
+	from := beginningOfTime
+
+	var snapshot BalanceSnapshot
+	err := d.client.Get(&snapshot, findSnapshotBefore, accountID, date)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		logger.Error("Error while finding the balance snapshot of an account " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	case snapshot.SnapshotDate == date:
+		b.SnapshotDate, b.SnapshotBalance, b.Movement = snapshot.SnapshotDate, snapshot.Balance, money.Zero()
+		return &b, nil
+	default:
+		b.SnapshotDate, b.SnapshotBalance = snapshot.SnapshotDate, snapshot.Balance
+		if from, appErr = endOfDay(snapshot.SnapshotDate); appErr != nil {
+			return nil, appErr
+		}
+	}
+
+	if err = d.client.Get(&b.Movement, sumMovement, CUSTOMER_DEPOSITS, accountID, from, end); err != nil {
+		logger.Error("Error while adding up the ledger of an account " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &b, nil
+}
+
+// SnapshotCandidates returns the open accounts opened before the end of date without a snapshot of date
+func (d BalanceRepositoryDB) SnapshotCandidates(date string) ([]EndOfDayBalance, *errs.AppError) {
+	end, appErr := endOfDay(date)
+	if appErr != nil {
+		return nil, appErr
+	}
+	candidates := make([]EndOfDayBalance, 0)
+	if err := d.client.Select(&candidates, findSnapshotCandidates, date, CUSTOMER_DEPOSITS, end, date, end, date); err != nil {
+		logger.Error("Error while finding accounts to take balance snapshots of " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return candidates, nil
+}
+
+// SaveSnapshot inserts the snapshot of a date. The primary key refuses a second snapshot of the same date.
+func (d BalanceRepositoryDB) SaveSnapshot(s BalanceSnapshot) (bool, *errs.AppError) {
+	_, err := d.client.Exec(insertSnapshot, s.AccountID, s.SnapshotDate, s.Balance, s.TakenAt)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlDuplicateEntry {
+			return false, nil
+		}
+		logger.Error("Error while saving balance snapshot " + err.Error())
+		return false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return true, nil
+}
+
+// FirstOpeningDate returns the date the first account was opened
+func (d BalanceRepositoryDB) FirstOpeningDate() (string, *errs.AppError) {
+	var date string
+	if err := d.client.Get(&date, findFirstOpening); err != nil {
+		logger.Error("Error while finding the first opening date " + err.Error())
+		return "", errs.NewUnexpectedError("Unexpected database error")
+	}
+	return date, nil
+}
+
+// CompareSnapshots returns the last snapshot of every account next to its stored balance, read in one query so
+// a transaction posted meanwhile is either in both or in neither
+func (d BalanceRepositoryDB) CompareSnapshots() ([]SnapshotComparison, *errs.AppError) {
+	comparisons := make([]SnapshotComparison, 0)
+	if err := d.client.Select(&comparisons, compareSnapshots, CUSTOMER_DEPOSITS); err != nil {
+		logger.Error("Error while comparing balance snapshots with the account balances " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return comparisons, nil
+}
@@ -13,15 +13,7 @@ type Customer struct {
 	Zipcode     string
 	DateofBirth string `db:"date_of_birth"`
 	Status      string
-}
-
-// statusAsText converts numeral string 0/1 to inactive/active
-func (c Customer) statusAsText() string {
-	statusAsText := "active"
-	if c.Status == "0" {
-		statusAsText = "inactive"
-	}
-	return statusAsText
+	Version     int
 }
 
 // ToDTO converts a customer object to the appropriate response to be passed from the service to the handler to the caller
@@ -32,8 +24,40 @@ func (c Customer) ToDTO() dto.CustomerResponse {
 		City:        c.City,
 		Zipcode:     c.Zipcode,
 		DateofBirth: c.DateofBirth,
-		Status:      c.statusAsText(),
+		Status:      c.Status,
+		Version:     c.Version,
+	}
+}
+
+// FieldChange records one customer field an update changed, by its json name
+type FieldChange struct {
+	Field    string `db:"field"`
+	OldValue string `db:"old_value"`
+	NewValue string `db:"new_value"`
+}
+
+// Changes compares the editable fields of two versions of a customer and returns the ones that differ
+func (c Customer) Changes(updated Customer) []FieldChange {
+	changes := make([]FieldChange, 0)
+	compare := func(field string, old string, new string) {
+		if old != new {
+			changes = append(changes, FieldChange{Field: field, OldValue: old, NewValue: new})
+		}
+	}
+	compare("full_name", c.Name, updated.Name)
+	compare("city", c.City, updated.City)
+	compare("zipcode", c.Zipcode, updated.Zipcode)
+	compare("date_of_birth", c.DateofBirth, updated.DateofBirth)
+	return changes
+}
+
+// ToUpdateDTO converts an updated customer and its changes to the response for the user
+func (c Customer) ToUpdateDTO(changes []FieldChange) dto.UpdateCustomerResponse {
+	response := dto.UpdateCustomerResponse{CustomerResponse: c.ToDTO(), ChangedFields: make([]string, 0)}
+	for _, change := range changes {
+		response.ChangedFields = append(response.ChangedFields, change.Field)
 	}
+	return response
 }
 
 // CustomerRepository implements:
@@ -41,13 +65,36 @@ func (c Customer) ToDTO() dto.CustomerResponse {
 // FindAll: returns all the customers or an error
 // Save: returns the customer with an id that was just inserted
 // ById: returns a customer using the customer_id
-// Delete: returns no error on success
+// Delete: removes a customer when the version matches, returns no error on success
+// UpdateStatus: moves a customer to a new status when the transition is allowed and the version matches
+// Update: saves the editable fields of a customer if it is still at the customer's version, and audits the ones that changed at changedAt
 // mockgen -destination=mocks/domain/mock_customer_repository.go -package=domain github.com/jonathanwamsley/banking/domain CustomerRepository
 type CustomerRepository interface {
 	FindAll() ([]Customer, *errs.AppError)
 	Save(Customer) (*Customer, *errs.AppError)
 	ByID(string) (*Customer, *errs.AppError)
-	Delete(string) *errs.AppError
+	Delete(id string, version int) *errs.AppError
+	UpdateStatus(id string, status string, version int) *errs.AppError
+	Update(c Customer, changedAt string) ([]FieldChange, *errs.AppError)
+}
+
+// UpdatedCustomer replaces the editable fields of a customer with the fields of a request
+func (c Customer) UpdatedCustomer(r dto.CustomerRequest) Customer {
+	c.Name = r.Name
+	c.City = r.City
+	c.Zipcode = r.Zipcode
+	c.DateofBirth = r.DateofBirth
+	return c
+}
+
+// ToRequest returns the editable fields of a customer, so a patch can be applied to them
+func (c Customer) ToRequest() dto.CustomerRequest {
+	return dto.CustomerRequest{
+		Name:        c.Name,
+		City:        c.City,
+		Zipcode:     c.Zipcode,
+		DateofBirth: c.DateofBirth,
+	}
 }
 
 // NewCustomer converts a customer request to a customer
@@ -58,6 +105,7 @@ func NewCustomer(c dto.CustomerRequest) Customer {
 		City:        c.City,
 		Zipcode:     c.Zipcode,
 		DateofBirth: c.DateofBirth,
-		Status:      "1",
+		Status:      STATUS_ACTIVE,
+		Version:     1,
 	}
 }
@@ -11,10 +11,15 @@ import (
 
 // the query need
 const (
-	findAllCustomers = "select customer_id, name, city, zipcode, date_of_birth, status from customers;"
-	insertCustomer   = "insert into customers(name, date_of_birth, city, zipcode, status) values(?, ?, ?, ?, ?);"
-	getCustomer      = "select customer_id, name, city, zipcode, date_of_birth, status from customers where customer_id = ?;"
-	deleteCustomer   = "delete from customers where customer_id=?;"
+	findAllCustomers      = "select customer_id, name, city, zipcode, date_of_birth, status, version from customers;"
+	insertCustomer        = "insert into customers(name, date_of_birth, city, zipcode, status) values(?, ?, ?, ?, ?);"
+	getCustomer           = "select customer_id, name, city, zipcode, date_of_birth, status, version from customers where customer_id = ?;"
+	deleteCustomer        = "delete from customers where customer_id = ? and version = ?;"
+	lockCustomerRow       = "select customer_id, status, version from customers where customer_id = ? FOR UPDATE;"
+	setCustomerStatus     = "update customers set status = ?, version = version + 1 where customer_id = ? and version = ?;"
+	lockCustomerForUpdate = "select customer_id, name, city, zipcode, date_of_birth, status, version from customers where customer_id = ? FOR UPDATE;"
+	updateCustomer        = "update customers set name = ?, city = ?, zipcode = ?, date_of_birth = ?, version = version + 1 where customer_id = ? and version = ?;"
+	insertCustomerAudit   = "insert into customer_audit(customer_id, field, old_value, new_value, changed_at) values(?, ?, ?, ?, ?);"
 )
 
 // CustomerRepositoryDB holds the sql client connection
@@ -74,12 +79,123 @@ func (d CustomerRepositoryDB) ByID(id string) (*Customer, *errs.AppError) {
 	return &c, nil
 }
 
-// Delete requires a user id. No error is returned on success
-func (d CustomerRepositoryDB) Delete(id string) *errs.AppError {
-	_, err := d.client.Exec(deleteCustomer, id)
+// Delete removes a customer if it is still at the expected version. No error is returned on success
+func (d CustomerRepositoryDB) Delete(id string, version int) *errs.AppError {
+	tx, err := d.client.Beginx()
 	if err != nil {
-		logger.Error("Error while trying to delete customer " + err.Error())
-		return errs.NewUnexpectedError("Unexpected error from database")
+		logger.Error("Error while starting a new transaction to delete a customer " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	var current Customer
+	if err = tx.Get(&current, lockCustomerRow, id); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errs.NewNotFoundError("Customer not found")
+		}
+		logger.Error("Error while locking customer " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if appErr := CheckVersion(SUBJECT_CUSTOMER, current.Version, version); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+
+	if appErr := execVersioned(tx, SUBJECT_CUSTOMER, deleteCustomer, id, current.Version); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting customer delete " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// UpdateStatus moves a customer to a new status if the transition is allowed and the customer is still
+// at the expected version
+func (d CustomerRepositoryDB) UpdateStatus(id string, status string, version int) *errs.AppError {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for a customer status " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	var current Customer
+	if err = tx.Get(&current, lockCustomerRow, id); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errs.NewNotFoundError("Customer not found")
+		}
+		logger.Error("Error while locking customer " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if appErr := CheckVersion(SUBJECT_CUSTOMER, current.Version, version); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+	if appErr := CheckTransition(SUBJECT_CUSTOMER, current.Status, status); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+
+	if appErr := execVersioned(tx, SUBJECT_CUSTOMER, setCustomerStatus, status, id, current.Version); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting customer status " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
 	}
 	return nil
 }
+
+// Update saves the editable fields of a customer, as long as nobody changed it since c.Version was read. The changes are found against the locked row and
+// written to customer_audit in the same db transaction, so the audit always matches what was saved.
+func (d CustomerRepositoryDB) Update(c Customer, changedAt string) ([]FieldChange, *errs.AppError) {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for a customer update " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	var current Customer
+	if err = tx.Get(&current, lockCustomerForUpdate, c.ID); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("Customer not found")
+		}
+		logger.Error("Error while locking customer " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	if appErr := CheckVersion(SUBJECT_CUSTOMER, current.Version, c.Version); appErr != nil {
+		tx.Rollback()
+		return nil, appErr
+	}
+	changes := current.Changes(c)
+	if len(changes) == 0 {
+		tx.Rollback()
+		return changes, nil
+	}
+
+	if appErr := execVersioned(tx, SUBJECT_CUSTOMER, updateCustomer, c.Name, c.City, c.Zipcode, c.DateofBirth, c.ID, c.Version); appErr != nil {
+		tx.Rollback()
+		return nil, appErr
+	}
+	for _, change := range changes {
+		if _, err = tx.Exec(insertCustomerAudit, c.ID, change.Field, change.OldValue, change.NewValue, changedAt); err != nil {
+			tx.Rollback()
+			logger.Error("Error while auditing customer update " + err.Error())
+			return nil, errs.NewUnexpectedError("Unexpected database error")
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting customer update " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return changes, nil
+}
@@ -15,8 +15,8 @@ func (s CustomerRepositoryStub) FindAll() ([]Customer, *errs.AppError) {
 // NewCustomerRepositoryStub creates the mock data
 func NewCustomerRepositoryStub() CustomerRepositoryStub {
 	customers := []Customer{
-		{"1001", "Ashish", "New Delhi", "110011", "2000-01-01", "1"},
-		{"1002", "Rob", "New Delhi", "110011", "2000-01-01", "1"},
+		{ID: "1001", Name: "Ashish", City: "New Delhi", Zipcode: "110011", DateofBirth: "2000-01-01", Status: STATUS_ACTIVE, Version: 1},
+		{ID: "1002", Name: "Rob", City: "New Delhi", Zipcode: "110011", DateofBirth: "2000-01-01", Status: STATUS_ACTIVE, Version: 1},
 	}
 	return CustomerRepositoryStub{customers}
 }
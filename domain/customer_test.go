@@ -7,18 +7,6 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestStatusAsTextActive(t *testing.T) {
-	c := Customer{Status: "1"}
-	status := c.statusAsText()
-	assert.EqualValues(t, "active", status)
-}
-
-func TestStatusAsTextInactive(t *testing.T) {
-	c := Customer{Status: "0"}
-	status := c.statusAsText()
-	assert.EqualValues(t, "inactive", status)
-}
-
 func TestToDTO(t *testing.T) {
 	c := Customer{
 		ID:          "1234",
@@ -26,7 +14,7 @@ func TestToDTO(t *testing.T) {
 		City:        "city",
 		Zipcode:     "123321",
 		DateofBirth: "11/11/2000",
-		Status:      "1",
+		Status:      STATUS_ACTIVE,
 	}
 	cr := c.ToDTO()
 	assert.EqualValues(t, "1234", cr.ID)
@@ -50,5 +38,24 @@ func TestNewCustomer(t *testing.T) {
 	assert.EqualValues(t, "city", c.City)
 	assert.EqualValues(t, "123321", c.Zipcode)
 	assert.EqualValues(t, "11/11/2000", c.DateofBirth)
-	assert.EqualValues(t, "1", c.Status)
+	assert.EqualValues(t, STATUS_ACTIVE, c.Status)
+}
+
+func TestChanges(t *testing.T) {
+	c := Customer{ID: "2001", Name: "Arian", City: "Newburgh, NY", Zipcode: "12550", DateofBirth: "1988-05-21", Status: STATUS_ACTIVE}
+	updated := c.UpdatedCustomer(dto.CustomerRequest{Name: "Arian", City: "Beacon, NY", Zipcode: "12508", DateofBirth: "1988-05-21"})
+
+	changes := c.Changes(updated)
+	assert.EqualValues(t, []FieldChange{
+		{Field: "city", OldValue: "Newburgh, NY", NewValue: "Beacon, NY"},
+		{Field: "zipcode", OldValue: "12550", NewValue: "12508"},
+	}, changes)
+	assert.EqualValues(t, []string{"city", "zipcode"}, updated.ToUpdateDTO(changes).ChangedFields)
+	assert.EqualValues(t, STATUS_ACTIVE, updated.Status)
+}
+
+func TestChangesNothingChanged(t *testing.T) {
+	c := Customer{ID: "2001", Name: "Arian"}
+	assert.Empty(t, c.Changes(c))
+	assert.NotNil(t, c.ToUpdateDTO(nil).ChangedFields)
 }
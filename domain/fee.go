@@ -0,0 +1,296 @@
+package domain
+
+import (
+	"database/sql"
+	"math/big"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// the fees an account can be charged. A waiver of FEE_ALL waives every one of them.
+const (
+	FEE_MAINTENANCE = "maintenance"
+	FEE_WITHDRAWAL  = "withdrawal"
+	FEE_OVERDRAFT   = "overdraft"
+	FEE_ALL         = "all"
+)
+
+// JOB_MAINTENANCE_FEES charges the monthly maintenance fees, it runs once per month for the last day of the month
+const JOB_MAINTENANCE_FEES = "maintenance_fees"
+
+// periodLayout is how a fee month is written
+const periodLayout = "2006-01"
+
+// FeeSchedule is what an account type is charged. The maintenance fee is charged for a month in which the
+// balance went below the minimum balance. The withdrawal fee is charged on every withdrawal or outgoing
+// transfer of a month after the free ones, free withdrawals that are not set means they are all free.
+// The overdraft fee is charged on every withdrawal that leaves the balance below zero, and a negative
+// balance accrues interest at the overdraft rate, an annual percentage like 18.0000.
+type FeeSchedule struct {
+	AccountType     string        `db:"account_type"`
+	MinimumBalance  money.Money   `db:"minimum_balance"`
+	MaintenanceFee  money.Money   `db:"maintenance_fee"`
+	FreeWithdrawals sql.NullInt64 `db:"free_withdrawals"`
+	WithdrawalFee   money.Money   `db:"withdrawal_fee"`
+	OverdraftFee    money.Money   `db:"overdraft_fee"`
+	OverdraftRate   string        `db:"overdraft_rate"`
+}
+
+// FeeWaiver stops a fee of an account from being charged from StartsOn until EndsOn, both dates included.
+// A waiver without an end lasts until it is ended.
+type FeeWaiver struct {
+	WaiverID  string         `db:"waiver_id"`
+	AccountID string         `db:"account_id"`
+	FeeType   string         `db:"fee_type"`
+	Reason    string         `db:"reason"`
+	StartsOn  string         `db:"starts_on"`
+	EndsOn    sql.NullString `db:"ends_on"`
+	CreatedBy string         `db:"created_by"`
+	CreatedAt string         `db:"created_at"`
+}
+
+// MaintenanceCandidate is an account on a schedule with a maintenance fee, with the lowest balance it had in a month
+type MaintenanceCandidate struct {
+	AccountID     string      `db:"account_id"`
+	AccountType   string      `db:"account_type"`
+	LowestBalance money.Money `db:"lowest_balance"`
+}
+
+// FeeAssessment is the maintenance fee decision for an account and month. Fee is what was charged, which is
+// zero when the balance stayed above the minimum or the fee was waived.
+type FeeAssessment struct {
+	AccountID     string         `db:"account_id"`
+	Period        string         `db:"period"`
+	LowestBalance money.Money    `db:"lowest_balance"`
+	Fee           money.Money    `db:"fee"`
+	WaiverID      sql.NullString `db:"waiver_id"`
+	TransactionID sql.NullString `db:"transaction_id"`
+	AssessedAt    string         `db:"assessed_at"`
+}
+
+// FeeRepository implements:
+//
+// FindSchedules: returns the fee schedule of every account type
+// SaveSchedule: creates or replaces the fee schedule of an account type
+// FindWaivers: returns the waivers of an account, newest first
+// SaveWaiver: stores a new waiver and returns it with its id
+// EndWaiver: ends a waiver of an account on a date
+// MaintenanceCandidates: returns the accounts with a maintenance fee and their lowest balance in a month
+// ChargeMaintenanceFee: records the assessment of a month and posts its fee, false is returned when the month
+// was already assessed for the account
+// mockgen -destination=mocks/domain/mock_fee_repository.go -package=domain github.com/jonathanwamsley/banking/domain FeeRepository
+type FeeRepository interface {
+	FindSchedules() ([]FeeSchedule, *errs.AppError)
+	SaveSchedule(FeeSchedule) *errs.AppError
+	FindWaivers(accountID string) ([]FeeWaiver, *errs.AppError)
+	SaveWaiver(FeeWaiver) (*FeeWaiver, *errs.AppError)
+	EndWaiver(accountID string, waiverID string, endsOn string) *errs.AppError
+	MaintenanceCandidates(period string) ([]MaintenanceCandidate, *errs.AppError)
+	ChargeMaintenanceFee(FeeAssessment) (*Transaction, bool, *errs.AppError)
+}
+
+// NewFeeSchedule converts a schedule request
+func NewFeeSchedule(accountType string, r dto.FeeScheduleRequest) FeeSchedule {
+	s := FeeSchedule{
+		AccountType:    accountType,
+		MinimumBalance: r.MinimumBalance,
+		MaintenanceFee: r.MaintenanceFee,
+		WithdrawalFee:  r.WithdrawalFee,
+		OverdraftFee:   r.OverdraftFee,
+		OverdraftRate:  r.OverdraftRate,
+	}
+	if s.OverdraftRate == "" {
+		s.OverdraftRate = "0"
+	}
+	if r.FreeWithdrawals != nil {
+		s.FreeWithdrawals = sql.NullInt64{Int64: int64(*r.FreeWithdrawals), Valid: true}
+	}
+	return s
+}
+
+// Validate checks the schedule is for an account type and no amount is negative
+func (s FeeSchedule) Validate() *errs.AppError {
+	if s.AccountType != dto.SAVING && s.AccountType != dto.CHECKING {
+		return errs.NewValidationError("Account type should be checking or saving")
+	}
+	if s.MinimumBalance.IsNegative() || s.MaintenanceFee.IsNegative() || s.WithdrawalFee.IsNegative() || s.OverdraftFee.IsNegative() {
+		return errs.NewValidationError("fee amounts cannot be less than zero")
+	}
+	rate, ok := new(big.Rat).SetString(s.OverdraftRate)
+	if !ok || rate.Sign() < 0 || rate.Cmp(big.NewRat(100, 1)) > 0 {
+		return errs.NewValidationError("overdraft_rate should be a percentage between 0 and 100")
+	}
+	if s.FreeWithdrawals.Valid && s.FreeWithdrawals.Int64 < 0 {
+		return errs.NewValidationError("free_withdrawals cannot be less than zero")
+	}
+	return nil
+}
+
+// ChargesWithdrawals checks if some withdrawals of a month are charged
+func (s FeeSchedule) ChargesWithdrawals() bool {
+	return s.FreeWithdrawals.Valid && s.WithdrawalFee.IsPositive()
+}
+
+// WithdrawalFeeFor is the fee of a withdrawal that follows withdrawalsBefore others in the same month
+func (s FeeSchedule) WithdrawalFeeFor(withdrawalsBefore int) money.Money {
+	if !s.ChargesWithdrawals() || int64(withdrawalsBefore) < s.FreeWithdrawals.Int64 {
+		return money.Zero()
+	}
+	return s.WithdrawalFee
+}
+
+// OverdraftFeeFor is the overdraft fee of a withdrawal that leaves the balance at balanceAfter
+func (s FeeSchedule) OverdraftFeeFor(balanceAfter money.Money) money.Money {
+	if !balanceAfter.IsNegative() {
+		return money.Zero()
+	}
+	return s.OverdraftFee
+}
+
+// MaintenanceFeeFor is the maintenance fee of a month in which the balance went as low as lowest
+func (s FeeSchedule) MaintenanceFeeFor(lowest money.Money) money.Money {
+	if !lowest.LessThan(s.MinimumBalance) {
+		return money.Zero()
+	}
+	return s.MaintenanceFee
+}
+
+// ToDTO converts a fee schedule
+func (s FeeSchedule) ToDTO() dto.FeeScheduleResponse {
+	response := dto.FeeScheduleResponse{
+		AccountType:    s.AccountType,
+		MinimumBalance: s.MinimumBalance,
+		MaintenanceFee: s.MaintenanceFee,
+		WithdrawalFee:  s.WithdrawalFee,
+		OverdraftFee:   s.OverdraftFee,
+		OverdraftRate:  s.OverdraftRate,
+	}
+	if s.FreeWithdrawals.Valid {
+		free := int(s.FreeWithdrawals.Int64)
+		response.FreeWithdrawals = &free
+	}
+	return response
+}
+
+// IsFeeableWithdrawal checks if a transaction counts as a withdrawal towards the free withdrawals of a month
+func IsFeeableWithdrawal(t Transaction) bool {
+	return t.TransactionType == WITHDRAWAL || t.TransactionType == TRANSFER_OUT
+}
+
+// NewFeeWaiver converts a waiver request
+func NewFeeWaiver(accountID string, r dto.FeeWaiverRequest, createdBy string, now time.Time) FeeWaiver {
+	w := FeeWaiver{
+		AccountID: accountID,
+		FeeType:   r.FeeType,
+		Reason:    r.Reason,
+		StartsOn:  r.StartsOn,
+		CreatedBy: createdBy,
+		CreatedAt: now.Format(dbTSLayout),
+	}
+	if w.StartsOn == "" {
+		w.StartsOn = now.Format(dateLayout)
+	}
+	if r.EndsOn != "" {
+		w.EndsOn = sql.NullString{String: r.EndsOn, Valid: true}
+	}
+	return w
+}
+
+// Validate checks the fee type and that the waiver does not end before it starts
+func (w FeeWaiver) Validate() *errs.AppError {
+	if w.FeeType != FEE_MAINTENANCE && w.FeeType != FEE_WITHDRAWAL && w.FeeType != FEE_OVERDRAFT && w.FeeType != FEE_ALL {
+		return errs.NewValidationError("fee_type should be maintenance, withdrawal, overdraft or all")
+	}
+	if _, err := time.Parse(dateLayout, w.StartsOn); err != nil {
+		return errs.NewValidationError("starts_on should look like " + dateLayout)
+	}
+	if w.EndsOn.Valid {
+		if _, err := time.Parse(dateLayout, w.EndsOn.String); err != nil {
+			return errs.NewValidationError("ends_on should look like " + dateLayout)
+		}
+		// the dates have the same layout, so they compare as strings
+		if w.EndsOn.String < w.StartsOn {
+			return errs.NewValidationError("ends_on must not be before starts_on")
+		}
+	}
+	return nil
+}
+
+// Covers checks if the waiver waives a fee on a day like 2021-03-31
+func (w FeeWaiver) Covers(feeType string, day string) bool {
+	if w.FeeType != feeType && w.FeeType != FEE_ALL {
+		return false
+	}
+	return w.StartsOn <= day && (!w.EndsOn.Valid || day <= w.EndsOn.String)
+}
+
+// ToDTO converts a waiver
+func (w FeeWaiver) ToDTO() dto.FeeWaiverResponse {
+	return dto.FeeWaiverResponse{
+		WaiverID:  w.WaiverID,
+		AccountID: w.AccountID,
+		FeeType:   w.FeeType,
+		Reason:    w.Reason,
+		StartsOn:  w.StartsOn,
+		EndsOn:    w.EndsOn.String,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+// CoveringWaiver returns the first waiver that waives a fee on a day, or nil
+func CoveringWaiver(waivers []FeeWaiver, feeType string, day string) *FeeWaiver {
+	for i := range waivers {
+		if waivers[i].Covers(feeType, day) {
+			return &waivers[i]
+		}
+	}
+	return nil
+}
+
+// FeeMonth returns the start of the month of a transaction date and the start of the following month,
+// a withdrawal at 23:59:59 on the last day still counts towards its own month
+func FeeMonth(transactionDate string) (time.Time, time.Time, *errs.AppError) {
+	date, err := time.ParseInLocation(dbTSLayout, transactionDate, wallClock)
+	if err != nil {
+		return time.Time{}, time.Time{}, errs.NewUnexpectedError("invalid transaction date " + transactionDate)
+	}
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, wallClock)
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// ParseFeePeriod reads a fee month like 2021-03 and returns its first and last day
+func ParseFeePeriod(period string, location *time.Location) (time.Time, time.Time, *errs.AppError) {
+	start, err := time.ParseInLocation(periodLayout, period, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, errs.NewValidationError("period should look like " + periodLayout)
+	}
+	return start, start.AddDate(0, 1, -1), nil
+}
+
+// FormatFeePeriod writes the fee month of a day
+func FormatFeePeriod(day time.Time) string {
+	return day.Format(periodLayout)
+}
+
+// NewFeeAssessment decides the maintenance fee of a candidate for a month. The fee is waived by a waiver
+// that covers the last day of the month.
+func NewFeeAssessment(c MaintenanceCandidate, s FeeSchedule, period string, lastDay string, waivers []FeeWaiver, assessedAt string) FeeAssessment {
+	a := FeeAssessment{
+		AccountID:     c.AccountID,
+		Period:        period,
+		LowestBalance: c.LowestBalance,
+		Fee:           s.MaintenanceFeeFor(c.LowestBalance),
+		AssessedAt:    assessedAt,
+	}
+	if a.Fee.IsPositive() {
+		if w := CoveringWaiver(waivers, FEE_MAINTENANCE, lastDay); w != nil {
+			a.Fee = money.Zero()
+			a.WaiverID = sql.NullString{String: w.WaiverID, Valid: true}
+		}
+	}
+	return a
+}
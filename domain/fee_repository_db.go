@@ -0,0 +1,257 @@
+package domain
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// The query statements
+const (
+	findFeeSchedules = "SELECT account_type, minimum_balance, maintenance_fee, free_withdrawals, withdrawal_fee, overdraft_fee, overdraft_rate from fee_schedules order by account_type;"
+	findFeeSchedule  = "SELECT account_type, minimum_balance, maintenance_fee, free_withdrawals, withdrawal_fee, overdraft_fee, overdraft_rate from fee_schedules where account_type = ?;"
+	saveFeeSchedule  = "INSERT INTO fee_schedules (account_type, minimum_balance, maintenance_fee, free_withdrawals, withdrawal_fee, overdraft_fee, overdraft_rate) " +
+		"values (?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE minimum_balance = VALUES(minimum_balance), maintenance_fee = VALUES(maintenance_fee), " +
+		"free_withdrawals = VALUES(free_withdrawals), withdrawal_fee = VALUES(withdrawal_fee), overdraft_fee = VALUES(overdraft_fee), overdraft_rate = VALUES(overdraft_rate);"
+	countWithdrawals = "SELECT COUNT(*) from transactions where account_id = ? and transaction_type in (?, ?) and transaction_date >= ? and transaction_date < ?;"
+	findWaivers      = "SELECT waiver_id, account_id, fee_type, reason, starts_on, ends_on, created_by, created_at from fee_waivers where account_id = ? order by waiver_id desc;"
+	findCoverWaiver  = `SELECT waiver_id, account_id, fee_type, reason, starts_on, ends_on, created_by, created_at from fee_waivers
+		where account_id = ? and fee_type in (?, ?) and starts_on <= ? and (ends_on IS NULL or ends_on >= ?) limit 1;`
+	insertWaiver = "INSERT INTO fee_waivers (account_id, fee_type, reason, starts_on, ends_on, created_by, created_at) values (?, ?, ?, ?, ?, ?, ?);"
+	endWaiver    = "UPDATE fee_waivers SET ends_on = ? where waiver_id = ? and account_id = ? and (ends_on IS NULL or ends_on > ?);"
+	findWaiver   = "SELECT waiver_id from fee_waivers where waiver_id = ? and account_id = ?;"
+	// the lowest balance of a month is the lower of the balance it started with and the lowest balance a
+	// transaction left. Only accounts open and active for the whole month are charged.
+	findMaintenanceCandidates = `SELECT c.account_id, c.account_type, LEAST(c.opening_balance, COALESCE(c.lowest_posted, c.opening_balance)) as lowest_balance from (
+		SELECT a.account_id, a.account_type,
+			COALESCE((SELECT SUM(l.credit - l.debit) from journal_lines l join journal_entries e on e.entry_id = l.entry_id
+				where l.ledger_account = ? and l.account_id = a.account_id and e.posted_at < ?), 0) as opening_balance,
+			(SELECT MIN(t.balance) from transactions t where t.account_id = a.account_id and t.transaction_date >= ? and t.transaction_date < ?) as lowest_posted
+		from accounts a join fee_schedules s on s.account_type = a.account_type
+		where s.maintenance_fee > 0 and a.status = 'active' and a.opening_date < ?) c order by c.account_id;`
+	insertAssessment = "INSERT INTO fee_assessments (account_id, period, lowest_balance, fee, waiver_id, assessed_at) values (?, ?, ?, ?, ?, ?);"
+	linkAssessment   = "UPDATE fee_assessments SET transaction_id = ? where account_id = ? and period = ?;"
+)
+
+// FeeRepositoryDB holds the sql client connection
+type FeeRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewFeeRepositoryDB creates a new FeeRepositoryDB to call sql methods
+func NewFeeRepositoryDB(client *sqlx.DB) FeeRepositoryDB {
+	return FeeRepositoryDB{client}
+}
+
+// FindSchedules returns every fee schedule
+func (d FeeRepositoryDB) FindSchedules() ([]FeeSchedule, *errs.AppError) {
+	schedules := make([]FeeSchedule, 0)
+	if err := d.client.Select(&schedules, findFeeSchedules); err != nil {
+		logger.Error("Error while querying fee_schedules table " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return schedules, nil
+}
+
+// SaveSchedule creates or replaces the fee schedule of an account type, it applies from the next transaction
+func (d FeeRepositoryDB) SaveSchedule(s FeeSchedule) *errs.AppError {
+	if _, err := d.client.Exec(saveFeeSchedule, s.AccountType, s.MinimumBalance, s.MaintenanceFee, s.FreeWithdrawals, s.WithdrawalFee, s.OverdraftFee, s.OverdraftRate); err != nil {
+		logger.Error("Error while saving fee schedule " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// FindWaivers returns the waivers of an account
+func (d FeeRepositoryDB) FindWaivers(accountID string) ([]FeeWaiver, *errs.AppError) {
+	waivers := make([]FeeWaiver, 0)
+	if err := d.client.Select(&waivers, findWaivers, accountID); err != nil {
+		logger.Error("Error while querying fee_waivers table " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return waivers, nil
+}
+
+// SaveWaiver stores a new waiver
+func (d FeeRepositoryDB) SaveWaiver(w FeeWaiver) (*FeeWaiver, *errs.AppError) {
+	result, err := d.client.Exec(insertWaiver, w.AccountID, w.FeeType, w.Reason, w.StartsOn, w.EndsOn, w.CreatedBy, w.CreatedAt)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlMissingParent {
+			return nil, errs.NewNotFoundError("Account not found")
+		}
+		logger.Error("Error while saving fee waiver " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Error while getting last id from the new fee waiver " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	w.WaiverID = strconv.FormatInt(id, 10)
+	return &w, nil
+}
+
+// EndWaiver ends a waiver on a date. A waiver that already ends before that date is left as it is.
+func (d FeeRepositoryDB) EndWaiver(accountID string, waiverID string, endsOn string) *errs.AppError {
+	var id string
+	if err := d.client.Get(&id, findWaiver, waiverID, accountID); err != nil {
+		if err == sql.ErrNoRows {
+			return errs.NewNotFoundError("Fee waiver not found")
+		}
+		logger.Error("Error while finding fee waiver " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if _, err := d.client.Exec(endWaiver, endsOn, waiverID, accountID, endsOn); err != nil {
+		logger.Error("Error while ending fee waiver " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// MaintenanceCandidates returns the accounts a month's maintenance fee may be charged to
+func (d FeeRepositoryDB) MaintenanceCandidates(period string) ([]MaintenanceCandidate, *errs.AppError) {
+	first, last, appErr := ParseFeePeriod(period, wallClock)
+	if appErr != nil {
+		return nil, appErr
+	}
+	start := first.Format(dbTSLayout)
+	end := last.AddDate(0, 0, 1).Format(dbTSLayout)
+	candidates := make([]MaintenanceCandidate, 0)
+	if err := d.client.Select(&candidates, findMaintenanceCandidates, CUSTOMER_DEPOSITS, start, start, end, start); err != nil {
+		logger.Error("Error while finding accounts to charge maintenance fees " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return candidates, nil
+}
+
+// ChargeMaintenanceFee records the assessment and posts its fee as a fee transaction in one db transaction.
+// The account is locked first and the fee is cut down to the balance, so a fee never overdraws the account.
+func (d FeeRepositoryDB) ChargeMaintenanceFee(a FeeAssessment) (*Transaction, bool, *errs.AppError) {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for a maintenance fee: " + err.Error())
+		return nil, false, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	var account Account
+	if err = tx.Get(&account, lockAccount, a.AccountID); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, false, errs.NewNotFoundError("Account not found")
+		}
+		logger.Error("Error while locking account: " + err.Error())
+		return nil, false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	if account.Amount.LessThan(a.Fee) {
+		a.Fee = account.Amount
+		if a.Fee.IsNegative() {
+			a.Fee = money.Zero()
+		}
+	}
+
+	if _, err = tx.Exec(insertAssessment, a.AccountID, a.Period, a.LowestBalance, a.Fee, a.WaiverID, a.AssessedAt); err != nil {
+		tx.Rollback()
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlDuplicateEntry {
+			return nil, false, nil
+		}
+		logger.Error("Error while saving fee assessment: " + err.Error())
+		return nil, false, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	var charged *Transaction
+	if a.Fee.IsPositive() {
+		t := Transaction{
+			AccountID:       a.AccountID,
+			Amount:          a.Fee,
+			TransactionType: FEE,
+			TransactionDate: a.AssessedAt,
+			ExpectedVersion: ANY_VERSION,
+		}
+		if appErr := postTransaction(tx, &t); appErr != nil {
+			tx.Rollback()
+			return nil, false, appErr
+		}
+		if _, err = tx.Exec(linkAssessment, t.TransactionID, a.AccountID, a.Period); err != nil {
+			tx.Rollback()
+			logger.Error("Error while linking fee assessment: " + err.Error())
+			return nil, false, errs.NewUnexpectedError("Unexpected database error")
+		}
+		charged = &t
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting maintenance fee: " + err.Error())
+		return nil, false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return charged, true, nil
+}
+
+// findSchedule reads the fee schedule of an account type, nil when the type has none
+func findSchedule(tx *sqlx.Tx, accountType string) (*FeeSchedule, *errs.AppError) {
+	var schedule FeeSchedule
+	if err := tx.Get(&schedule, findFeeSchedule, accountType); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		logger.Error("Error while reading fee schedule: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &schedule, nil
+}
+
+// withdrawalFee returns the fee t is charged on the locked account: zero unless t is a withdrawal past the
+// free ones of its month and no waiver covers its day
+func withdrawalFee(tx *sqlx.Tx, schedule *FeeSchedule, account Account, t Transaction) (money.Money, *errs.AppError) {
+	if !IsFeeableWithdrawal(t) || schedule == nil || !schedule.ChargesWithdrawals() {
+		return money.Zero(), nil
+	}
+
+	start, end, appErr := FeeMonth(t.TransactionDate)
+	if appErr != nil {
+		return money.Zero(), appErr
+	}
+	// the account row is locked, so withdrawals of the same account are counted one after the other
+	var before int
+	if err := tx.Get(&before, countWithdrawals, account.AccountID, WITHDRAWAL, TRANSFER_OUT, start.Format(dbTSLayout), end.Format(dbTSLayout)); err != nil {
+		logger.Error("Error while counting withdrawals: " + err.Error())
+		return money.Zero(), errs.NewUnexpectedError("Unexpected database error")
+	}
+	fee := schedule.WithdrawalFeeFor(before)
+	if !fee.IsPositive() {
+		return fee, nil
+	}
+	return unlessWaived(tx, account.AccountID, FEE_WITHDRAWAL, t, fee)
+}
+
+// overdraftFee returns the overdraft fee of t when it leaves the account at balanceAfter: zero unless the balance
+// is negative and no waiver covers the day
+func overdraftFee(tx *sqlx.Tx, schedule *FeeSchedule, account Account, t Transaction, balanceAfter money.Money) (money.Money, *errs.AppError) {
+	if schedule == nil {
+		return money.Zero(), nil
+	}
+	fee := schedule.OverdraftFeeFor(balanceAfter)
+	if !fee.IsPositive() {
+		return fee, nil
+	}
+	return unlessWaived(tx, account.AccountID, FEE_OVERDRAFT, t, fee)
+}
+
+// unlessWaived returns zero when a waiver of the fee type covers the day of t, and the fee otherwise
+func unlessWaived(tx *sqlx.Tx, accountID string, feeType string, t Transaction, fee money.Money) (money.Money, *errs.AppError) {
+	day := t.TransactionDate[:len(dateLayout)]
+	var waiver FeeWaiver
+	err := tx.Get(&waiver, findCoverWaiver, accountID, feeType, FEE_ALL, day, day)
+	if err == nil {
+		return money.Zero(), nil
+	}
+	if err != sql.ErrNoRows {
+		logger.Error("Error while reading fee waivers: " + err.Error())
+		return money.Zero(), errs.NewUnexpectedError("Unexpected database error")
+	}
+	return fee, nil
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+var savingFees = FeeSchedule{
+	AccountType:     dto.SAVING,
+	MinimumBalance:  money.MustParse("300.00"),
+	MaintenanceFee:  money.MustParse("5.00"),
+	FreeWithdrawals: sql.NullInt64{Int64: 6, Valid: true},
+	WithdrawalFee:   money.MustParse("10.00"),
+}
+
+func TestWithdrawalFeeAfterFreeWithdrawals(t *testing.T) {
+	assert.True(t, savingFees.WithdrawalFeeFor(0).IsZero())
+	assert.True(t, savingFees.WithdrawalFeeFor(5).IsZero())
+	assert.Equal(t, money.MustParse("10.00"), savingFees.WithdrawalFeeFor(6))
+	assert.Equal(t, money.MustParse("10.00"), savingFees.WithdrawalFeeFor(20))
+
+	unlimited := savingFees
+	unlimited.FreeWithdrawals = sql.NullInt64{}
+	assert.False(t, unlimited.ChargesWithdrawals())
+	assert.True(t, unlimited.WithdrawalFeeFor(100).IsZero())
+}
+
+func TestMaintenanceFeeBelowMinimum(t *testing.T) {
+	assert.True(t, savingFees.MaintenanceFeeFor(money.MustParse("300.00")).IsZero())
+	assert.Equal(t, money.MustParse("5.00"), savingFees.MaintenanceFeeFor(money.MustParse("299.99")))
+	assert.Equal(t, money.MustParse("5.00"), savingFees.MaintenanceFeeFor(money.MustParse("-20.00")))
+}
+
+func TestFeeMonthEdges(t *testing.T) {
+	cases := map[string][2]string{
+		"2021-01-31 23:59:59": {"2021-01-01", "2021-02-01"},
+		"2021-02-01 00:00:00": {"2021-02-01", "2021-03-01"},
+		"2021-02-28 23:59:59": {"2021-02-01", "2021-03-01"},
+		"2024-02-29 12:00:00": {"2024-02-01", "2024-03-01"},
+		"2021-12-31 23:59:59": {"2021-12-01", "2022-01-01"},
+	}
+	for date, month := range cases {
+		start, end, err := FeeMonth(date)
+		assert.Nil(t, err, date)
+		assert.Equal(t, month[0], FormatRunDate(start), date)
+		assert.Equal(t, month[1], FormatRunDate(end), date)
+	}
+	_, _, err := FeeMonth("2021-02-30")
+	assert.NotNil(t, err)
+}
+
+func TestParseFeePeriodLastDay(t *testing.T) {
+	cases := map[string]string{"2021-01": "2021-01-31", "2021-02": "2021-02-28", "2024-02": "2024-02-29", "2021-04": "2021-04-30", "2021-12": "2021-12-31"}
+	for period, lastDay := range cases {
+		first, last, err := ParseFeePeriod(period, time.Local)
+		assert.Nil(t, err, period)
+		assert.Equal(t, period+"-01", FormatRunDate(first))
+		assert.Equal(t, lastDay, FormatRunDate(last))
+	}
+	_, _, err := ParseFeePeriod("2021-13", time.Local)
+	assert.EqualValues(t, "period should look like 2006-01", err.Message)
+}
+
+func TestFeeWaiverCovers(t *testing.T) {
+	w := FeeWaiver{FeeType: FEE_MAINTENANCE, StartsOn: "2021-03-01", EndsOn: sql.NullString{String: "2021-03-31", Valid: true}}
+	assert.True(t, w.Covers(FEE_MAINTENANCE, "2021-03-01"))
+	assert.True(t, w.Covers(FEE_MAINTENANCE, "2021-03-31"))
+	assert.False(t, w.Covers(FEE_MAINTENANCE, "2021-04-01"))
+	assert.False(t, w.Covers(FEE_MAINTENANCE, "2021-02-28"))
+	assert.False(t, w.Covers(FEE_WITHDRAWAL, "2021-03-10"))
+
+	all := FeeWaiver{FeeType: FEE_ALL, StartsOn: "2021-03-01"}
+	assert.True(t, all.Covers(FEE_WITHDRAWAL, "2030-01-01"))
+}
+
+func TestFeeWaiverValidate(t *testing.T) {
+	w := FeeWaiver{FeeType: "late_payment", StartsOn: "2021-03-01"}
+	assert.EqualValues(t, "fee_type should be maintenance, withdrawal, overdraft or all", w.Validate().Message)
+
+	w.FeeType = FEE_ALL
+	w.EndsOn = sql.NullString{String: "2021-02-28", Valid: true}
+	assert.EqualValues(t, "ends_on must not be before starts_on", w.Validate().Message)
+
+	w.EndsOn.String = "2021-03-01"
+	assert.Nil(t, w.Validate())
+}
+
+func TestNewFeeAssessmentWaivedOnLastDayOfMonth(t *testing.T) {
+	c := MaintenanceCandidate{AccountID: "95470", AccountType: dto.SAVING, LowestBalance: money.MustParse("120.00")}
+	endsMidMonth := FeeWaiver{WaiverID: "1", FeeType: FEE_MAINTENANCE, StartsOn: "2021-02-01", EndsOn: sql.NullString{String: "2021-02-27", Valid: true}}
+
+	a := NewFeeAssessment(c, savingFees, "2021-02", "2021-02-28", []FeeWaiver{endsMidMonth}, "2021-03-01 01:00:00")
+	assert.Equal(t, money.MustParse("5.00"), a.Fee)
+	assert.False(t, a.WaiverID.Valid)
+
+	endsOnLastDay := endsMidMonth
+	endsOnLastDay.EndsOn.String = "2021-02-28"
+	a = NewFeeAssessment(c, savingFees, "2021-02", "2021-02-28", []FeeWaiver{endsOnLastDay}, "2021-03-01 01:00:00")
+	assert.True(t, a.Fee.IsZero())
+	assert.Equal(t, "1", a.WaiverID.String)
+}
+
+func TestNewFeeChargeLinksTheWithdrawal(t *testing.T) {
+	withdrawal := Transaction{TransactionID: "42", AccountID: "95470", Amount: money.MustParse("20.00"), TransactionType: WITHDRAWAL, TransactionDate: "2021-03-31 23:59:59"}
+	fee := NewFeeCharge(withdrawal, FEE_WITHDRAWAL, money.MustParse("10.00"))
+	assert.Equal(t, FEE, fee.TransactionType)
+	assert.Equal(t, FEE_WITHDRAWAL, fee.FeeType)
+	assert.Equal(t, "42", fee.RelatedTransactionID.String)
+	assert.Equal(t, withdrawal.TransactionDate, fee.TransactionDate)
+	assert.Equal(t, ANY_VERSION, fee.ExpectedVersion)
+
+	assert.True(t, IsFeeableWithdrawal(withdrawal))
+	assert.False(t, IsFeeableWithdrawal(fee))
+}
+
+func TestTransactionToDTOWithFees(t *testing.T) {
+	withdrawal := Transaction{TransactionID: "42", Balance: money.MustParse("-20.00")}
+	withdrawal.Fees = []Transaction{
+		{TransactionID: "43", FeeType: FEE_WITHDRAWAL, Amount: money.MustParse("10.00"), Balance: money.MustParse("-30.00")},
+		{TransactionID: "44", FeeType: FEE_OVERDRAFT, Amount: money.MustParse("25.00"), Balance: money.MustParse("-55.00")},
+	}
+	response := withdrawal.ToDTO()
+	assert.Equal(t, money.MustParse("-55.00"), response.Amount)
+	assert.Equal(t, "43", response.Fees[0].TransactionID)
+	assert.Equal(t, FEE_OVERDRAFT, response.Fees[1].FeeType)
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// the statuses of a hold. Only an active hold reserves funds, the others are closed for good.
+//
+// active: the amount is reserved, it lowers the available balance but not the ledger balance
+// captured: the hold was settled as a withdrawal, a partial capture releases the rest
+// voided: the hold was released without moving any money
+// expired: the hold was not captured before it expired and was released by the sweeper
+const (
+	HOLD_ACTIVE   = "active"
+	HOLD_CAPTURED = "captured"
+	HOLD_VOIDED   = "voided"
+	HOLD_EXPIRED  = "expired"
+)
+
+// Hold reserves an amount of an account until it is captured, voided or expires. A captured hold links to
+// the withdrawal it was settled with.
+type Hold struct {
+	HoldID         string `db:"hold_id"`
+	AccountID      string `db:"account_id"`
+	Amount         money.Money
+	CapturedAmount money.Money `db:"captured_amount"`
+	Status         string
+	Description    string
+	CreatedBy      string         `db:"created_by"`
+	CreatedAt      string         `db:"created_at"`
+	ExpiresAt      string         `db:"expires_at"`
+	ClosedAt       sql.NullString `db:"closed_at"`
+	TransactionID  sql.NullString `db:"transaction_id"`
+}
+
+// HoldRepository implements:
+//
+// FindHolds: returns the holds of an account, newest first
+// SaveHold: reserves the amount of a new hold when the account can pay it, and returns it with its id
+// CaptureHold: settles an active hold as a withdrawal of amount and closes it, what is not captured is released
+// VoidHold: releases an active hold
+// ExpireHolds: releases every active hold that expired by now, and returns how many there were
+// mockgen -destination=mocks/domain/mock_hold_repository.go -package=domain github.com/jonathanwamsley/banking/domain HoldRepository
+type HoldRepository interface {
+	FindHolds(accountID string) ([]Hold, *errs.AppError)
+	SaveHold(Hold) (*Hold, *errs.AppError)
+	CaptureHold(accountID string, holdID string, amount *money.Money, capturedAt time.Time) (*Hold, *Transaction, *errs.AppError)
+	VoidHold(accountID string, holdID string, voidedAt time.Time) (*Hold, *errs.AppError)
+	ExpireHolds(now time.Time) (int, *errs.AppError)
+}
+
+// NewHold converts a hold request, the hold expires ttl after now
+func NewHold(accountID string, r dto.CreateHoldRequest, createdBy string, now time.Time, ttl time.Duration) Hold {
+	return Hold{
+		AccountID:      accountID,
+		Amount:         r.Amount,
+		CapturedAmount: money.New(0, r.Amount.Currency()),
+		Status:         HOLD_ACTIVE,
+		Description:    r.Description,
+		CreatedBy:      createdBy,
+		CreatedAt:      now.Format(dbTSLayout),
+		ExpiresAt:      now.Add(ttl).Format(dbTSLayout),
+	}
+}
+
+// CheckOpen checks the hold can still be captured or voided at now. A hold past its expiry that the sweeper
+// did not get to yet is treated as expired.
+func (h Hold) CheckOpen(now time.Time) *errs.AppError {
+	if h.Status != HOLD_ACTIVE {
+		return errs.NewConflictError("hold is already " + h.Status).WithReason("hold_" + h.Status)
+	}
+	if h.IsExpired(now) {
+		return errs.NewConflictError("hold expired at " + h.ExpiresAt).WithReason("hold_" + HOLD_EXPIRED)
+	}
+	return nil
+}
+
+// IsExpired checks if the hold expired by now
+func (h Hold) IsExpired(now time.Time) bool {
+	// both are written with the db layout, so they compare as strings
+	return h.ExpiresAt <= now.Format(dbTSLayout)
+}
+
+// CaptureAmount is what a capture of requested settles, the whole hold when no amount was requested.
+// A partial capture may not be more than the hold.
+func (h Hold) CaptureAmount(requested *money.Money) (money.Money, *errs.AppError) {
+	if requested == nil {
+		return h.Amount, nil
+	}
+	if !requested.IsPositive() {
+		return money.Money{}, errs.NewValidationError("capture amount must be greater than zero")
+	}
+	if h.Amount.LessThan(*requested) {
+		return money.Money{}, errs.NewValidationError("capture amount can not be more than the hold of " + h.Amount.String()).
+			WithReason("capture_exceeds_hold")
+	}
+	return *requested, nil
+}
+
+// NewCapture is the withdrawal a hold is settled with. It is posted at any account version, the hold was
+// placed against the balance and is captured whatever changed since.
+func (h Hold) NewCapture(amount money.Money, capturedAt time.Time) Transaction {
+	return Transaction{
+		AccountID:       h.AccountID,
+		Amount:          amount,
+		TransactionType: WITHDRAWAL,
+		TransactionDate: capturedAt.Format(dbTSLayout),
+		ExpectedVersion: ANY_VERSION,
+	}
+}
+
+// ToDTO converts a hold
+func (h Hold) ToDTO() dto.HoldResponse {
+	return dto.HoldResponse{
+		HoldID:         h.HoldID,
+		AccountID:      h.AccountID,
+		Amount:         h.Amount,
+		CapturedAmount: h.CapturedAmount,
+		Status:         h.Status,
+		Description:    h.Description,
+		CreatedBy:      h.CreatedBy,
+		CreatedAt:      h.CreatedAt,
+		ExpiresAt:      h.ExpiresAt,
+		ClosedAt:       h.ClosedAt.String,
+		TransactionID:  h.TransactionID.String,
+	}
+}
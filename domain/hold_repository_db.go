@@ -0,0 +1,208 @@
+package domain
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// The query statements
+const (
+	holdColumns = "hold_id, account_id, amount, captured_amount, status, description, created_by, created_at, expires_at, closed_at, transaction_id"
+	findHolds   = "SELECT " + holdColumns + " from holds where account_id = ? order by hold_id desc;"
+	lockHold    = "SELECT " + holdColumns + " from holds where hold_id = ? and account_id = ? FOR UPDATE;"
+	insertHold  = "INSERT INTO holds (account_id, amount, captured_amount, status, description, created_by, created_at, expires_at) values (?, ?, ?, ?, ?, ?, ?, ?);"
+	closeHold   = "UPDATE holds SET status = ?, captured_amount = ?, closed_at = ? where hold_id = ?;"
+	linkHold    = "UPDATE holds SET transaction_id = ? where hold_id = ?;"
+	expireHolds = "UPDATE holds SET status = ?, closed_at = ? where status = ? and expires_at <= ?;"
+)
+
+// HoldRepositoryDB holds the sql client connection
+type HoldRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewHoldRepositoryDB creates a new HoldRepositoryDB to call sql methods
+func NewHoldRepositoryDB(client *sqlx.DB) HoldRepositoryDB {
+	return HoldRepositoryDB{client}
+}
+
+// FindHolds returns the holds of an account
+func (d HoldRepositoryDB) FindHolds(accountID string) ([]Hold, *errs.AppError) {
+	holds := make([]Hold, 0)
+	if err := d.client.Select(&holds, findHolds, accountID); err != nil {
+		logger.Error("Error while querying holds table " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return holds, nil
+}
+
+// SaveHold stores a new active hold. The account row is locked while the hold is checked against the available
+// balance, so two holds can not reserve the same funds. A hold never sweeps from saving.
+func (d HoldRepositoryDB) SaveHold(h Hold) (*Hold, *errs.AppError) {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for a hold: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	var account Account
+	if err = tx.Get(&account, lockAccount, h.AccountID); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("Account not found")
+		}
+		logger.Error("Error while locking account: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	// a hold is captured as a withdrawal, so the account has to allow one now
+	if appErr := account.CanPost(Transaction{AccountID: h.AccountID, TransactionType: WITHDRAWAL}); appErr != nil {
+		tx.Rollback()
+		return nil, appErr
+	}
+	if appErr := account.CheckFunds(h.Amount, money.Zero()); appErr != nil {
+		tx.Rollback()
+		return nil, appErr
+	}
+
+	result, err := tx.Exec(insertHold, h.AccountID, h.Amount, h.CapturedAmount, h.Status, h.Description, h.CreatedBy, h.CreatedAt, h.ExpiresAt)
+	if err != nil {
+		tx.Rollback()
+		logger.Error("Error while saving hold: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		logger.Error("Error while getting the last hold id: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	h.HoldID = strconv.FormatInt(id, 10)
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting hold: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &h, nil
+}
+
+// CaptureHold settles an active hold as a withdrawal in one db transaction. The hold is closed before the
+// withdrawal is posted, so the withdrawal spends the funds the hold released. A hold is captured once, a
+// partial capture releases the rest. The withdrawal is charged its fees like any other.
+func (d HoldRepositoryDB) CaptureHold(accountID string, holdID string, amount *money.Money, capturedAt time.Time) (*Hold, *Transaction, *errs.AppError) {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for a capture: " + err.Error())
+		return nil, nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	// the account rows are locked before the hold, in the same order as any other withdrawal
+	if appErr := lockInOrder(tx, accountID); appErr != nil {
+		tx.Rollback()
+		return nil, nil, appErr
+	}
+	h, appErr := lockOpenHold(tx, accountID, holdID, capturedAt)
+	if appErr != nil {
+		tx.Rollback()
+		return nil, nil, appErr
+	}
+	captured, appErr := h.CaptureAmount(amount)
+	if appErr != nil {
+		tx.Rollback()
+		return nil, nil, appErr
+	}
+
+	h.Status = HOLD_CAPTURED
+	h.CapturedAmount = captured
+	h.ClosedAt = sql.NullString{String: capturedAt.Format(dbTSLayout), Valid: true}
+	if _, err = tx.Exec(closeHold, h.Status, h.CapturedAmount, h.ClosedAt, h.HoldID); err != nil {
+		tx.Rollback()
+		logger.Error("Error while capturing hold: " + err.Error())
+		return nil, nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	t := h.NewCapture(captured, capturedAt)
+	if appErr = postTransaction(tx, &t); appErr != nil {
+		tx.Rollback()
+		return nil, nil, appErr
+	}
+	h.TransactionID = sql.NullString{String: t.TransactionID, Valid: true}
+	if _, err = tx.Exec(linkHold, h.TransactionID, h.HoldID); err != nil {
+		tx.Rollback()
+		logger.Error("Error while linking hold: " + err.Error())
+		return nil, nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting capture: " + err.Error())
+		return nil, nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return h, &t, nil
+}
+
+// VoidHold releases an active hold without moving any money
+func (d HoldRepositoryDB) VoidHold(accountID string, holdID string, voidedAt time.Time) (*Hold, *errs.AppError) {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for a void: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	h, appErr := lockOpenHold(tx, accountID, holdID, voidedAt)
+	if appErr != nil {
+		tx.Rollback()
+		return nil, appErr
+	}
+	h.Status = HOLD_VOIDED
+	h.ClosedAt = sql.NullString{String: voidedAt.Format(dbTSLayout), Valid: true}
+	if _, err = tx.Exec(closeHold, h.Status, h.CapturedAmount, h.ClosedAt, h.HoldID); err != nil {
+		tx.Rollback()
+		logger.Error("Error while voiding hold: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting void: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return h, nil
+}
+
+// ExpireHolds releases the active holds that expired by now
+func (d HoldRepositoryDB) ExpireHolds(now time.Time) (int, *errs.AppError) {
+	at := now.Format(dbTSLayout)
+	result, err := d.client.Exec(expireHolds, HOLD_EXPIRED, at, HOLD_ACTIVE, at)
+	if err != nil {
+		logger.Error("Error while expiring holds: " + err.Error())
+		return 0, errs.NewUnexpectedError("Unexpected database error")
+	}
+	expired, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("Error while counting expired holds: " + err.Error())
+		return 0, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return int(expired), nil
+}
+
+// lockOpenHold locks a hold of the account and checks it can still be captured or voided at now
+func lockOpenHold(tx *sqlx.Tx, accountID string, holdID string, now time.Time) (*Hold, *errs.AppError) {
+	var h Hold
+	if err := tx.Get(&h, lockHold, holdID, accountID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("Hold not found")
+		}
+		logger.Error("Error while locking hold: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	if appErr := h.CheckOpen(now); appErr != nil {
+		return nil, appErr
+	}
+	return &h, nil
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHold(t *testing.T) {
+	now := time.Date(2021, time.March, 10, 9, 0, 0, 0, time.Local)
+	h := NewHold("95470", dto.CreateHoldRequest{Amount: money.MustParse("60.00"), Description: "fuel"}, "teller", now, 7*24*time.Hour)
+	assert.Equal(t, HOLD_ACTIVE, h.Status)
+	assert.True(t, h.CapturedAmount.IsZero())
+	assert.Equal(t, "2021-03-10 09:00:00", h.CreatedAt)
+	assert.Equal(t, "2021-03-17 09:00:00", h.ExpiresAt)
+}
+
+func TestHoldCheckOpen(t *testing.T) {
+	now := time.Date(2021, time.March, 10, 9, 0, 0, 0, time.Local)
+	h := Hold{Status: HOLD_ACTIVE, ExpiresAt: "2021-03-10 09:00:01"}
+	assert.Nil(t, h.CheckOpen(now))
+	assert.Equal(t, "hold_expired", h.CheckOpen(now.Add(time.Second)).Reason)
+
+	h.Status = HOLD_VOIDED
+	err := h.CheckOpen(now)
+	assert.EqualValues(t, 409, err.Code)
+	assert.Equal(t, "hold_voided", err.Reason)
+}
+
+func TestHoldCaptureAmount(t *testing.T) {
+	h := Hold{Amount: money.MustParse("60.00")}
+
+	full, err := h.CaptureAmount(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, money.MustParse("60.00"), full)
+
+	partial := money.MustParse("45.50")
+	amount, err := h.CaptureAmount(&partial)
+	assert.Nil(t, err)
+	assert.Equal(t, partial, amount)
+
+	more := money.MustParse("60.01")
+	_, err = h.CaptureAmount(&more)
+	assert.Equal(t, "capture_exceeds_hold", err.Reason)
+
+	zero := money.Zero()
+	_, err = h.CaptureAmount(&zero)
+	assert.EqualValues(t, 422, err.Code)
+}
+
+func TestHoldsLowerTheAvailableBalanceOnly(t *testing.T) {
+	a := Account{Amount: money.MustParse("100.00"), OverdraftLimit: money.MustParse("50.00"), Held: money.MustParse("80.00")}
+	resp := a.ToGetAccountResponseDTO()
+	assert.Equal(t, money.MustParse("100.00"), resp.LedgerBalance)
+	assert.Equal(t, money.MustParse("70.00"), resp.AvailableBalance)
+	assert.True(t, a.CanWithdraw(money.MustParse("70.00")))
+	assert.False(t, a.CanWithdraw(money.MustParse("70.01")))
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// IdempotencyKey stores the response to a mutating request, so a retry sent with the same
+// Idempotency-Key header gets the stored response instead of running the request again
+type IdempotencyKey struct {
+	Key          string `db:"idempotency_key"`
+	Fingerprint  string `db:"request_fingerprint"`
+	StatusCode   int    `db:"status_code"`
+	ResponseBody string `db:"response_body"`
+	CreatedAt    string `db:"created_at"`
+	ExpiresAt    string `db:"expires_at"`
+}
+
+// IdempotencyRepository implements:
+//
+// FindKey: returns a stored key, or a not found error
+// Reserve: stores a key before its request runs, a conflict error is returned if the key already exists
+// Complete: stores the response of a reserved key
+// Release: removes a key so the request can be tried again
+// DeleteExpired: removes all keys that expired before now, and returns how many were removed
+// mockgen -destination=mocks/domain/mock_idempotency_repository.go -package=domain github.com/jonathanwamsley/banking/domain IdempotencyRepository
+type IdempotencyRepository interface {
+	FindKey(key string) (*IdempotencyKey, *errs.AppError)
+	Reserve(IdempotencyKey) *errs.AppError
+	Complete(key string, statusCode int, responseBody string) *errs.AppError
+	Release(key string) *errs.AppError
+	DeleteExpired(now string) (int64, *errs.AppError)
+}
+
+// NewIdempotencyKey reserves a key for a request fingerprint that is kept for the retention window
+func NewIdempotencyKey(key string, fingerprint string, now time.Time, retention time.Duration) IdempotencyKey {
+	return IdempotencyKey{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now.Format(dbTSLayout),
+		ExpiresAt:   now.Add(retention).Format(dbTSLayout),
+	}
+}
+
+// IsPending checks if the request of the key is still running and has no response yet
+func (k IdempotencyKey) IsPending() bool {
+	return k.StatusCode == 0
+}
+
+// IsExpired checks if the key is past its retention window and should no longer be replayed
+func (k IdempotencyKey) IsExpired(now time.Time) bool {
+	expiresAt, err := time.ParseInLocation(dbTSLayout, k.ExpiresAt, time.Local)
+	if err != nil {
+		return true
+	}
+	return !now.Before(expiresAt)
+}
+
+// Matches checks if a retried request is the same request the key was first used with
+func (k IdempotencyKey) Matches(fingerprint string) bool {
+	return k.Fingerprint == fingerprint
+}
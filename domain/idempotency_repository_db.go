@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"database/sql"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// mysqlDuplicateEntry is the mysql error number for a primary or unique key violation
+const mysqlDuplicateEntry = 1062
+
+// The query statements
+const (
+	findIdempotencyKey     = "SELECT idempotency_key, request_fingerprint, status_code, response_body, created_at, expires_at from idempotency_keys where idempotency_key = ?;"
+	reserveIdempotencyKey  = "INSERT INTO idempotency_keys (idempotency_key, request_fingerprint, status_code, response_body, created_at, expires_at) values (?, ?, 0, '', ?, ?);"
+	completeIdempotencyKey = "UPDATE idempotency_keys SET status_code = ?, response_body = ? where idempotency_key = ?;"
+	releaseIdempotencyKey  = "DELETE from idempotency_keys where idempotency_key = ?;"
+	deleteExpiredKeys      = "DELETE from idempotency_keys where expires_at <= ?;"
+)
+
+// IdempotencyRepositoryDB holds the sql client connection
+type IdempotencyRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewIdempotencyRepositoryDB creates a new IdempotencyRepositoryDB to call sql methods
+func NewIdempotencyRepositoryDB(client *sqlx.DB) IdempotencyRepositoryDB {
+	return IdempotencyRepositoryDB{client}
+}
+
+// FindKey returns a stored idempotency key
+func (d IdempotencyRepositoryDB) FindKey(key string) (*IdempotencyKey, *errs.AppError) {
+	var k IdempotencyKey
+	err := d.client.Get(&k, findIdempotencyKey, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("Idempotency key not found")
+		}
+		logger.Error("Error while fetching idempotency key: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &k, nil
+}
+
+// Reserve inserts a key without a response. The primary key makes sure only one request can reserve it.
+func (d IdempotencyRepositoryDB) Reserve(k IdempotencyKey) *errs.AppError {
+	_, err := d.client.Exec(reserveIdempotencyKey, k.Key, k.Fingerprint, k.CreatedAt, k.ExpiresAt)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlDuplicateEntry {
+			return errs.NewConflictError("A request with this Idempotency-Key is already in progress")
+		}
+		logger.Error("Error while reserving idempotency key: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// Complete stores the response that will be replayed for the key
+func (d IdempotencyRepositoryDB) Complete(key string, statusCode int, responseBody string) *errs.AppError {
+	_, err := d.client.Exec(completeIdempotencyKey, statusCode, responseBody, key)
+	if err != nil {
+		logger.Error("Error while storing idempotent response: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// Release removes a key
+func (d IdempotencyRepositoryDB) Release(key string) *errs.AppError {
+	_, err := d.client.Exec(releaseIdempotencyKey, key)
+	if err != nil {
+		logger.Error("Error while releasing idempotency key: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// DeleteExpired removes every key past its retention window
+func (d IdempotencyRepositoryDB) DeleteExpired(now string) (int64, *errs.AppError) {
+	result, err := d.client.Exec(deleteExpiredKeys, now)
+	if err != nil {
+		logger.Error("Error while deleting expired idempotency keys: " + err.Error())
+		return 0, errs.NewUnexpectedError("Unexpected database error")
+	}
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
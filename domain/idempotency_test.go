@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	now := time.Date(2021, 3, 10, 9, 0, 0, 0, time.Local)
+	k := NewIdempotencyKey("abc", "fingerprint", now, 24*time.Hour)
+	assert.Equal(t, "abc", k.Key)
+	assert.Equal(t, "2021-03-10 09:00:00", k.CreatedAt)
+	assert.Equal(t, "2021-03-11 09:00:00", k.ExpiresAt)
+	assert.True(t, k.IsPending())
+	assert.True(t, k.Matches("fingerprint"))
+	assert.False(t, k.Matches("other"))
+}
+
+func TestIdempotencyKeyIsExpired(t *testing.T) {
+	now := time.Date(2021, 3, 10, 9, 0, 0, 0, time.Local)
+	k := NewIdempotencyKey("abc", "fingerprint", now, time.Hour)
+	assert.False(t, k.IsExpired(now))
+	assert.False(t, k.IsExpired(now.Add(59*time.Minute)))
+	assert.True(t, k.IsExpired(now.Add(time.Hour)))
+
+	k.ExpiresAt = "not a date"
+	assert.True(t, k.IsExpired(now))
+}
+
+func TestIdempotencyKeyIsPending(t *testing.T) {
+	k := IdempotencyKey{StatusCode: 201}
+	assert.False(t, k.IsPending())
+}
@@ -0,0 +1,270 @@
+package domain
+
+import (
+	"database/sql"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// how often interest compounds. Daily interest compounds through the accrual base and is posted monthly,
+// every other frequency is posted, and so compounds, once per period.
+const (
+	COMPOUND_DAILY     = "daily"
+	COMPOUND_MONTHLY   = "monthly"
+	COMPOUND_QUARTERLY = "quarterly"
+	COMPOUND_ANNUALLY  = "annually"
+)
+
+// day count conventions, they decide what fraction of a year one day of interest is
+const (
+	DAY_COUNT_ACT_365 = "actual/365"
+	DAY_COUNT_ACT_360 = "actual/360"
+	DAY_COUNT_30_360  = "30/360"
+)
+
+// the interest jobs, each runs at most once per date
+const (
+	JOB_INTEREST_ACCRUAL = "interest_accrual"
+	JOB_INTEREST_POSTING = "interest_posting"
+)
+
+// DEFAULT_SAVING_PRODUCT is the interest product new saving accounts are opened with
+const DEFAULT_SAVING_PRODUCT = "savings"
+
+// ACCRUAL_DIGITS is the number of decimal places a daily accrual is kept with, interest is only rounded to
+// the currency when it is posted
+const ACCRUAL_DIGITS = 8
+
+// dateLayout is how accrual and run dates are written
+const dateLayout = "2006-01-02"
+
+// wallClock is the time zone dates and timestamps of the db are read in when only their wall clock matters, like
+// to find the day after a date. A date that is compared with now is read in the bank's time zone instead.
+var wallClock = time.UTC
+
+var compoundingPeriods = map[string]int{COMPOUND_MONTHLY: 12, COMPOUND_QUARTERLY: 4, COMPOUND_ANNUALLY: 1}
+
+var daysPerYear = map[string]int{DAY_COUNT_ACT_365: 365, DAY_COUNT_ACT_360: 360, DAY_COUNT_30_360: 360}
+
+// InterestProduct is how an account earns interest. APY is the annual percentage yield in percent, like 2.5000.
+type InterestProduct struct {
+	ProductCode string `db:"product_code"`
+	Name        string
+	APY         string `db:"apy"`
+	Compounding string
+	DayCount    string `db:"day_count"`
+}
+
+// AccrualCandidate is an account that earns interest or pays it on a negative balance, with its balance at
+// the end of the accrual date and the interest it accrued before that date which is not posted yet.
+// ProductCode is empty for an account without an interest product, OverdraftRate is the rate of its fee schedule.
+type AccrualCandidate struct {
+	AccountID        string      `db:"account_id"`
+	ProductCode      string      `db:"interest_product"`
+	OverdraftRate    string      `db:"overdraft_rate"`
+	Balance          money.Money `db:"balance"`
+	UnpostedInterest string      `db:"unposted_interest"`
+}
+
+// InterestAccrual is the interest one account earned on one day. Accrued is a decimal with ACCRUAL_DIGITS places,
+// PostedTransactionID is set once it was paid by an interest transaction.
+type InterestAccrual struct {
+	AccountID           string         `db:"account_id"`
+	AccrualDate         string         `db:"accrual_date"`
+	ProductCode         string         `db:"product_code"`
+	Balance             money.Money    `db:"balance"`
+	AnnualRate          string         `db:"annual_rate"`
+	Accrued             string         `db:"accrued"`
+	PostedTransactionID sql.NullString `db:"posted_transaction_id"`
+}
+
+// InterestRepository implements:
+//
+// FindProducts: returns every interest product
+// SaveProduct: creates or replaces an interest product
+// AccrualCandidates: returns the accounts that earn or pay interest with their balance at the end of a date
+// SaveAccrual: stores the accrual of an account for a date, false is returned when that date was already accrued
+// PostableAccounts: returns the accounts of a product with unposted accruals up to and including a date
+// PostInterest: posts the unposted accruals of a product of an account up to a date as one transaction, interest
+// paid or overdraft interest charged. nil is returned when they round to nothing and are left for the next period
+// mockgen -destination=mocks/domain/mock_interest_repository.go -package=domain github.com/jonathanwamsley/banking/domain InterestRepository
+type InterestRepository interface {
+	FindProducts() ([]InterestProduct, *errs.AppError)
+	SaveProduct(InterestProduct) *errs.AppError
+	AccrualCandidates(date string) ([]AccrualCandidate, *errs.AppError)
+	SaveAccrual(InterestAccrual) (bool, *errs.AppError)
+	PostableAccounts(productCode string, through string) ([]string, *errs.AppError)
+	PostInterest(accountID string, productCode string, through string, postedAt string) (*Transaction, *errs.AppError)
+}
+
+// NewInterestProduct converts a product request
+func NewInterestProduct(productCode string, req dto.InterestProductRequest) InterestProduct {
+	return InterestProduct{
+		ProductCode: productCode,
+		Name:        req.Name,
+		APY:         req.APY,
+		Compounding: req.Compounding,
+		DayCount:    req.DayCount,
+	}
+}
+
+// Validate checks the code is not the one kept for overdraft interest, the compounding frequency and day count
+// are known and the apy is a rate between 0 and 100 percent
+func (p InterestProduct) Validate() *errs.AppError {
+	if p.ProductCode == OVERDRAFT_PRODUCT {
+		return errs.NewValidationError("product code " + OVERDRAFT_PRODUCT + " is kept for overdraft interest")
+	}
+	if p.Compounding != COMPOUND_DAILY && compoundingPeriods[p.Compounding] == 0 {
+		return errs.NewValidationError("compounding should be daily, monthly, quarterly or annually")
+	}
+	if daysPerYear[p.DayCount] == 0 {
+		return errs.NewValidationError("day_count should be actual/365, actual/360 or 30/360")
+	}
+	apy, ok := new(big.Rat).SetString(p.APY)
+	if !ok || apy.Sign() < 0 || apy.Cmp(big.NewRat(100, 1)) > 0 {
+		return errs.NewValidationError("apy should be a percentage between 0 and 100")
+	}
+	return nil
+}
+
+// AnnualRate is the nominal annual rate that compounds to the apy, as a fraction: n * ((1 + apy)^(1/n) - 1).
+// n is the number of times a year the product compounds, for daily compounding the days of its day count year.
+// The root is taken in floating point and kept to 12 decimal places, money itself is never a float.
+func (p InterestProduct) AnnualRate() *big.Rat {
+	apy, err := strconv.ParseFloat(p.APY, 64)
+	if err != nil {
+		return new(big.Rat)
+	}
+	n := compoundingPeriods[p.Compounding]
+	if p.Compounding == COMPOUND_DAILY {
+		n = daysPerYear[p.DayCount]
+	}
+	if n == 0 {
+		return new(big.Rat)
+	}
+	nominal := float64(n) * (math.Pow(1+apy/100, 1/float64(n)) - 1)
+	rate, _ := new(big.Rat).SetString(strconv.FormatFloat(nominal, 'f', 12, 64))
+	return rate
+}
+
+// DayFraction is the fraction of a year one day of interest is worth. With 30/360 a day is worth the 30/360 days
+// since the day before, so every month is worth 30 days: the 31st is worth nothing and the last day of
+// February makes up the rest of the month.
+func (p InterestProduct) DayFraction(day time.Time) *big.Rat {
+	if p.DayCount == DAY_COUNT_30_360 {
+		return big.NewRat(serial360(day)-serial360(day.AddDate(0, 0, -1)), 360)
+	}
+	return big.NewRat(1, int64(daysPerYear[p.DayCount]))
+}
+
+// serial360 numbers days so that every month has 30, the difference of two serials is the 30/360 day count.
+// The 31st and the last day of February are the 30th.
+func serial360(d time.Time) int64 {
+	day := d.Day()
+	if day > 30 || (d.Month() == time.February && d.AddDate(0, 0, 1).Month() != time.February) {
+		day = 30
+	}
+	return int64(d.Year())*360 + int64(d.Month())*30 + int64(day)
+}
+
+// HasOverdraftInterest checks if the candidate is charged interest for the day instead of earning it
+func (c AccrualCandidate) HasOverdraftInterest() bool {
+	rate, ok := new(big.Rat).SetString(c.OverdraftRate)
+	return c.Balance.IsNegative() && ok && rate.Sign() > 0
+}
+
+// Accrue returns the interest of one day on a balance, rounded half even to ACCRUAL_DIGITS places.
+// Daily compounding earns on the unposted interest as well. A balance that is not positive earns nothing.
+func (p InterestProduct) Accrue(c AccrualCandidate, day time.Time) InterestAccrual {
+	base := toRat(c.Balance)
+	if p.Compounding == COMPOUND_DAILY {
+		if unposted, ok := new(big.Rat).SetString(c.UnpostedInterest); ok {
+			base.Add(base, unposted)
+		}
+	}
+	rate := p.AnnualRate()
+	accrued := new(big.Rat)
+	if base.Sign() > 0 {
+		accrued.Mul(base, rate)
+		accrued.Mul(accrued, p.DayFraction(day))
+	}
+	return InterestAccrual{
+		AccountID:   c.AccountID,
+		AccrualDate: day.Format(dateLayout),
+		ProductCode: p.ProductCode,
+		Balance:     c.Balance,
+		AnnualRate:  rate.FloatString(12),
+		Accrued:     roundRat(accrued, ACCRUAL_DIGITS).FloatString(ACCRUAL_DIGITS),
+	}
+}
+
+// PostingPeriodEnd is the last day of the last posting period that ended before the run date.
+// Daily and monthly interest is posted monthly, quarterly interest quarterly and annual interest once a year.
+func (p InterestProduct) PostingPeriodEnd(runDate time.Time) time.Time {
+	year, month, _ := runDate.Date()
+	switch p.Compounding {
+	case COMPOUND_QUARTERLY:
+		month = (month-1)/3*3 + 1
+	case COMPOUND_ANNUALLY:
+		month = time.January
+	}
+	return time.Date(year, month, 1, 0, 0, 0, 0, runDate.Location()).AddDate(0, 0, -1)
+}
+
+// ToDTO converts an interest product, with the nominal annual rate it compounds from
+func (p InterestProduct) ToDTO() dto.InterestProductResponse {
+	return dto.InterestProductResponse{
+		ProductCode: p.ProductCode,
+		Name:        p.Name,
+		APY:         p.APY,
+		AnnualRate:  new(big.Rat).Mul(p.AnnualRate(), big.NewRat(100, 1)).FloatString(6),
+		Compounding: p.Compounding,
+		DayCount:    p.DayCount,
+	}
+}
+
+// InterestPayment is the amount unposted accruals pay, rounded half even to the currency once
+func InterestPayment(accrued string, currency string) money.Money {
+	total, ok := new(big.Rat).SetString(accrued)
+	if !ok {
+		return money.New(0, currency)
+	}
+	scaled := total.Mul(total, new(big.Rat).SetInt(pow10(money.Digits(currency))))
+	return money.New(money.Round(scaled, money.HalfEven), currency)
+}
+
+// ParseRunDate reads a job date like 2021-03-10 as midnight in location, the bank's time zone
+func ParseRunDate(date string, location *time.Location) (time.Time, *errs.AppError) {
+	day, err := time.ParseInLocation(dateLayout, date, location)
+	if err != nil {
+		return time.Time{}, errs.NewValidationError("date should look like " + dateLayout)
+	}
+	return day, nil
+}
+
+// FormatRunDate writes a job date
+func FormatRunDate(day time.Time) string {
+	return day.Format(dateLayout)
+}
+
+// toRat returns an amount in whole currency units
+func toRat(m money.Money) *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.Minor()), pow10(money.Digits(m.Currency())))
+}
+
+// roundRat rounds half even to a number of decimal places
+func roundRat(r *big.Rat, digits int) *big.Rat {
+	scale := pow10(digits)
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(scale))
+	return new(big.Rat).SetFrac(big.NewInt(money.Round(scaled, money.HalfEven)), scale)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"math/big"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// The query statements
+const (
+	findInterestProducts = "SELECT product_code, name, apy, compounding, day_count from interest_products order by product_code;"
+	saveInterestProduct  = "INSERT INTO interest_products (product_code, name, apy, compounding, day_count) values (?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE name = VALUES(name), apy = VALUES(apy), compounding = VALUES(compounding), day_count = VALUES(day_count);"
+	// the balance at the end of the day is read from the ledger, so a rerun of an old date sees the same balance.
+	// Accounts on a schedule with an overdraft rate are candidates too, the unposted interest of the product
+	// they earn with is kept apart from unposted overdraft interest.
+	findAccrualCandidates = `SELECT a.account_id, COALESCE(a.interest_product, '') as interest_product, COALESCE(s.overdraft_rate, 0) as overdraft_rate,
+		COALESCE((SELECT SUM(l.credit - l.debit) from journal_lines l join journal_entries e on e.entry_id = l.entry_id
+			where l.ledger_account = ? and l.account_id = a.account_id and e.posted_at < ?), 0) as balance,
+		COALESCE((SELECT SUM(i.accrued) from interest_accruals i where i.account_id = a.account_id and i.product_code <> ?
+			and i.posted_transaction_id IS NULL and i.accrual_date < ?), 0) as unposted_interest
+		from accounts a left join fee_schedules s on s.account_type = a.account_type
+		where (a.interest_product IS NOT NULL or s.overdraft_rate > 0) and a.status <> 'closed' and a.opening_date < ? order by a.account_id;`
+	insertAccrual = "INSERT INTO interest_accruals (account_id, accrual_date, product_code, balance, annual_rate, accrued) values (?, ?, ?, ?, ?, ?);"
+	findPostable  = "SELECT DISTINCT account_id from interest_accruals where product_code = ? and posted_transaction_id IS NULL and accrual_date <= ? order by account_id;"
+	lockUnposted  = "SELECT accrued from interest_accruals where account_id = ? and product_code = ? and posted_transaction_id IS NULL and accrual_date <= ? FOR UPDATE;"
+	markPosted    = "UPDATE interest_accruals SET posted_transaction_id = ? where account_id = ? and product_code = ? and posted_transaction_id IS NULL and accrual_date <= ?;"
+)
+
+// InterestRepositoryDB holds the sql client connection
+type InterestRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewInterestRepositoryDB creates a new InterestRepositoryDB to call sql methods
+func NewInterestRepositoryDB(client *sqlx.DB) InterestRepositoryDB {
+	return InterestRepositoryDB{client}
+}
+
+// FindProducts returns every interest product
+func (d InterestRepositoryDB) FindProducts() ([]InterestProduct, *errs.AppError) {
+	products := make([]InterestProduct, 0)
+	if err := d.client.Select(&products, findInterestProducts); err != nil {
+		logger.Error("Error while querying interest_products table " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return products, nil
+}
+
+// SaveProduct creates or replaces an interest product, the accounts on it earn the new rate from the next accrual
+func (d InterestRepositoryDB) SaveProduct(p InterestProduct) *errs.AppError {
+	if _, err := d.client.Exec(saveInterestProduct, p.ProductCode, p.Name, p.APY, p.Compounding, p.DayCount); err != nil {
+		logger.Error("Error while saving interest product " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// AccrualCandidates returns the open accounts with an interest product or an overdraft rate that were opened
+// before the end of date
+func (d InterestRepositoryDB) AccrualCandidates(date string) ([]AccrualCandidate, *errs.AppError) {
+	day, appErr := ParseRunDate(date, wallClock)
+	if appErr != nil {
+		return nil, appErr
+	}
+	endOfDay := day.AddDate(0, 0, 1).Format(dbTSLayout)
+	candidates := make([]AccrualCandidate, 0)
+	if err := d.client.Select(&candidates, findAccrualCandidates, CUSTOMER_DEPOSITS, endOfDay, OVERDRAFT_PRODUCT, date, endOfDay); err != nil {
+		logger.Error("Error while finding accounts to accrue interest for " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return candidates, nil
+}
+
+// SaveAccrual inserts the accrual of a day. The primary key refuses a second accrual of the same day.
+func (d InterestRepositoryDB) SaveAccrual(a InterestAccrual) (bool, *errs.AppError) {
+	_, err := d.client.Exec(insertAccrual, a.AccountID, a.AccrualDate, a.ProductCode, a.Balance, a.AnnualRate, a.Accrued)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlDuplicateEntry {
+			return false, nil
+		}
+		logger.Error("Error while saving interest accrual " + err.Error())
+		return false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return true, nil
+}
+
+// PostableAccounts returns the accounts with unposted accruals of a product up to and including through
+func (d InterestRepositoryDB) PostableAccounts(productCode string, through string) ([]string, *errs.AppError) {
+	accounts := make([]string, 0)
+	if err := d.client.Select(&accounts, findPostable, productCode, through); err != nil {
+		logger.Error("Error while finding accounts to post interest to " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return accounts, nil
+}
+
+// PostInterest locks the unposted accruals of a product, posts their rounded total through the ledger and marks
+// them posted by it, all in one db transaction. A second run finds nothing left to post.
+// Interest is paid with an interest transaction, overdraft interest adds up negative and is charged with
+// an overdraft_interest transaction.
+func (d InterestRepositoryDB) PostInterest(accountID string, productCode string, through string, postedAt string) (*Transaction, *errs.AppError) {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for interest: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	var accruals []string
+	if err = tx.Select(&accruals, lockUnposted, accountID, productCode, through); err != nil {
+		tx.Rollback()
+		logger.Error("Error while locking interest accruals: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	total := new(big.Rat)
+	for _, accrued := range accruals {
+		if r, ok := new(big.Rat).SetString(accrued); ok {
+			total.Add(total, r)
+		}
+	}
+	payment := InterestPayment(total.FloatString(ACCRUAL_DIGITS), money.DefaultCurrency)
+	if payment.IsZero() {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	t := Transaction{
+		AccountID:       accountID,
+		Amount:          payment,
+		TransactionType: INTEREST,
+		TransactionDate: postedAt,
+		ExpectedVersion: ANY_VERSION,
+	}
+	if payment.IsNegative() {
+		t.Amount = payment.Neg()
+		t.TransactionType = OVERDRAFT_INTEREST
+	}
+	if appErr := postTransaction(tx, &t); appErr != nil {
+		tx.Rollback()
+		return nil, appErr
+	}
+	if _, err = tx.Exec(markPosted, t.TransactionID, accountID, productCode, through); err != nil {
+		tx.Rollback()
+		logger.Error("Error while marking interest accruals posted: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting interest: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &t, nil
+}
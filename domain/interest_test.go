@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func day(date string) time.Time {
+	d, _ := ParseRunDate(date, time.Local)
+	return d
+}
+
+func TestInterestProductValidate(t *testing.T) {
+	p := InterestProduct{ProductCode: "savings", APY: "2.50", Compounding: COMPOUND_DAILY, DayCount: DAY_COUNT_ACT_365}
+	assert.Nil(t, p.Validate())
+
+	p.Compounding = "weekly"
+	assert.EqualValues(t, "compounding should be daily, monthly, quarterly or annually", p.Validate().Message)
+
+	p.Compounding = COMPOUND_MONTHLY
+	p.DayCount = "actual/actual"
+	assert.EqualValues(t, "day_count should be actual/365, actual/360 or 30/360", p.Validate().Message)
+
+	p.DayCount = DAY_COUNT_30_360
+	p.APY = "101"
+	assert.EqualValues(t, "apy should be a percentage between 0 and 100", p.Validate().Message)
+}
+
+func TestAnnualRate(t *testing.T) {
+	annually := InterestProduct{APY: "5", Compounding: COMPOUND_ANNUALLY, DayCount: DAY_COUNT_ACT_365}
+	assert.Equal(t, "0.050000000000", annually.AnnualRate().FloatString(12))
+
+	monthly := InterestProduct{APY: "2.5", Compounding: COMPOUND_MONTHLY, DayCount: DAY_COUNT_ACT_365}
+	rate, _ := monthly.AnnualRate().Float64()
+	assert.InDelta(t, 0.0247180, rate, 0.0000005)
+
+	// compounding the nominal rate monthly gets back to the apy
+	rate, _ = new(big.Rat).Quo(monthly.AnnualRate(), big.NewRat(12, 1)).Float64()
+	effective := 1.0
+	for i := 0; i < 12; i++ {
+		effective *= 1 + rate
+	}
+	assert.InDelta(t, 1.025, effective, 0.000000001)
+}
+
+func TestDayFraction30360(t *testing.T) {
+	p := InterestProduct{DayCount: DAY_COUNT_30_360}
+	for _, month := range []string{"2021-01-01", "2021-02-01", "2024-02-01", "2021-04-01"} {
+		total := new(big.Rat)
+		start := day(month)
+		for d := start; d.Month() == start.Month(); d = d.AddDate(0, 0, 1) {
+			total.Add(total, p.DayFraction(d))
+		}
+		assert.Equal(t, big.NewRat(30, 360), total, month)
+	}
+	assert.Equal(t, 0, p.DayFraction(day("2021-01-31")).Sign())
+	assert.Equal(t, big.NewRat(3, 360), p.DayFraction(day("2021-02-28")))
+}
+
+func TestDayFractionActual(t *testing.T) {
+	assert.Equal(t, big.NewRat(1, 365), InterestProduct{DayCount: DAY_COUNT_ACT_365}.DayFraction(day("2024-02-29")))
+	assert.Equal(t, big.NewRat(1, 360), InterestProduct{DayCount: DAY_COUNT_ACT_360}.DayFraction(day("2021-01-31")))
+}
+
+func TestAccrue(t *testing.T) {
+	p := InterestProduct{ProductCode: "notice", APY: "3.65", Compounding: COMPOUND_ANNUALLY, DayCount: DAY_COUNT_ACT_365}
+	a := p.Accrue(AccrualCandidate{AccountID: "95470", Balance: money.MustParse("1000.00"), UnpostedInterest: "5.00000000"}, day("2021-03-10"))
+	assert.Equal(t, "95470", a.AccountID)
+	assert.Equal(t, "2021-03-10", a.AccrualDate)
+	assert.Equal(t, "notice", a.ProductCode)
+	assert.Equal(t, "0.036500000000", a.AnnualRate)
+	// only daily compounding earns on unposted interest
+	assert.Equal(t, "0.10000000", a.Accrued)
+
+	overdrawn := p.Accrue(AccrualCandidate{Balance: money.MustParse("-50.00"), UnpostedInterest: "0"}, day("2021-03-10"))
+	assert.Equal(t, "0.00000000", overdrawn.Accrued)
+}
+
+func TestAccrueDailyCompoundsUnpostedInterest(t *testing.T) {
+	p := InterestProduct{APY: "2.5", Compounding: COMPOUND_DAILY, DayCount: DAY_COUNT_ACT_365}
+	onBalance := p.Accrue(AccrualCandidate{Balance: money.MustParse("1000.00"), UnpostedInterest: "0"}, day("2021-03-10"))
+	onInterest := p.Accrue(AccrualCandidate{Balance: money.MustParse("0.00"), UnpostedInterest: "1000.00000000"}, day("2021-03-10"))
+	assert.Equal(t, onBalance.Accrued, onInterest.Accrued)
+	assert.Equal(t, "0.06765328", onBalance.Accrued)
+}
+
+func TestPostingPeriodEnd(t *testing.T) {
+	run := day("2021-03-10")
+	assert.Equal(t, "2021-02-28", FormatRunDate(InterestProduct{Compounding: COMPOUND_DAILY}.PostingPeriodEnd(run)))
+	assert.Equal(t, "2021-02-28", FormatRunDate(InterestProduct{Compounding: COMPOUND_MONTHLY}.PostingPeriodEnd(run)))
+	assert.Equal(t, "2020-12-31", FormatRunDate(InterestProduct{Compounding: COMPOUND_QUARTERLY}.PostingPeriodEnd(run)))
+	assert.Equal(t, "2021-03-31", FormatRunDate(InterestProduct{Compounding: COMPOUND_QUARTERLY}.PostingPeriodEnd(day("2021-04-01"))))
+	assert.Equal(t, "2020-12-31", FormatRunDate(InterestProduct{Compounding: COMPOUND_ANNUALLY}.PostingPeriodEnd(run)))
+}
+
+func TestInterestPaymentRoundsHalfEven(t *testing.T) {
+	assert.Equal(t, money.MustParse("1.23"), InterestPayment("1.23499999", money.USD))
+	assert.Equal(t, money.MustParse("0.02"), InterestPayment("0.01500000", money.USD))
+	assert.True(t, InterestPayment("0.00500000", money.USD).IsZero())
+	assert.True(t, InterestPayment("not a number", money.USD).IsZero())
+}
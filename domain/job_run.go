@@ -0,0 +1,19 @@
+package domain
+
+import "github.com/jonathanwamsley/banking/errs"
+
+// JobRunRepository records the dates batch jobs completed for, so a scheduler that was down knows where
+// to catch up from. Jobs are named by their JOB_ constants.
+//
+// A job leaves out the accounts it already has a result for, and saving a result that is already there is
+// reported as not saved instead of as an error, so a rerun only fills in what a failed or interrupted run
+// missed. A failed account is logged and counted, it does not stop the others, and a date is only completed
+// when no account failed.
+//
+// LastRun: returns the last date a job completed, an empty string when it never ran
+// CompleteRun: records that a job completed for a date, completing it again updates the record
+// mockgen -destination=mocks/domain/mock_job_run_repository.go -package=domain github.com/jonathanwamsley/banking/domain JobRunRepository
+type JobRunRepository interface {
+	LastRun(job string) (string, *errs.AppError)
+	CompleteRun(job string, date string, accounts int, completedAt string) *errs.AppError
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// The query statements
+const (
+	findLastRun = "SELECT COALESCE(MAX(run_date), '') from job_runs where job = ?;"
+	completeRun = "INSERT INTO job_runs (job, run_date, accounts, completed_at) values (?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE accounts = VALUES(accounts), completed_at = VALUES(completed_at);"
+)
+
+// JobRunRepositoryDB holds the sql client connection
+type JobRunRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewJobRunRepositoryDB creates a new JobRunRepositoryDB to call sql methods
+func NewJobRunRepositoryDB(client *sqlx.DB) JobRunRepositoryDB {
+	return JobRunRepositoryDB{client}
+}
+
+// LastRun returns the last date a job completed
+func (d JobRunRepositoryDB) LastRun(job string) (string, *errs.AppError) {
+	var date string
+	if err := d.client.Get(&date, findLastRun, job); err != nil {
+		logger.Error("Error while reading the last run of " + job + " " + err.Error())
+		return "", errs.NewUnexpectedError("Unexpected database error")
+	}
+	return date, nil
+}
+
+// CompleteRun records a job completed for a date
+func (d JobRunRepositoryDB) CompleteRun(job string, date string, accounts int, completedAt string) *errs.AppError {
+	if _, err := d.client.Exec(completeRun, job, date, accounts, completedAt); err != nil {
+		logger.Error("Error while recording a run of " + job + " " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
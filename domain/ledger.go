@@ -0,0 +1,257 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// ledger account codes in the chart of accounts
+const (
+	CASH_CLEARING     = "1000"
+	CUSTOMER_DEPOSITS = "2000"
+	FEE_INCOME        = "4000"
+	INTEREST_INCOME   = "4100"
+	INTEREST_EXPENSE  = "5000"
+)
+
+// ledger account types
+const (
+	ASSET     = "asset"
+	LIABILITY = "liability"
+	INCOME    = "income"
+	EXPENSE   = "expense"
+)
+
+// LedgerAccount is an account of the bank's own books, not to be confused with a customer Account.
+// Customer deposits are one liability account that is split per customer account by JournalLine.AccountID.
+type LedgerAccount struct {
+	Code string
+	Name string
+	Type string
+}
+
+// ChartOfAccounts lists every ledger account journal lines can be posted to
+var ChartOfAccounts = map[string]LedgerAccount{
+	CASH_CLEARING:     {Code: CASH_CLEARING, Name: "Cash and clearing", Type: ASSET},
+	CUSTOMER_DEPOSITS: {Code: CUSTOMER_DEPOSITS, Name: "Customer deposits", Type: LIABILITY},
+	FEE_INCOME:        {Code: FEE_INCOME, Name: "Fee income", Type: INCOME},
+	INTEREST_INCOME:   {Code: INTEREST_INCOME, Name: "Interest income", Type: INCOME},
+	INTEREST_EXPENSE:  {Code: INTEREST_EXPENSE, Name: "Interest expense", Type: EXPENSE},
+}
+
+// JournalLine is one side of a journal entry. AccountID is only set on customer deposit lines.
+type JournalLine struct {
+	LedgerAccount string      `db:"ledger_account"`
+	AccountID     string      `db:"account_id"`
+	Debit         money.Money `db:"debit"`
+	Credit        money.Money `db:"credit"`
+}
+
+// JournalEntry records a transaction in the books. Its debits always equal its credits.
+type JournalEntry struct {
+	EntryID       string
+	TransactionID string
+	Description   string
+	PostedAt      string
+	Lines         []JournalLine
+}
+
+// LedgerRepository implements:
+//
+// CheckInvariants: totals the whole ledger and finds every entry or account balance that breaks the books
+// mockgen -destination=mocks/domain/mock_ledger_repository.go -package=domain github.com/jonathanwamsley/banking/domain LedgerRepository
+type LedgerRepository interface {
+	CheckInvariants() (*LedgerCheck, *errs.AppError)
+}
+
+func debitLine(ledgerAccount string, accountID string, amount money.Money) JournalLine {
+	return JournalLine{LedgerAccount: ledgerAccount, AccountID: accountID, Debit: amount, Credit: money.New(0, amount.Currency())}
+}
+
+func creditLine(ledgerAccount string, accountID string, amount money.Money) JournalLine {
+	return JournalLine{LedgerAccount: ledgerAccount, AccountID: accountID, Debit: money.New(0, amount.Currency()), Credit: amount}
+}
+
+// JournalEntryFor applies the posting rules of a transaction type to build its journal entry.
+//
+// deposit: debit cash and clearing, credit the customer deposit
+// withdrawal: debit the customer deposit, credit cash and clearing
+// transfer_out: debit the customer deposit, credit cash and clearing
+// transfer_in: debit cash and clearing, credit the customer deposit
+// sweep_out: debit the customer deposit, credit cash and clearing
+// sweep_in: debit cash and clearing, credit the customer deposit
+// fee: debit the customer deposit, credit fee income
+// interest: debit interest expense, credit the customer deposit
+// overdraft_interest: debit the customer deposit, credit interest income
+// reversal_out, reversal_in: the rule of the reversed type with debits and credits swapped
+func JournalEntryFor(t Transaction) (JournalEntry, *errs.AppError) {
+	var lines []JournalLine
+	switch t.TransactionType {
+	case REVERSAL_OUT, REVERSAL_IN:
+		return reversalEntry(t)
+	case DEPOSIT, TRANSFER_IN, SWEEP_IN:
+		lines = []JournalLine{
+			debitLine(CASH_CLEARING, "", t.Amount),
+			creditLine(CUSTOMER_DEPOSITS, t.AccountID, t.Amount),
+		}
+	case WITHDRAWAL, TRANSFER_OUT, SWEEP_OUT:
+		lines = []JournalLine{
+			debitLine(CUSTOMER_DEPOSITS, t.AccountID, t.Amount),
+			creditLine(CASH_CLEARING, "", t.Amount),
+		}
+	case FEE:
+		lines = []JournalLine{
+			debitLine(CUSTOMER_DEPOSITS, t.AccountID, t.Amount),
+			creditLine(FEE_INCOME, "", t.Amount),
+		}
+	case INTEREST:
+		lines = []JournalLine{
+			debitLine(INTEREST_EXPENSE, "", t.Amount),
+			creditLine(CUSTOMER_DEPOSITS, t.AccountID, t.Amount),
+		}
+	case OVERDRAFT_INTEREST:
+		lines = []JournalLine{
+			debitLine(CUSTOMER_DEPOSITS, t.AccountID, t.Amount),
+			creditLine(INTEREST_INCOME, "", t.Amount),
+		}
+	default:
+		return JournalEntry{}, errs.NewUnexpectedError(fmt.Sprintf("no posting rule for transaction type %s", t.TransactionType))
+	}
+	return JournalEntry{
+		TransactionID: t.TransactionID,
+		Description:   t.TransactionType,
+		PostedAt:      t.TransactionDate,
+		Lines:         lines,
+	}, nil
+}
+
+// reversalEntry posts the entry of the reversed transaction type for the reversal amount, backwards. A fee is
+// reversed out of fee income and a deposit out of cash and clearing, where they were posted to.
+func reversalEntry(t Transaction) (JournalEntry, *errs.AppError) {
+	reversed := t
+	reversed.TransactionType = t.ReversedType
+	if reversed.IsReversal() || reversed.IsDebit() != (t.TransactionType == REVERSAL_IN) {
+		return JournalEntry{}, errs.NewUnexpectedError(fmt.Sprintf("%s can not reverse a transaction of type %s", t.TransactionType, t.ReversedType))
+	}
+	entry, appErr := JournalEntryFor(reversed)
+	if appErr != nil {
+		return JournalEntry{}, appErr
+	}
+	for i, l := range entry.Lines {
+		entry.Lines[i].Debit, entry.Lines[i].Credit = l.Credit, l.Debit
+	}
+	entry.Description = "reversal of " + t.ReversedType
+	return entry, nil
+}
+
+// OpeningEntry records the first deposit an account is opened with
+func OpeningEntry(a Account) JournalEntry {
+	return JournalEntry{
+		Description: "opening deposit",
+		PostedAt:    a.OpeningDate,
+		Lines: []JournalLine{
+			debitLine(CASH_CLEARING, "", a.Amount),
+			creditLine(CUSTOMER_DEPOSITS, a.AccountID, a.Amount),
+		},
+	}
+}
+
+// Totals returns the sum of the debits and the sum of the credits of the entry
+func (e JournalEntry) Totals() (money.Money, money.Money) {
+	debits, credits := money.Zero(), money.Zero()
+	for _, l := range e.Lines {
+		debits = debits.Add(l.Debit)
+		credits = credits.Add(l.Credit)
+	}
+	return debits, credits
+}
+
+// IsBalanced checks the entry has lines, only posts to known ledger accounts, and its debits equal its credits
+func (e JournalEntry) IsBalanced() bool {
+	if len(e.Lines) < 2 {
+		return false
+	}
+	for _, l := range e.Lines {
+		if _, ok := ChartOfAccounts[l.LedgerAccount]; !ok {
+			return false
+		}
+		if l.Debit.IsNegative() || l.Credit.IsNegative() {
+			return false
+		}
+	}
+	debits, credits := e.Totals()
+	return debits.Cmp(credits) == 0
+}
+
+// DepositChange returns how much the entry moves the balance of a customer account.
+// Deposits are a liability, so credits raise the balance and debits lower it.
+func (e JournalEntry) DepositChange(accountID string) money.Money {
+	change := money.Zero()
+	for _, l := range e.Lines {
+		if l.LedgerAccount == CUSTOMER_DEPOSITS && l.AccountID == accountID {
+			change = change.Add(l.Credit).Sub(l.Debit)
+		}
+	}
+	return change
+}
+
+// TrialBalanceRow holds the totals posted to one ledger account
+type TrialBalanceRow struct {
+	LedgerAccount string      `db:"ledger_account"`
+	Debits        money.Money `db:"debits"`
+	Credits       money.Money `db:"credits"`
+}
+
+// BalanceMismatch is a customer account whose stored balance differs from its ledger balance
+type BalanceMismatch struct {
+	AccountID      string      `db:"account_id"`
+	AccountBalance money.Money `db:"amount"`
+	LedgerBalance  money.Money `db:"ledger_balance"`
+}
+
+// LedgerCheck is the result of checking the books
+type LedgerCheck struct {
+	TotalDebits       money.Money
+	TotalCredits      money.Money
+	TrialBalance      []TrialBalanceRow
+	UnbalancedEntries []string
+	BalanceMismatches []BalanceMismatch
+}
+
+// IsBalanced checks that total debits equal total credits, every entry balances,
+// and every account balance matches the ledger
+func (c LedgerCheck) IsBalanced() bool {
+	return c.TotalDebits.Cmp(c.TotalCredits) == 0 && len(c.UnbalancedEntries) == 0 && len(c.BalanceMismatches) == 0
+}
+
+// ToDTO converts a ledger check to the response for the user
+func (c LedgerCheck) ToDTO() dto.LedgerCheckResponse {
+	response := dto.LedgerCheckResponse{
+		Balanced:          c.IsBalanced(),
+		TotalDebits:       c.TotalDebits,
+		TotalCredits:      c.TotalCredits,
+		TrialBalance:      make([]dto.TrialBalanceRow, 0),
+		UnbalancedEntries: make([]string, 0),
+		BalanceMismatches: make([]dto.BalanceMismatch, 0),
+	}
+	for _, row := range c.TrialBalance {
+		response.TrialBalance = append(response.TrialBalance, dto.TrialBalanceRow{
+			LedgerAccount: row.LedgerAccount,
+			Name:          ChartOfAccounts[row.LedgerAccount].Name,
+			Debits:        row.Debits,
+			Credits:       row.Credits,
+		})
+	}
+	response.UnbalancedEntries = append(response.UnbalancedEntries, c.UnbalancedEntries...)
+	for _, m := range c.BalanceMismatches {
+		response.BalanceMismatches = append(response.BalanceMismatches, dto.BalanceMismatch{
+			AccountID:      m.AccountID,
+			AccountBalance: m.AccountBalance,
+			LedgerBalance:  m.LedgerBalance,
+		})
+	}
+	return response
+}
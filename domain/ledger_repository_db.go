@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// The query statements
+const (
+	insertJournalEntry = "INSERT INTO journal_entries (transaction_id, description, posted_at) values (?, ?, ?);"
+	insertJournalLine  = "INSERT INTO journal_lines (entry_id, ledger_account, account_id, debit, credit) values (?, ?, ?, ?, ?);"
+	getTrialBalance    = "SELECT ledger_account, COALESCE(SUM(debit), 0) as debits, COALESCE(SUM(credit), 0) as credits from journal_lines group by ledger_account order by ledger_account;"
+	getUnbalanced      = "SELECT entry_id from journal_lines group by entry_id having SUM(debit) <> SUM(credit) order by entry_id;"
+	getMismatches      = `SELECT a.account_id, a.amount, COALESCE(SUM(l.credit - l.debit), 0) as ledger_balance
+		from accounts a left join journal_lines l on l.account_id = a.account_id and l.ledger_account = ?
+		group by a.account_id, a.amount having a.amount <> ledger_balance order by a.account_id;`
+)
+
+// LedgerRepositoryDB holds the sql client connection
+type LedgerRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewLedgerRepositoryDB creates a new LedgerRepositoryDB to call sql methods
+func NewLedgerRepositoryDB(client *sqlx.DB) LedgerRepositoryDB {
+	return LedgerRepositoryDB{client}
+}
+
+// CheckInvariants totals the ledger, and lists every unbalanced entry and every account whose balance is off
+func (d LedgerRepositoryDB) CheckInvariants() (*LedgerCheck, *errs.AppError) {
+	check := LedgerCheck{TotalDebits: money.Zero(), TotalCredits: money.Zero()}
+
+	if err := d.client.Select(&check.TrialBalance, getTrialBalance); err != nil {
+		logger.Error("Error while computing trial balance: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	for _, row := range check.TrialBalance {
+		check.TotalDebits = check.TotalDebits.Add(row.Debits)
+		check.TotalCredits = check.TotalCredits.Add(row.Credits)
+	}
+	if err := d.client.Select(&check.UnbalancedEntries, getUnbalanced); err != nil {
+		logger.Error("Error while finding unbalanced journal entries: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	if err := d.client.Select(&check.BalanceMismatches, getMismatches, CUSTOMER_DEPOSITS); err != nil {
+		logger.Error("Error while comparing account balances with the ledger: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &check, nil
+}
+
+// postJournalEntry writes a balanced entry and its lines within tx. An unbalanced entry is never written.
+func postJournalEntry(tx *sqlx.Tx, e JournalEntry) (string, *errs.AppError) {
+	if !e.IsBalanced() {
+		logger.Error("refusing to post unbalanced journal entry: " + e.Description)
+		return "", errs.NewUnexpectedError("Unbalanced journal entry")
+	}
+
+	result, err := tx.Exec(insertJournalEntry, nullable(e.TransactionID), e.Description, e.PostedAt)
+	if err != nil {
+		logger.Error("Error while saving journal entry: " + err.Error())
+		return "", errs.NewUnexpectedError("Unexpected database error")
+	}
+	entryID, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Error while getting the last journal entry id: " + err.Error())
+		return "", errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	for _, l := range e.Lines {
+		if _, err = tx.Exec(insertJournalLine, entryID, l.LedgerAccount, nullable(l.AccountID), l.Debit, l.Credit); err != nil {
+			logger.Error("Error while saving journal line: " + err.Error())
+			return "", errs.NewUnexpectedError("Unexpected database error")
+		}
+	}
+	return strconv.FormatInt(entryID, 10), nil
+}
+
+// nullable stores an empty id as NULL
+func nullable(id string) sql.NullString {
+	return sql.NullString{String: id, Valid: id != ""}
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalEntryForPostingRules(t *testing.T) {
+	amount := money.MustParse("40.00")
+	rules := map[string][2]string{
+		DEPOSIT:            {CASH_CLEARING, CUSTOMER_DEPOSITS},
+		TRANSFER_IN:        {CASH_CLEARING, CUSTOMER_DEPOSITS},
+		WITHDRAWAL:         {CUSTOMER_DEPOSITS, CASH_CLEARING},
+		TRANSFER_OUT:       {CUSTOMER_DEPOSITS, CASH_CLEARING},
+		FEE:                {CUSTOMER_DEPOSITS, FEE_INCOME},
+		INTEREST:           {INTEREST_EXPENSE, CUSTOMER_DEPOSITS},
+		SWEEP_OUT:          {CUSTOMER_DEPOSITS, CASH_CLEARING},
+		SWEEP_IN:           {CASH_CLEARING, CUSTOMER_DEPOSITS},
+		OVERDRAFT_INTEREST: {CUSTOMER_DEPOSITS, INTEREST_INCOME},
+	}
+	for transactionType, sides := range rules {
+		entry, err := JournalEntryFor(Transaction{TransactionID: "5", AccountID: "95470", Amount: amount, TransactionType: transactionType})
+		assert.Nil(t, err, transactionType)
+		assert.True(t, entry.IsBalanced(), transactionType)
+		assert.Equal(t, "5", entry.TransactionID)
+		assert.Equal(t, sides[0], entry.Lines[0].LedgerAccount, transactionType)
+		assert.Equal(t, amount, entry.Lines[0].Debit, transactionType)
+		assert.Equal(t, sides[1], entry.Lines[1].LedgerAccount, transactionType)
+		assert.Equal(t, amount, entry.Lines[1].Credit, transactionType)
+	}
+}
+
+func TestJournalEntryForReversalSwapsTheReversedRule(t *testing.T) {
+	amount := money.MustParse("15.00")
+	fee, err := JournalEntryFor(Transaction{AccountID: "95470", Amount: amount, TransactionType: REVERSAL_IN, ReversedType: FEE})
+	assert.Nil(t, err)
+	assert.True(t, fee.IsBalanced())
+	assert.Equal(t, FEE_INCOME, fee.Lines[1].LedgerAccount)
+	assert.Equal(t, amount, fee.Lines[1].Debit)
+	assert.Equal(t, amount, fee.DepositChange("95470"))
+
+	deposit, err := JournalEntryFor(Transaction{AccountID: "95470", Amount: amount, TransactionType: REVERSAL_OUT, ReversedType: DEPOSIT})
+	assert.Nil(t, err)
+	assert.Equal(t, amount.Neg(), deposit.DepositChange("95470"))
+	assert.Equal(t, "reversal of deposit", deposit.Description)
+}
+
+func TestJournalEntryForReversalOfTheWrongDirection(t *testing.T) {
+	_, err := JournalEntryFor(Transaction{Amount: money.MustParse("15.00"), TransactionType: REVERSAL_OUT, ReversedType: WITHDRAWAL})
+	assert.EqualValues(t, 500, err.Code)
+	_, err = JournalEntryFor(Transaction{Amount: money.MustParse("15.00"), TransactionType: REVERSAL_IN, ReversedType: REVERSAL_OUT})
+	assert.EqualValues(t, 500, err.Code)
+}
+
+func TestJournalEntryForUnknownType(t *testing.T) {
+	_, err := JournalEntryFor(Transaction{TransactionType: "gift"})
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 500, err.Code)
+}
+
+func TestDepositChange(t *testing.T) {
+	deposit, _ := JournalEntryFor(Transaction{AccountID: "95470", Amount: money.MustParse("40.00"), TransactionType: DEPOSIT})
+	assert.Equal(t, money.MustParse("40.00"), deposit.DepositChange("95470"))
+	assert.True(t, deposit.DepositChange("95471").IsZero())
+
+	fee, _ := JournalEntryFor(Transaction{AccountID: "95470", Amount: money.MustParse("2.50"), TransactionType: FEE})
+	assert.Equal(t, money.MustParse("-2.50"), fee.DepositChange("95470"))
+}
+
+func TestOpeningEntry(t *testing.T) {
+	entry := OpeningEntry(Account{AccountID: "95474", OpeningDate: "2021-03-10 09:00:00", Amount: money.MustParse("5000.00")})
+	assert.True(t, entry.IsBalanced())
+	assert.Equal(t, "", entry.TransactionID)
+	assert.Equal(t, money.MustParse("5000.00"), entry.DepositChange("95474"))
+}
+
+func TestJournalEntryIsBalanced(t *testing.T) {
+	ten := money.MustParse("10.00")
+	unbalanced := JournalEntry{Lines: []JournalLine{
+		debitLine(CASH_CLEARING, "", ten),
+		creditLine(CUSTOMER_DEPOSITS, "95470", money.MustParse("9.99")),
+	}}
+	assert.False(t, unbalanced.IsBalanced())
+
+	oneSided := JournalEntry{Lines: []JournalLine{debitLine(CASH_CLEARING, "", money.Zero())}}
+	assert.False(t, oneSided.IsBalanced())
+
+	unknownAccount := JournalEntry{Lines: []JournalLine{
+		debitLine("9999", "", ten),
+		creditLine(CUSTOMER_DEPOSITS, "95470", ten),
+	}}
+	assert.False(t, unknownAccount.IsBalanced())
+
+	negative := JournalEntry{Lines: []JournalLine{
+		debitLine(CASH_CLEARING, "", ten.Neg()),
+		creditLine(CUSTOMER_DEPOSITS, "95470", ten.Neg()),
+	}}
+	assert.False(t, negative.IsBalanced())
+}
+
+func TestLedgerCheckIsBalanced(t *testing.T) {
+	total := money.MustParse("23028.05")
+	check := LedgerCheck{
+		TotalDebits:  total,
+		TotalCredits: total,
+		TrialBalance: []TrialBalanceRow{
+			{LedgerAccount: CASH_CLEARING, Debits: total, Credits: money.Zero()},
+			{LedgerAccount: CUSTOMER_DEPOSITS, Debits: money.Zero(), Credits: total},
+		},
+	}
+	assert.True(t, check.IsBalanced())
+	resp := check.ToDTO()
+	assert.True(t, resp.Balanced)
+	assert.Equal(t, "Customer deposits", resp.TrialBalance[1].Name)
+	assert.Equal(t, 0, len(resp.UnbalancedEntries))
+
+	check.BalanceMismatches = []BalanceMismatch{{AccountID: "95470", AccountBalance: money.MustParse("1.00"), LedgerBalance: money.Zero()}}
+	assert.False(t, check.IsBalanced())
+
+	check.BalanceMismatches = nil
+	check.TotalCredits = total.Sub(money.FromMinor(1))
+	assert.False(t, check.IsBalanced())
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/policy"
+)
+
+// LocalAuthRepository verifies tokens in process with the signing keys of the auth api,
+// then lets the policy decide on the role, route, route variables and body
+type LocalAuthRepository struct {
+	keys     *VerificationKeys
+	issuer   string
+	audience string
+	leeway   time.Duration
+	policy   *policy.Engine
+	now      func() time.Time
+}
+
+// NewLocalAuthRepository creates a LocalAuthRepository. The issuer and audience are only checked when they are set,
+// and leeway is the clock skew allowed on exp and nbf.
+func NewLocalAuthRepository(keys *VerificationKeys, issuer string, audience string, leeway time.Duration, engine *policy.Engine) LocalAuthRepository {
+	return LocalAuthRepository{keys: keys, issuer: issuer, audience: audience, leeway: leeway, policy: engine, now: time.Now}
+}
+
+// IsAuthorized verifies the token and asks the policy if its role may make the request
+func (r LocalAuthRepository) IsAuthorized(req AuthRequest) (*AccessClaims, bool) {
+	claims, err := r.Verify(req.Token)
+	if err != nil {
+		logger.Info("token rejected: " + err.Error())
+		return nil, false
+	}
+	decision := r.policy.Decide(policy.Input{
+		Role:  claims.Role,
+		Route: req.RouteName,
+		Token: claims.Attributes(),
+		Vars:  req.Vars,
+		Body:  req.Body,
+	})
+	if !decision.Allowed {
+		logger.Info("request denied: " + decision.Reason)
+		return nil, false
+	}
+	return claims, true
+}
+
+// Verify checks the signature, that it is an access token, that the token has an exp that has not passed, an nbf that has passed,
+// and the configured issuer and audience. The claims are returned for a valid token.
+func (r LocalAuthRepository) Verify(token string) (*AccessClaims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{ALG_HS256, ALG_RS256}), jwt.WithoutClaimsValidation())
+	claims := &AccessClaims{}
+	if _, err := parser.ParseWithClaims(token, claims, r.keys.keyFor); err != nil {
+		return nil, err
+	}
+
+	if claims.TokenUse != "" && claims.TokenUse != TOKEN_USE_ACCESS {
+		return nil, errors.New("token is not an access token")
+	}
+	now := r.now()
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("token has no exp")
+	}
+	if !claims.VerifyExpiresAt(now.Add(-r.leeway), true) {
+		return nil, errors.New("token is expired")
+	}
+	if !claims.VerifyNotBefore(now.Add(r.leeway), false) {
+		return nil, errors.New("token is not valid yet")
+	}
+	if r.issuer != "" && !claims.VerifyIssuer(r.issuer, true) {
+		return nil, errors.New("token has the wrong issuer")
+	}
+	if r.audience != "" && !claims.VerifyAudience(r.audience, true) {
+		return nil, errors.New("token has the wrong audience")
+	}
+	return claims, nil
+}
@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/jonathanwamsley/banking/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2021, 3, 10, 9, 0, 0, 0, time.UTC)
+
+func testClaims(role string) AccessClaims {
+	return AccessClaims{
+		CustomerID: "2001",
+		Accounts:   []string{"95472", "95473"},
+		Username:   "2001",
+		Role:       role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "banking-auth",
+			Audience:  jwt.ClaimStrings{"banking"},
+			ExpiresAt: jwt.NewNumericDate(testNow.Add(time.Hour)),
+			NotBefore: jwt.NewNumericDate(testNow.Add(-time.Minute)),
+		},
+	}
+}
+
+func signHS256(t *testing.T, claims AccessClaims, secret string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func localRepo(keys *VerificationKeys) LocalAuthRepository {
+	engine, err := policy.NewFileEngine("../resources/policy.yaml")
+	if err != nil {
+		panic(err)
+	}
+	r := NewLocalAuthRepository(keys, "banking-auth", "banking", 30*time.Second, engine)
+	r.now = func() time.Time { return testNow }
+	return r
+}
+
+func hmacKeys() *VerificationKeys {
+	keys := NewVerificationKeys()
+	keys.AddHMAC("", []byte("hmacSampleSecret"))
+	return keys
+}
+
+func TestVerifyHS256(t *testing.T) {
+	claims, err := localRepo(hmacKeys()).Verify(signHS256(t, testClaims(ROLE_USER), "hmacSampleSecret"))
+	assert.Nil(t, err)
+	assert.Equal(t, "2001", claims.CustomerID)
+	assert.Equal(t, ROLE_USER, claims.Role)
+}
+
+func TestVerifyRejectsWrongSecret(t *testing.T) {
+	_, err := localRepo(hmacKeys()).Verify(signHS256(t, testClaims(ROLE_USER), "another secret"))
+	assert.NotNil(t, err)
+}
+
+func TestVerifyTimeClaims(t *testing.T) {
+	repo := localRepo(hmacKeys())
+
+	expired := testClaims(ROLE_USER)
+	expired.ExpiresAt = jwt.NewNumericDate(testNow.Add(-time.Minute))
+	_, err := repo.Verify(signHS256(t, expired, "hmacSampleSecret"))
+	assert.EqualError(t, err, "token is expired")
+
+	withinLeeway := testClaims(ROLE_USER)
+	withinLeeway.ExpiresAt = jwt.NewNumericDate(testNow.Add(-10 * time.Second))
+	_, err = repo.Verify(signHS256(t, withinLeeway, "hmacSampleSecret"))
+	assert.Nil(t, err)
+
+	noExp := testClaims(ROLE_USER)
+	noExp.ExpiresAt = nil
+	_, err = repo.Verify(signHS256(t, noExp, "hmacSampleSecret"))
+	assert.EqualError(t, err, "token has no exp")
+
+	notYet := testClaims(ROLE_USER)
+	notYet.NotBefore = jwt.NewNumericDate(testNow.Add(time.Minute))
+	_, err = repo.Verify(signHS256(t, notYet, "hmacSampleSecret"))
+	assert.EqualError(t, err, "token is not valid yet")
+}
+
+func TestVerifyIssuerAndAudience(t *testing.T) {
+	repo := localRepo(hmacKeys())
+
+	wrongIssuer := testClaims(ROLE_USER)
+	wrongIssuer.Issuer = "someone-else"
+	_, err := repo.Verify(signHS256(t, wrongIssuer, "hmacSampleSecret"))
+	assert.EqualError(t, err, "token has the wrong issuer")
+
+	wrongAudience := testClaims(ROLE_USER)
+	wrongAudience.Audience = jwt.ClaimStrings{"payments"}
+	_, err = repo.Verify(signHS256(t, wrongAudience, "hmacSampleSecret"))
+	assert.EqualError(t, err, "token has the wrong audience")
+}
+
+func TestVerifyRejectsNoneAlgorithm(t *testing.T) {
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims(ROLE_ADMIN)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	_, err := localRepo(hmacKeys()).Verify(token)
+	assert.NotNil(t, err)
+}
+
+func TestVerifyRS256WithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","use":"enc","kid":"enc-1","n":"AQAB","e":"AQAB"},
+		{"kty":"EC","kid":"ec-1","crv":"P-256"},
+		{"kty":"RSA","use":"sig","alg":"RS256","kid":"rsa-1","n":"%s","e":"%s"}
+	]}`,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	ioutil.WriteFile(path, []byte(jwks), 0600)
+
+	keys := NewVerificationKeys()
+	assert.Nil(t, keys.AddJWKSFile(path))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims(ROLE_ADMIN))
+	token.Header["kid"] = "rsa-1"
+	signed, _ := token.SignedString(key)
+	claims, err := localRepo(keys).Verify(signed)
+	assert.Nil(t, err)
+	assert.Equal(t, ROLE_ADMIN, claims.Role)
+
+	token.Header["kid"] = "rsa-2"
+	signed, _ = token.SignedString(key)
+	_, err = localRepo(keys).Verify(signed)
+	assert.NotNil(t, err)
+}
+
+func TestAddJWKSFileWithoutSigningKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	ioutil.WriteFile(path, []byte(`{"keys":[{"kty":"EC","kid":"ec-1"}]}`), 0600)
+
+	assert.NotNil(t, NewVerificationKeys().AddJWKSFile(path))
+	assert.NotNil(t, NewVerificationKeys().AddJWKSFile(filepath.Join(os.TempDir(), "missing-jwks.json")))
+}
+
+func TestVerifyRejectsHS256SignedWithRSAKey(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keys := NewVerificationKeys()
+	keys.AddRSA("", &key.PublicKey)
+
+	// the classic alg confusion attack signs with the public key as an HMAC secret
+	_, err := localRepo(keys).Verify(signHS256(t, testClaims(ROLE_ADMIN), string(key.PublicKey.N.Bytes())))
+	assert.NotNil(t, err)
+}
+
+func TestIsAuthorizedUsesPolicyWithRouteVariablesAndBody(t *testing.T) {
+	repo := localRepo(hmacKeys())
+	user := signHS256(t, testClaims(ROLE_USER), "hmacSampleSecret")
+	admin := signHS256(t, testClaims(ROLE_ADMIN), "hmacSampleSecret")
+	request := func(token string, route string, vars map[string]string, body map[string]interface{}) AuthRequest {
+		return AuthRequest{Token: token, RouteName: route, Vars: vars, Body: body}
+	}
+	allowed := func(req AuthRequest) bool {
+		_, ok := repo.IsAuthorized(req)
+		return ok
+	}
+	own := map[string]string{"customer_id": "2001", "account_id": "95472"}
+	small := map[string]interface{}{"amount": json.Number("250.00")}
+
+	assert.True(t, allowed(request(user, "NewTransaction", own, small)))
+	assert.False(t, allowed(request(user, "NewTransaction", own, map[string]interface{}{"amount": json.Number("10000")})))
+	assert.False(t, allowed(request(user, "NewTransaction", map[string]string{"customer_id": "2001", "account_id": "95470"}, small)))
+	assert.False(t, allowed(request(user, "GetCustomer", map[string]string{"customer_id": "2000"}, nil)))
+	assert.False(t, allowed(request(user, "DeleteCustomer", map[string]string{"customer_id": "2001"}, nil)))
+	assert.True(t, allowed(request(admin, "DeleteCustomer", map[string]string{"customer_id": "2000"}, nil)))
+	assert.False(t, allowed(request("not a token", "GetCustomer", map[string]string{"customer_id": "2001"}, nil)))
+
+	claims, _ := repo.IsAuthorized(request(user, "GetCustomer", map[string]string{"customer_id": "2001"}, nil))
+	assert.Equal(t, "2001", claims.Username)
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"database/sql"
+	"math/big"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// the reasons a customer debit is declined for money
+//
+// insufficient_funds: the balance does not cover the debit and the account has no overdraft
+// overdraft_limit_exceeded: the debit would take the balance past the overdraft limit
+// insufficient_funds_for_fees: the debit itself fits, but not together with the fees it is charged
+const (
+	REASON_INSUFFICIENT_FUNDS          = "insufficient_funds"
+	REASON_OVERDRAFT_LIMIT_EXCEEDED    = "overdraft_limit_exceeded"
+	REASON_INSUFFICIENT_FUNDS_FOR_FEES = "insufficient_funds_for_fees"
+)
+
+// OVERDRAFT_PRODUCT is the product code overdraft interest is accrued under. It is not an interest product,
+// the rate comes from the fee schedule of the account type.
+const OVERDRAFT_PRODUCT = "overdraft"
+
+// Overdraft is how far below zero an account may go, and whether a checking account sweeps from saving first
+type Overdraft struct {
+	Limit money.Money
+	Sweep bool
+}
+
+// Sweep is money moved from the customer's saving account into checking to cover a debit.
+// Out is posted to the saving account and In to the checking account, both link to the debit.
+type Sweep struct {
+	FromAccountID string
+	Out           Transaction
+	In            Transaction
+}
+
+// NewOverdraft converts an overdraft request
+func NewOverdraft(r dto.UpdateOverdraftRequest) Overdraft {
+	return Overdraft{Limit: r.Limit, Sweep: r.Sweep}
+}
+
+// ValidateFor checks the settings fit the account, only checking accounts sweep from saving.
+// The request already checked the limit is not negative.
+func (o Overdraft) ValidateFor(a Account) *errs.AppError {
+	if o.Sweep && a.AccountType != dto.CHECKING {
+		return errs.NewValidationError("only checking accounts can sweep from saving").WithReason("sweep_not_allowed")
+	}
+	return nil
+}
+
+// CheckFunds checks the balance and the overdraft limit cover a customer debit of amount and the fees it is
+// charged. A decline names why, along with what is available.
+func (a Account) CheckFunds(amount money.Money, fees money.Money) *errs.AppError {
+	if a.CanWithdraw(amount.Add(fees)) {
+		return nil
+	}
+	available := a.Available().String()
+	if fees.IsPositive() && a.CanWithdraw(amount) {
+		return errs.NewValidationError("Insufficient balance in the account to pay the transaction and its " + fees.String() +
+			" in fees, " + available + " is available").WithReason(REASON_INSUFFICIENT_FUNDS_FOR_FEES)
+	}
+	if a.OverdraftLimit.IsPositive() {
+		return errs.NewValidationError("The transaction would go past the overdraft limit of " + a.OverdraftLimit.String() +
+			", " + available + " is available").WithReason(REASON_OVERDRAFT_LIMIT_EXCEEDED)
+	}
+	return errs.NewValidationError("Insufficient balance in the account, " + available + " is available").
+		WithReason(REASON_INSUFFICIENT_FUNDS)
+}
+
+// SweepAmount is how much to move from a saving balance so that a checking balance covers need:
+// the shortfall, but never more than the saving account has
+func SweepAmount(balance money.Money, need money.Money, saving money.Money) money.Money {
+	shortfall := need.Sub(balance)
+	if !shortfall.IsPositive() || !saving.IsPositive() {
+		return money.New(0, need.Currency())
+	}
+	if saving.LessThan(shortfall) {
+		return saving
+	}
+	return shortfall
+}
+
+// NewSweep builds both legs of a sweep from the saving account into the account of the debit t
+func NewSweep(t Transaction, fromAccountID string, amount money.Money) Sweep {
+	leg := func(accountID string, transactionType string) Transaction {
+		return Transaction{
+			AccountID:       accountID,
+			Amount:          amount,
+			TransactionType: transactionType,
+			TransactionDate: t.TransactionDate,
+			BookingDate:     t.BookingDate,
+			ValueDate:       t.ValueDate,
+			ExpectedVersion: ANY_VERSION,
+		}
+	}
+	return Sweep{FromAccountID: fromAccountID, Out: leg(fromAccountID, SWEEP_OUT), In: leg(t.AccountID, SWEEP_IN)}
+}
+
+// IsCustomerDebit checks if a transaction is money the customer takes out. Only those use the overdraft, sweep
+// from saving and are charged fees, bank charges and sweep legs do not.
+func IsCustomerDebit(t Transaction) bool {
+	return t.TransactionType == WITHDRAWAL || t.TransactionType == TRANSFER_OUT
+}
+
+// NewFeeCharge is a fee of feeType charged for the transaction t, it links back to t
+func NewFeeCharge(t Transaction, feeType string, fee money.Money) Transaction {
+	return Transaction{
+		AccountID:            t.AccountID,
+		Amount:               fee,
+		TransactionType:      FEE,
+		TransactionDate:      t.TransactionDate,
+		BookingDate:          t.BookingDate,
+		ValueDate:            t.ValueDate,
+		RelatedTransactionID: sql.NullString{String: t.TransactionID, Valid: t.TransactionID != ""},
+		FeeType:              feeType,
+		ExpectedVersion:      ANY_VERSION,
+	}
+}
+
+// AccrueOverdraft returns one day of interest on a negative balance at the annual overdraft rate in percent.
+// It is simple interest on actual/365 and is accrued negative, since the customer pays it.
+// A balance that is not negative accrues nothing.
+func AccrueOverdraft(c AccrualCandidate, day time.Time) InterestAccrual {
+	rate, ok := new(big.Rat).SetString(c.OverdraftRate)
+	if !ok {
+		rate = new(big.Rat)
+	}
+	rate.Quo(rate, big.NewRat(100, 1))
+	accrued := new(big.Rat)
+	if c.Balance.IsNegative() {
+		accrued.Mul(toRat(c.Balance), rate)
+		accrued.Mul(accrued, big.NewRat(1, int64(daysPerYear[DAY_COUNT_ACT_365])))
+	}
+	return InterestAccrual{
+		AccountID:   c.AccountID,
+		AccrualDate: day.Format(dateLayout),
+		ProductCode: OVERDRAFT_PRODUCT,
+		Balance:     c.Balance,
+		AnnualRate:  rate.FloatString(12),
+		Accrued:     roundRat(accrued, ACCRUAL_DIGITS).FloatString(ACCRUAL_DIGITS),
+	}
+}
+
+// OverdraftPeriodEnd is the last day of the month before the run date, overdraft interest is charged monthly
+func OverdraftPeriodEnd(runDate time.Time) time.Time {
+	year, month, _ := runDate.Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, runDate.Location()).AddDate(0, 0, -1)
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanWithdrawIntoOverdraft(t *testing.T) {
+	a := Account{Amount: money.MustParse("100.00"), OverdraftLimit: money.MustParse("50.00")}
+	assert.True(t, a.CanWithdraw(money.MustParse("150.00")))
+	assert.False(t, a.CanWithdraw(money.MustParse("150.01")))
+	assert.Equal(t, money.MustParse("150.00"), a.Available())
+
+	// charges took it past the limit
+	a.Amount = money.MustParse("-60.00")
+	assert.True(t, a.Available().IsZero())
+}
+
+func TestCheckFundsReasons(t *testing.T) {
+	noOverdraft := Account{Amount: money.MustParse("100.00")}
+	assert.Nil(t, noOverdraft.CheckFunds(money.MustParse("100.00"), money.Zero()))
+
+	err := noOverdraft.CheckFunds(money.MustParse("100.01"), money.Zero())
+	assert.EqualValues(t, 422, err.Code)
+	assert.EqualValues(t, REASON_INSUFFICIENT_FUNDS, err.Reason)
+	assert.EqualValues(t, "Insufficient balance in the account, 100.00 is available", err.Message)
+
+	err = noOverdraft.CheckFunds(money.MustParse("95.00"), money.MustParse("10.00"))
+	assert.EqualValues(t, REASON_INSUFFICIENT_FUNDS_FOR_FEES, err.Reason)
+
+	overdraft := Account{Amount: money.MustParse("100.00"), OverdraftLimit: money.MustParse("50.00")}
+	assert.Nil(t, overdraft.CheckFunds(money.MustParse("140.00"), money.MustParse("10.00")))
+	err = overdraft.CheckFunds(money.MustParse("150.01"), money.Zero())
+	assert.EqualValues(t, REASON_OVERDRAFT_LIMIT_EXCEEDED, err.Reason)
+	assert.EqualValues(t, "The transaction would go past the overdraft limit of 50.00, 150.00 is available", err.Message)
+}
+
+func TestSweepAmount(t *testing.T) {
+	// covered by checking alone
+	assert.True(t, SweepAmount(money.MustParse("100.00"), money.MustParse("100.00"), money.MustParse("500.00")).IsZero())
+	// the shortfall
+	assert.Equal(t, money.MustParse("30.00"), SweepAmount(money.MustParse("-10.00"), money.MustParse("20.00"), money.MustParse("500.00")))
+	// no more than saving has
+	assert.Equal(t, money.MustParse("12.50"), SweepAmount(money.MustParse("0.00"), money.MustParse("20.00"), money.MustParse("12.50")))
+	assert.True(t, SweepAmount(money.MustParse("0.00"), money.MustParse("20.00"), money.MustParse("-1.00")).IsZero())
+}
+
+func TestNewSweepLegs(t *testing.T) {
+	withdrawal := Transaction{AccountID: "95473", Amount: money.MustParse("80.00"), TransactionType: WITHDRAWAL, TransactionDate: "2021-03-10 09:02:44"}
+	sweep := NewSweep(withdrawal, "95472", money.MustParse("30.00"))
+	assert.Equal(t, "95472", sweep.Out.AccountID)
+	assert.Equal(t, SWEEP_OUT, sweep.Out.TransactionType)
+	assert.Equal(t, "95473", sweep.In.AccountID)
+	assert.Equal(t, SWEEP_IN, sweep.In.TransactionType)
+	assert.Equal(t, withdrawal.TransactionDate, sweep.In.TransactionDate)
+
+	assert.True(t, IsCustomerDebit(withdrawal))
+	assert.False(t, IsCustomerDebit(sweep.Out))
+	assert.True(t, sweep.Out.IsDebit())
+	assert.False(t, sweep.Out.IsCharge())
+}
+
+func TestOverdraftValidateFor(t *testing.T) {
+	o := NewOverdraft(dto.UpdateOverdraftRequest{Limit: money.MustParse("500.00"), Sweep: true})
+	assert.Nil(t, o.ValidateFor(Account{AccountType: dto.CHECKING}))
+	assert.EqualValues(t, "sweep_not_allowed", o.ValidateFor(Account{AccountType: dto.SAVING}).Reason)
+}
+
+func TestOverdraftFeeOnlyBelowZero(t *testing.T) {
+	checking := FeeSchedule{AccountType: dto.CHECKING, OverdraftFee: money.MustParse("25.00"), OverdraftRate: "18.0000"}
+	assert.True(t, checking.OverdraftFeeFor(money.MustParse("0.00")).IsZero())
+	assert.Equal(t, money.MustParse("25.00"), checking.OverdraftFeeFor(money.MustParse("-0.01")))
+	assert.Nil(t, checking.Validate())
+
+	checking.OverdraftRate = "101"
+	assert.NotNil(t, checking.Validate())
+}
+
+func TestAccrueOverdraft(t *testing.T) {
+	day := time.Date(2021, time.March, 9, 0, 0, 0, 0, time.Local)
+	c := AccrualCandidate{AccountID: "95473", OverdraftRate: "18.0000", Balance: money.MustParse("-1000.00")}
+	assert.True(t, c.HasOverdraftInterest())
+	accrual := AccrueOverdraft(c, day)
+	assert.Equal(t, OVERDRAFT_PRODUCT, accrual.ProductCode)
+	assert.Equal(t, "2021-03-09", accrual.AccrualDate)
+	// 1000 * 0.18 / 365
+	assert.Equal(t, "-0.49315068", accrual.Accrued)
+	assert.Equal(t, money.MustParse("-0.49"), InterestPayment(accrual.Accrued, money.DefaultCurrency))
+
+	c.Balance = money.MustParse("10.00")
+	assert.False(t, c.HasOverdraftInterest())
+	c.Balance, c.OverdraftRate = money.MustParse("-10.00"), "0"
+	assert.False(t, c.HasOverdraftInterest())
+}
+
+func TestOverdraftProductCodeIsKept(t *testing.T) {
+	p := InterestProduct{ProductCode: OVERDRAFT_PRODUCT, APY: "1", Compounding: COMPOUND_MONTHLY, DayCount: DAY_COUNT_ACT_365}
+	assert.NotNil(t, p.Validate())
+}
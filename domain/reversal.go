@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// Reversal records why and by whom a transaction was reversed, in full or in part. A transaction can be reversed
+// more than once until the reversals add up to its amount.
+type Reversal struct {
+	ReversalID            string `db:"reversal_id"`
+	TransactionID         string `db:"transaction_id"`
+	ReversalTransactionID string `db:"reversal_transaction_id"`
+	AccountID             string `db:"account_id"`
+	Amount                money.Money
+	ReasonCode            string `db:"reason_code"`
+	Note                  string
+	CreatedBy             string `db:"created_by"`
+	CreatedAt             string `db:"created_at"`
+	// Requested is the amount asked for, nil reverses what is left of the transaction
+	Requested *money.Money `db:"-"`
+	// Transaction is the reversal_out or reversal_in posted for the reversal
+	Transaction Transaction `db:"-"`
+	// ReversibleAmount is what is left to reverse of the transaction after this reversal
+	ReversibleAmount money.Money `db:"-"`
+	// FeeReversals reverse the fees charged with the transaction, when this reversal reverses the rest of it
+	FeeReversals []Reversal `db:"-"`
+}
+
+// ReversalRepository implements:
+//
+// SaveReversal: posts the reversal of a transaction of an account and records it, and returns it with its id.
+// Reversing the rest of a transaction also reverses the fees charged with it.
+// mockgen -destination=mocks/domain/mock_reversal_repository.go -package=domain github.com/jonathanwamsley/banking/domain ReversalRepository
+type ReversalRepository interface {
+	SaveReversal(Reversal) (*Reversal, *errs.AppError)
+}
+
+// NewReversal converts a reversal request for a transaction of an account
+func NewReversal(accountID string, transactionID string, r dto.ReverseTransactionRequest, createdBy string, now time.Time) Reversal {
+	return Reversal{
+		TransactionID: transactionID,
+		AccountID:     accountID,
+		ReasonCode:    r.ReasonCode,
+		Note:          r.Note,
+		CreatedBy:     createdBy,
+		CreatedAt:     now.Format(dbTSLayout),
+		Requested:     r.Amount,
+	}
+}
+
+// ReversalTypeFor returns the type that reverses the original transaction: money it put in is taken out again
+// and money it took out is put back. A reversal itself can not be reversed, and neither can one leg of a
+// transfer or a sweep, that would create or destroy the money the other leg moved.
+func ReversalTypeFor(original Transaction) (string, *errs.AppError) {
+	if original.IsReversal() {
+		return "", errs.NewConflictError("a reversal can not be reversed").WithReason("reversal_not_reversible")
+	}
+	switch original.TransactionType {
+	case TRANSFER_OUT, TRANSFER_IN, SWEEP_OUT, SWEEP_IN:
+		return "", errs.NewConflictError("one leg of a transfer can not be reversed, transfer the money back instead").
+			WithReason("transfer_not_reversible")
+	}
+	if original.IsDebit() {
+		return REVERSAL_IN, nil
+	}
+	return REVERSAL_OUT, nil
+}
+
+// ReverseAmount is what a reversal of requested reverses of the original transaction, when reversed was already
+// reversed by earlier reversals. No amount reverses all that is left.
+func ReverseAmount(original Transaction, reversed money.Money, requested *money.Money) (money.Money, *errs.AppError) {
+	left := original.Amount.Sub(reversed)
+	if !left.IsPositive() {
+		return money.Money{}, errs.NewConflictError("Transaction is already reversed").WithReason("already_reversed")
+	}
+	if requested == nil {
+		return left, nil
+	}
+	if left.LessThan(*requested) {
+		return money.Money{}, errs.NewValidationError("Only " + left.String() + " of the transaction can still be reversed").
+			WithReason("reversal_exceeds_amount")
+	}
+	return *requested, nil
+}
+
+// NewFeeReversal reverses what is left of a fee charged with the transaction r reverses, for the same reason
+func NewFeeReversal(r Reversal, fee Transaction) Reversal {
+	return Reversal{
+		TransactionID: fee.TransactionID,
+		AccountID:     fee.AccountID,
+		ReasonCode:    r.ReasonCode,
+		Note:          r.Note,
+		CreatedBy:     r.CreatedBy,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// NewReversalTransaction is the transaction that reverses amount of the original transaction, it links back
+// to the original. It is posted whatever the account version is, like a charge.
+func NewReversalTransaction(original Transaction, reversalType string, amount money.Money, transactionDate string) Transaction {
+	return Transaction{
+		AccountID:            original.AccountID,
+		Amount:               amount,
+		TransactionType:      reversalType,
+		TransactionDate:      transactionDate,
+		RelatedTransactionID: sql.NullString{String: original.TransactionID, Valid: true},
+		ReversedType:         original.TransactionType,
+		ExpectedVersion:      ANY_VERSION,
+	}
+}
+
+// ToDTO converts a reversal
+func (r Reversal) ToDTO() dto.ReversalResponse {
+	response := dto.ReversalResponse{
+		ReversalID:            r.ReversalID,
+		TransactionID:         r.TransactionID,
+		ReversalTransactionID: r.ReversalTransactionID,
+		AccountID:             r.AccountID,
+		TransactionType:       r.Transaction.TransactionType,
+		Amount:                r.Amount,
+		ReasonCode:            r.ReasonCode,
+		Note:                  r.Note,
+		NewBalance:            r.Transaction.Balance,
+		ReversibleAmount:      r.ReversibleAmount,
+		CreatedBy:             r.CreatedBy,
+		CreatedAt:             r.CreatedAt,
+	}
+	for _, fee := range r.FeeReversals {
+		response.FeeReversals = append(response.FeeReversals, fee.ToDTO())
+	}
+	return response
+}
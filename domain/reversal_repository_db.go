@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// The query statements
+const (
+	findTransaction = "SELECT transaction_id, account_id, amount, transaction_type, transaction_date, booking_date, value_date, balance, related_transaction_id from transactions where transaction_id = ? and account_id = ?;"
+	sumReversed     = "SELECT COALESCE(SUM(amount), 0) from reversals where transaction_id = ?;"
+	// the fees charged with a transaction link to it, like its reversals do
+	findFees       = "SELECT transaction_id, account_id, amount, transaction_type, transaction_date, booking_date, value_date, balance, related_transaction_id from transactions where related_transaction_id = ? and account_id = ? and transaction_type = 'fee' order by transaction_id;"
+	insertReversal = "INSERT INTO reversals (transaction_id, reversal_transaction_id, account_id, amount, reason_code, note, created_by, created_at) values (?, ?, ?, ?, ?, ?, ?, ?);"
+)
+
+// ReversalRepositoryDB holds the sql client connection
+type ReversalRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewReversalRepositoryDB creates a new ReversalRepositoryDB to call sql methods
+func NewReversalRepositoryDB(client *sqlx.DB) ReversalRepositoryDB {
+	return ReversalRepositoryDB{client}
+}
+
+// SaveReversal posts the reversal transaction and records the reversal in one db transaction. Every reversal of a
+// transaction posts to the account of the transaction, so the account row is locked before what was already
+// reversed is summed, and two reversals arriving at the same time can not reverse more than the transaction.
+//
+// A reversal that reverses the rest of a transaction also reverses what is left of the fees charged with it in
+// the same db transaction, since they were only charged because of it. A partial reversal leaves the fees.
+func (d ReversalRepositoryDB) SaveReversal(r Reversal) (*Reversal, *errs.AppError) {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for a reversal: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	if appErr := lockInOrder(tx, r.AccountID); appErr != nil {
+		tx.Rollback()
+		return nil, appErr
+	}
+	if appErr := reverse(tx, &r); appErr != nil {
+		tx.Rollback()
+		return nil, appErr
+	}
+	if r.ReversibleAmount.IsZero() {
+		fees := make([]Transaction, 0)
+		if err = tx.Select(&fees, findFees, r.TransactionID, r.AccountID); err != nil {
+			tx.Rollback()
+			logger.Error("Error while fetching the fees of the reversed transaction: " + err.Error())
+			return nil, errs.NewUnexpectedError("Unexpected database error")
+		}
+		for _, fee := range fees {
+			feeReversal := NewFeeReversal(r, fee)
+			appErr := reverse(tx, &feeReversal)
+			if appErr != nil && appErr.Reason == "already_reversed" {
+				continue
+			}
+			if appErr != nil {
+				tx.Rollback()
+				return nil, appErr
+			}
+			r.FeeReversals = append(r.FeeReversals, feeReversal)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting reversal: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &r, nil
+}
+
+// reverse posts the reversal of r.TransactionID and records it, filling in its amount, transaction and id. The
+// account of the transaction must already be locked.
+func reverse(tx *sqlx.Tx, r *Reversal) *errs.AppError {
+	var original Transaction
+	if err := tx.Get(&original, findTransaction, r.TransactionID, r.AccountID); err != nil {
+		if err == sql.ErrNoRows {
+			return errs.NewNotFoundError("Transaction not found")
+		}
+		logger.Error("Error while fetching the transaction to reverse: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	reversalType, appErr := ReversalTypeFor(original)
+	if appErr != nil {
+		return appErr
+	}
+	reversed := money.Zero()
+	if err := tx.Get(&reversed, sumReversed, r.TransactionID); err != nil {
+		logger.Error("Error while summing reversals: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if r.Amount, appErr = ReverseAmount(original, reversed, r.Requested); appErr != nil {
+		return appErr
+	}
+
+	r.Transaction = NewReversalTransaction(original, reversalType, r.Amount, r.CreatedAt)
+	if appErr = postTransaction(tx, &r.Transaction); appErr != nil {
+		return appErr
+	}
+	r.ReversalTransactionID = r.Transaction.TransactionID
+
+	result, err := tx.Exec(insertReversal, r.TransactionID, r.ReversalTransactionID, r.AccountID, r.Amount, r.ReasonCode, r.Note, r.CreatedBy, r.CreatedAt)
+	if err != nil {
+		logger.Error("Error while saving reversal: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Error while getting the last reversal id: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	r.ReversalID = strconv.FormatInt(id, 10)
+	r.ReversibleAmount = original.Amount.Sub(reversed).Sub(r.Amount)
+	return nil
+}
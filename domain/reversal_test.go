@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReversalTypeFor(t *testing.T) {
+	in := []string{WITHDRAWAL, FEE, OVERDRAFT_INTEREST}
+	for _, transactionType := range in {
+		reversalType, err := ReversalTypeFor(Transaction{TransactionType: transactionType})
+		assert.Nil(t, err)
+		assert.Equal(t, REVERSAL_IN, reversalType, transactionType)
+	}
+	out := []string{DEPOSIT, INTEREST}
+	for _, transactionType := range out {
+		reversalType, err := ReversalTypeFor(Transaction{TransactionType: transactionType})
+		assert.Nil(t, err)
+		assert.Equal(t, REVERSAL_OUT, reversalType, transactionType)
+	}
+
+	_, err := ReversalTypeFor(Transaction{TransactionType: REVERSAL_OUT})
+	assert.Equal(t, "reversal_not_reversible", err.Reason)
+}
+
+func TestReversalTypeForRefusesOneLegOfATransferOrSweep(t *testing.T) {
+	for _, transactionType := range []string{TRANSFER_OUT, TRANSFER_IN, SWEEP_OUT, SWEEP_IN} {
+		_, err := ReversalTypeFor(Transaction{TransactionType: transactionType})
+		assert.EqualValues(t, 409, err.Code, transactionType)
+		assert.Equal(t, "transfer_not_reversible", err.Reason, transactionType)
+	}
+}
+
+func TestReverseAmount(t *testing.T) {
+	original := Transaction{Amount: money.MustParse("100.00")}
+
+	amount, err := ReverseAmount(original, money.MustParse("30.00"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, money.MustParse("70.00"), amount)
+
+	partial := money.MustParse("70.00")
+	amount, err = ReverseAmount(original, money.MustParse("30.00"), &partial)
+	assert.Nil(t, err)
+	assert.Equal(t, partial, amount)
+
+	more := money.MustParse("70.01")
+	_, err = ReverseAmount(original, money.MustParse("30.00"), &more)
+	assert.Equal(t, "reversal_exceeds_amount", err.Reason)
+
+	_, err = ReverseAmount(original, money.MustParse("100.00"), nil)
+	assert.EqualValues(t, 409, err.Code)
+	assert.Equal(t, "already_reversed", err.Reason)
+}
+
+func TestNewReversalTransactionLinksTheOriginal(t *testing.T) {
+	original := Transaction{TransactionID: "12", AccountID: "95470", Amount: money.MustParse("100.00"), TransactionType: DEPOSIT}
+	reversal := NewReversalTransaction(original, REVERSAL_OUT, money.MustParse("40.00"), "2021-03-10 09:00:00")
+	assert.Equal(t, "12", reversal.RelatedTransactionID.String)
+	assert.Equal(t, DEPOSIT, reversal.ReversedType)
+	assert.Equal(t, ANY_VERSION, reversal.ExpectedVersion)
+	assert.True(t, reversal.IsCharge())
+}
+
+func TestNewFeeReversalReversesTheRestOfTheFeeForTheSameReason(t *testing.T) {
+	r := Reversal{TransactionID: "12", AccountID: "95470", ReasonCode: "fraud", Note: "card stolen", CreatedBy: "admin", CreatedAt: "2021-03-10 09:00:00"}
+	fee := Transaction{TransactionID: "13", AccountID: "95470", Amount: money.MustParse("25.00"), TransactionType: FEE}
+
+	feeReversal := NewFeeReversal(r, fee)
+	assert.Equal(t, "13", feeReversal.TransactionID)
+	assert.Equal(t, "fraud", feeReversal.ReasonCode)
+	assert.Equal(t, "card stolen", feeReversal.Note)
+	assert.Nil(t, feeReversal.Requested)
+
+	r.FeeReversals = []Reversal{feeReversal}
+	assert.Equal(t, "13", r.ToDTO().FeeReversals[0].TransactionID)
+}
@@ -0,0 +1,426 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// the statuses of a scheduled payment, completed and cancelled ones never run again
+//
+// active: the payment runs on its next run date
+// suspended: the payment is paused by the customer, or after a run that can not be fixed by retrying
+// completed: the schedule ended
+// cancelled: the customer cancelled the schedule
+const (
+	PAYMENT_ACTIVE    = "active"
+	PAYMENT_SUSPENDED = "suspended"
+	PAYMENT_COMPLETED = "completed"
+	PAYMENT_CANCELLED = "cancelled"
+)
+
+// the statuses of a run of a scheduled payment
+//
+// running: the run was claimed and the payment is being made, a run that stays running was interrupted
+// and is reclaimed once it is stale
+// succeeded: the payment was made
+// declined: the account did not have the funds
+// failed: the payment could not be made for another reason
+const (
+	RUN_RUNNING   = "running"
+	RUN_SUCCEEDED = "succeeded"
+	RUN_DECLINED  = "declined"
+	RUN_FAILED    = "failed"
+)
+
+// STALE_RUN_AFTER is how long a run may stay running before another scheduler reclaims it. A payment takes well
+// under a second, a run still running after this was interrupted or its outcome could not be recorded.
+const STALE_RUN_AFTER = 15 * time.Minute
+
+// REASON_RUN_INTERRUPTED is the reason of a run that was reclaimed after it stayed running. Its payment may or may
+// not have been made, so it is not made again and the schedule is suspended until the customer checks it.
+const REASON_RUN_INTERRUPTED = "run_interrupted"
+
+// SUBJECT_SCHEDULED_PAYMENT names a scheduled payment in version errors
+const SUBJECT_SCHEDULED_PAYMENT = "scheduled payment"
+
+// ScheduledPayment is a standing order: a transfer to ToAccountID, or a withdrawal when it is not set, made from
+// an account on every date of its schedule. NextRunDate is the date of the next payment, and Attempts how often
+// it was declined so far. A payment that is retried runs again Attempts days after its date.
+type ScheduledPayment struct {
+	PaymentID   string         `db:"payment_id"`
+	CustomerID  string         `db:"customer_id"`
+	AccountID   string         `db:"account_id"`
+	ToAccountID sql.NullString `db:"to_account_id"`
+	Amount      money.Money
+	Description string
+	Frequency   string
+	DayOfMonth  sql.NullInt64  `db:"day_of_month"`
+	StartDate   string         `db:"start_date"`
+	EndDate     sql.NullString `db:"end_date"`
+	NextRunDate sql.NullString `db:"next_run_date"`
+	Attempts    int
+	RetryPolicy string `db:"retry_policy"`
+	MaxRetries  int    `db:"max_retries"`
+	Status      string
+	CreatedAt   string `db:"created_at"`
+	Version     int
+}
+
+// PaymentRun is one attempt at the payment of a scheduled date. A payment date is attempted once per attempt
+// number, so two schedulers can never make the same payment twice.
+type PaymentRun struct {
+	RunID         string `db:"run_id"`
+	PaymentID     string `db:"payment_id"`
+	ScheduledDate string `db:"scheduled_date"`
+	Attempt       int
+	Status        string
+	Reason        string
+	TransactionID sql.NullString `db:"transaction_id"`
+	RanAt         string         `db:"ran_at"`
+}
+
+// ScheduledPaymentRepository implements:
+//
+// FindPayments: returns the scheduled payments of a customer, newest first
+// FindPayment: returns a scheduled payment of a customer
+// SavePayment: stores a new scheduled payment and returns it with its id
+// UpdatePayment: stores the changes of a scheduled payment when the version matches
+// FindRuns: returns the runs of a scheduled payment, newest first
+// DuePayments: returns the active scheduled payments that are due to be attempted on a date
+// ClaimRun: records that a run started, false is returned when the run was already claimed
+// ReclaimRun: takes over the claim of a run that is still running since before a time, false is returned when
+// there is none
+// FinishRun: records the outcome of a run and moves the scheduled payment to where the run left it
+// mockgen -destination=mocks/domain/mock_scheduled_payment_repository.go -package=domain github.com/jonathanwamsley/banking/domain ScheduledPaymentRepository
+type ScheduledPaymentRepository interface {
+	FindPayments(customerID string) ([]ScheduledPayment, *errs.AppError)
+	FindPayment(customerID string, paymentID string) (*ScheduledPayment, *errs.AppError)
+	SavePayment(ScheduledPayment) (*ScheduledPayment, *errs.AppError)
+	UpdatePayment(p ScheduledPayment, version int) *errs.AppError
+	FindRuns(paymentID string) ([]PaymentRun, *errs.AppError)
+	DuePayments(date string) ([]ScheduledPayment, *errs.AppError)
+	ClaimRun(PaymentRun) (*PaymentRun, bool, *errs.AppError)
+	ReclaimRun(r PaymentRun, staleBefore string) (*PaymentRun, bool, *errs.AppError)
+	FinishRun(run PaymentRun, ran ScheduledPayment, next ScheduledPayment) *errs.AppError
+}
+
+// NewScheduledPayment converts a scheduled payment request, with its first run date. The start date may not be
+// before the day of now, which is in the bank's time zone, and the schedule must have at least one date before it ends.
+func NewScheduledPayment(r dto.CreateScheduledPaymentRequest, now time.Time) (ScheduledPayment, *errs.AppError) {
+	today := startOfDay(now)
+	p := ScheduledPayment{
+		CustomerID:  r.CustomerID,
+		AccountID:   r.AccountID,
+		Amount:      r.Amount,
+		Description: r.Description,
+		Frequency:   r.Frequency,
+		StartDate:   r.StartDate,
+		RetryPolicy: r.RetryPolicy,
+		MaxRetries:  dto.DEFAULT_MAX_RETRIES,
+		Status:      PAYMENT_ACTIVE,
+		CreatedAt:   now.Format(dbTSLayout),
+		Version:     1,
+	}
+	if r.ToAccountID != "" {
+		p.ToAccountID = sql.NullString{String: r.ToAccountID, Valid: true}
+	}
+	if p.RetryPolicy == "" {
+		p.RetryPolicy = dto.RETRY_RETRY
+	}
+	if r.MaxRetries != nil {
+		p.MaxRetries = *r.MaxRetries
+	}
+	if p.StartDate == "" {
+		p.StartDate = FormatRunDate(today)
+	}
+	start, err := time.ParseInLocation(dateLayout, p.StartDate, wallClock)
+	if err != nil {
+		return p, errs.NewValidationError("start_date should look like " + dateLayout)
+	}
+	if start.Before(today) {
+		return p, errs.NewValidationError("start_date can not be in the past")
+	}
+	if r.Frequency == dto.FREQUENCY_MONTHLY {
+		day := r.DayOfMonth
+		if day == 0 {
+			day = start.Day()
+		}
+		p.DayOfMonth = sql.NullInt64{Int64: int64(day), Valid: true}
+	}
+	if r.EndDate != "" {
+		if _, err = time.ParseInLocation(dateLayout, r.EndDate, wallClock); err != nil {
+			return p, errs.NewValidationError("end_date should look like " + dateLayout)
+		}
+		p.EndDate = sql.NullString{String: r.EndDate, Valid: true}
+	}
+	p.NextRunDate = p.within(p.first(start))
+	if !p.NextRunDate.Valid {
+		return p, errs.NewValidationError("the schedule ends before its first payment")
+	}
+	return p, nil
+}
+
+// Apply changes a scheduled payment as the update asks. Resuming a suspended payment moves it to its first run
+// date from the day of now in the bank's time zone, the dates it was suspended for are not paid.
+func (p ScheduledPayment) Apply(r dto.UpdateScheduledPaymentRequest, now time.Time) (ScheduledPayment, *errs.AppError) {
+	if err := p.CheckOpen(); err != nil {
+		return p, err
+	}
+	p.Amount = r.Amount
+	p.Description = r.Description
+	if r.RetryPolicy != "" {
+		p.RetryPolicy = r.RetryPolicy
+	}
+	if r.MaxRetries != nil {
+		p.MaxRetries = *r.MaxRetries
+	}
+	p.EndDate = sql.NullString{}
+	if r.EndDate != "" {
+		if _, err := time.ParseInLocation(dateLayout, r.EndDate, wallClock); err != nil {
+			return p, errs.NewValidationError("end_date should look like " + dateLayout)
+		}
+		if r.EndDate < p.StartDate {
+			return p, errs.NewValidationError("end_date must not be before start_date")
+		}
+		p.EndDate = sql.NullString{String: r.EndDate, Valid: true}
+	}
+	if p.Status == PAYMENT_SUSPENDED && r.Status == PAYMENT_ACTIVE {
+		p.NextRunDate = p.from(startOfDay(now))
+		p.Attempts = 0
+	}
+	p.Status = r.Status
+	if p.NextRunDate.Valid {
+		next := parseDate(p.NextRunDate.String)
+		p.NextRunDate = p.within(&next)
+	}
+	if !p.NextRunDate.Valid {
+		p.Status = PAYMENT_COMPLETED
+	}
+	return p, nil
+}
+
+// CheckOpen checks the scheduled payment did not end, a completed or cancelled schedule can not change
+func (p ScheduledPayment) CheckOpen() *errs.AppError {
+	if p.Status == PAYMENT_COMPLETED || p.Status == PAYMENT_CANCELLED {
+		return errs.NewConflictError("scheduled payment is " + p.Status).WithReason("scheduled_payment_" + p.Status)
+	}
+	return nil
+}
+
+// Cancel ends the schedule, the payment never runs again
+func (p ScheduledPayment) Cancel() (ScheduledPayment, *errs.AppError) {
+	if err := p.CheckOpen(); err != nil {
+		return p, err
+	}
+	p.Status = PAYMENT_CANCELLED
+	p.NextRunDate = sql.NullString{}
+	p.Attempts = 0
+	return p, nil
+}
+
+// AttemptDate is the date the next attempt is due, the run date plus a day for every declined attempt
+func (p ScheduledPayment) AttemptDate() time.Time {
+	return parseDate(p.NextRunDate.String).AddDate(0, 0, p.Attempts)
+}
+
+// NewPaymentRun starts the next attempt at the payment of its next run date
+func (p ScheduledPayment) NewPaymentRun(now time.Time) PaymentRun {
+	return PaymentRun{
+		PaymentID:     p.PaymentID,
+		ScheduledDate: p.NextRunDate.String,
+		Attempt:       p.Attempts + 1,
+		Status:        RUN_RUNNING,
+		RanAt:         now.Format(dbTSLayout),
+	}
+}
+
+// Settle records the outcome of a run on the run and returns the scheduled payment as the run leaves it.
+//
+// A payment that was made moves on to the next date. A payment declined for funds is retried the next day as
+// the retry policy allows, and is missed after that. An unexpected error is retried the same way, it is likely
+// to pass. Anything else, like a frozen or closed account, suspends the schedule until the customer resumes it.
+func (p ScheduledPayment) Settle(run *PaymentRun, transactionID string, appErr *errs.AppError) ScheduledPayment {
+	switch {
+	case appErr == nil:
+		run.Status = RUN_SUCCEEDED
+		run.TransactionID = sql.NullString{String: transactionID, Valid: true}
+		return p.advance()
+	case IsFundsDecline(appErr):
+		run.Status = RUN_DECLINED
+		run.Reason = appErr.Reason
+	case appErr.Code >= 500:
+		run.Status = RUN_FAILED
+		run.Reason = "unexpected_error"
+	default:
+		run.Status = RUN_FAILED
+		run.Reason = appErr.Reason
+		if run.Reason == "" {
+			run.Reason = appErr.Message
+		}
+		next := p.advance()
+		if next.Status == PAYMENT_ACTIVE {
+			next.Status = PAYMENT_SUSPENDED
+		}
+		return next
+	}
+
+	if p.RetryPolicy != dto.RETRY_SKIP && p.Attempts < p.MaxRetries {
+		p.Attempts++
+		return p
+	}
+	next := p.advance()
+	if p.RetryPolicy == dto.RETRY_THEN_SUSPEND && next.Status == PAYMENT_ACTIVE {
+		next.Status = PAYMENT_SUSPENDED
+	}
+	return next
+}
+
+// StaleRunBefore is the ran_at a run that is still running at now must be older than to be reclaimed
+func StaleRunBefore(now time.Time) string {
+	return now.Add(-STALE_RUN_AFTER).Format(dbTSLayout)
+}
+
+// Interrupt settles a run that was reclaimed after it stayed running. It fails with REASON_RUN_INTERRUPTED and the
+// schedule moves on suspended, like after any failure that is not a decline.
+func (p ScheduledPayment) Interrupt(run *PaymentRun) ScheduledPayment {
+	return p.Settle(run, "", errs.NewConflictError("the run was interrupted").WithReason(REASON_RUN_INTERRUPTED))
+}
+
+// IsFundsDecline checks if a debit was declined because the account did not have the funds
+func IsFundsDecline(appErr *errs.AppError) bool {
+	switch appErr.Reason {
+	case REASON_INSUFFICIENT_FUNDS, REASON_OVERDRAFT_LIMIT_EXCEEDED, REASON_INSUFFICIENT_FUNDS_FOR_FEES:
+		return true
+	}
+	return false
+}
+
+// advance moves the payment to the date after its next run date, it is completed when the schedule has no more
+func (p ScheduledPayment) advance() ScheduledPayment {
+	p.Attempts = 0
+	p.NextRunDate = p.within(p.after(parseDate(p.NextRunDate.String)))
+	if !p.NextRunDate.Valid {
+		p.Status = PAYMENT_COMPLETED
+	}
+	return p
+}
+
+// first is the first date of the schedule on or after its start date
+func (p ScheduledPayment) first(start time.Time) *time.Time {
+	var first time.Time
+	switch p.Frequency {
+	case dto.FREQUENCY_MONTHLY:
+		first = dayOfMonth(start.Year(), start.Month(), int(p.DayOfMonth.Int64))
+		if first.Before(start) {
+			first = dayOfMonth(start.Year(), start.Month()+1, int(p.DayOfMonth.Int64))
+		}
+	case dto.FREQUENCY_END_OF_MONTH:
+		first = dayOfMonth(start.Year(), start.Month(), 31)
+	default:
+		first = start
+	}
+	return &first
+}
+
+// after is the date of the schedule that follows date, nil when the payment is only made once
+func (p ScheduledPayment) after(date time.Time) *time.Time {
+	var next time.Time
+	switch p.Frequency {
+	case dto.FREQUENCY_DAILY:
+		next = date.AddDate(0, 0, 1)
+	case dto.FREQUENCY_WEEKLY:
+		next = date.AddDate(0, 0, 7)
+	case dto.FREQUENCY_MONTHLY:
+		next = dayOfMonth(date.Year(), date.Month()+1, int(p.DayOfMonth.Int64))
+	case dto.FREQUENCY_END_OF_MONTH:
+		next = dayOfMonth(date.Year(), date.Month()+1, 31)
+	default:
+		return nil
+	}
+	return &next
+}
+
+// from is the first date of the schedule that is not before day, still subject to the end date
+func (p ScheduledPayment) from(day time.Time) sql.NullString {
+	next := parseDate(p.NextRunDate.String)
+	for next.Before(day) {
+		following := p.after(next)
+		if following == nil {
+			return sql.NullString{}
+		}
+		next = *following
+	}
+	return sql.NullString{String: FormatRunDate(next), Valid: true}
+}
+
+// within returns the date when it is on or before the end date of the schedule
+func (p ScheduledPayment) within(date *time.Time) sql.NullString {
+	if date == nil || p.EndDate.Valid && FormatRunDate(*date) > p.EndDate.String {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: FormatRunDate(*date), Valid: true}
+}
+
+// ToDTO converts a scheduled payment
+func (p ScheduledPayment) ToDTO() dto.ScheduledPaymentResponse {
+	return dto.ScheduledPaymentResponse{
+		PaymentID:   p.PaymentID,
+		CustomerID:  p.CustomerID,
+		AccountID:   p.AccountID,
+		ToAccountID: p.ToAccountID.String,
+		Amount:      p.Amount,
+		Description: p.Description,
+		Frequency:   p.Frequency,
+		DayOfMonth:  int(p.DayOfMonth.Int64),
+		StartDate:   p.StartDate,
+		EndDate:     p.EndDate.String,
+		NextRunDate: p.NextRunDate.String,
+		Attempts:    p.Attempts,
+		RetryPolicy: p.RetryPolicy,
+		MaxRetries:  p.MaxRetries,
+		Status:      p.Status,
+		CreatedAt:   p.CreatedAt,
+		Version:     p.Version,
+	}
+}
+
+// ToDTO converts a run of a scheduled payment
+func (r PaymentRun) ToDTO() dto.ScheduledPaymentRunResponse {
+	return dto.ScheduledPaymentRunResponse{
+		RunID:         r.RunID,
+		PaymentID:     r.PaymentID,
+		ScheduledDate: r.ScheduledDate,
+		Attempt:       r.Attempt,
+		Status:        r.Status,
+		Reason:        r.Reason,
+		TransactionID: r.TransactionID.String,
+		RanAt:         r.RanAt,
+	}
+}
+
+// dayOfMonth is the day of a month, or the last day of the month when it is shorter. The month may be
+// past December, it rolls over into the next year.
+func dayOfMonth(year int, month time.Month, day int) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, wallClock)
+	if day > last.Day() {
+		day = last.Day()
+	}
+	return time.Date(last.Year(), last.Month(), day, 0, 0, 0, 0, wallClock)
+}
+
+// startOfDay is midnight of the day of now in the time zone of now, read on the wall clock like the dates of a
+// schedule
+func startOfDay(now time.Time) time.Time {
+	year, month, day := now.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, wallClock)
+}
+
+// parseDate reads a date that was stored by the db or written by FormatRunDate
+func parseDate(date string) time.Time {
+	day, _ := time.ParseInLocation(dateLayout, date, wallClock)
+	return day
+}
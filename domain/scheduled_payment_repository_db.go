@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// The query statements
+const (
+	paymentColumns = "payment_id, customer_id, account_id, to_account_id, amount, description, frequency, day_of_month, start_date, end_date, next_run_date, attempts, retry_policy, max_retries, status, created_at, version"
+	findPayments   = "SELECT " + paymentColumns + " from scheduled_payments where customer_id = ? order by payment_id desc;"
+	findPayment    = "SELECT " + paymentColumns + " from scheduled_payments where payment_id = ? and customer_id = ?;"
+	duePayments    = "SELECT " + paymentColumns + " from scheduled_payments where status = ? and DATE_ADD(next_run_date, INTERVAL attempts DAY) <= ? order by payment_id;"
+	insertPayment  = "INSERT INTO scheduled_payments (customer_id, account_id, to_account_id, amount, description, frequency, day_of_month, start_date, end_date, next_run_date, attempts, retry_policy, max_retries, status, created_at, version) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);"
+	updatePayment  = "UPDATE scheduled_payments SET amount = ?, description = ?, end_date = ?, next_run_date = ?, attempts = ?, retry_policy = ?, max_retries = ?, status = ?, version = version + 1 where payment_id = ? and version = ?;"
+	// a run only moves the payment on when nobody else did, and never reactivates a payment that was suspended
+	// or cancelled while it ran
+	advancePayment = "UPDATE scheduled_payments SET next_run_date = ?, attempts = ?, status = CASE WHEN status = ? THEN ? ELSE status END, version = version + 1 where payment_id = ? and next_run_date <=> ? and attempts = ?;"
+	runColumns     = "run_id, payment_id, scheduled_date, attempt, status, reason, transaction_id, ran_at"
+	findRuns       = "SELECT " + runColumns + " from scheduled_payment_runs where payment_id = ? order by run_id desc;"
+	insertRun      = "INSERT INTO scheduled_payment_runs (payment_id, scheduled_date, attempt, status, reason, ran_at) values (?, ?, ?, ?, ?, ?);"
+	findRun        = "SELECT " + runColumns + " from scheduled_payment_runs where payment_id = ? and scheduled_date = ? and attempt = ?;"
+	// only one scheduler moves ran_at of a stale run forward, the others find it is no longer stale
+	reclaimRun = "UPDATE scheduled_payment_runs SET ran_at = ? where payment_id = ? and scheduled_date = ? and attempt = ? and status = ? and ran_at < ?;"
+	finishRun  = "UPDATE scheduled_payment_runs SET status = ?, reason = ?, transaction_id = ? where run_id = ?;"
+)
+
+// ScheduledPaymentRepositoryDB holds the sql client connection
+type ScheduledPaymentRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewScheduledPaymentRepositoryDB creates a new ScheduledPaymentRepositoryDB to call sql methods
+func NewScheduledPaymentRepositoryDB(client *sqlx.DB) ScheduledPaymentRepositoryDB {
+	return ScheduledPaymentRepositoryDB{client}
+}
+
+// FindPayments returns the scheduled payments of a customer
+func (d ScheduledPaymentRepositoryDB) FindPayments(customerID string) ([]ScheduledPayment, *errs.AppError) {
+	payments := make([]ScheduledPayment, 0)
+	if err := d.client.Select(&payments, findPayments, customerID); err != nil {
+		logger.Error("Error while querying scheduled_payments table " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return payments, nil
+}
+
+// FindPayment returns a scheduled payment of a customer
+func (d ScheduledPaymentRepositoryDB) FindPayment(customerID string, paymentID string) (*ScheduledPayment, *errs.AppError) {
+	var p ScheduledPayment
+	if err := d.client.Get(&p, findPayment, paymentID, customerID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("Scheduled payment not found")
+		}
+		logger.Error("Error while scanning scheduled payment " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &p, nil
+}
+
+// SavePayment stores a new scheduled payment
+func (d ScheduledPaymentRepositoryDB) SavePayment(p ScheduledPayment) (*ScheduledPayment, *errs.AppError) {
+	result, err := d.client.Exec(insertPayment, p.CustomerID, p.AccountID, p.ToAccountID, p.Amount, p.Description, p.Frequency,
+		p.DayOfMonth, p.StartDate, p.EndDate, p.NextRunDate, p.Attempts, p.RetryPolicy, p.MaxRetries, p.Status, p.CreatedAt, p.Version)
+	if err != nil {
+		logger.Error("Error while saving scheduled payment: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Error while getting the last scheduled payment id: " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	p.PaymentID = strconv.FormatInt(id, 10)
+	return &p, nil
+}
+
+// UpdatePayment stores the changes of a scheduled payment, it fails when the payment is no longer at version
+func (d ScheduledPaymentRepositoryDB) UpdatePayment(p ScheduledPayment, version int) *errs.AppError {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for a scheduled payment: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if appErr := execVersioned(tx, SUBJECT_SCHEDULED_PAYMENT, updatePayment, p.Amount, p.Description, p.EndDate, p.NextRunDate,
+		p.Attempts, p.RetryPolicy, p.MaxRetries, p.Status, p.PaymentID, version); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting scheduled payment: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// FindRuns returns the runs of a scheduled payment
+func (d ScheduledPaymentRepositoryDB) FindRuns(paymentID string) ([]PaymentRun, *errs.AppError) {
+	runs := make([]PaymentRun, 0)
+	if err := d.client.Select(&runs, findRuns, paymentID); err != nil {
+		logger.Error("Error while querying scheduled_payment_runs table " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return runs, nil
+}
+
+// DuePayments returns the active scheduled payments whose next attempt is on or before date
+func (d ScheduledPaymentRepositoryDB) DuePayments(date string) ([]ScheduledPayment, *errs.AppError) {
+	payments := make([]ScheduledPayment, 0)
+	if err := d.client.Select(&payments, duePayments, PAYMENT_ACTIVE, date); err != nil {
+		logger.Error("Error while querying due scheduled payments " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return payments, nil
+}
+
+// ClaimRun stores a running run. The unique key on the payment, date and attempt lets only one scheduler claim
+// an attempt, the others get false and leave the payment alone.
+func (d ScheduledPaymentRepositoryDB) ClaimRun(r PaymentRun) (*PaymentRun, bool, *errs.AppError) {
+	result, err := d.client.Exec(insertRun, r.PaymentID, r.ScheduledDate, r.Attempt, r.Status, r.Reason, r.RanAt)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlDuplicateEntry {
+			return nil, false, nil
+		}
+		logger.Error("Error while claiming scheduled payment run: " + err.Error())
+		return nil, false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Error while getting the last scheduled payment run id: " + err.Error())
+		return nil, false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	r.RunID = strconv.FormatInt(id, 10)
+	return &r, true, nil
+}
+
+// ReclaimRun takes over the claim of the attempt r when it is still running since before staleBefore, because the
+// scheduler that claimed it stopped or could not record how it ended. The run keeps its id and gets r.RanAt.
+func (d ScheduledPaymentRepositoryDB) ReclaimRun(r PaymentRun, staleBefore string) (*PaymentRun, bool, *errs.AppError) {
+	result, err := d.client.Exec(reclaimRun, r.RanAt, r.PaymentID, r.ScheduledDate, r.Attempt, RUN_RUNNING, staleBefore)
+	if err != nil {
+		logger.Error("Error while reclaiming scheduled payment run: " + err.Error())
+		return nil, false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	reclaimed, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("Error while counting reclaimed scheduled payment runs: " + err.Error())
+		return nil, false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	if reclaimed == 0 {
+		return nil, false, nil
+	}
+	var run PaymentRun
+	if err = d.client.Get(&run, findRun, r.PaymentID, r.ScheduledDate, r.Attempt); err != nil {
+		logger.Error("Error while scanning reclaimed scheduled payment run: " + err.Error())
+		return nil, false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &run, true, nil
+}
+
+// FinishRun records the outcome of a run and moves the payment from where it ran to next in one db transaction.
+// A payment the customer changed while it ran keeps the customer's change, the run is still recorded.
+func (d ScheduledPaymentRepositoryDB) FinishRun(r PaymentRun, ran ScheduledPayment, next ScheduledPayment) *errs.AppError {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for a scheduled payment run: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if _, err = tx.Exec(finishRun, r.Status, r.Reason, r.TransactionID, r.RunID); err != nil {
+		tx.Rollback()
+		logger.Error("Error while finishing scheduled payment run: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	result, err := tx.Exec(advancePayment, next.NextRunDate, next.Attempts, PAYMENT_ACTIVE, next.Status, ran.PaymentID, ran.NextRunDate, ran.Attempts)
+	if err != nil {
+		tx.Rollback()
+		logger.Error("Error while advancing scheduled payment: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if advanced, err := result.RowsAffected(); err == nil && advanced == 0 {
+		logger.Info("scheduled payment " + ran.PaymentID + " was changed while it ran, it keeps the change")
+	}
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		logger.Error("Error while commiting scheduled payment run: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func scheduledPaymentRequest(frequency string, startDate string) dto.CreateScheduledPaymentRequest {
+	return dto.CreateScheduledPaymentRequest{
+		CustomerID:  "2001",
+		AccountID:   "95472",
+		ToAccountID: "95473",
+		Amount:      money.MustParse("250.00"),
+		Frequency:   frequency,
+		StartDate:   startDate,
+	}
+}
+
+func TestNewScheduledPaymentFirstRunDate(t *testing.T) {
+	now := time.Date(2021, time.January, 15, 9, 0, 0, 0, time.Local)
+	cases := []struct {
+		frequency  string
+		dayOfMonth int
+		start      string
+		first      string
+	}{
+		{dto.FREQUENCY_ONCE, 0, "2021-01-20", "2021-01-20"},
+		{dto.FREQUENCY_WEEKLY, 0, "", "2021-01-15"},
+		{dto.FREQUENCY_MONTHLY, 0, "2021-01-20", "2021-01-20"},
+		{dto.FREQUENCY_MONTHLY, 10, "2021-01-20", "2021-02-10"},
+		{dto.FREQUENCY_MONTHLY, 31, "2021-02-01", "2021-02-28"},
+		{dto.FREQUENCY_END_OF_MONTH, 0, "2021-02-01", "2021-02-28"},
+	}
+	for _, c := range cases {
+		r := scheduledPaymentRequest(c.frequency, c.start)
+		r.DayOfMonth = c.dayOfMonth
+		p, err := NewScheduledPayment(r, now)
+		assert.Nil(t, err, c.frequency)
+		assert.Equal(t, c.first, p.NextRunDate.String, c.frequency)
+	}
+}
+
+func TestNewScheduledPaymentDefaults(t *testing.T) {
+	now := time.Date(2021, time.January, 15, 9, 0, 0, 0, time.Local)
+	p, err := NewScheduledPayment(scheduledPaymentRequest(dto.FREQUENCY_MONTHLY, ""), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "2021-01-15", p.StartDate)
+	assert.Equal(t, int64(15), p.DayOfMonth.Int64)
+	assert.Equal(t, dto.RETRY_RETRY, p.RetryPolicy)
+	assert.Equal(t, dto.DEFAULT_MAX_RETRIES, p.MaxRetries)
+	assert.Equal(t, PAYMENT_ACTIVE, p.Status)
+	assert.Equal(t, "95473", p.ToAccountID.String)
+}
+
+func TestNewScheduledPaymentRejectsDates(t *testing.T) {
+	now := time.Date(2021, time.January, 15, 9, 0, 0, 0, time.Local)
+	_, err := NewScheduledPayment(scheduledPaymentRequest(dto.FREQUENCY_ONCE, "2021-01-14"), now)
+	assert.Equal(t, "start_date can not be in the past", err.Message)
+
+	_, err = NewScheduledPayment(scheduledPaymentRequest(dto.FREQUENCY_ONCE, "15/01/2021"), now)
+	assert.EqualValues(t, 422, err.Code)
+
+	r := scheduledPaymentRequest(dto.FREQUENCY_END_OF_MONTH, "2021-01-20")
+	r.EndDate = "2021-01-30"
+	_, err = NewScheduledPayment(r, now)
+	assert.Equal(t, "the schedule ends before its first payment", err.Message)
+}
+
+func TestScheduledPaymentAdvance(t *testing.T) {
+	monthly := ScheduledPayment{Frequency: dto.FREQUENCY_MONTHLY, DayOfMonth: sql.NullInt64{Int64: 31, Valid: true}, Status: PAYMENT_ACTIVE}
+	monthly.NextRunDate = sql.NullString{String: "2021-01-31", Valid: true}
+	var dates []string
+	for i := 0; i < 3; i++ {
+		monthly = monthly.advance()
+		dates = append(dates, monthly.NextRunDate.String)
+	}
+	// the day of month is kept after a short month
+	assert.Equal(t, []string{"2021-02-28", "2021-03-31", "2021-04-30"}, dates)
+
+	endOfMonth := ScheduledPayment{Frequency: dto.FREQUENCY_END_OF_MONTH, Status: PAYMENT_ACTIVE, NextRunDate: sql.NullString{String: "2020-12-31", Valid: true}}
+	assert.Equal(t, "2021-01-31", endOfMonth.advance().NextRunDate.String)
+
+	weekly := ScheduledPayment{Frequency: dto.FREQUENCY_WEEKLY, Status: PAYMENT_ACTIVE, NextRunDate: sql.NullString{String: "2021-03-01", Valid: true},
+		EndDate: sql.NullString{String: "2021-03-10", Valid: true}}
+	weekly = weekly.advance()
+	assert.Equal(t, "2021-03-08", weekly.NextRunDate.String)
+	weekly = weekly.advance()
+	assert.False(t, weekly.NextRunDate.Valid)
+	assert.Equal(t, PAYMENT_COMPLETED, weekly.Status)
+
+	once := ScheduledPayment{Frequency: dto.FREQUENCY_ONCE, Status: PAYMENT_ACTIVE, NextRunDate: sql.NullString{String: "2021-03-01", Valid: true}}
+	assert.Equal(t, PAYMENT_COMPLETED, once.advance().Status)
+}
+
+func TestScheduledPaymentSettle(t *testing.T) {
+	declined := errs.NewValidationError("Insufficient funds").WithReason(REASON_INSUFFICIENT_FUNDS)
+	p := ScheduledPayment{
+		Frequency:   dto.FREQUENCY_DAILY,
+		NextRunDate: sql.NullString{String: "2021-03-01", Valid: true},
+		RetryPolicy: dto.RETRY_THEN_SUSPEND,
+		MaxRetries:  1,
+		Status:      PAYMENT_ACTIVE,
+	}
+
+	var run PaymentRun
+	next := p.Settle(&run, "", declined)
+	assert.Equal(t, RUN_DECLINED, run.Status)
+	assert.Equal(t, REASON_INSUFFICIENT_FUNDS, run.Reason)
+	assert.Equal(t, 1, next.Attempts)
+	assert.Equal(t, "2021-03-02", FormatRunDate(next.AttemptDate()))
+
+	run = PaymentRun{}
+	last := next.Settle(&run, "", declined)
+	assert.Equal(t, "2021-03-02", last.NextRunDate.String)
+	assert.Equal(t, 0, last.Attempts)
+	assert.Equal(t, PAYMENT_SUSPENDED, last.Status)
+
+	run = PaymentRun{}
+	paid := next.Settle(&run, "42", nil)
+	assert.Equal(t, RUN_SUCCEEDED, run.Status)
+	assert.Equal(t, "42", run.TransactionID.String)
+	assert.Equal(t, "2021-03-02", paid.NextRunDate.String)
+	assert.Equal(t, PAYMENT_ACTIVE, paid.Status)
+
+	p.RetryPolicy = dto.RETRY_SKIP
+	run = PaymentRun{}
+	skipped := p.Settle(&run, "", declined)
+	assert.Equal(t, "2021-03-02", skipped.NextRunDate.String)
+	assert.Equal(t, PAYMENT_ACTIVE, skipped.Status)
+
+	run = PaymentRun{}
+	frozen := p.Settle(&run, "", errs.NewConflictError("account is frozen").WithReason("account_frozen"))
+	assert.Equal(t, RUN_FAILED, run.Status)
+	assert.Equal(t, "account_frozen", run.Reason)
+	assert.Equal(t, PAYMENT_SUSPENDED, frozen.Status)
+
+	run = PaymentRun{}
+	p.RetryPolicy = dto.RETRY_RETRY
+	retried := p.Settle(&run, "", errs.NewUnexpectedError("Unexpected database error"))
+	assert.Equal(t, "unexpected_error", run.Reason)
+	assert.Equal(t, 1, retried.Attempts)
+}
+
+func TestScheduledPaymentInterruptSuspendsWithoutPaying(t *testing.T) {
+	p := ScheduledPayment{
+		Frequency:   dto.FREQUENCY_DAILY,
+		NextRunDate: sql.NullString{String: "2021-03-01", Valid: true},
+		RetryPolicy: dto.RETRY_RETRY,
+		MaxRetries:  3,
+		Status:      PAYMENT_ACTIVE,
+	}
+
+	run := PaymentRun{Status: RUN_RUNNING}
+	next := p.Interrupt(&run)
+	assert.Equal(t, RUN_FAILED, run.Status)
+	assert.Equal(t, REASON_RUN_INTERRUPTED, run.Reason)
+	assert.False(t, run.TransactionID.Valid)
+	assert.Equal(t, "2021-03-02", next.NextRunDate.String)
+	assert.Equal(t, PAYMENT_SUSPENDED, next.Status)
+
+	assert.Equal(t, "2021-03-10 08:45:00", StaleRunBefore(time.Date(2021, time.March, 10, 9, 0, 0, 0, time.Local)))
+}
+
+func TestScheduledPaymentResume(t *testing.T) {
+	now := time.Date(2021, time.March, 10, 9, 0, 0, 0, time.Local)
+	p := ScheduledPayment{
+		Frequency:   dto.FREQUENCY_WEEKLY,
+		StartDate:   "2021-02-01",
+		NextRunDate: sql.NullString{String: "2021-02-15", Valid: true},
+		Attempts:    2,
+		RetryPolicy: dto.RETRY_RETRY,
+		Status:      PAYMENT_SUSPENDED,
+	}
+	resumed, err := p.Apply(dto.UpdateScheduledPaymentRequest{Amount: money.MustParse("20.00"), Status: PAYMENT_ACTIVE}, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "2021-03-15", resumed.NextRunDate.String)
+	assert.Equal(t, 0, resumed.Attempts)
+	assert.Equal(t, PAYMENT_ACTIVE, resumed.Status)
+
+	cancelled, err := resumed.Cancel()
+	assert.Nil(t, err)
+	_, err = cancelled.Apply(dto.UpdateScheduledPaymentRequest{Amount: money.MustParse("20.00"), Status: PAYMENT_ACTIVE}, now)
+	assert.Equal(t, "scheduled_payment_cancelled", err.Reason)
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// the reasons a refresh is refused with
+const (
+	REASON_SESSION_REVOKED       = "session_revoked"
+	REASON_SESSION_EXPIRED       = "session_expired"
+	REASON_REFRESH_TOKEN_REUSED  = "refresh_token_reused"
+	REASON_INVALID_REFRESH_TOKEN = "invalid_refresh_token"
+)
+
+// Session is a login. RefreshID is the jti of the only refresh token of the session that can still be used,
+// every refresh replaces it, so an older refresh token showing up again means it was stolen.
+type Session struct {
+	SessionID  string `db:"session_id"`
+	Username   string
+	RefreshID  string         `db:"refresh_id"`
+	UserAgent  string         `db:"user_agent"`
+	IPAddress  string         `db:"ip_address"`
+	CreatedAt  string         `db:"created_at"`
+	LastUsedAt string         `db:"last_used_at"`
+	ExpiresAt  string         `db:"expires_at"`
+	RevokedAt  sql.NullString `db:"revoked_at"`
+}
+
+// SessionRepository implements:
+//
+// Save: stores a new session
+// ByID: returns a session, or a not found error
+// FindActive: returns the sessions of a user that are neither revoked nor expired, newest first
+// Rotate: replaces the refresh token of a session, a reused refresh token revokes the session and is refused
+// Revoke: revokes a session, which revokes its access tokens and refresh token
+// RevokeAll: revokes every session of a user
+// RevokeToken: adds the jti of a single token to the revocation list until the token expires
+// IsRevoked: checks the revocation list for a jti and a session
+// DeleteExpired: removes expired sessions and revoked tokens, and returns how many were removed
+// mockgen -destination=mocks/domain/mock_session_repository.go -package=domain github.com/jonathanwamsley/banking/domain SessionRepository
+type SessionRepository interface {
+	Save(Session) *errs.AppError
+	ByID(sessionID string) (*Session, *errs.AppError)
+	FindActive(username string, now string) ([]Session, *errs.AppError)
+	Rotate(sessionID string, usedRefreshID string, newRefreshID string, now time.Time) *errs.AppError
+	Revoke(sessionID string, now string) *errs.AppError
+	RevokeAll(username string, now string) *errs.AppError
+	RevokeToken(jti string, expiresAt string) *errs.AppError
+	IsRevoked(jti string, sessionID string) (bool, *errs.AppError)
+	DeleteExpired(now string) (int64, *errs.AppError)
+}
+
+// RevocationList is what the authorization check needs of a SessionRepository
+type RevocationList interface {
+	IsRevoked(jti string, sessionID string) (bool, *errs.AppError)
+}
+
+// NewSession starts a session for a login that lasts as long as its refresh tokens
+func NewSession(sessionID string, username string, refreshID string, userAgent string, ipAddress string, now time.Time, ttl time.Duration) Session {
+	return Session{
+		SessionID:  sessionID,
+		Username:   username,
+		RefreshID:  refreshID,
+		UserAgent:  truncate(userAgent, 255),
+		IPAddress:  truncate(ipAddress, 45),
+		CreatedAt:  now.Format(dbTSLayout),
+		LastUsedAt: now.Format(dbTSLayout),
+		ExpiresAt:  now.Add(ttl).Format(dbTSLayout),
+	}
+}
+
+// IsExpired checks if the session lasted past its refresh tokens
+func (s Session) IsExpired(now time.Time) bool {
+	expiresAt, err := time.ParseInLocation(dbTSLayout, s.ExpiresAt, time.Local)
+	if err != nil {
+		return true
+	}
+	return !now.Before(expiresAt)
+}
+
+// CheckRefresh checks a refresh token with the jti usedRefreshID can still be used. A jti that is not the
+// current one was already used, the error of that has reason refresh_token_reused and the caller revokes the session.
+func (s Session) CheckRefresh(usedRefreshID string, now time.Time) *errs.AppError {
+	if s.RevokedAt.Valid {
+		return errs.NewUnauthorizedError("session was revoked").WithReason(REASON_SESSION_REVOKED)
+	}
+	if s.IsExpired(now) {
+		return errs.NewUnauthorizedError("session expired, log in again").WithReason(REASON_SESSION_EXPIRED)
+	}
+	if s.RefreshID != usedRefreshID {
+		return errs.NewUnauthorizedError("refresh token was already used, the session is revoked").WithReason(REASON_REFRESH_TOKEN_REUSED)
+	}
+	return nil
+}
+
+// ToDTO converts a session, current marks the session the request was made with
+func (s Session) ToDTO(currentSessionID string) dto.SessionResponse {
+	return dto.SessionResponse{
+		SessionID:  s.SessionID,
+		Username:   s.Username,
+		UserAgent:  s.UserAgent,
+		IPAddress:  s.IPAddress,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    s.SessionID == currentSessionID,
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
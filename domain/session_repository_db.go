@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// The query statements
+const (
+	sessionColumns       = "session_id, username, refresh_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at"
+	insertSession        = "INSERT INTO sessions (session_id, username, refresh_id, user_agent, ip_address, created_at, last_used_at, expires_at) values (?, ?, ?, ?, ?, ?, ?, ?);"
+	findSession          = "SELECT " + sessionColumns + " from sessions where session_id = ?;"
+	findSessionForUpdate = "SELECT " + sessionColumns + " from sessions where session_id = ? FOR UPDATE;"
+	findActiveSessions   = "SELECT " + sessionColumns + " from sessions where username = ? and revoked_at IS NULL and expires_at > ? order by last_used_at desc, session_id;"
+	rotateSession        = "UPDATE sessions SET refresh_id = ?, last_used_at = ? where session_id = ?;"
+	revokeSession        = "UPDATE sessions SET revoked_at = ? where session_id = ? and revoked_at IS NULL;"
+	revokeUserSessions   = "UPDATE sessions SET revoked_at = ? where username = ? and revoked_at IS NULL;"
+	insertRevokedToken   = "INSERT INTO revoked_tokens (jti, expires_at) values (?, ?) ON DUPLICATE KEY UPDATE expires_at = GREATEST(expires_at, VALUES(expires_at));"
+	countRevoked         = "SELECT (SELECT COUNT(*) from revoked_tokens where jti = ?) + (SELECT COUNT(*) from sessions where session_id = ? and revoked_at IS NOT NULL);"
+	deleteExpiredTokens  = "DELETE from revoked_tokens where expires_at <= ?;"
+	deleteExpiredSession = "DELETE from sessions where expires_at <= ?;"
+)
+
+// SessionRepositoryDB holds the sql client connection
+type SessionRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewSessionRepositoryDB creates a new SessionRepositoryDB to call sql methods
+func NewSessionRepositoryDB(client *sqlx.DB) SessionRepositoryDB {
+	return SessionRepositoryDB{client}
+}
+
+// Save stores a new session
+func (d SessionRepositoryDB) Save(s Session) *errs.AppError {
+	_, err := d.client.Exec(insertSession, s.SessionID, s.Username, s.RefreshID, s.UserAgent, s.IPAddress, s.CreatedAt, s.LastUsedAt, s.ExpiresAt)
+	if err != nil {
+		logger.Error("Error while saving session " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// ByID returns a session
+func (d SessionRepositoryDB) ByID(sessionID string) (*Session, *errs.AppError) {
+	var s Session
+	if err := d.client.Get(&s, findSession, sessionID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("Session not found")
+		}
+		logger.Error("Error while scanning session " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &s, nil
+}
+
+// FindActive returns the sessions of a user that can still be refreshed
+func (d SessionRepositoryDB) FindActive(username string, now string) ([]Session, *errs.AppError) {
+	sessions := make([]Session, 0)
+	if err := d.client.Select(&sessions, findActiveSessions, username, now); err != nil {
+		logger.Error("Error while querying sessions table " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return sessions, nil
+}
+
+// Rotate locks the session while it checks and replaces the refresh token, so two refreshes with the same
+// token cannot both succeed. A reused token revokes the session and the revocation is committed with the refusal.
+func (d SessionRepositoryDB) Rotate(sessionID string, usedRefreshID string, newRefreshID string, now time.Time) *errs.AppError {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for a refresh " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	var s Session
+	if err = tx.Get(&s, findSessionForUpdate, sessionID); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errs.NewUnauthorizedError("session not found").WithReason(REASON_INVALID_REFRESH_TOKEN)
+		}
+		logger.Error("Error while locking session " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+
+	refused := s.CheckRefresh(usedRefreshID, now)
+	if refused != nil && refused.Reason != REASON_REFRESH_TOKEN_REUSED {
+		tx.Rollback()
+		return refused
+	}
+	if refused != nil {
+		_, err = tx.Exec(revokeSession, now.Format(dbTSLayout), sessionID)
+	} else {
+		_, err = tx.Exec(rotateSession, newRefreshID, now.Format(dbTSLayout), sessionID)
+	}
+	if err != nil {
+		tx.Rollback()
+		logger.Error("Error while refreshing session " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if err = tx.Commit(); err != nil {
+		logger.Error("Error while committing session refresh " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if refused != nil {
+		logger.Info("refresh token reuse detected, revoked session " + sessionID)
+	}
+	return refused
+}
+
+// Revoke revokes a session, revoking it again keeps the first revocation time
+func (d SessionRepositoryDB) Revoke(sessionID string, now string) *errs.AppError {
+	if _, err := d.client.Exec(revokeSession, now, sessionID); err != nil {
+		logger.Error("Error while revoking session " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// RevokeAll revokes every session of a user that is not revoked yet
+func (d SessionRepositoryDB) RevokeAll(username string, now string) *errs.AppError {
+	if _, err := d.client.Exec(revokeUserSessions, now, username); err != nil {
+		logger.Error("Error while revoking the sessions of a user " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// RevokeToken adds a jti to the revocation list, it is kept until the token would have expired anyway
+func (d SessionRepositoryDB) RevokeToken(jti string, expiresAt string) *errs.AppError {
+	if _, err := d.client.Exec(insertRevokedToken, jti, expiresAt); err != nil {
+		logger.Error("Error while revoking token " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// IsRevoked checks the revocation list for the jti and the session of a token in one query
+func (d SessionRepositoryDB) IsRevoked(jti string, sessionID string) (bool, *errs.AppError) {
+	var revoked int
+	if err := d.client.Get(&revoked, countRevoked, jti, sessionID); err != nil {
+		logger.Error("Error while checking the revocation list " + err.Error())
+		return false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return revoked > 0, nil
+}
+
+// DeleteExpired removes revoked tokens and sessions that expired before now, their tokens are refused for expiring anyway
+func (d SessionRepositoryDB) DeleteExpired(now string) (int64, *errs.AppError) {
+	var deleted int64
+	for _, query := range []string{deleteExpiredTokens, deleteExpiredSession} {
+		result, err := d.client.Exec(query, now)
+		if err != nil {
+			logger.Error("Error while deleting expired sessions " + err.Error())
+			return deleted, errs.NewUnexpectedError("Unexpected database error")
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			logger.Error("Error while reading deleted sessions " + err.Error())
+			return deleted, errs.NewUnexpectedError("Unexpected database error")
+		}
+		deleted += n
+	}
+	return deleted, nil
+}
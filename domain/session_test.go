@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var sessionNow = time.Date(2021, 3, 10, 9, 0, 0, 0, time.Local)
+
+func TestNewSessionExpiresWithItsRefreshTokens(t *testing.T) {
+	s := NewSession("s1", "arian", "r1", "curl/7.68.0", "10.0.0.7", sessionNow, 24*time.Hour)
+
+	assert.Equal(t, "2021-03-11 09:00:00", s.ExpiresAt)
+	assert.False(t, s.IsExpired(sessionNow.Add(23*time.Hour)))
+	assert.True(t, s.IsExpired(sessionNow.Add(24*time.Hour)))
+}
+
+func TestCheckRefresh(t *testing.T) {
+	s := NewSession("s1", "arian", "r2", "", "", sessionNow, 24*time.Hour)
+
+	assert.Nil(t, s.CheckRefresh("r2", sessionNow))
+	assert.EqualValues(t, REASON_REFRESH_TOKEN_REUSED, s.CheckRefresh("r1", sessionNow).Reason)
+	assert.EqualValues(t, REASON_SESSION_EXPIRED, s.CheckRefresh("r2", sessionNow.Add(25*time.Hour)).Reason)
+
+	s.RevokedAt = sql.NullString{String: "2021-03-10 09:30:00", Valid: true}
+	assert.EqualValues(t, REASON_SESSION_REVOKED, s.CheckRefresh("r2", sessionNow).Reason)
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// JOB_STATEMENTS generates the monthly statements, it runs once per month for the last day of the month
+const JOB_STATEMENTS = "statements"
+
+// OPENING_DEPOSIT is the statement line of the deposit an account was opened with, which is not a transaction
+const OPENING_DEPOSIT = "opening_deposit"
+
+// StatementEntry is a journal entry that moved the balance of an account in a month: a transaction, or the
+// opening deposit when TransactionID is empty. Amount is what it added to the balance.
+type StatementEntry struct {
+	TransactionID   string      `db:"transaction_id"`
+	TransactionType string      `db:"transaction_type"`
+	Description     string      `db:"description"`
+	PostedAt        string      `db:"posted_at"`
+	ValueDate       string      `db:"value_date"`
+	Amount          money.Money `db:"amount"`
+}
+
+// Statement is what the statement of an account for a month is made from. Opening is the balance at the end of
+// the month before, the entries are in the order they were posted.
+type Statement struct {
+	Account     Account
+	Customer    Customer
+	Period      string
+	Opening     money.Money
+	Entries     []StatementEntry
+	GeneratedAt string
+}
+
+// StoredStatement is a generated statement as it is kept: its json document and the sha256 of the document.
+// A stored statement is never changed, a statement that no longer matches its checksum was tampered with.
+type StoredStatement struct {
+	StatementID string `db:"statement_id"`
+	AccountID   string `db:"account_id"`
+	Period      string `db:"period"`
+	Document    string `db:"document"`
+	Checksum    string `db:"checksum"`
+	GeneratedAt string `db:"generated_at"`
+}
+
+// StatementRepository implements:
+//
+// StatementAccounts: returns the accounts that need a statement of a month and do not have one yet, the accounts
+// opened before its end that are not closed or moved money in it
+// StatementEntries: returns the journal entries that moved the balance of an account in a month
+// SaveStatement: stores a statement, false is returned when the account already has one of that month
+// FindStatement: returns the stored statement of an account and month
+// mockgen -destination=mocks/domain/mock_statement_repository.go -package=domain github.com/jonathanwamsley/banking/domain StatementRepository
+type StatementRepository interface {
+	StatementAccounts(period string) ([]Account, *errs.AppError)
+	StatementEntries(accountID string, period string) ([]StatementEntry, *errs.AppError)
+	SaveStatement(StoredStatement) (bool, *errs.AppError)
+	FindStatement(accountID string, period string) (*StoredStatement, *errs.AppError)
+}
+
+// ToDTO lists the entries with the balance after each, and adds up the money in and out, the fees and the interest
+func (s Statement) ToDTO() dto.StatementResponse {
+	start, end, _ := ParseFeePeriod(s.Period, wallClock)
+	zero := money.New(0, s.Opening.Currency())
+	response := dto.StatementResponse{
+		AccountID:   s.Account.AccountID,
+		AccountType: s.Account.AccountType,
+		Period:      s.Period,
+		PeriodStart: FormatRunDate(start),
+		PeriodEnd:   FormatRunDate(end),
+		Customer: dto.StatementCustomer{
+			CustomerID: s.Customer.ID,
+			Name:       s.Customer.Name,
+			City:       s.Customer.City,
+			Zipcode:    s.Customer.Zipcode,
+		},
+		OpeningBalance:  s.Opening,
+		TotalCredits:    zero,
+		TotalDebits:     zero,
+		FeesCharged:     zero,
+		InterestPaid:    zero,
+		InterestCharged: zero,
+		Transactions:    make([]dto.StatementLine, 0),
+		GeneratedAt:     s.GeneratedAt,
+	}
+
+	balance := s.Opening
+	for _, e := range s.Entries {
+		balance = balance.Add(e.Amount)
+		line := dto.StatementLine{
+			TransactionID:   e.TransactionID,
+			TransactionType: e.TransactionType,
+			Description:     e.Description,
+			TransactionDate: e.PostedAt,
+			ValueDate:       e.ValueDate,
+			Amount:          e.Amount,
+			Balance:         balance,
+		}
+		if e.TransactionID == "" {
+			line.TransactionType = OPENING_DEPOSIT
+		}
+		response.Transactions = append(response.Transactions, line)
+
+		if e.Amount.IsNegative() {
+			response.TotalDebits = response.TotalDebits.Add(e.Amount.Neg())
+		} else {
+			response.TotalCredits = response.TotalCredits.Add(e.Amount)
+		}
+		switch e.TransactionType {
+		case FEE:
+			response.FeesCharged = response.FeesCharged.Add(e.Amount.Neg())
+		case INTEREST:
+			response.InterestPaid = response.InterestPaid.Add(e.Amount)
+		case OVERDRAFT_INTEREST:
+			response.InterestCharged = response.InterestCharged.Add(e.Amount.Neg())
+		}
+	}
+	response.ClosingBalance = balance
+	return response
+}
+
+// NewStoredStatement keeps a statement as its json document and the checksum of the document
+func NewStoredStatement(s dto.StatementResponse) (StoredStatement, *errs.AppError) {
+	s.Checksum = ""
+	document, err := json.Marshal(s)
+	if err != nil {
+		logger.Error("Error while writing statement " + err.Error())
+		return StoredStatement{}, errs.NewUnexpectedError("Unexpected error while writing the statement")
+	}
+	return StoredStatement{
+		AccountID:   s.AccountID,
+		Period:      s.Period,
+		Document:    string(document),
+		Checksum:    checksum(document),
+		GeneratedAt: s.GeneratedAt,
+	}, nil
+}
+
+// Open checks the document still matches its checksum and reads it, with the checksum
+func (s StoredStatement) Open() (*dto.StatementResponse, *errs.AppError) {
+	if checksum([]byte(s.Document)) != s.Checksum {
+		logger.Error("statement " + s.StatementID + " of account " + s.AccountID + " does not match its checksum")
+		return nil, errs.NewUnexpectedError("The statement does not match its checksum").WithReason("statement_checksum_mismatch")
+	}
+	var statement dto.StatementResponse
+	if err := json.Unmarshal([]byte(s.Document), &statement); err != nil {
+		logger.Error("Error while reading statement " + s.StatementID + " " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected error while reading the statement")
+	}
+	statement.Checksum = s.Checksum
+	return &statement, nil
+}
+
+// checksum is the hex sha256 of a document
+func checksum(document []byte) string {
+	sum := sha256.Sum256(document)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"database/sql"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// The query statements
+const (
+	// closed accounts only get a statement of a month they still moved money in, and accounts that already have
+	// a statement of the month are left out so a rerun only generates the missing ones
+	findStatementAccounts = `SELECT a.account_id, a.customer_id, a.opening_date, a.account_type, a.amount, a.status, a.version, a.overdraft_limit, a.overdraft_sweep
+		from accounts a where a.opening_date < ?
+			and (a.status <> 'closed' or EXISTS (SELECT 1 from journal_lines l join journal_entries e on e.entry_id = l.entry_id
+				where l.ledger_account = ? and l.account_id = a.account_id and e.posted_at >= ? and e.posted_at < ?))
+			and NOT EXISTS (SELECT 1 from statements s where s.account_id = a.account_id and s.period = ?)
+		order by a.account_id;`
+	// an entry without a transaction is the opening deposit, it is valued the day it was posted
+	findStatementEntries = `SELECT COALESCE(t.transaction_id, '') as transaction_id, COALESCE(t.transaction_type, '') as transaction_type, e.description, e.posted_at,
+		COALESCE(t.value_date, DATE(e.posted_at)) as value_date, SUM(l.credit - l.debit) as amount
+		from journal_entries e join journal_lines l on l.entry_id = e.entry_id left join transactions t on t.transaction_id = e.transaction_id
+		where l.ledger_account = ? and l.account_id = ? and e.posted_at >= ? and e.posted_at < ?
+		group by e.entry_id, t.transaction_id, t.transaction_type, e.description, e.posted_at, t.value_date
+		order by e.posted_at, e.entry_id;`
+	insertStatement = "INSERT INTO statements (account_id, period, document, checksum, generated_at) values (?, ?, ?, ?, ?);"
+	findStatement   = "SELECT statement_id, account_id, period, document, checksum, generated_at from statements where account_id = ? and period = ?;"
+)
+
+// StatementRepositoryDB holds the sql client connection
+type StatementRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewStatementRepositoryDB creates a new StatementRepositoryDB to call sql methods
+func NewStatementRepositoryDB(client *sqlx.DB) StatementRepositoryDB {
+	return StatementRepositoryDB{client}
+}
+
+// StatementAccounts returns the accounts opened before the end of the month that need a statement of it
+func (d StatementRepositoryDB) StatementAccounts(period string) ([]Account, *errs.AppError) {
+	from, to, appErr := periodBounds(period)
+	if appErr != nil {
+		return nil, appErr
+	}
+	accounts := make([]Account, 0)
+	if err := d.client.Select(&accounts, findStatementAccounts, to, CUSTOMER_DEPOSITS, from, to, period); err != nil {
+		logger.Error("Error while finding accounts to generate statements of " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return accounts, nil
+}
+
+// StatementEntries returns the ledger entries of the account posted in the month, with what each added to its balance
+func (d StatementRepositoryDB) StatementEntries(accountID string, period string) ([]StatementEntry, *errs.AppError) {
+	from, to, appErr := periodBounds(period)
+	if appErr != nil {
+		return nil, appErr
+	}
+	entries := make([]StatementEntry, 0)
+	if err := d.client.Select(&entries, findStatementEntries, CUSTOMER_DEPOSITS, accountID, from, to); err != nil {
+		logger.Error("Error while reading the statement entries of an account " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return entries, nil
+}
+
+// SaveStatement inserts a statement. Statements are never updated, the unique key refuses a second statement
+// of the same account and month.
+func (d StatementRepositoryDB) SaveStatement(s StoredStatement) (bool, *errs.AppError) {
+	_, err := d.client.Exec(insertStatement, s.AccountID, s.Period, s.Document, s.Checksum, s.GeneratedAt)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlDuplicateEntry {
+			return false, nil
+		}
+		logger.Error("Error while saving statement " + err.Error())
+		return false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return true, nil
+}
+
+// FindStatement returns the statement of an account and month
+func (d StatementRepositoryDB) FindStatement(accountID string, period string) (*StoredStatement, *errs.AppError) {
+	var s StoredStatement
+	if err := d.client.Get(&s, findStatement, accountID, period); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("Statement not found")
+		}
+		logger.Error("Error while finding statement " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &s, nil
+}
+
+// periodBounds returns the first moment of a month and of the month after, as db datetimes
+func periodBounds(period string) (string, string, *errs.AppError) {
+	start, end, appErr := ParseFeePeriod(period, wallClock)
+	if appErr != nil {
+		return "", "", appErr
+	}
+	return start.Format(dbTSLayout), end.AddDate(0, 0, 1).Format(dbTSLayout), nil
+}
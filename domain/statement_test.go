@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementToDTOAddsUpTheMonth(t *testing.T) {
+	s := Statement{
+		Account:  Account{AccountID: "95470", AccountType: "checking"},
+		Customer: Customer{ID: "2000", Name: "Steve", City: "Delhi", Zipcode: "110075"},
+		Period:   "2021-03",
+		Opening:  money.Zero(),
+		Entries: []StatementEntry{
+			{Description: "opening deposit", PostedAt: "2021-03-01 09:00:00", ValueDate: "2021-03-01", Amount: money.MustParse("6000.00")},
+			{TransactionID: "1", TransactionType: WITHDRAWAL, Description: WITHDRAWAL, PostedAt: "2021-03-05 10:00:00", ValueDate: "2021-03-05", Amount: money.MustParse("-6100.00")},
+			{TransactionID: "2", TransactionType: OVERDRAFT_INTEREST, Description: OVERDRAFT_INTEREST, PostedAt: "2021-03-06 00:00:00", ValueDate: "2021-03-06", Amount: money.MustParse("-1.25")},
+			{TransactionID: "3", TransactionType: INTEREST, Description: INTEREST, PostedAt: "2021-03-31 00:00:00", ValueDate: "2021-03-31", Amount: money.MustParse("0.40")},
+			{TransactionID: "4", TransactionType: FEE, Description: FEE, PostedAt: "2021-03-31 00:00:00", ValueDate: "2021-03-31", Amount: money.MustParse("-5.00")},
+		},
+		GeneratedAt: "2021-04-01 01:00:00",
+	}
+
+	statement := s.ToDTO()
+	assert.Equal(t, "2021-03-01", statement.PeriodStart)
+	assert.Equal(t, "2021-03-31", statement.PeriodEnd)
+	assert.Equal(t, OPENING_DEPOSIT, statement.Transactions[0].TransactionType)
+	assert.Equal(t, money.MustParse("-100.00"), statement.Transactions[1].Balance)
+	assert.Equal(t, money.MustParse("6000.40"), statement.TotalCredits)
+	assert.Equal(t, money.MustParse("6106.25"), statement.TotalDebits)
+	assert.Equal(t, money.MustParse("5.00"), statement.FeesCharged)
+	assert.Equal(t, money.MustParse("0.40"), statement.InterestPaid)
+	assert.Equal(t, money.MustParse("1.25"), statement.InterestCharged)
+	assert.Equal(t, money.MustParse("-105.85"), statement.ClosingBalance)
+}
+
+func TestStoredStatementOpensOnlyWhenItMatchesItsChecksum(t *testing.T) {
+	s := Statement{Account: Account{AccountID: "95470"}, Period: "2021-03", Opening: money.MustParse("10.00"), GeneratedAt: "2021-04-01 01:00:00"}
+	stored, err := NewStoredStatement(s.ToDTO())
+	assert.Nil(t, err)
+
+	statement, err := stored.Open()
+	assert.Nil(t, err)
+	assert.Equal(t, stored.Checksum, statement.Checksum)
+	assert.Equal(t, money.MustParse("10.00"), statement.ClosingBalance)
+
+	again, _ := NewStoredStatement(*statement)
+	assert.Equal(t, stored.Checksum, again.Checksum)
+
+	stored.Document = stored.Document[:len(stored.Document)-1] + " }"
+	_, err = stored.Open()
+	assert.Equal(t, "statement_checksum_mismatch", err.Reason)
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// statuses a customer or an account can be in
+//
+// active: everything is allowed
+// frozen: money can come in but not go out, the bank can still charge it, and no new accounts can be opened
+// dormant: nothing is allowed until an admin makes it active again
+// closed: nothing is allowed, and it can never be reopened
+const (
+	STATUS_ACTIVE  = "active"
+	STATUS_FROZEN  = "frozen"
+	STATUS_DORMANT = "dormant"
+	STATUS_CLOSED  = "closed"
+)
+
+// operations that are checked against a status
+const (
+	OP_DEPOSIT      = "deposit"
+	OP_WITHDRAW     = "withdraw"
+	OP_CHARGE       = "charge"
+	OP_OPEN_ACCOUNT = "open_account"
+)
+
+// what a customer or an account is being checked as, used to build the error reason
+const (
+	SUBJECT_CUSTOMER = "customer"
+	SUBJECT_ACCOUNT  = "account"
+)
+
+// statusAllows lists the operations each status allows
+var statusAllows = map[string]map[string]bool{
+	STATUS_ACTIVE:  {OP_DEPOSIT: true, OP_WITHDRAW: true, OP_CHARGE: true, OP_OPEN_ACCOUNT: true},
+	STATUS_FROZEN:  {OP_DEPOSIT: true, OP_CHARGE: true},
+	STATUS_DORMANT: {},
+	STATUS_CLOSED:  {},
+}
+
+// statusTransitions lists the statuses each status can be changed to
+var statusTransitions = map[string][]string{
+	STATUS_ACTIVE:  {STATUS_FROZEN, STATUS_DORMANT, STATUS_CLOSED},
+	STATUS_FROZEN:  {STATUS_ACTIVE, STATUS_CLOSED},
+	STATUS_DORMANT: {STATUS_ACTIVE, STATUS_CLOSED},
+	STATUS_CLOSED:  {},
+}
+
+// IsValidStatus checks the status is one of the known statuses
+func IsValidStatus(status string) bool {
+	_, ok := statusAllows[status]
+	return ok
+}
+
+// CanTransition checks if a status can be changed to another status
+func CanTransition(from string, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition returns a conflict error when the status change is not allowed
+func CheckTransition(subject string, from string, to string) *errs.AppError {
+	if !IsValidStatus(to) {
+		return errs.NewValidationError("Status should be active, frozen, dormant or closed").WithReason("invalid_status")
+	}
+	if !CanTransition(from, to) {
+		return errs.NewConflictError(fmt.Sprintf("A %s cannot go from %s to %s", subject, from, to)).WithReason("invalid_status_transition")
+	}
+	return nil
+}
+
+// CheckStatus returns a conflict error when the status does not allow the operation.
+// The reason names the subject and its status, like account_frozen or customer_closed.
+func CheckStatus(subject string, status string, operation string) *errs.AppError {
+	if statusAllows[status][operation] {
+		return nil
+	}
+	return errs.NewConflictError(fmt.Sprintf("The %s is %s and does not allow this operation", subject, status)).
+		WithReason(subject + "_" + status)
+}
+
+// operationFor returns the operation a transaction needs to be allowed
+func operationFor(t Transaction) string {
+	if t.IsCharge() {
+		return OP_CHARGE
+	}
+	if t.IsDebit() {
+		return OP_WITHDRAW
+	}
+	return OP_DEPOSIT
+}
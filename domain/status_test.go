@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStatusAllowsDepositsIntoFrozenAccount(t *testing.T) {
+	assert.Nil(t, CheckStatus(SUBJECT_ACCOUNT, STATUS_FROZEN, OP_DEPOSIT))
+
+	err := CheckStatus(SUBJECT_ACCOUNT, STATUS_FROZEN, OP_WITHDRAW)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 409, err.Code)
+	assert.EqualValues(t, "account_frozen", err.Reason)
+}
+
+func TestCheckStatusDormantAndClosedAllowNothing(t *testing.T) {
+	for _, status := range []string{STATUS_DORMANT, STATUS_CLOSED} {
+		for _, op := range []string{OP_DEPOSIT, OP_WITHDRAW, OP_OPEN_ACCOUNT} {
+			err := CheckStatus(SUBJECT_CUSTOMER, status, op)
+			assert.NotNil(t, err, "%s should not allow %s", status, op)
+			assert.EqualValues(t, "customer_"+status, err.Reason)
+		}
+	}
+}
+
+func TestCheckStatusUnknownStatusAllowsNothing(t *testing.T) {
+	assert.NotNil(t, CheckStatus(SUBJECT_ACCOUNT, "1", OP_DEPOSIT))
+}
+
+func TestCheckTransition(t *testing.T) {
+	assert.Nil(t, CheckTransition(SUBJECT_ACCOUNT, STATUS_ACTIVE, STATUS_FROZEN))
+	assert.Nil(t, CheckTransition(SUBJECT_ACCOUNT, STATUS_DORMANT, STATUS_ACTIVE))
+
+	err := CheckTransition(SUBJECT_ACCOUNT, STATUS_CLOSED, STATUS_ACTIVE)
+	assert.EqualValues(t, 409, err.Code)
+	assert.EqualValues(t, "invalid_status_transition", err.Reason)
+
+	err = CheckTransition(SUBJECT_ACCOUNT, STATUS_FROZEN, STATUS_DORMANT)
+	assert.EqualValues(t, "invalid_status_transition", err.Reason)
+
+	err = CheckTransition(SUBJECT_ACCOUNT, STATUS_ACTIVE, "suspended")
+	assert.EqualValues(t, 422, err.Code)
+	assert.EqualValues(t, "invalid_status", err.Reason)
+}
+
+func TestCanPostChecksCustomerFirst(t *testing.T) {
+	a := Account{Status: STATUS_FROZEN, CustomerStatus: STATUS_DORMANT}
+	err := a.CanPost(Transaction{TransactionType: DEPOSIT, Amount: money.MustParse("1.00")})
+	assert.EqualValues(t, "customer_dormant", err.Reason)
+}
+
+func TestCanPostFrozenAccount(t *testing.T) {
+	a := Account{Status: STATUS_FROZEN, CustomerStatus: STATUS_ACTIVE}
+	assert.Nil(t, a.CanPost(Transaction{TransactionType: DEPOSIT}))
+	assert.Nil(t, a.CanPost(Transaction{TransactionType: TRANSFER_IN}))
+
+	err := a.CanPost(Transaction{TransactionType: TRANSFER_OUT})
+	assert.EqualValues(t, "account_frozen", err.Reason)
+}
+
+func TestCanPostChargesFrozenAccount(t *testing.T) {
+	a := Account{Status: STATUS_FROZEN, CustomerStatus: STATUS_ACTIVE}
+	assert.Nil(t, a.CanPost(Transaction{TransactionType: FEE}))
+	assert.Nil(t, a.CanPost(Transaction{TransactionType: OVERDRAFT_INTEREST}))
+
+	a.Status = STATUS_DORMANT
+	assert.EqualValues(t, "account_dormant", a.CanPost(Transaction{TransactionType: FEE}).Reason)
+}
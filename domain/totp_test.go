@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the SHA1 test vectors of RFC 6238 appendix B, with 8 digit codes
+func TestHOTPMatchesRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	for unix, code := range vectors {
+		assert.Equal(t, code, hotp(key, uint64(unix/TOTP_PERIOD), 8), unix)
+	}
+}
+
+func TestMatchTOTPAllowsOneStepOfSkew(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+	previous, _ := TOTPCode(secret, TOTPStep(now)-1)
+	tooOld, _ := TOTPCode(secret, TOTPStep(now)-2)
+
+	step, ok := MatchTOTP(secret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now)-1, step)
+
+	_, ok = MatchTOTP(secret, tooOld, now)
+	assert.False(t, ok)
+	_, ok = MatchTOTP(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestNewTOTPSecretAndProvisioningURI(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	assert.Nil(t, err)
+	assert.Len(t, secret, 32)
+
+	uri := TOTPProvisioningURI("Banking", "2001", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Banking:2001?"))
+	assert.Contains(t, uri, "secret="+secret)
+}
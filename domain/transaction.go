@@ -1,17 +1,65 @@
 package domain
 
-import "github.com/jonathanwamsley/banking/dto"
+import (
+	"database/sql"
 
-// WITHDRAWAL is an transaction type
-const WITHDRAWAL = "withdrawal"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// transaction types
+const (
+	WITHDRAWAL   = "withdrawal"
+	DEPOSIT      = "deposit"
+	TRANSFER_OUT = "transfer_out"
+	TRANSFER_IN  = "transfer_in"
+	FEE          = "fee"
+	INTEREST     = "interest"
+	SWEEP_OUT    = "sweep_out"
+	SWEEP_IN     = "sweep_in"
+	// OVERDRAFT_INTEREST is the interest charged on a negative balance
+	OVERDRAFT_INTEREST = "overdraft_interest"
+	// REVERSAL_OUT takes back money a transaction put in, REVERSAL_IN returns money a transaction took out
+	REVERSAL_OUT = "reversal_out"
+	REVERSAL_IN  = "reversal_in"
+)
 
 // Transaction holds requirements to do a bank transaction
 type Transaction struct {
-	TransactionID   string  `db:"transaction_id"`
-	AccountID       string  `db:"account_id"`
-	Amount          float64 `db:"amount"`
-	TransactionType string  `db:"transaction_type"`
-	TransactionDate string  `db:"transaction_date"`
+	TransactionID   string      `db:"transaction_id"`
+	AccountID       string      `db:"account_id"`
+	Amount          money.Money `db:"amount"`
+	TransactionType string      `db:"transaction_type"`
+	TransactionDate string      `db:"transaction_date"`
+	// BookingDate is the bank's date the transaction was posted on, ValueDate the business day its money moves
+	// on. A transfer after the cut-off is valued on the next business day, everything else on its booking date.
+	BookingDate string      `db:"booking_date"`
+	ValueDate   string      `db:"value_date"`
+	Balance     money.Money `db:"balance"`
+	// RelatedTransactionID links a fee or a sweep to the transaction it was made for, and a reversal to
+	// the transaction it reverses
+	RelatedTransactionID sql.NullString `db:"related_transaction_id"`
+	// Sweep is the sweep_in leg posted to cover this transaction, if money was swept from saving
+	Sweep *Sweep `db:"-"`
+	// Fees are the fee transactions posted with this one, in the order they were charged
+	Fees []Transaction `db:"-"`
+	// FeeType is the kind of fee a fee transaction charges, it is only known when the fee is posted
+	FeeType string `db:"-"`
+	// ReversedType is the type of the transaction a reversal reverses, it is only known when the reversal is posted
+	ReversedType string `db:"-"`
+	// ExpectedVersion is the account version the client sent with If-Match, ANY_VERSION skips the check
+	ExpectedVersion int `db:"-"`
+}
+
+// fillDates books a transaction on the date of its timestamp and values it on its booking date, unless
+// they were set
+func (t *Transaction) fillDates() {
+	if t.BookingDate == "" && len(t.TransactionDate) >= len(dateLayout) {
+		t.BookingDate = t.TransactionDate[:len(dateLayout)]
+	}
+	if t.ValueDate == "" {
+		t.ValueDate = t.BookingDate
+	}
 }
 
 // IsWithdrawal checks transaction type
@@ -22,13 +70,50 @@ func (t Transaction) IsWithdrawal() bool {
 	return false
 }
 
-// ToDTO converts transaction to the transaction response for the user
+// IsDebit checks if the transaction takes money out of the account
+func (t Transaction) IsDebit() bool {
+	switch t.TransactionType {
+	case WITHDRAWAL, TRANSFER_OUT, SWEEP_OUT, FEE, OVERDRAFT_INTEREST, REVERSAL_OUT:
+		return true
+	}
+	return false
+}
+
+// IsCharge checks if the transaction is the bank charging the account, or taking back money it should not have
+// put in. Charges are taken even when they overdraw the account past its limit, the customer can not decline them.
+func (t Transaction) IsCharge() bool {
+	return t.TransactionType == FEE || t.TransactionType == OVERDRAFT_INTEREST || t.TransactionType == REVERSAL_OUT
+}
+
+// IsReversal checks if the transaction reverses another one
+func (t Transaction) IsReversal() bool {
+	return t.TransactionType == REVERSAL_OUT || t.TransactionType == REVERSAL_IN
+}
+
+// ToDTO converts transaction to the transaction response for the user, the balance is the one left after its fees
 func (t Transaction) ToDTO() dto.MakeTransactionResponse {
-	return dto.MakeTransactionResponse{
+	response := dto.MakeTransactionResponse{
 		TransactionID:   t.TransactionID,
 		AccountID:       t.AccountID,
-		Amount:          t.Amount,
+		Amount:          t.BalanceAfter(),
 		TransactionType: t.TransactionType,
 		TransactionDate: t.TransactionDate,
+		BookingDate:     t.BookingDate,
+		ValueDate:       t.ValueDate,
+	}
+	if t.Sweep != nil {
+		response.Sweep = &dto.SweepResponse{FromAccountID: t.Sweep.FromAccountID, TransactionID: t.Sweep.In.TransactionID, Amount: t.Sweep.In.Amount}
+	}
+	for _, fee := range t.Fees {
+		response.Fees = append(response.Fees, dto.FeeChargedResponse{TransactionID: fee.TransactionID, FeeType: fee.FeeType, Amount: fee.Amount})
+	}
+	return response
+}
+
+// BalanceAfter is the balance once the transaction and its fees were posted
+func (t Transaction) BalanceAfter() money.Money {
+	if len(t.Fees) > 0 {
+		return t.Fees[len(t.Fees)-1].Balance
 	}
+	return t.Balance
 }
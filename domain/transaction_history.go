@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// TransactionCursor marks the last transaction of a page. Pages are keyed on the transaction date and id,
+// so transactions posted while a client is paging never shift the pages it has not read yet.
+type TransactionCursor struct {
+	TransactionDate string
+	TransactionID   string
+}
+
+// Encode turns the cursor into the opaque next_cursor string given to clients
+func (c TransactionCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.TransactionDate + "|" + c.TransactionID))
+}
+
+// DecodeTransactionCursor reads a next_cursor string given out by Encode
+func DecodeTransactionCursor(cursor string) (*TransactionCursor, *errs.AppError) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errs.NewValidationError("invalid cursor")
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, errs.NewValidationError("invalid cursor")
+	}
+	if _, err = time.Parse(dbTSLayout, parts[0]); err != nil {
+		return nil, errs.NewValidationError("invalid cursor")
+	}
+	return &TransactionCursor{TransactionDate: parts[0], TransactionID: parts[1]}, nil
+}
+
+// TransactionFilter narrows and pages the transactions of one account.
+// From is inclusive and To is exclusive, both in the db time layout.
+type TransactionFilter struct {
+	AccountID       string
+	From            string
+	To              string
+	TransactionType string
+	MinAmount       *money.Money
+	MaxAmount       *money.Money
+	Descending      bool
+	After           *TransactionCursor
+	Limit           int
+}
+
+// NewTransactionFilter converts a validated history request into a filter for the db.
+// The To date is inclusive for the user, so the filter stops at the start of the following day.
+func NewTransactionFilter(r dto.TransactionHistoryRequest) (TransactionFilter, *errs.AppError) {
+	f := TransactionFilter{
+		AccountID:       r.AccountID,
+		TransactionType: r.TransactionType,
+		MinAmount:       r.MinAmount,
+		MaxAmount:       r.MaxAmount,
+		Descending:      r.IsDescending(),
+		Limit:           r.Limit,
+	}
+	if r.From != "" {
+		from, _ := time.Parse(dto.DATE_LAYOUT, r.From)
+		f.From = from.Format(dbTSLayout)
+	}
+	if r.To != "" {
+		to, _ := time.Parse(dto.DATE_LAYOUT, r.To)
+		f.To = to.AddDate(0, 0, 1).Format(dbTSLayout)
+	}
+	if r.Cursor != "" {
+		cursor, err := DecodeTransactionCursor(r.Cursor)
+		if err != nil {
+			return f, err
+		}
+		f.After = cursor
+	}
+	return f, nil
+}
+
+// Cursor returns the cursor pointing just after this transaction
+func (t Transaction) Cursor() TransactionCursor {
+	return TransactionCursor{TransactionDate: t.TransactionDate, TransactionID: t.TransactionID}
+}
+
+// ToHistoryDTO converts a stored transaction to a row of the transaction history
+func (t Transaction) ToHistoryDTO() dto.TransactionHistoryItem {
+	return dto.TransactionHistoryItem{
+		TransactionID:        t.TransactionID,
+		TransactionType:      t.TransactionType,
+		TransactionDate:      t.TransactionDate,
+		BookingDate:          t.BookingDate,
+		ValueDate:            t.ValueDate,
+		Amount:               t.Amount,
+		RunningBalance:       t.Balance,
+		RelatedTransactionID: t.RelatedTransactionID.String,
+	}
+}
+
+// ToExportDTO converts a stored transaction to an exported transaction, with money out as a negative amount
+func (t Transaction) ToExportDTO() dto.TransactionExportItem {
+	amount := t.Amount
+	if t.IsDebit() {
+		amount = amount.Neg()
+	}
+	return dto.TransactionExportItem{
+		TransactionID:        t.TransactionID,
+		TransactionType:      t.TransactionType,
+		TransactionDate:      t.TransactionDate,
+		BookingDate:          t.BookingDate,
+		ValueDate:            t.ValueDate,
+		Amount:               amount,
+		Balance:              t.Balance,
+		RelatedTransactionID: t.RelatedTransactionID.String,
+	}
+}
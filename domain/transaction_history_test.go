@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionCursorRoundTrip(t *testing.T) {
+	c := TransactionCursor{TransactionDate: "2021-03-10 09:02:44", TransactionID: "42"}
+	decoded, err := DecodeTransactionCursor(c.Encode())
+	assert.Nil(t, err)
+	assert.Equal(t, c, *decoded)
+}
+
+func TestDecodeTransactionCursorInvalid(t *testing.T) {
+	for _, cursor := range []string{"!!!", "bm9wZQ", TransactionCursor{TransactionDate: "yesterday", TransactionID: "1"}.Encode()} {
+		_, err := DecodeTransactionCursor(cursor)
+		assert.NotNil(t, err, cursor)
+		assert.EqualValues(t, 422, err.Code)
+	}
+}
+
+func TestNewTransactionFilter(t *testing.T) {
+	min := money.MustParse("10.00")
+	cursor := TransactionCursor{TransactionDate: "2021-03-10 09:02:44", TransactionID: "42"}
+	r := dto.TransactionHistoryRequest{
+		AccountID:       "95470",
+		Cursor:          cursor.Encode(),
+		Limit:           20,
+		From:            "2021-03-01",
+		To:              "2021-03-31",
+		TransactionType: dto.DEPOSIT,
+		MinAmount:       &min,
+		Sort:            dto.SORT_ASC,
+	}
+	f, err := NewTransactionFilter(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "95470", f.AccountID)
+	assert.Equal(t, "2021-03-01 00:00:00", f.From)
+	assert.Equal(t, "2021-04-01 00:00:00", f.To)
+	assert.Equal(t, dto.DEPOSIT, f.TransactionType)
+	assert.Equal(t, &min, f.MinAmount)
+	assert.Nil(t, f.MaxAmount)
+	assert.False(t, f.Descending)
+	assert.Equal(t, &cursor, f.After)
+	assert.Equal(t, 20, f.Limit)
+}
+
+func TestToHistoryDTO(t *testing.T) {
+	tr := Transaction{
+		TransactionID:   "42",
+		TransactionType: WITHDRAWAL,
+		TransactionDate: "2021-03-10 09:02:44",
+		Amount:          money.MustParse("25.00"),
+		Balance:         money.MustParse("975.00"),
+	}
+	item := tr.ToHistoryDTO()
+	assert.Equal(t, "42", item.TransactionID)
+	assert.Equal(t, money.MustParse("25.00"), item.Amount)
+	assert.Equal(t, money.MustParse("975.00"), item.RunningBalance)
+}
+
+func TestToExportDTOSignsMoneyOut(t *testing.T) {
+	withdrawal := Transaction{TransactionID: "42", TransactionType: WITHDRAWAL, Amount: money.MustParse("25.00"), Balance: money.MustParse("975.00")}
+	assert.Equal(t, money.MustParse("-25.00"), withdrawal.ToExportDTO().Amount)
+
+	interest := Transaction{TransactionID: "43", TransactionType: INTEREST, Amount: money.MustParse("0.40"), Balance: money.MustParse("975.40")}
+	assert.Equal(t, money.MustParse("0.40"), interest.ToExportDTO().Amount)
+}
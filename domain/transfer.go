@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// Transfer moves money between two accounts. The debit and credit are posted together or not at all.
+type Transfer struct {
+	Amount          money.Money
+	TransactionDate string
+	BookingDate     string
+	ValueDate       string
+	Debit           Transaction
+	Credit          Transaction
+}
+
+// NewTransfer builds the two transactions of a transfer, booked on bookingDate and valued on valueDate
+func NewTransfer(r dto.TransferRequest, transactionDate string, bookingDate string, valueDate string) Transfer {
+	return Transfer{
+		Amount:          r.Amount,
+		TransactionDate: transactionDate,
+		BookingDate:     bookingDate,
+		ValueDate:       valueDate,
+		Debit: Transaction{
+			AccountID:       r.FromAccountID,
+			Amount:          r.Amount,
+			TransactionType: TRANSFER_OUT,
+			TransactionDate: transactionDate,
+			BookingDate:     bookingDate,
+			ValueDate:       valueDate,
+			ExpectedVersion: r.Version,
+		},
+		Credit: Transaction{
+			AccountID:       r.ToAccountID,
+			Amount:          r.Amount,
+			TransactionType: TRANSFER_IN,
+			TransactionDate: transactionDate,
+			BookingDate:     bookingDate,
+			ValueDate:       valueDate,
+		},
+	}
+}
+
+// ToDTO converts a saved transfer to the transfer response for the user
+func (t Transfer) ToDTO() dto.TransferResponse {
+	return dto.TransferResponse{
+		Amount:          t.Amount,
+		TransactionDate: t.TransactionDate,
+		BookingDate:     t.BookingDate,
+		ValueDate:       t.ValueDate,
+		From: dto.TransferLeg{
+			TransactionID: t.Debit.TransactionID,
+			AccountID:     t.Debit.AccountID,
+			NewBalance:    t.Debit.BalanceAfter(),
+		},
+		To: dto.TransferLeg{
+			TransactionID: t.Credit.TransactionID,
+			AccountID:     t.Credit.AccountID,
+			NewBalance:    t.Credit.Balance,
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransfer(t *testing.T) {
+	r := dto.TransferRequest{FromAccountID: "95470", ToAccountID: "95471", Amount: money.MustParse("25.00")}
+	transfer := NewTransfer(r, "2021-03-10 09:02:44", "2021-03-10", "2021-03-11")
+
+	assert.Equal(t, "95470", transfer.Debit.AccountID)
+	assert.Equal(t, TRANSFER_OUT, transfer.Debit.TransactionType)
+	assert.True(t, transfer.Debit.IsDebit())
+	assert.Equal(t, "95471", transfer.Credit.AccountID)
+	assert.Equal(t, TRANSFER_IN, transfer.Credit.TransactionType)
+	assert.False(t, transfer.Credit.IsDebit())
+	assert.Equal(t, money.MustParse("25.00"), transfer.Debit.Amount)
+	assert.Equal(t, money.MustParse("25.00"), transfer.Credit.Amount)
+	assert.Equal(t, "2021-03-10 09:02:44", transfer.Credit.TransactionDate)
+	assert.Equal(t, "2021-03-10", transfer.Debit.BookingDate)
+	assert.Equal(t, "2021-03-11", transfer.Credit.ValueDate)
+}
+
+func TestTransferToDTO(t *testing.T) {
+	transfer := Transfer{
+		Amount:          money.MustParse("25.00"),
+		TransactionDate: "2021-03-10 09:02:44",
+		Debit:           Transaction{TransactionID: "7", AccountID: "95470", Amount: money.MustParse("25.00"), Balance: money.MustParse("75.00")},
+		Credit:          Transaction{TransactionID: "8", AccountID: "95471", Amount: money.MustParse("25.00"), Balance: money.MustParse("125.00")},
+	}
+	resp := transfer.ToDTO()
+	assert.Equal(t, "7", resp.From.TransactionID)
+	assert.Equal(t, money.MustParse("75.00"), resp.From.NewBalance)
+	assert.Equal(t, "8", resp.To.TransactionID)
+	assert.Equal(t, money.MustParse("125.00"), resp.To.NewBalance)
+	assert.Equal(t, money.MustParse("25.00"), resp.Amount)
+}
+
+func TestTransactionIsValuedOnItsBookingDate(t *testing.T) {
+	fee := Transaction{TransactionType: FEE, TransactionDate: "2021-03-12 18:30:00"}
+	fee.fillDates()
+	assert.Equal(t, "2021-03-12", fee.BookingDate)
+	assert.Equal(t, "2021-03-12", fee.ValueDate)
+
+	late := Transaction{TransactionType: TRANSFER_OUT, TransactionDate: "2021-03-12 18:30:00", ValueDate: "2021-03-15"}
+	late.fillDates()
+	assert.Equal(t, "2021-03-12", late.BookingDate)
+	assert.Equal(t, "2021-03-15", late.ValueDate)
+}
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User holds the login credentials of a customer or a staff member. Only customer users are linked to a customer.
+type User struct {
+	Username     string
+	PasswordHash string `db:"password"`
+	Role         string
+	CustomerID   sql.NullString `db:"customer_id"`
+	FailedLogins int            `db:"failed_logins"`
+	LockedUntil  sql.NullString `db:"locked_until"`
+	CreatedOn    string         `db:"created_on"`
+	// TOTPSecret is set when mfa enrollment starts, and only checked on login once TOTPEnabled is confirmed
+	TOTPSecret   sql.NullString `db:"totp_secret"`
+	TOTPEnabled  bool           `db:"totp_enabled"`
+	TOTPLastStep int64          `db:"totp_last_step"`
+	// Accounts are the account ids of the linked customer, they are only loaded by ByUsername
+	Accounts []string `db:"-"`
+}
+
+// LoginLockout locks a user for Duration after MaxAttempts failed logins in a row
+type LoginLockout struct {
+	MaxAttempts int
+	Duration    time.Duration
+}
+
+// UserRepository implements:
+//
+// FindAll: returns every user
+// ByUsername: returns a user with the account ids of its customer
+// Save: creates a user, a conflict error is returned when the username is taken
+// Update: replaces the role, customer and password hash of a user
+// Delete: removes a user
+// RecordFailedLogin: counts a failed login, and locks the user until lockedUntil once the lockout's attempts are reached
+// ResetFailedLogins: clears the failed login count and any lock
+// SetTOTPSecret: starts mfa enrollment with a new secret, mfa stays off until it is confirmed
+// EnableTOTP: turns mfa on, marks the period of the confirming code as used and replaces the recovery codes
+// UseTOTPStep: marks a period as used, false is returned when it or a later period was already used
+// UseRecoveryCode: marks an unused recovery code as used, false is returned when there is no such unused code
+// DisableTOTP: turns mfa off and removes the secret and recovery codes
+// mockgen -destination=mocks/domain/mock_user_repository.go -package=domain github.com/jonathanwamsley/banking/domain UserRepository
+type UserRepository interface {
+	FindAll() ([]User, *errs.AppError)
+	ByUsername(username string) (*User, *errs.AppError)
+	Save(User) *errs.AppError
+	Update(User) *errs.AppError
+	Delete(username string) *errs.AppError
+	RecordFailedLogin(username string, maxAttempts int, lockedUntil string) *errs.AppError
+	ResetFailedLogins(username string) *errs.AppError
+	SetTOTPSecret(username string, secret string) *errs.AppError
+	EnableTOTP(username string, step int64, recoveryCodeHashes []string) *errs.AppError
+	UseTOTPStep(username string, step int64) (bool, *errs.AppError)
+	UseRecoveryCode(username string, codeHash string, usedAt string) (bool, *errs.AppError)
+	DisableTOTP(username string) *errs.AppError
+}
+
+// staffRoles are the roles that are not linked to a customer
+var staffRoles = map[string]bool{ROLE_ADMIN: true, ROLE_TELLER: true, ROLE_AUDITOR: true}
+
+// CheckRole makes sure the role exists and that only customer users are linked to a customer
+func CheckRole(role string, customerID string) *errs.AppError {
+	if role == ROLE_CUSTOMER {
+		if customerID == "" {
+			return errs.NewValidationError("a customer user needs a customer_id")
+		}
+		return nil
+	}
+	if !staffRoles[role] {
+		return errs.NewValidationError("role should be customer, teller, auditor or admin")
+	}
+	if customerID != "" {
+		return errs.NewValidationError("only a customer user can have a customer_id")
+	}
+	return nil
+}
+
+// NewUser creates a user with a bcrypt hash of the password
+func NewUser(r dto.CreateUserRequest, now time.Time) (*User, *errs.AppError) {
+	if err := CheckRole(r.Role, r.CustomerID); err != nil {
+		return nil, err
+	}
+	hash, err := HashPassword(r.Password)
+	if err != nil {
+		return nil, err
+	}
+	return &User{
+		Username:     r.Username,
+		PasswordHash: hash,
+		Role:         r.Role,
+		CustomerID:   nullString(r.CustomerID),
+		CreatedOn:    now.Format(dbTSLayout),
+	}, nil
+}
+
+// Updated returns the user with the role, customer and, when one is sent, the password of the request
+func (u User) Updated(r dto.UpdateUserRequest) (*User, *errs.AppError) {
+	if err := CheckRole(r.Role, r.CustomerID); err != nil {
+		return nil, err
+	}
+	u.Role = r.Role
+	u.CustomerID = nullString(r.CustomerID)
+	if r.Password != "" {
+		hash, err := HashPassword(r.Password)
+		if err != nil {
+			return nil, err
+		}
+		u.PasswordHash = hash
+	}
+	return &u, nil
+}
+
+// HashPassword returns the bcrypt hash of a password
+func HashPassword(password string) (string, *errs.AppError) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		logger.Error("Error while hashing a password " + err.Error())
+		return "", errs.NewUnexpectedError("Unexpected error while hashing the password")
+	}
+	return string(hash), nil
+}
+
+// CheckPassword compares the password with the user's hash in constant time
+func (u User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+// IsLocked checks if the user is locked out at now
+func (u User) IsLocked(now time.Time) bool {
+	if !u.LockedUntil.Valid {
+		return false
+	}
+	lockedUntil, err := time.ParseInLocation(dbTSLayout, u.LockedUntil.String, time.Local)
+	if err != nil {
+		return false
+	}
+	return now.Before(lockedUntil)
+}
+
+// ToDTO converts a user to a response without its password hash
+func (u User) ToDTO() dto.UserResponse {
+	return dto.UserResponse{
+		Username:     u.Username,
+		Role:         u.Role,
+		CustomerID:   u.CustomerID.String,
+		CreatedOn:    u.CreatedOn,
+		FailedLogins: u.FailedLogins,
+		LockedUntil:  u.LockedUntil.String,
+		MFAEnabled:   u.TOTPEnabled,
+	}
+}
+
+// AccessClaims returns the claims of an access token for the user
+func (u User) AccessClaims() AccessClaims {
+	claims := AccessClaims{
+		CustomerID: u.CustomerID.String,
+		Accounts:   u.Accounts,
+		Username:   u.Username,
+		Role:       u.Role,
+	}
+	claims.Subject = u.Username
+	return claims
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,235 @@
+package domain
+
+import (
+	"database/sql"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// mysqlMissingParent is the mysql error number for a foreign key that points at a missing row
+const mysqlMissingParent = 1452
+
+// The query statements
+const (
+	findAllUsers     = "SELECT username, password, role, customer_id, failed_logins, locked_until, created_on, totp_enabled from users;"
+	findUser         = "SELECT username, password, role, customer_id, failed_logins, locked_until, created_on, totp_secret, totp_enabled, totp_last_step from users where username = ?;"
+	findUserAccounts = "SELECT account_id from accounts where customer_id = ? and status <> 'closed';"
+	insertUser       = "INSERT INTO users (username, password, role, customer_id, created_on) values (?, ?, ?, ?, ?);"
+	updateUser       = "UPDATE users SET password = ?, role = ?, customer_id = ? where username = ?;"
+	deleteUser       = "DELETE from users where username = ?;"
+	// locked_until is set before failed_logins, mysql evaluates the assignments in order so both see the count before this failure
+	recordFailedLogin = "UPDATE users SET locked_until = CASE WHEN failed_logins + 1 >= ? THEN ? ELSE locked_until END, " +
+		"failed_logins = CASE WHEN failed_logins + 1 >= ? THEN 0 ELSE failed_logins + 1 END where username = ?;"
+	resetFailedLogins = "UPDATE users SET failed_logins = 0, locked_until = NULL where username = ?;"
+	setTOTPSecret     = "UPDATE users SET totp_secret = ?, totp_enabled = 0, totp_last_step = 0 where username = ?;"
+	enableTOTP        = "UPDATE users SET totp_enabled = 1, totp_last_step = ? where username = ? and totp_secret IS NOT NULL;"
+	useTOTPStep       = "UPDATE users SET totp_last_step = ? where username = ? and totp_last_step < ?;"
+	disableTOTP       = "UPDATE users SET totp_secret = NULL, totp_enabled = 0, totp_last_step = 0 where username = ?;"
+	deleteRecovery    = "DELETE from user_recovery_codes where username = ?;"
+	insertRecovery    = "INSERT INTO user_recovery_codes (username, code_hash) values (?, ?);"
+	useRecoveryCode   = "UPDATE user_recovery_codes SET used_at = ? where username = ? and code_hash = ? and used_at IS NULL;"
+)
+
+// UserRepositoryDB holds the sql client connection
+type UserRepositoryDB struct {
+	client *sqlx.DB
+}
+
+// NewUserRepositoryDB creates a new UserRepositoryDB to call sql methods
+func NewUserRepositoryDB(client *sqlx.DB) UserRepositoryDB {
+	return UserRepositoryDB{client}
+}
+
+// FindAll returns every user
+func (d UserRepositoryDB) FindAll() ([]User, *errs.AppError) {
+	users := make([]User, 0)
+	if err := d.client.Select(&users, findAllUsers); err != nil {
+		logger.Error("Error while querying users table " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return users, nil
+}
+
+// ByUsername returns a user, and for a customer user the ids of the customer's open accounts
+func (d UserRepositoryDB) ByUsername(username string) (*User, *errs.AppError) {
+	var u User
+	if err := d.client.Get(&u, findUser, username); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errs.NewNotFoundError("User not found")
+		}
+		logger.Error("Error while scanning user " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	if !u.CustomerID.Valid {
+		return &u, nil
+	}
+	u.Accounts = make([]string, 0)
+	if err := d.client.Select(&u.Accounts, findUserAccounts, u.CustomerID.String); err != nil {
+		logger.Error("Error while querying the accounts of a user " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return &u, nil
+}
+
+// Save creates a user
+func (d UserRepositoryDB) Save(u User) *errs.AppError {
+	_, err := d.client.Exec(insertUser, u.Username, u.PasswordHash, u.Role, u.CustomerID, u.CreatedOn)
+	if err != nil {
+		return userWriteError(err)
+	}
+	return nil
+}
+
+// Update replaces the password hash, role and customer of a user. Mysql reports a row that did not change
+// as unaffected, so callers look the user up first instead of relying on the affected rows.
+func (d UserRepositoryDB) Update(u User) *errs.AppError {
+	if _, err := d.client.Exec(updateUser, u.PasswordHash, u.Role, u.CustomerID, u.Username); err != nil {
+		return userWriteError(err)
+	}
+	return nil
+}
+
+// Delete removes a user
+func (d UserRepositoryDB) Delete(username string) *errs.AppError {
+	result, err := d.client.Exec(deleteUser, username)
+	if err != nil {
+		logger.Error("Error while deleting user " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return userAffected(result)
+}
+
+// RecordFailedLogin counts a failed login in a single statement, so concurrent failures are all counted
+func (d UserRepositoryDB) RecordFailedLogin(username string, maxAttempts int, lockedUntil string) *errs.AppError {
+	if _, err := d.client.Exec(recordFailedLogin, maxAttempts, lockedUntil, maxAttempts, username); err != nil {
+		logger.Error("Error while recording a failed login " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// ResetFailedLogins clears the failed login count and unlocks the user
+func (d UserRepositoryDB) ResetFailedLogins(username string) *errs.AppError {
+	if _, err := d.client.Exec(resetFailedLogins, username); err != nil {
+		logger.Error("Error while resetting failed logins " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// SetTOTPSecret stores the secret of an enrollment that still has to be confirmed
+func (d UserRepositoryDB) SetTOTPSecret(username string, secret string) *errs.AppError {
+	if _, err := d.client.Exec(setTOTPSecret, secret, username); err != nil {
+		logger.Error("Error while storing a totp secret " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// EnableTOTP turns mfa on and replaces the recovery codes in one db transaction
+func (d UserRepositoryDB) EnableTOTP(username string, step int64, recoveryCodeHashes []string) *errs.AppError {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for mfa " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if _, err = tx.Exec(enableTOTP, step, username); err == nil {
+		if _, err = tx.Exec(deleteRecovery, username); err == nil {
+			for _, hash := range recoveryCodeHashes {
+				if _, err = tx.Exec(insertRecovery, username, hash); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		logger.Error("Error while turning on mfa " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if err = tx.Commit(); err != nil {
+		logger.Error("Error while committing mfa " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+// UseTOTPStep marks a period as used in a single statement, so a code raced by two requests is only accepted once
+func (d UserRepositoryDB) UseTOTPStep(username string, step int64) (bool, *errs.AppError) {
+	result, err := d.client.Exec(useTOTPStep, step, username, step)
+	if err != nil {
+		logger.Error("Error while using a totp code " + err.Error())
+		return false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return rowsChanged(result)
+}
+
+// UseRecoveryCode marks a recovery code as used, each code works once
+func (d UserRepositoryDB) UseRecoveryCode(username string, codeHash string, usedAt string) (bool, *errs.AppError) {
+	result, err := d.client.Exec(useRecoveryCode, usedAt, username, codeHash)
+	if err != nil {
+		logger.Error("Error while using a recovery code " + err.Error())
+		return false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return rowsChanged(result)
+}
+
+// DisableTOTP turns mfa off and removes the recovery codes in one db transaction
+func (d UserRepositoryDB) DisableTOTP(username string) *errs.AppError {
+	tx, err := d.client.Beginx()
+	if err != nil {
+		logger.Error("Error while starting a new transaction for mfa " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if _, err = tx.Exec(disableTOTP, username); err == nil {
+		_, err = tx.Exec(deleteRecovery, username)
+	}
+	if err != nil {
+		tx.Rollback()
+		logger.Error("Error while turning off mfa " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if err = tx.Commit(); err != nil {
+		logger.Error("Error while committing mfa " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	return nil
+}
+
+func rowsChanged(result sql.Result) (bool, *errs.AppError) {
+	n, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("Error while reading affected rows " + err.Error())
+		return false, errs.NewUnexpectedError("Unexpected database error")
+	}
+	return n > 0, nil
+}
+
+func userWriteError(err error) *errs.AppError {
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry:
+			return errs.NewConflictError("Username is already taken")
+		case mysqlMissingParent:
+			return errs.NewValidationError("Customer not found")
+		}
+	}
+	logger.Error("Error while saving user " + err.Error())
+	return errs.NewUnexpectedError("Unexpected database error")
+}
+
+// userAffected turns a delete of no rows into a not found error
+func userAffected(result sql.Result) *errs.AppError {
+	n, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("Error while reading affected users " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if n == 0 {
+		return errs.NewNotFoundError("User not found")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserIsLocked(t *testing.T) {
+	now := time.Date(2021, 3, 10, 9, 0, 0, 0, time.Local)
+	u := User{}
+	assert.False(t, u.IsLocked(now))
+
+	u.LockedUntil = sql.NullString{String: "2021-03-10 09:00:01", Valid: true}
+	assert.True(t, u.IsLocked(now))
+
+	u.LockedUntil = sql.NullString{String: "2021-03-10 09:00:00", Valid: true}
+	assert.False(t, u.IsLocked(now))
+}
+
+func TestCheckRole(t *testing.T) {
+	assert.Nil(t, CheckRole(ROLE_CUSTOMER, "2001"))
+	assert.Nil(t, CheckRole(ROLE_TELLER, ""))
+	assert.NotNil(t, CheckRole(ROLE_CUSTOMER, ""))
+	assert.NotNil(t, CheckRole(ROLE_AUDITOR, "2001"))
+	assert.NotNil(t, CheckRole(ROLE_USER, "2001"))
+}
+
+func TestIssuedTokensVerifyAndRefreshTokensAreNotAccepted(t *testing.T) {
+	issuer := NewHMACTokenIssuer([]byte("hmacSampleSecret"), "banking-auth", "banking", 15*time.Minute, 24*time.Hour)
+	issuer.now = func() time.Time { return testNow }
+	u := User{Username: "arian", Role: ROLE_CUSTOMER, CustomerID: sql.NullString{String: "2001", Valid: true}, Accounts: []string{"95472"}}
+
+	tokens, err := issuer.Issue(u.AccessClaims())
+	assert.Nil(t, err)
+
+	repo := localRepo(hmacKeys())
+	claims, err := repo.Verify(tokens.AccessToken)
+	assert.Nil(t, err)
+	assert.Equal(t, "arian", claims.Subject)
+	assert.Equal(t, []string{"95472"}, claims.Accounts)
+
+	_, err = repo.Verify(tokens.RefreshToken)
+	assert.EqualError(t, err, "token is not an access token")
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// ANY_VERSION skips the version check, it is used when the client did not send If-Match
+const ANY_VERSION = 0
+
+// CheckVersion returns a precondition failed error when the client expected another version of a row
+func CheckVersion(subject string, current int, expected int) *errs.AppError {
+	if expected == ANY_VERSION || expected == current {
+		return nil
+	}
+	return staleVersion(subject)
+}
+
+// staleVersion is returned when a versioned update finds the row was changed by someone else
+func staleVersion(subject string) *errs.AppError {
+	return errs.NewPreconditionFailedError("The " + subject + " was changed by another request").WithReason("version_mismatch")
+}
+
+// execVersioned runs an UPDATE ... WHERE version = ? and fails with a precondition error when no row
+// matched, because the row was changed since its version was read
+func execVersioned(tx *sqlx.Tx, subject string, query string, args ...interface{}) *errs.AppError {
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		logger.Error("Error while updating " + subject + ": " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("Error while counting updated " + subject + " rows: " + err.Error())
+		return errs.NewUnexpectedError("Unexpected database error")
+	}
+	if updated == 0 {
+		return staleVersion(subject)
+	}
+	return nil
+}
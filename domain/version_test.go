@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckVersion(t *testing.T) {
+	assert.Nil(t, CheckVersion(SUBJECT_CUSTOMER, 3, ANY_VERSION))
+	assert.Nil(t, CheckVersion(SUBJECT_CUSTOMER, 3, 3))
+
+	err := CheckVersion(SUBJECT_CUSTOMER, 4, 3)
+	assert.EqualValues(t, 412, err.Code)
+	assert.EqualValues(t, "version_mismatch", err.Reason)
+	assert.EqualValues(t, "The customer was changed by another request", err.Message)
+}
@@ -4,19 +4,23 @@ import (
 	"strings"
 
 	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
 )
 
+// account types
 const (
-	MINIMUM_FUNDS = 5000.0
-	SAVING        = "saving"
-	CHECKING      = "checking"
+	SAVING   = "saving"
+	CHECKING = "checking"
 )
 
+// MINIMUM_FUNDS is the smallest opening deposit for a new account
+var MINIMUM_FUNDS = money.MustParse("5000.00")
+
 // CreateAccountRequest must follow this format to create a new account
 type CreateAccountRequest struct {
-	CustomerID  string  `json:"customer_id"`
-	AccountType string  `json:"account_type"`
-	Amount      float64 `json:"amount"`
+	CustomerID  string      `json:"customer_id"`
+	AccountType string      `json:"account_type"`
+	Amount      money.Money `json:"amount"`
 }
 
 // CreateAccountResponse must follow this format to return a new account created
@@ -24,13 +28,21 @@ type CreateAccountResponse struct {
 	AccountID string `json:"account_id"`
 }
 
-// GetAccountResponse must follow this format to return a an account
+// GetAccountResponse must follow this format to return a an account. The ledger balance is everything posted
+// to the account, amount is the same and kept for older clients. The available balance is what can still be
+// withdrawn: the ledger balance less active holds, plus the unused overdraft limit.
 type GetAccountResponse struct {
-	AccountID   string  `json:"account_id"`
-	CustomerID  string  `json:"customer_id"`
-	OpeningDate string  `json:"opening_date"`
-	AccountType string  `json:"account_type"`
-	Amount      float64 `json:"amount"`
+	AccountID        string      `json:"account_id"`
+	CustomerID       string      `json:"customer_id"`
+	OpeningDate      string      `json:"opening_date"`
+	AccountType      string      `json:"account_type"`
+	Amount           money.Money `json:"amount"`
+	LedgerBalance    money.Money `json:"ledger_balance"`
+	AvailableBalance money.Money `json:"available_balance"`
+	Status           string      `json:"status"`
+	OverdraftLimit   money.Money `json:"overdraft_limit"`
+	OverdraftSweep   bool        `json:"overdraft_sweep"`
+	Version          int         `json:"-"`
 }
 
 // Validate checks that an a new account being created has
@@ -46,8 +58,8 @@ func (r CreateAccountRequest) Validate() *errs.AppError {
 	return nil
 }
 
-func minimumFunds(amount float64) bool {
-	return amount < MINIMUM_FUNDS
+func minimumFunds(amount money.Money) bool {
+	return amount.LessThan(MINIMUM_FUNDS)
 }
 
 func validAccountType(accountType string) bool {
@@ -3,17 +3,19 @@ package dto
 import (
 	"testing"
 
+	"github.com/jonathanwamsley/banking/money"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestMinimumAmount(t *testing.T) {
-	assert.True(t, minimumFunds(MINIMUM_FUNDS-1))
+	cent := money.FromMinor(1)
+	assert.True(t, minimumFunds(MINIMUM_FUNDS.Sub(cent)))
 	assert.False(t, minimumFunds(MINIMUM_FUNDS))
-	assert.False(t, minimumFunds(MINIMUM_FUNDS+1))
+	assert.False(t, minimumFunds(MINIMUM_FUNDS.Add(cent)))
 }
 
 func TestConstants(t *testing.T) {
-	assert.EqualValues(t, 5000.0, MINIMUM_FUNDS)
+	assert.EqualValues(t, money.MustParse("5000.00"), MINIMUM_FUNDS)
 	assert.EqualValues(t, "saving", SAVING)
 	assert.EqualValues(t, "checking", CHECKING)
 }
@@ -33,7 +35,7 @@ func TestValidateNoErrors(t *testing.T) {
 }
 
 func TestValidateBadAmount(t *testing.T) {
-	a := CreateAccountRequest{Amount: MINIMUM_FUNDS - 1}
+	a := CreateAccountRequest{Amount: MINIMUM_FUNDS.Sub(money.FromMinor(1))}
 	err := a.Validate()
 	assert.NotNil(t, err)
 	assert.EqualValues(t, 422, err.Code)
@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// TIMESTAMP_LAYOUT is how times are written in requests and responses
+const TIMESTAMP_LAYOUT = "2006-01-02 15:04:05"
+
+// routeNamePattern is the shape of the route names given in app.Start
+var routeNamePattern = regexp.MustCompile(`^[A-Za-z]+$`)
+
+// CreateAPIKeyRequest names a key and the routes it may call. ExpiresAt is optional, a key without it lasts until revoked.
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+// APIKeyResponse is an api key without its secret
+type APIKeyResponse struct {
+	KeyID      string   `json:"key_id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	CreatedBy  string   `json:"created_by"`
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+}
+
+// CreateAPIKeyResponse holds a new api key, the key itself is only ever shown once
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// Validate checks the key has a name, at least one well formed scope, and an expiry in the future when one is set
+func (r CreateAPIKeyRequest) Validate(now time.Time) *errs.AppError {
+	if r.Name == "" || len(r.Name) > 100 {
+		return errs.NewValidationError("name must be 1 to 100 characters")
+	}
+	if len(r.Scopes) == 0 {
+		return errs.NewValidationError("scopes must name at least one route")
+	}
+	for _, scope := range r.Scopes {
+		if !routeNamePattern.MatchString(scope) {
+			return errs.NewValidationError("scope " + scope + " is not a route name")
+		}
+	}
+	if r.ExpiresAt != "" {
+		expiresAt, err := time.ParseInLocation(TIMESTAMP_LAYOUT, r.ExpiresAt, time.Local)
+		if err != nil {
+			return errs.NewValidationError("expires_at should look like " + TIMESTAMP_LAYOUT)
+		}
+		if !expiresAt.After(now) {
+			return errs.NewValidationError("expires_at should be in the future")
+		}
+	}
+	return nil
+}
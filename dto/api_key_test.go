@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAPIKeyRequestValidate(t *testing.T) {
+	now := time.Date(2021, 3, 10, 9, 0, 0, 0, time.Local)
+
+	assert.Nil(t, CreateAPIKeyRequest{Name: "nightly", Scopes: []string{"CheckLedger"}}.Validate(now))
+	assert.Nil(t, CreateAPIKeyRequest{Name: "nightly", Scopes: []string{"CheckLedger"}, ExpiresAt: "2021-06-01 00:00:00"}.Validate(now))
+	assert.NotNil(t, CreateAPIKeyRequest{Scopes: []string{"CheckLedger"}}.Validate(now))
+	assert.NotNil(t, CreateAPIKeyRequest{Name: "nightly"}.Validate(now))
+	assert.NotNil(t, CreateAPIKeyRequest{Name: "nightly", Scopes: []string{"*"}}.Validate(now))
+	assert.NotNil(t, CreateAPIKeyRequest{Name: "nightly", Scopes: []string{"CheckLedger"}, ExpiresAt: "2021-03-01 00:00:00"}.Validate(now))
+	assert.NotNil(t, CreateAPIKeyRequest{Name: "nightly", Scopes: []string{"CheckLedger"}, ExpiresAt: "June"}.Validate(now))
+}
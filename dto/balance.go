@@ -0,0 +1,39 @@
+package dto
+
+import "github.com/jonathanwamsley/banking/money"
+
+// BalanceResponse is the balance of an account at the end of AsOf. SnapshotDate is the end of day snapshot it
+// was computed from, it is left out when the whole history was read.
+type BalanceResponse struct {
+	AccountID    string      `json:"account_id"`
+	AsOf         string      `json:"as_of"`
+	Balance      money.Money `json:"balance"`
+	SnapshotDate string      `json:"snapshot_date,omitempty"`
+}
+
+// SnapshotRunResponse reports what a snapshot run did. Skipped accounts already had a snapshot of the date.
+type SnapshotRunResponse struct {
+	Date     string `json:"date"`
+	Accounts int    `json:"accounts"`
+	Skipped  int    `json:"skipped"`
+	Failed   int    `json:"failed"`
+}
+
+// SnapshotMismatch returns an account whose last snapshot, moved by the ledger since, is not its stored balance
+type SnapshotMismatch struct {
+	AccountID       string      `json:"account_id"`
+	AccountBalance  money.Money `json:"account_balance"`
+	SnapshotDate    string      `json:"snapshot_date"`
+	SnapshotBalance money.Money `json:"snapshot_balance"`
+	MovementSince   money.Money `json:"movement_since"`
+	ExpectedBalance money.Money `json:"expected_balance"`
+}
+
+// SnapshotCheckResponse returns the result of comparing the balance snapshots with the account balances.
+// LastSnapshotDate is the last date the snapshot job completed.
+type SnapshotCheckResponse struct {
+	Consistent       bool               `json:"consistent"`
+	AccountsChecked  int                `json:"accounts_checked"`
+	LastSnapshotDate string             `json:"last_snapshot_date"`
+	Mismatches       []SnapshotMismatch `json:"mismatches"`
+}
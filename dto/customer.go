@@ -1,11 +1,26 @@
 package dto
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/jonathanwamsley/banking/errs"
 )
 
+// the longest values the customers table can hold
+const (
+	MAX_NAME_LENGTH    = 100
+	MAX_CITY_LENGTH    = 100
+	MAX_ZIPCODE_LENGTH = 10
+)
+
+// zipcodes are letters and digits, optionally split by a space or a dash like 12550-1234 or SW1A 1AA
+var zipcodePattern = regexp.MustCompile(`^[A-Za-z0-9]+([ -][A-Za-z0-9]+)?$`)
+
 // CustomerResponse returns the expected json response after a customer query is requested
 type CustomerResponse struct {
 	ID          string `json:"customer_id"`
@@ -14,6 +29,13 @@ type CustomerResponse struct {
 	Zipcode     string `json:"zipcode"`
 	DateofBirth string `json:"date_of_birth"`
 	Status      string `json:"status"`
+	Version     int    `json:"-"`
+}
+
+// UpdateCustomerResponse is the updated customer with the json names of the fields that changed
+type UpdateCustomerResponse struct {
+	CustomerResponse
+	ChangedFields []string `json:"changed_fields"`
 }
 
 // CustomerRequest holds the expected input fields from a request
@@ -24,24 +46,66 @@ type CustomerRequest struct {
 	DateofBirth string `json:"date_of_birth"`
 }
 
-// Validate makes sure all fields are not empty
+// Validate makes sure all fields are not empty, fit in the db, and that the zipcode
+// and date of birth (like 1988-05-21, in the past) are well formed
 func (c CustomerRequest) Validate() *errs.AppError {
 	c.Name = strings.TrimSpace(c.Name)
 	c.City = strings.TrimSpace(c.City)
 	c.Zipcode = strings.TrimSpace(c.Zipcode)
 	c.DateofBirth = strings.TrimSpace(c.DateofBirth)
 
-	if c.Name == "" {
+	if c.Name == "" || len(c.Name) > MAX_NAME_LENGTH {
 		return errs.NewValidationError("invalid name")
 	}
-	if c.City == "" {
+	if c.City == "" || len(c.City) > MAX_CITY_LENGTH {
 		return errs.NewValidationError("invalid city")
 	}
-	if c.Zipcode == "" {
+	if c.Zipcode == "" || len(c.Zipcode) > MAX_ZIPCODE_LENGTH || !zipcodePattern.MatchString(c.Zipcode) {
 		return errs.NewValidationError("invalid zipcode")
 	}
-	if c.DateofBirth == "" {
+	dob, err := time.Parse(DATE_LAYOUT, c.DateofBirth)
+	if err != nil || !dob.Before(time.Now()) {
 		return errs.NewValidationError("invalid date of birth")
 	}
 	return nil
 }
+
+// Trimmed returns the request without leading or trailing spaces, the way it is stored
+func (c CustomerRequest) Trimmed() CustomerRequest {
+	return CustomerRequest{
+		Name:        strings.TrimSpace(c.Name),
+		City:        strings.TrimSpace(c.City),
+		Zipcode:     strings.TrimSpace(c.Zipcode),
+		DateofBirth: strings.TrimSpace(c.DateofBirth),
+	}
+}
+
+// CustomerPatch is a json merge patch (RFC 7396) for a customer. A member that is left out keeps its value,
+// a member that is set replaces it. Every customer field is required, so a null member is rejected.
+type CustomerPatch map[string]json.RawMessage
+
+// ApplyTo merges the patch into the current customer fields and validates the result
+func (p CustomerPatch) ApplyTo(current CustomerRequest) (CustomerRequest, *errs.AppError) {
+	fields := map[string]*string{
+		"full_name":     &current.Name,
+		"city":          &current.City,
+		"zipcode":       &current.Zipcode,
+		"date_of_birth": &current.DateofBirth,
+	}
+	for member, raw := range p {
+		field, ok := fields[member]
+		if !ok {
+			return current, errs.NewValidationError(fmt.Sprintf("%s cannot be updated", member))
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			return current, errs.NewValidationError(fmt.Sprintf("%s cannot be removed", member))
+		}
+		if err := json.Unmarshal(raw, field); err != nil {
+			return current, errs.NewValidationError(fmt.Sprintf("%s must be a string", member))
+		}
+	}
+	if err := current.Validate(); err != nil {
+		return current, err
+	}
+	return current.Trimmed(), nil
+}
@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,7 +41,75 @@ func TestValidateInvalidDateOfBirth(t *testing.T) {
 }
 
 func TestValidateNoError(t *testing.T) {
-	cr := CustomerRequest{"name", "city", "321123", "01/01/2000"}
+	cr := CustomerRequest{"name", "city", "321123", "2000-01-01"}
 	err := cr.Validate()
 	assert.Nil(t, err)
 }
+
+func TestValidateMalformedDateOfBirth(t *testing.T) {
+	for _, dob := range []string{"01/01/2000", "2000-13-01", "2999-01-01"} {
+		cr := CustomerRequest{"name", "city", "32123", dob}
+		err := cr.Validate()
+		assert.NotNil(t, err, dob)
+		assert.EqualValues(t, "invalid date of birth", err.Message)
+	}
+}
+
+func TestValidateZipcodeFormat(t *testing.T) {
+	assert.Nil(t, CustomerRequest{"name", "city", "12550-1234", "2000-01-01"}.Validate())
+	assert.Nil(t, CustomerRequest{"name", "city", "SW1A 1AA", "2000-01-01"}.Validate())
+
+	for _, zipcode := range []string{"125#50", "12550-12345", "-12550"} {
+		err := CustomerRequest{"name", "city", zipcode, "2000-01-01"}.Validate()
+		assert.NotNil(t, err, zipcode)
+		assert.EqualValues(t, "invalid zipcode", err.Message)
+	}
+}
+
+func TestValidateNameTooLong(t *testing.T) {
+	err := CustomerRequest{strings.Repeat("n", MAX_NAME_LENGTH+1), "city", "32123", "2000-01-01"}.Validate()
+	assert.EqualValues(t, "invalid name", err.Message)
+}
+
+func currentCustomer() CustomerRequest {
+	return CustomerRequest{Name: "Arian", City: "Newburgh, NY", Zipcode: "12550", DateofBirth: "1988-05-21"}
+}
+
+func TestCustomerPatchKeepsMissingFields(t *testing.T) {
+	var patch CustomerPatch
+	json.Unmarshal([]byte(`{"city": " Beacon, NY ", "zipcode": "12508"}`), &patch)
+
+	updated, err := patch.ApplyTo(currentCustomer())
+	assert.Nil(t, err)
+	assert.EqualValues(t, CustomerRequest{Name: "Arian", City: "Beacon, NY", Zipcode: "12508", DateofBirth: "1988-05-21"}, updated)
+}
+
+func TestCustomerPatchRejectsNull(t *testing.T) {
+	var patch CustomerPatch
+	json.Unmarshal([]byte(`{"city": null}`), &patch)
+
+	_, err := patch.ApplyTo(currentCustomer())
+	assert.EqualValues(t, 422, err.Code)
+	assert.EqualValues(t, "city cannot be removed", err.Message)
+}
+
+func TestCustomerPatchRejectsReadOnlyFields(t *testing.T) {
+	var patch CustomerPatch
+	json.Unmarshal([]byte(`{"status": "closed"}`), &patch)
+
+	_, err := patch.ApplyTo(currentCustomer())
+	assert.EqualValues(t, "status cannot be updated", err.Message)
+}
+
+func TestCustomerPatchValidatesResult(t *testing.T) {
+	var patch CustomerPatch
+	json.Unmarshal([]byte(`{"date_of_birth": "05/21/1988"}`), &patch)
+
+	_, err := patch.ApplyTo(currentCustomer())
+	assert.EqualValues(t, "invalid date of birth", err.Message)
+
+	patch = nil
+	json.Unmarshal([]byte(`{"zipcode": 12550}`), &patch)
+	_, err = patch.ApplyTo(currentCustomer())
+	assert.EqualValues(t, "zipcode must be a string", err.Message)
+}
@@ -0,0 +1,65 @@
+package dto
+
+import "github.com/jonathanwamsley/banking/money"
+
+// FeeScheduleRequest creates or replaces the fees of an account type. Leaving out free_withdrawals makes
+// every withdrawal free. The overdraft rate is the annual interest charged on a negative balance in percent,
+// like 18.0000, and an empty rate charges none.
+type FeeScheduleRequest struct {
+	MinimumBalance  money.Money `json:"minimum_balance"`
+	MaintenanceFee  money.Money `json:"maintenance_fee"`
+	FreeWithdrawals *int        `json:"free_withdrawals"`
+	WithdrawalFee   money.Money `json:"withdrawal_fee"`
+	OverdraftFee    money.Money `json:"overdraft_fee"`
+	OverdraftRate   string      `json:"overdraft_rate"`
+}
+
+// FeeScheduleResponse is the fee schedule of an account type
+type FeeScheduleResponse struct {
+	AccountType     string      `json:"account_type"`
+	MinimumBalance  money.Money `json:"minimum_balance"`
+	MaintenanceFee  money.Money `json:"maintenance_fee"`
+	FreeWithdrawals *int        `json:"free_withdrawals"`
+	WithdrawalFee   money.Money `json:"withdrawal_fee"`
+	OverdraftFee    money.Money `json:"overdraft_fee"`
+	OverdraftRate   string      `json:"overdraft_rate"`
+}
+
+// FeeWaiverRequest waives a fee of an account. Dates look like 2021-03-31, starts_on is today when left out
+// and a waiver without ends_on lasts until it is ended.
+type FeeWaiverRequest struct {
+	FeeType  string `json:"fee_type"`
+	Reason   string `json:"reason"`
+	StartsOn string `json:"starts_on"`
+	EndsOn   string `json:"ends_on"`
+}
+
+// FeeWaiverResponse is a fee waiver of an account
+type FeeWaiverResponse struct {
+	WaiverID  string `json:"waiver_id"`
+	AccountID string `json:"account_id"`
+	FeeType   string `json:"fee_type"`
+	Reason    string `json:"reason"`
+	StartsOn  string `json:"starts_on"`
+	EndsOn    string `json:"ends_on,omitempty"`
+	CreatedBy string `json:"created_by"`
+	CreatedAt string `json:"created_at"`
+}
+
+// FeeRunRequest names the month maintenance fees are charged for, like 2021-03
+type FeeRunRequest struct {
+	Period string `json:"period"`
+}
+
+// FeeRunResponse reports what a maintenance fee run did. Charged accounts paid a fee, waived accounts
+// were below the minimum but had a waiver, and skipped accounts were already assessed for the month.
+type FeeRunResponse struct {
+	Job      string      `json:"job"`
+	Period   string      `json:"period"`
+	Charged  int         `json:"charged"`
+	Waived   int         `json:"waived"`
+	Assessed int         `json:"assessed"`
+	Skipped  int         `json:"skipped"`
+	Failed   int         `json:"failed"`
+	Total    money.Money `json:"total"`
+}
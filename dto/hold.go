@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// CreateHoldRequest reserves an amount of an account, like a card authorization
+type CreateHoldRequest struct {
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+}
+
+// CaptureHoldRequest settles a hold. Leaving out amount captures the whole hold, a smaller amount captures
+// part of it and releases the rest.
+type CaptureHoldRequest struct {
+	Amount *money.Money `json:"amount"`
+}
+
+// HoldResponse is a hold of an account. A captured hold has the withdrawal it was settled with.
+type HoldResponse struct {
+	HoldID         string                   `json:"hold_id"`
+	AccountID      string                   `json:"account_id"`
+	Amount         money.Money              `json:"amount"`
+	CapturedAmount money.Money              `json:"captured_amount"`
+	Status         string                   `json:"status"`
+	Description    string                   `json:"description"`
+	CreatedBy      string                   `json:"created_by"`
+	CreatedAt      string                   `json:"created_at"`
+	ExpiresAt      string                   `json:"expires_at"`
+	ClosedAt       string                   `json:"closed_at,omitempty"`
+	TransactionID  string                   `json:"transaction_id,omitempty"`
+	Transaction    *MakeTransactionResponse `json:"transaction,omitempty"`
+}
+
+// Validate checks the hold is for an amount greater than zero
+func (r CreateHoldRequest) Validate() *errs.AppError {
+	if !r.Amount.IsPositive() {
+		return errs.NewValidationError("Amount must be greater than zero")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package dto
+
+import "github.com/jonathanwamsley/banking/money"
+
+// InterestProductRequest creates or replaces an interest product. APY is a percentage like "2.50".
+type InterestProductRequest struct {
+	Name        string `json:"name"`
+	APY         string `json:"apy"`
+	Compounding string `json:"compounding"`
+	DayCount    string `json:"day_count"`
+}
+
+// InterestProductResponse is an interest product. AnnualRate is the nominal rate in percent that compounds to the APY.
+type InterestProductResponse struct {
+	ProductCode string `json:"product_code"`
+	Name        string `json:"name"`
+	APY         string `json:"apy"`
+	AnnualRate  string `json:"annual_rate"`
+	Compounding string `json:"compounding"`
+	DayCount    string `json:"day_count"`
+}
+
+// InterestRunRequest names the date an interest job runs for, an accrual date or a posting run date
+type InterestRunRequest struct {
+	Date string `json:"date"`
+}
+
+// InterestRunResponse reports what an interest job did. Skipped accounts were already done for the date.
+// Total is the interest paid, OverdraftTotal the interest charged on negative balances.
+type InterestRunResponse struct {
+	Job            string      `json:"job"`
+	Date           string      `json:"date"`
+	Accounts       int         `json:"accounts"`
+	Skipped        int         `json:"skipped"`
+	Failed         int         `json:"failed"`
+	Total          money.Money `json:"total"`
+	OverdraftTotal money.Money `json:"overdraft_total"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import "github.com/jonathanwamsley/banking/money"
+
+// TrialBalanceRow returns the totals posted to one ledger account
+type TrialBalanceRow struct {
+	LedgerAccount string      `json:"ledger_account"`
+	Name          string      `json:"name"`
+	Debits        money.Money `json:"debits"`
+	Credits       money.Money `json:"credits"`
+}
+
+// BalanceMismatch returns an account whose stored balance differs from the ledger
+type BalanceMismatch struct {
+	AccountID      string      `json:"account_id"`
+	AccountBalance money.Money `json:"account_balance"`
+	LedgerBalance  money.Money `json:"ledger_balance"`
+}
+
+// LedgerCheckResponse returns the result of checking that the books balance
+type LedgerCheckResponse struct {
+	Balanced          bool              `json:"balanced"`
+	TotalDebits       money.Money       `json:"total_debits"`
+	TotalCredits      money.Money       `json:"total_credits"`
+	TrialBalance      []TrialBalanceRow `json:"trial_balance"`
+	UnbalancedEntries []string          `json:"unbalanced_entries"`
+	BalanceMismatches []BalanceMismatch `json:"balance_mismatches"`
+}
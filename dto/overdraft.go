@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// UpdateOverdraftRequest sets how far an account may be overdrawn and whether checking sweeps from saving first
+type UpdateOverdraftRequest struct {
+	Limit money.Money `json:"overdraft_limit"`
+	Sweep bool        `json:"overdraft_sweep"`
+	// Version is the version sent with If-Match, 0 when there was none
+	Version int `json:"-"`
+}
+
+// Validate makes sure the limit is not negative, the domain decides which accounts can sweep
+func (r UpdateOverdraftRequest) Validate() *errs.AppError {
+	if r.Limit.IsNegative() {
+		return errs.NewValidationError("overdraft_limit cannot be less than zero")
+	}
+	return nil
+}
+
+// SweepResponse is money swept from the customer's saving account to cover a transaction
+type SweepResponse struct {
+	FromAccountID string      `json:"from_account_id"`
+	TransactionID string      `json:"transaction_id"`
+	Amount        money.Money `json:"amount"`
+}
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// reversal reason codes
+//
+// duplicate: the transaction was posted twice
+// posting_error: the transaction was posted to the wrong account or for the wrong amount
+// customer_dispute: the customer disputed the transaction and the bank agreed
+// fraud: the transaction was not made by the customer
+// goodwill: a fee or charge is given back as a courtesy
+const (
+	REVERSAL_DUPLICATE     = "duplicate"
+	REVERSAL_POSTING_ERROR = "posting_error"
+	REVERSAL_DISPUTE       = "customer_dispute"
+	REVERSAL_FRAUD         = "fraud"
+	REVERSAL_GOODWILL      = "goodwill"
+)
+
+var reversalReasonCodes = map[string]bool{
+	REVERSAL_DUPLICATE:     true,
+	REVERSAL_POSTING_ERROR: true,
+	REVERSAL_DISPUTE:       true,
+	REVERSAL_FRAUD:         true,
+	REVERSAL_GOODWILL:      true,
+}
+
+// ReverseTransactionRequest reverses a transaction. Leaving out amount reverses what is left of it, a smaller
+// amount reverses part of it.
+type ReverseTransactionRequest struct {
+	Amount     *money.Money `json:"amount"`
+	ReasonCode string       `json:"reason_code"`
+	Note       string       `json:"note"`
+}
+
+// ReversalResponse is a reversal with the transaction that was posted for it. The reversible amount is what
+// can still be reversed of the original transaction.
+type ReversalResponse struct {
+	ReversalID            string      `json:"reversal_id"`
+	TransactionID         string      `json:"transaction_id"`
+	ReversalTransactionID string      `json:"reversal_transaction_id"`
+	AccountID             string      `json:"account_id"`
+	TransactionType       string      `json:"transaction_type"`
+	Amount                money.Money `json:"amount"`
+	ReasonCode            string      `json:"reason_code"`
+	Note                  string      `json:"note,omitempty"`
+	NewBalance            money.Money `json:"new_balance"`
+	ReversibleAmount      money.Money `json:"reversible_amount"`
+	CreatedBy             string      `json:"created_by"`
+	CreatedAt             string      `json:"created_at"`
+	// FeeReversals are the reversals of the fees charged with the transaction, when it was reversed in full
+	FeeReversals []ReversalResponse `json:"fee_reversals,omitempty"`
+}
+
+// Validate checks the reason code and that an amount, when there is one, is greater than zero
+func (r ReverseTransactionRequest) Validate() *errs.AppError {
+	if !reversalReasonCodes[r.ReasonCode] {
+		return errs.NewValidationError("reason_code should be duplicate, posting_error, customer_dispute, fraud or goodwill").
+			WithReason("invalid_reason_code")
+	}
+	if r.Amount != nil && !r.Amount.IsPositive() {
+		return errs.NewValidationError("Amount must be greater than zero")
+	}
+	return nil
+}
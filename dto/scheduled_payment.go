@@ -0,0 +1,165 @@
+package dto
+
+import (
+	"strings"
+
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// how often a scheduled payment is made
+//
+// once: one payment on the start date
+// daily, weekly: every day or every 7 days from the start date
+// monthly: on day_of_month every month, on the last day of months that are shorter
+// end_of_month: on the last day of every month
+const (
+	FREQUENCY_ONCE         = "once"
+	FREQUENCY_DAILY        = "daily"
+	FREQUENCY_WEEKLY       = "weekly"
+	FREQUENCY_MONTHLY      = "monthly"
+	FREQUENCY_END_OF_MONTH = "end_of_month"
+)
+
+// what happens to a payment the account has no funds for
+//
+// skip: the payment is missed and the schedule moves on to its next date
+// retry: the payment is tried again every day, max_retries times, before it is missed
+// retry_then_suspend: like retry, but then the scheduled payment is suspended until the customer resumes it
+const (
+	RETRY_SKIP            = "skip"
+	RETRY_RETRY           = "retry"
+	RETRY_THEN_SUSPEND    = "retry_then_suspend"
+	DEFAULT_MAX_RETRIES   = 3
+	MAX_SCHEDULED_RETRIES = 10
+)
+
+var frequencies = map[string]bool{
+	FREQUENCY_ONCE:         true,
+	FREQUENCY_DAILY:        true,
+	FREQUENCY_WEEKLY:       true,
+	FREQUENCY_MONTHLY:      true,
+	FREQUENCY_END_OF_MONTH: true,
+}
+
+var retryPolicies = map[string]bool{RETRY_SKIP: true, RETRY_RETRY: true, RETRY_THEN_SUSPEND: true}
+
+// CreateScheduledPaymentRequest schedules a transfer to to_account_id, or a withdrawal when there is none, from
+// an account of the customer. Dates look like 2021-03-31, start_date is today when left out and a schedule
+// without end_date runs until it is cancelled. day_of_month defaults to the day of the start date.
+type CreateScheduledPaymentRequest struct {
+	CustomerID  string      `json:"-"`
+	AccountID   string      `json:"account_id"`
+	ToAccountID string      `json:"to_account_id"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+	Frequency   string      `json:"frequency"`
+	DayOfMonth  int         `json:"day_of_month"`
+	StartDate   string      `json:"start_date"`
+	EndDate     string      `json:"end_date"`
+	RetryPolicy string      `json:"retry_policy"`
+	MaxRetries  *int        `json:"max_retries"`
+}
+
+// UpdateScheduledPaymentRequest replaces what can change of a scheduled payment. A status of suspended pauses
+// it and active resumes it from its next date that is not past.
+type UpdateScheduledPaymentRequest struct {
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+	EndDate     string      `json:"end_date"`
+	RetryPolicy string      `json:"retry_policy"`
+	MaxRetries  *int        `json:"max_retries"`
+	Status      string      `json:"status"`
+	// Version is the version sent with If-Match, 0 when there was none
+	Version int `json:"-"`
+}
+
+// ScheduledPaymentResponse is a scheduled payment. next_run_date is left out once it will not run again.
+type ScheduledPaymentResponse struct {
+	PaymentID   string      `json:"payment_id"`
+	CustomerID  string      `json:"customer_id"`
+	AccountID   string      `json:"account_id"`
+	ToAccountID string      `json:"to_account_id,omitempty"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+	Frequency   string      `json:"frequency"`
+	DayOfMonth  int         `json:"day_of_month,omitempty"`
+	StartDate   string      `json:"start_date"`
+	EndDate     string      `json:"end_date,omitempty"`
+	NextRunDate string      `json:"next_run_date,omitempty"`
+	Attempts    int         `json:"attempts"`
+	RetryPolicy string      `json:"retry_policy"`
+	MaxRetries  int         `json:"max_retries"`
+	Status      string      `json:"status"`
+	CreatedAt   string      `json:"created_at"`
+	Version     int         `json:"-"`
+}
+
+// ScheduledPaymentRunResponse is one attempt at a scheduled payment. A succeeded run has the transaction it
+// posted, a declined or failed run the reason it did not.
+type ScheduledPaymentRunResponse struct {
+	RunID         string `json:"run_id"`
+	PaymentID     string `json:"payment_id"`
+	ScheduledDate string `json:"scheduled_date"`
+	Attempt       int    `json:"attempt"`
+	Status        string `json:"status"`
+	Reason        string `json:"reason,omitempty"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	RanAt         string `json:"ran_at"`
+}
+
+// ScheduledPaymentsRunResponse reports what a run of the due scheduled payments did. Skipped payments were
+// already being run by another scheduler.
+type ScheduledPaymentsRunResponse struct {
+	Date      string `json:"date"`
+	Succeeded int    `json:"succeeded"`
+	Declined  int    `json:"declined"`
+	Failed    int    `json:"failed"`
+	Skipped   int    `json:"skipped"`
+}
+
+// Validate checks the accounts, amount, frequency and retry policy of a new scheduled payment. The dates are
+// checked once they are read.
+func (r CreateScheduledPaymentRequest) Validate() *errs.AppError {
+	if strings.TrimSpace(r.AccountID) == "" {
+		return errs.NewValidationError("account_id is required")
+	}
+	if r.ToAccountID == r.AccountID {
+		return errs.NewValidationError("Cannot transfer to the same account")
+	}
+	if !r.Amount.IsPositive() {
+		return errs.NewValidationError("Amount must be greater than zero")
+	}
+	if !frequencies[r.Frequency] {
+		return errs.NewValidationError("frequency should be once, daily, weekly, monthly or end_of_month")
+	}
+	if r.DayOfMonth < 0 || r.DayOfMonth > 31 {
+		return errs.NewValidationError("day_of_month must be between 1 and 31")
+	}
+	if r.DayOfMonth != 0 && r.Frequency != FREQUENCY_MONTHLY {
+		return errs.NewValidationError("day_of_month is only used with a monthly frequency")
+	}
+	return validateRetries(r.RetryPolicy, r.MaxRetries)
+}
+
+// Validate checks the amount, retry policy and status of a scheduled payment update
+func (r UpdateScheduledPaymentRequest) Validate() *errs.AppError {
+	if !r.Amount.IsPositive() {
+		return errs.NewValidationError("Amount must be greater than zero")
+	}
+	if r.Status != "active" && r.Status != "suspended" {
+		return errs.NewValidationError("status should be active or suspended")
+	}
+	return validateRetries(r.RetryPolicy, r.MaxRetries)
+}
+
+// validateRetries checks a retry policy, an empty one is the default, and the number of retries
+func validateRetries(policy string, maxRetries *int) *errs.AppError {
+	if policy != "" && !retryPolicies[policy] {
+		return errs.NewValidationError("retry_policy should be skip, retry or retry_then_suspend")
+	}
+	if maxRetries != nil && (*maxRetries < 0 || *maxRetries > MAX_SCHEDULED_RETRIES) {
+		return errs.NewValidationError("max_retries must be between 0 and 10")
+	}
+	return nil
+}
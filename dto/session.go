@@ -0,0 +1,41 @@
+package dto
+
+import "github.com/jonathanwamsley/banking/errs"
+
+// RefreshRequest holds the refresh token that is traded for a new access token and refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RevokeTokenRequest holds a token to put on the revocation list
+type RevokeTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// SessionResponse is a login session of a user. Current marks the session of the token that asked.
+type SessionResponse struct {
+	SessionID  string `json:"session_id"`
+	Username   string `json:"username"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	IPAddress  string `json:"ip_address,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"`
+}
+
+// Validate checks a refresh token was sent
+func (r RefreshRequest) Validate() *errs.AppError {
+	if r.RefreshToken == "" {
+		return errs.NewValidationError("refresh_token is required")
+	}
+	return nil
+}
+
+// Validate checks a token was sent
+func (r RevokeTokenRequest) Validate() *errs.AppError {
+	if r.Token == "" {
+		return errs.NewValidationError("token is required")
+	}
+	return nil
+}
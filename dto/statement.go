@@ -0,0 +1,64 @@
+package dto
+
+import "github.com/jonathanwamsley/banking/money"
+
+// the formats a statement is returned in
+const (
+	STATEMENT_JSON = "json"
+	STATEMENT_CSV  = "csv"
+	STATEMENT_PDF  = "pdf"
+)
+
+// StatementCustomer is the owner of the account a statement was made for, as they were when it was generated
+type StatementCustomer struct {
+	CustomerID string `json:"customer_id"`
+	Name       string `json:"full_name"`
+	City       string `json:"city"`
+	Zipcode    string `json:"zipcode"`
+}
+
+// StatementLine is one transaction of a statement. Amount is positive for money in and negative for money out,
+// Balance is the balance right after it.
+type StatementLine struct {
+	TransactionID   string      `json:"transaction_id"`
+	TransactionType string      `json:"transaction_type"`
+	Description     string      `json:"description"`
+	TransactionDate string      `json:"transaction_date"`
+	ValueDate       string      `json:"value_date"`
+	Amount          money.Money `json:"amount"`
+	Balance         money.Money `json:"balance"`
+}
+
+// StatementResponse is the statement of an account for a month. Checksum is the sha256 of the stored statement,
+// the statement without its checksum, so a copy can be checked against the bank's.
+type StatementResponse struct {
+	AccountID       string            `json:"account_id"`
+	AccountType     string            `json:"account_type"`
+	Period          string            `json:"period"`
+	PeriodStart     string            `json:"period_start"`
+	PeriodEnd       string            `json:"period_end"`
+	Customer        StatementCustomer `json:"customer"`
+	OpeningBalance  money.Money       `json:"opening_balance"`
+	TotalCredits    money.Money       `json:"total_credits"`
+	TotalDebits     money.Money       `json:"total_debits"`
+	FeesCharged     money.Money       `json:"fees_charged"`
+	InterestPaid    money.Money       `json:"interest_paid"`
+	InterestCharged money.Money       `json:"interest_charged"`
+	ClosingBalance  money.Money       `json:"closing_balance"`
+	Transactions    []StatementLine   `json:"transactions"`
+	GeneratedAt     string            `json:"generated_at"`
+	Checksum        string            `json:"checksum,omitempty"`
+}
+
+// StatementRunRequest names the month statements are generated for, like 2021-03
+type StatementRunRequest struct {
+	Period string `json:"period"`
+}
+
+// StatementRunResponse reports what a statement run did. Accounts that already had a statement of the month
+// are not generated again.
+type StatementRunResponse struct {
+	Period     string `json:"period"`
+	Statements int    `json:"statements"`
+	Failed     int    `json:"failed"`
+}
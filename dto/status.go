@@ -0,0 +1,18 @@
+package dto
+
+import "github.com/jonathanwamsley/banking/errs"
+
+// UpdateStatusRequest moves a customer or an account to a new status
+type UpdateStatusRequest struct {
+	Status string `json:"status"`
+	// Version is the version sent with If-Match, 0 when there was none
+	Version int `json:"-"`
+}
+
+// Validate makes sure a status was sent, the domain decides if it is a status it knows
+func (r UpdateStatusRequest) Validate() *errs.AppError {
+	if r.Status == "" {
+		return errs.NewValidationError("status is required")
+	}
+	return nil
+}
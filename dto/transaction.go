@@ -1,20 +1,36 @@
 package dto
 
-import "github.com/jonathanwamsley/banking/errs"
+import (
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
 
 // transaction types
 const (
-	WITHDRAWAL = "withdrawal"
-	DEPOSIT    = "deposit"
+	WITHDRAWAL   = "withdrawal"
+	DEPOSIT      = "deposit"
+	TRANSFER_OUT = "transfer_out"
+	TRANSFER_IN  = "transfer_in"
+	INTEREST     = "interest"
+	FEE          = "fee"
+	SWEEP_OUT    = "sweep_out"
+	SWEEP_IN     = "sweep_in"
+	// OVERDRAFT_INTEREST is the interest charged on a negative balance
+	OVERDRAFT_INTEREST = "overdraft_interest"
+	// REVERSAL_OUT and REVERSAL_IN reverse a transaction that put money in or took it out
+	REVERSAL_OUT = "reversal_out"
+	REVERSAL_IN  = "reversal_in"
 )
 
 // MakeTransactionRequest fields to store a transaction
 type MakeTransactionRequest struct {
-	AccountID       string  `json:"account_id"`
-	Amount          float64 `json:"amount"`
-	TransactionType string  `json:"transaction_type"`
-	TransactionDate string  `json:"transaction_date"`
-	CustomerID      string  `json:"-"`
+	AccountID       string      `json:"account_id"`
+	Amount          money.Money `json:"amount"`
+	TransactionType string      `json:"transaction_type"`
+	TransactionDate string      `json:"transaction_date"`
+	CustomerID      string      `json:"-"`
+	// Version is the account version sent with If-Match, 0 when there was none
+	Version int `json:"-"`
 }
 
 // IsTransactionTypeWithdrawal checks for withdrawal type
@@ -32,17 +48,30 @@ func (r MakeTransactionRequest) Validate() *errs.AppError {
 	if !r.IsTransactionTypeWithdrawal() && !r.IsTransactionTypeDeposit() {
 		return errs.NewValidationError("Transaction type can only be deposit or withdrawal")
 	}
-	if r.Amount < 0 {
+	if r.Amount.IsNegative() {
 		return errs.NewValidationError("Amount cannot be less than zero")
 	}
 	return nil
 }
 
-// MakeTransactionResponse dto requirements
+// MakeTransactionResponse dto requirements. When the transaction was charged fees the new balance is
+// the balance after the fees, and the fee transactions are returned with it. Sweep is the money moved in
+// from the customer's saving account before the transaction was posted.
 type MakeTransactionResponse struct {
-	TransactionID   string  `json:"transaction_id"`
-	AccountID       string  `json:"account_id"`
-	Amount          float64 `json:"new_balance"`
-	TransactionType string  `json:"transaction_type"`
-	TransactionDate string  `json:"transaction_date"`
+	TransactionID   string               `json:"transaction_id"`
+	AccountID       string               `json:"account_id"`
+	Amount          money.Money          `json:"new_balance"`
+	TransactionType string               `json:"transaction_type"`
+	TransactionDate string               `json:"transaction_date"`
+	BookingDate     string               `json:"booking_date"`
+	ValueDate       string               `json:"value_date"`
+	Sweep           *SweepResponse       `json:"sweep,omitempty"`
+	Fees            []FeeChargedResponse `json:"fees,omitempty"`
+}
+
+// FeeChargedResponse is a fee transaction charged for another transaction
+type FeeChargedResponse struct {
+	TransactionID string      `json:"transaction_id"`
+	FeeType       string      `json:"fee_type"`
+	Amount        money.Money `json:"amount"`
 }
@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// the formats the transaction history is exported in for personal finance tools
+const (
+	EXPORT_OFX = "ofx"
+	EXPORT_QIF = "qif"
+	EXPORT_CSV = "csv"
+)
+
+// TransactionExportRequest holds the account and the dates of a transaction export.
+// From and To are inclusive dates in the DATE_LAYOUT format, leaving them out exports the whole history.
+type TransactionExportRequest struct {
+	AccountID  string
+	CustomerID string
+	From       string
+	To         string
+	Format     string
+}
+
+// Validate checks the format and the dates of the export request
+func (r TransactionExportRequest) Validate() *errs.AppError {
+	if r.Format != EXPORT_OFX && r.Format != EXPORT_QIF && r.Format != EXPORT_CSV {
+		return errs.NewValidationError("format must be ofx, qif or csv")
+	}
+	from, err := parseOptionalDate(r.From)
+	if err != nil {
+		return errs.NewValidationError("from must be a date like 2021-03-31")
+	}
+	to, err := parseOptionalDate(r.To)
+	if err != nil {
+		return errs.NewValidationError("to must be a date like 2021-03-31")
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return errs.NewValidationError("from must not be after to")
+	}
+	return nil
+}
+
+// TransactionExport describes an export before its transactions are written. From and To are the dates it
+// covers, Balance is the ledger balance of the account at ExportedAt.
+type TransactionExport struct {
+	AccountID   string
+	AccountType string
+	Currency    string
+	From        string
+	To          string
+	Balance     money.Money
+	ExportedAt  string
+}
+
+// TransactionExportItem is one exported transaction. Amount is positive for money in and negative for money out,
+// Balance is the balance right after it.
+type TransactionExportItem struct {
+	TransactionID        string
+	TransactionType      string
+	TransactionDate      string
+	BookingDate          string
+	ValueDate            string
+	Amount               money.Money
+	Balance              money.Money
+	RelatedTransactionID string
+}
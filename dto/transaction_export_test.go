@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportValidateFormatAndDates(t *testing.T) {
+	r := TransactionExportRequest{AccountID: "95470", CustomerID: "2000", Format: EXPORT_OFX}
+	assert.Nil(t, r.Validate())
+
+	r.Format = "xls"
+	assert.EqualValues(t, "format must be ofx, qif or csv", r.Validate().Message)
+
+	r.Format = EXPORT_QIF
+	r.From = "2021-03-31"
+	r.To = "2021-03-01"
+	assert.EqualValues(t, "from must not be after to", r.Validate().Message)
+
+	r.To = "31/03/2021"
+	assert.EqualValues(t, "to must be a date like 2021-03-31", r.Validate().Message)
+}
@@ -0,0 +1,112 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// paging and sorting of the transaction history
+const (
+	DEFAULT_PAGE_SIZE = 50
+	MAX_PAGE_SIZE     = 200
+	SORT_ASC          = "asc"
+	SORT_DESC         = "desc"
+	DATE_LAYOUT       = "2006-01-02"
+)
+
+// historyTransactionTypes are the types the history can be filtered by
+var historyTransactionTypes = map[string]bool{
+	WITHDRAWAL:         true,
+	DEPOSIT:            true,
+	TRANSFER_OUT:       true,
+	TRANSFER_IN:        true,
+	INTEREST:           true,
+	FEE:                true,
+	SWEEP_OUT:          true,
+	SWEEP_IN:           true,
+	OVERDRAFT_INTEREST: true,
+	REVERSAL_OUT:       true,
+	REVERSAL_IN:        true,
+}
+
+// TransactionHistoryRequest holds the filters and the page of an account's transaction history.
+// From and To are inclusive dates in the DATE_LAYOUT format.
+type TransactionHistoryRequest struct {
+	AccountID       string
+	CustomerID      string
+	Cursor          string
+	Limit           int
+	From            string
+	To              string
+	TransactionType string
+	MinAmount       *money.Money
+	MaxAmount       *money.Money
+	Sort            string
+}
+
+// Validate checks the page size, dates, type, amount range and sort order of the history request
+func (r TransactionHistoryRequest) Validate() *errs.AppError {
+	if r.Limit < 1 || r.Limit > MAX_PAGE_SIZE {
+		return errs.NewValidationError("limit must be between 1 and 200")
+	}
+	from, err := parseOptionalDate(r.From)
+	if err != nil {
+		return errs.NewValidationError("from must be a date like 2021-03-31")
+	}
+	to, err := parseOptionalDate(r.To)
+	if err != nil {
+		return errs.NewValidationError("to must be a date like 2021-03-31")
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return errs.NewValidationError("from must not be after to")
+	}
+	if r.TransactionType != "" && !historyTransactionTypes[r.TransactionType] {
+		return errs.NewValidationError("type must be withdrawal, deposit, transfer_out, transfer_in, interest, fee, sweep_out, sweep_in, overdraft_interest, reversal_out or reversal_in")
+	}
+	if r.MinAmount != nil && r.MinAmount.IsNegative() || r.MaxAmount != nil && r.MaxAmount.IsNegative() {
+		return errs.NewValidationError("Amount cannot be less than zero")
+	}
+	if r.MinAmount != nil && r.MaxAmount != nil && r.MaxAmount.LessThan(*r.MinAmount) {
+		return errs.NewValidationError("min_amount must not be greater than max_amount")
+	}
+	if r.Sort != SORT_ASC && r.Sort != SORT_DESC {
+		return errs.NewValidationError("sort must be asc or desc")
+	}
+	return nil
+}
+
+// IsDescending checks if the newest transactions come first
+func (r TransactionHistoryRequest) IsDescending() bool {
+	return r.Sort == SORT_DESC
+}
+
+func parseOptionalDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(DATE_LAYOUT, date)
+}
+
+// TransactionHistoryItem is one transaction with the account balance right after it was posted
+type TransactionHistoryItem struct {
+	TransactionID   string      `json:"transaction_id"`
+	TransactionType string      `json:"transaction_type"`
+	TransactionDate string      `json:"transaction_date"`
+	BookingDate     string      `json:"booking_date"`
+	ValueDate       string      `json:"value_date"`
+	Amount          money.Money `json:"amount"`
+	RunningBalance  money.Money `json:"running_balance"`
+	// RelatedTransactionID is the transaction a fee was charged for, or a reversal reverses
+	RelatedTransactionID string `json:"related_transaction_id,omitempty"`
+}
+
+// TransactionHistoryResponse is one page of an account's transactions.
+// NextCursor is passed back as the cursor query parameter to get the following page.
+type TransactionHistoryResponse struct {
+	AccountID    string                   `json:"account_id"`
+	Transactions []TransactionHistoryItem `json:"transactions"`
+	NextCursor   string                   `json:"next_cursor,omitempty"`
+	HasMore      bool                     `json:"has_more"`
+}
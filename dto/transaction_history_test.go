@@ -0,0 +1,73 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func historyRequest() TransactionHistoryRequest {
+	return TransactionHistoryRequest{AccountID: "95470", Limit: DEFAULT_PAGE_SIZE, Sort: SORT_DESC}
+}
+
+func amountOf(s string) *money.Money {
+	m := money.MustParse(s)
+	return &m
+}
+
+func TestHistoryValidateNoError(t *testing.T) {
+	r := historyRequest()
+	r.From = "2021-03-01"
+	r.To = "2021-03-31"
+	r.TransactionType = DEPOSIT
+	r.MinAmount = amountOf("10")
+	r.MaxAmount = amountOf("100")
+	assert.Nil(t, r.Validate())
+	assert.True(t, r.IsDescending())
+}
+
+func TestHistoryValidateLimit(t *testing.T) {
+	r := historyRequest()
+	r.Limit = 0
+	assert.NotNil(t, r.Validate())
+	r.Limit = MAX_PAGE_SIZE + 1
+	assert.NotNil(t, r.Validate())
+}
+
+func TestHistoryValidateDates(t *testing.T) {
+	r := historyRequest()
+	r.From = "03/01/2021"
+	assert.EqualValues(t, "from must be a date like 2021-03-31", r.Validate().Message)
+
+	r = historyRequest()
+	r.From = "2021-03-31"
+	r.To = "2021-03-01"
+	assert.EqualValues(t, "from must not be after to", r.Validate().Message)
+}
+
+func TestHistoryValidateType(t *testing.T) {
+	r := historyRequest()
+	r.TransactionType = "bonus"
+	assert.NotNil(t, r.Validate())
+
+	r.TransactionType = "interest"
+	assert.Nil(t, r.Validate())
+}
+
+func TestHistoryValidateAmounts(t *testing.T) {
+	r := historyRequest()
+	r.MinAmount = amountOf("100")
+	r.MaxAmount = amountOf("10")
+	assert.EqualValues(t, "min_amount must not be greater than max_amount", r.Validate().Message)
+
+	r = historyRequest()
+	r.MinAmount = amountOf("-1")
+	assert.NotNil(t, r.Validate())
+}
+
+func TestHistoryValidateSort(t *testing.T) {
+	r := historyRequest()
+	r.Sort = "newest"
+	assert.EqualValues(t, "sort must be asc or desc", r.Validate().Message)
+}
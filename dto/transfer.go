@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"strings"
+
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// TransferRequest moves an amount from the account in the route to another account
+type TransferRequest struct {
+	FromAccountID string      `json:"-"`
+	ToAccountID   string      `json:"to_account_id"`
+	Amount        money.Money `json:"amount"`
+	CustomerID    string      `json:"-"`
+	// Version is the version of the source account sent with If-Match, 0 when there was none
+	Version int `json:"-"`
+}
+
+// Validate makes sure the transfer has a destination that is not the source and a positive amount
+func (r TransferRequest) Validate() *errs.AppError {
+	if strings.TrimSpace(r.ToAccountID) == "" {
+		return errs.NewValidationError("to_account_id is required")
+	}
+	if r.ToAccountID == r.FromAccountID {
+		return errs.NewValidationError("Cannot transfer to the same account")
+	}
+	if !r.Amount.IsPositive() {
+		return errs.NewValidationError("Amount must be greater than zero")
+	}
+	return nil
+}
+
+// TransferLeg is one side of a transfer with the balance left after it was posted
+type TransferLeg struct {
+	TransactionID string      `json:"transaction_id"`
+	AccountID     string      `json:"account_id"`
+	NewBalance    money.Money `json:"new_balance"`
+}
+
+// TransferResponse returns both transactions of a transfer
+type TransferResponse struct {
+	Amount          money.Money `json:"amount"`
+	TransactionDate string      `json:"transaction_date"`
+	BookingDate     string      `json:"booking_date"`
+	ValueDate       string      `json:"value_date"`
+	From            TransferLeg `json:"from"`
+	To              TransferLeg `json:"to"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferValidateNoError(t *testing.T) {
+	r := TransferRequest{FromAccountID: "95470", ToAccountID: "95471", Amount: money.MustParse("10.00")}
+	assert.Nil(t, r.Validate())
+}
+
+func TestTransferValidateMissingDestination(t *testing.T) {
+	r := TransferRequest{FromAccountID: "95470", Amount: money.MustParse("10.00")}
+	err := r.Validate()
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 422, err.Code)
+	assert.EqualValues(t, "to_account_id is required", err.Message)
+}
+
+func TestTransferValidateSameAccount(t *testing.T) {
+	r := TransferRequest{FromAccountID: "95470", ToAccountID: "95470", Amount: money.MustParse("10.00")}
+	err := r.Validate()
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Cannot transfer to the same account", err.Message)
+}
+
+func TestTransferValidateAmount(t *testing.T) {
+	r := TransferRequest{FromAccountID: "95470", ToAccountID: "95471", Amount: money.Zero()}
+	err := r.Validate()
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Amount must be greater than zero", err.Message)
+
+	r.Amount = money.MustParse("-1")
+	assert.NotNil(t, r.Validate())
+}
@@ -0,0 +1,119 @@
+package dto
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// password rules, bcrypt only reads the first 72 bytes so longer passwords are rejected instead of silently cut
+const (
+	MIN_PASSWORD_LENGTH = 8
+	MAX_PASSWORD_BYTES  = 72
+)
+
+// usernames are 3 to 20 letters, digits, dots, dashes or underscores
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{3,20}$`)
+
+// CreateUserRequest holds the fields an admin sends to create a user. A customer user is linked
+// to their customer_id, staff users are not linked to a customer.
+type CreateUserRequest struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	Role       string `json:"role"`
+	CustomerID string `json:"customer_id"`
+}
+
+// UpdateUserRequest holds the fields an admin sends to change a user, the password is only changed when it is sent
+type UpdateUserRequest struct {
+	Password   string `json:"password"`
+	Role       string `json:"role"`
+	CustomerID string `json:"customer_id"`
+}
+
+// UserResponse is a user without its password hash
+type UserResponse struct {
+	Username     string `json:"username"`
+	Role         string `json:"role"`
+	CustomerID   string `json:"customer_id,omitempty"`
+	CreatedOn    string `json:"created_on"`
+	FailedLogins int    `json:"failed_logins"`
+	LockedUntil  string `json:"locked_until,omitempty"`
+	MFAEnabled   bool   `json:"mfa_enabled"`
+}
+
+// LoginRequest holds the credentials of a login. OTP is a code from the authenticator app or a recovery code,
+// it is only needed once the user turned on mfa.
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	OTP      string `json:"otp"`
+	// UserAgent and IPAddress describe the client in the session list, they are set by the handler
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+// LoginResponse holds the tokens of a login or a refresh. ExpiresIn is the lifetime of the access token in seconds.
+type LoginResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	SessionID    string `json:"session_id"`
+}
+
+// Validate checks the username is well formed and the password follows the password policy
+func (r CreateUserRequest) Validate() *errs.AppError {
+	if !usernamePattern.MatchString(r.Username) {
+		return errs.NewValidationError("username must be 3 to 20 letters, digits, dots, dashes or underscores")
+	}
+	return ValidatePassword(r.Password)
+}
+
+// Validate checks a new password, when one is sent, follows the password policy
+func (r UpdateUserRequest) Validate() *errs.AppError {
+	if r.Password == "" {
+		return nil
+	}
+	return ValidatePassword(r.Password)
+}
+
+// ValidatePassword enforces the password policy: at least 8 characters with a capital letter
+func ValidatePassword(password string) *errs.AppError {
+	if len([]rune(password)) < MIN_PASSWORD_LENGTH {
+		return errs.NewValidationError("password must be at least 8 characters").WithReason("weak_password")
+	}
+	if len(password) > MAX_PASSWORD_BYTES {
+		return errs.NewValidationError("password must be at most 72 bytes").WithReason("weak_password")
+	}
+	if strings.IndexFunc(password, unicode.IsUpper) < 0 {
+		return errs.NewValidationError("password must contain a capital letter").WithReason("weak_password")
+	}
+	return nil
+}
+
+// Validate checks both credentials were sent
+func (r LoginRequest) Validate() *errs.AppError {
+	if r.Username == "" || r.Password == "" {
+		return errs.NewValidationError("username and password are required")
+	}
+	return nil
+}
+
+// MFAEnrollmentResponse holds the totp secret of a new enrollment. Authenticator apps scan the provisioning uri as a QR code.
+type MFAEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAConfirmRequest holds the first code from the authenticator app, it proves the app was set up
+type MFAConfirmRequest struct {
+	OTP string `json:"otp"`
+}
+
+// RecoveryCodesResponse holds the recovery codes of a user, they are only ever shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
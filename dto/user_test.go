@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePasswordPolicy(t *testing.T) {
+	assert.Nil(t, ValidatePassword("Password1"))
+	assert.Nil(t, ValidatePassword("Ünïcode!"))
+
+	tooShort := ValidatePassword("Pass1")
+	assert.EqualValues(t, 422, tooShort.Code)
+	assert.EqualValues(t, "weak_password", tooShort.Reason)
+
+	noCapital := ValidatePassword("password1")
+	assert.EqualValues(t, "password must contain a capital letter", noCapital.Message)
+
+	tooLong := ValidatePassword("P" + strings.Repeat("a", 72))
+	assert.EqualValues(t, "password must be at most 72 bytes", tooLong.Message)
+}
+
+func TestValidateCreateUserRequest(t *testing.T) {
+	assert.Nil(t, CreateUserRequest{Username: "arian.2001", Password: "Password1"}.Validate())
+	assert.NotNil(t, CreateUserRequest{Username: "a", Password: "Password1"}.Validate())
+	assert.NotNil(t, CreateUserRequest{Username: "arian 2001", Password: "Password1"}.Validate())
+	assert.NotNil(t, CreateUserRequest{Username: "arian", Password: "password1"}.Validate())
+}
+
+func TestValidateUpdateUserRequestOnlyChecksSentPassword(t *testing.T) {
+	assert.Nil(t, UpdateUserRequest{Role: "teller"}.Validate())
+	assert.NotNil(t, UpdateUserRequest{Role: "teller", Password: "short"}.Validate())
+}
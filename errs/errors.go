@@ -2,19 +2,28 @@ package errs
 
 import "net/http"
 
-// AppError returns errors relevant for a http response, a status and error message
+// AppError returns errors relevant for a http response, a status and error message.
+// Reason is an optional machine readable code, so callers can tell apart errors that share a status.
 type AppError struct {
 	Code    int    `json:",omitempty"`
 	Message string `json:"message"`
+	Reason  string `json:"reason,omitempty"`
 }
 
-// AsMessage returns only the string
+// AsMessage returns only the string and the reason
 func (e AppError) AsMessage() *AppError {
 	return &AppError{
 		Message: e.Message,
+		Reason:  e.Reason,
 	}
 }
 
+// WithReason returns the error with a machine readable reason attached
+func (e *AppError) WithReason(reason string) *AppError {
+	e.Reason = reason
+	return e
+}
+
 // NewNotFoundError returns status not found(404) error + msg
 func NewNotFoundError(message string) *AppError {
 	return &AppError{
@@ -38,3 +47,43 @@ func NewValidationError(message string) *AppError {
 		Message: message,
 	}
 }
+
+// NewBadRequestError returns status bad request(400) error + msg
+func NewBadRequestError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusBadRequest,
+		Message: message,
+	}
+}
+
+// NewConflictError returns status conflict(409) error + msg
+func NewConflictError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusConflict,
+		Message: message,
+	}
+}
+
+// NewUnauthorizedError returns status unauthorized(401) error + msg
+func NewUnauthorizedError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusUnauthorized,
+		Message: message,
+	}
+}
+
+// NewForbiddenError returns status forbidden(403) error + msg
+func NewForbiddenError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusForbidden,
+		Message: message,
+	}
+}
+
+// NewPreconditionFailedError returns status precondition failed(412) error + msg
+func NewPreconditionFailedError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusPreconditionFailed,
+		Message: message,
+	}
+}
@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/domain (interfaces: BalanceRepository)
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/jonathanwamsley/banking/domain"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockBalanceRepository is a mock of BalanceRepository interface.
+type MockBalanceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBalanceRepositoryMockRecorder
+}
+
+// MockBalanceRepositoryMockRecorder is the mock recorder for MockBalanceRepository.
+type MockBalanceRepositoryMockRecorder struct {
+	mock *MockBalanceRepository
+}
+
+// NewMockBalanceRepository creates a new mock instance.
+func NewMockBalanceRepository(ctrl *gomock.Controller) *MockBalanceRepository {
+	mock := &MockBalanceRepository{ctrl: ctrl}
+	mock.recorder = &MockBalanceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBalanceRepository) EXPECT() *MockBalanceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// BalanceAsOf mocks base method.
+func (m *MockBalanceRepository) BalanceAsOf(arg0, arg1 string) (*domain.EndOfDayBalance, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BalanceAsOf", arg0, arg1)
+	ret0, _ := ret[0].(*domain.EndOfDayBalance)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// BalanceAsOf indicates an expected call of BalanceAsOf.
+func (mr *MockBalanceRepositoryMockRecorder) BalanceAsOf(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BalanceAsOf", reflect.TypeOf((*MockBalanceRepository)(nil).BalanceAsOf), arg0, arg1)
+}
+
+// CompareSnapshots mocks base method.
+func (m *MockBalanceRepository) CompareSnapshots() ([]domain.SnapshotComparison, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareSnapshots")
+	ret0, _ := ret[0].([]domain.SnapshotComparison)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// CompareSnapshots indicates an expected call of CompareSnapshots.
+func (mr *MockBalanceRepositoryMockRecorder) CompareSnapshots() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareSnapshots", reflect.TypeOf((*MockBalanceRepository)(nil).CompareSnapshots))
+}
+
+// FirstOpeningDate mocks base method.
+func (m *MockBalanceRepository) FirstOpeningDate() (string, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FirstOpeningDate")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// FirstOpeningDate indicates an expected call of FirstOpeningDate.
+func (mr *MockBalanceRepositoryMockRecorder) FirstOpeningDate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FirstOpeningDate", reflect.TypeOf((*MockBalanceRepository)(nil).FirstOpeningDate))
+}
+
+// SaveSnapshot mocks base method.
+func (m *MockBalanceRepository) SaveSnapshot(arg0 domain.BalanceSnapshot) (bool, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSnapshot", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// SaveSnapshot indicates an expected call of SaveSnapshot.
+func (mr *MockBalanceRepositoryMockRecorder) SaveSnapshot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSnapshot", reflect.TypeOf((*MockBalanceRepository)(nil).SaveSnapshot), arg0)
+}
+
+// SnapshotCandidates mocks base method.
+func (m *MockBalanceRepository) SnapshotCandidates(arg0 string) ([]domain.EndOfDayBalance, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnapshotCandidates", arg0)
+	ret0, _ := ret[0].([]domain.EndOfDayBalance)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// SnapshotCandidates indicates an expected call of SnapshotCandidates.
+func (mr *MockBalanceRepositoryMockRecorder) SnapshotCandidates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotCandidates", reflect.TypeOf((*MockBalanceRepository)(nil).SnapshotCandidates), arg0)
+}
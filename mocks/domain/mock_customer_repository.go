@@ -51,17 +51,17 @@ func (mr *MockCustomerRepositoryMockRecorder) ByID(arg0 interface{}) *gomock.Cal
 }
 
 // Delete mocks base method.
-func (m *MockCustomerRepository) Delete(arg0 string) *errs.AppError {
+func (m *MockCustomerRepository) Delete(arg0 string, arg1 int) *errs.AppError {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", arg0)
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
 	ret0, _ := ret[0].(*errs.AppError)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockCustomerRepositoryMockRecorder) Delete(arg0 interface{}) *gomock.Call {
+func (mr *MockCustomerRepositoryMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCustomerRepository)(nil).Delete), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCustomerRepository)(nil).Delete), arg0, arg1)
 }
 
 // FindAll mocks base method.
@@ -93,3 +93,32 @@ func (mr *MockCustomerRepositoryMockRecorder) Save(arg0 interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockCustomerRepository)(nil).Save), arg0)
 }
+
+// Update mocks base method.
+func (m *MockCustomerRepository) Update(arg0 domain.Customer, arg1 string) ([]domain.FieldChange, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].([]domain.FieldChange)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCustomerRepositoryMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCustomerRepository)(nil).Update), arg0, arg1)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockCustomerRepository) UpdateStatus(arg0, arg1 string, arg2 int) *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockCustomerRepositoryMockRecorder) UpdateStatus(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockCustomerRepository)(nil).UpdateStatus), arg0, arg1, arg2)
+}
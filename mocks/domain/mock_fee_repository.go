@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/domain (interfaces: FeeRepository)
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/jonathanwamsley/banking/domain"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockFeeRepository is a mock of FeeRepository interface.
+type MockFeeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeeRepositoryMockRecorder
+}
+
+// MockFeeRepositoryMockRecorder is the mock recorder for MockFeeRepository.
+type MockFeeRepositoryMockRecorder struct {
+	mock *MockFeeRepository
+}
+
+// NewMockFeeRepository creates a new mock instance.
+func NewMockFeeRepository(ctrl *gomock.Controller) *MockFeeRepository {
+	mock := &MockFeeRepository{ctrl: ctrl}
+	mock.recorder = &MockFeeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeeRepository) EXPECT() *MockFeeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ChargeMaintenanceFee mocks base method.
+func (m *MockFeeRepository) ChargeMaintenanceFee(arg0 domain.FeeAssessment) (*domain.Transaction, bool, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChargeMaintenanceFee", arg0)
+	ret0, _ := ret[0].(*domain.Transaction)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(*errs.AppError)
+	return ret0, ret1, ret2
+}
+
+// ChargeMaintenanceFee indicates an expected call of ChargeMaintenanceFee.
+func (mr *MockFeeRepositoryMockRecorder) ChargeMaintenanceFee(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChargeMaintenanceFee", reflect.TypeOf((*MockFeeRepository)(nil).ChargeMaintenanceFee), arg0)
+}
+
+// EndWaiver mocks base method.
+func (m *MockFeeRepository) EndWaiver(arg0, arg1, arg2 string) *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndWaiver", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// EndWaiver indicates an expected call of EndWaiver.
+func (mr *MockFeeRepositoryMockRecorder) EndWaiver(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndWaiver", reflect.TypeOf((*MockFeeRepository)(nil).EndWaiver), arg0, arg1, arg2)
+}
+
+// FindSchedules mocks base method.
+func (m *MockFeeRepository) FindSchedules() ([]domain.FeeSchedule, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSchedules")
+	ret0, _ := ret[0].([]domain.FeeSchedule)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// FindSchedules indicates an expected call of FindSchedules.
+func (mr *MockFeeRepositoryMockRecorder) FindSchedules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSchedules", reflect.TypeOf((*MockFeeRepository)(nil).FindSchedules))
+}
+
+// FindWaivers mocks base method.
+func (m *MockFeeRepository) FindWaivers(arg0 string) ([]domain.FeeWaiver, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWaivers", arg0)
+	ret0, _ := ret[0].([]domain.FeeWaiver)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// FindWaivers indicates an expected call of FindWaivers.
+func (mr *MockFeeRepositoryMockRecorder) FindWaivers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWaivers", reflect.TypeOf((*MockFeeRepository)(nil).FindWaivers), arg0)
+}
+
+// MaintenanceCandidates mocks base method.
+func (m *MockFeeRepository) MaintenanceCandidates(arg0 string) ([]domain.MaintenanceCandidate, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaintenanceCandidates", arg0)
+	ret0, _ := ret[0].([]domain.MaintenanceCandidate)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// MaintenanceCandidates indicates an expected call of MaintenanceCandidates.
+func (mr *MockFeeRepositoryMockRecorder) MaintenanceCandidates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaintenanceCandidates", reflect.TypeOf((*MockFeeRepository)(nil).MaintenanceCandidates), arg0)
+}
+
+// SaveSchedule mocks base method.
+func (m *MockFeeRepository) SaveSchedule(arg0 domain.FeeSchedule) *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSchedule", arg0)
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// SaveSchedule indicates an expected call of SaveSchedule.
+func (mr *MockFeeRepositoryMockRecorder) SaveSchedule(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSchedule", reflect.TypeOf((*MockFeeRepository)(nil).SaveSchedule), arg0)
+}
+
+// SaveWaiver mocks base method.
+func (m *MockFeeRepository) SaveWaiver(arg0 domain.FeeWaiver) (*domain.FeeWaiver, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWaiver", arg0)
+	ret0, _ := ret[0].(*domain.FeeWaiver)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// SaveWaiver indicates an expected call of SaveWaiver.
+func (mr *MockFeeRepositoryMockRecorder) SaveWaiver(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWaiver", reflect.TypeOf((*MockFeeRepository)(nil).SaveWaiver), arg0)
+}
@@ -0,0 +1,114 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/domain (interfaces: HoldRepository)
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/jonathanwamsley/banking/domain"
+	errs "github.com/jonathanwamsley/banking/errs"
+	money "github.com/jonathanwamsley/banking/money"
+)
+
+// MockHoldRepository is a mock of HoldRepository interface.
+type MockHoldRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockHoldRepositoryMockRecorder
+}
+
+// MockHoldRepositoryMockRecorder is the mock recorder for MockHoldRepository.
+type MockHoldRepositoryMockRecorder struct {
+	mock *MockHoldRepository
+}
+
+// NewMockHoldRepository creates a new mock instance.
+func NewMockHoldRepository(ctrl *gomock.Controller) *MockHoldRepository {
+	mock := &MockHoldRepository{ctrl: ctrl}
+	mock.recorder = &MockHoldRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHoldRepository) EXPECT() *MockHoldRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CaptureHold mocks base method.
+func (m *MockHoldRepository) CaptureHold(arg0, arg1 string, arg2 *money.Money, arg3 time.Time) (*domain.Hold, *domain.Transaction, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHold", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*domain.Hold)
+	ret1, _ := ret[1].(*domain.Transaction)
+	ret2, _ := ret[2].(*errs.AppError)
+	return ret0, ret1, ret2
+}
+
+// CaptureHold indicates an expected call of CaptureHold.
+func (mr *MockHoldRepositoryMockRecorder) CaptureHold(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHold", reflect.TypeOf((*MockHoldRepository)(nil).CaptureHold), arg0, arg1, arg2, arg3)
+}
+
+// ExpireHolds mocks base method.
+func (m *MockHoldRepository) ExpireHolds(arg0 time.Time) (int, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHolds", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// ExpireHolds indicates an expected call of ExpireHolds.
+func (mr *MockHoldRepositoryMockRecorder) ExpireHolds(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHolds", reflect.TypeOf((*MockHoldRepository)(nil).ExpireHolds), arg0)
+}
+
+// FindHolds mocks base method.
+func (m *MockHoldRepository) FindHolds(arg0 string) ([]domain.Hold, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindHolds", arg0)
+	ret0, _ := ret[0].([]domain.Hold)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// FindHolds indicates an expected call of FindHolds.
+func (mr *MockHoldRepositoryMockRecorder) FindHolds(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindHolds", reflect.TypeOf((*MockHoldRepository)(nil).FindHolds), arg0)
+}
+
+// SaveHold mocks base method.
+func (m *MockHoldRepository) SaveHold(arg0 domain.Hold) (*domain.Hold, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveHold", arg0)
+	ret0, _ := ret[0].(*domain.Hold)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// SaveHold indicates an expected call of SaveHold.
+func (mr *MockHoldRepositoryMockRecorder) SaveHold(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveHold", reflect.TypeOf((*MockHoldRepository)(nil).SaveHold), arg0)
+}
+
+// VoidHold mocks base method.
+func (m *MockHoldRepository) VoidHold(arg0, arg1 string, arg2 time.Time) (*domain.Hold, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidHold", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.Hold)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// VoidHold indicates an expected call of VoidHold.
+func (mr *MockHoldRepositoryMockRecorder) VoidHold(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidHold", reflect.TypeOf((*MockHoldRepository)(nil).VoidHold), arg0, arg1, arg2)
+}
@@ -0,0 +1,125 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/domain (interfaces: InterestRepository)
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/jonathanwamsley/banking/domain"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockInterestRepository is a mock of InterestRepository interface.
+type MockInterestRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockInterestRepositoryMockRecorder
+}
+
+// MockInterestRepositoryMockRecorder is the mock recorder for MockInterestRepository.
+type MockInterestRepositoryMockRecorder struct {
+	mock *MockInterestRepository
+}
+
+// NewMockInterestRepository creates a new mock instance.
+func NewMockInterestRepository(ctrl *gomock.Controller) *MockInterestRepository {
+	mock := &MockInterestRepository{ctrl: ctrl}
+	mock.recorder = &MockInterestRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInterestRepository) EXPECT() *MockInterestRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AccrualCandidates mocks base method.
+func (m *MockInterestRepository) AccrualCandidates(arg0 string) ([]domain.AccrualCandidate, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccrualCandidates", arg0)
+	ret0, _ := ret[0].([]domain.AccrualCandidate)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// AccrualCandidates indicates an expected call of AccrualCandidates.
+func (mr *MockInterestRepositoryMockRecorder) AccrualCandidates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccrualCandidates", reflect.TypeOf((*MockInterestRepository)(nil).AccrualCandidates), arg0)
+}
+
+// FindProducts mocks base method.
+func (m *MockInterestRepository) FindProducts() ([]domain.InterestProduct, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindProducts")
+	ret0, _ := ret[0].([]domain.InterestProduct)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// FindProducts indicates an expected call of FindProducts.
+func (mr *MockInterestRepositoryMockRecorder) FindProducts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindProducts", reflect.TypeOf((*MockInterestRepository)(nil).FindProducts))
+}
+
+// PostInterest mocks base method.
+func (m *MockInterestRepository) PostInterest(arg0, arg1, arg2, arg3 string) (*domain.Transaction, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostInterest", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*domain.Transaction)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// PostInterest indicates an expected call of PostInterest.
+func (mr *MockInterestRepositoryMockRecorder) PostInterest(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostInterest", reflect.TypeOf((*MockInterestRepository)(nil).PostInterest), arg0, arg1, arg2, arg3)
+}
+
+// PostableAccounts mocks base method.
+func (m *MockInterestRepository) PostableAccounts(arg0, arg1 string) ([]string, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostableAccounts", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// PostableAccounts indicates an expected call of PostableAccounts.
+func (mr *MockInterestRepositoryMockRecorder) PostableAccounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostableAccounts", reflect.TypeOf((*MockInterestRepository)(nil).PostableAccounts), arg0, arg1)
+}
+
+// SaveAccrual mocks base method.
+func (m *MockInterestRepository) SaveAccrual(arg0 domain.InterestAccrual) (bool, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAccrual", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// SaveAccrual indicates an expected call of SaveAccrual.
+func (mr *MockInterestRepositoryMockRecorder) SaveAccrual(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAccrual", reflect.TypeOf((*MockInterestRepository)(nil).SaveAccrual), arg0)
+}
+
+// SaveProduct mocks base method.
+func (m *MockInterestRepository) SaveProduct(arg0 domain.InterestProduct) *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveProduct", arg0)
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// SaveProduct indicates an expected call of SaveProduct.
+func (mr *MockInterestRepositoryMockRecorder) SaveProduct(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveProduct", reflect.TypeOf((*MockInterestRepository)(nil).SaveProduct), arg0)
+}
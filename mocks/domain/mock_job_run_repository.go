@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/domain (interfaces: JobRunRepository)
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockJobRunRepository is a mock of JobRunRepository interface.
+type MockJobRunRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobRunRepositoryMockRecorder
+}
+
+// MockJobRunRepositoryMockRecorder is the mock recorder for MockJobRunRepository.
+type MockJobRunRepositoryMockRecorder struct {
+	mock *MockJobRunRepository
+}
+
+// NewMockJobRunRepository creates a new mock instance.
+func NewMockJobRunRepository(ctrl *gomock.Controller) *MockJobRunRepository {
+	mock := &MockJobRunRepository{ctrl: ctrl}
+	mock.recorder = &MockJobRunRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobRunRepository) EXPECT() *MockJobRunRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CompleteRun mocks base method.
+func (m *MockJobRunRepository) CompleteRun(arg0, arg1 string, arg2 int, arg3 string) *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteRun", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// CompleteRun indicates an expected call of CompleteRun.
+func (mr *MockJobRunRepositoryMockRecorder) CompleteRun(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteRun", reflect.TypeOf((*MockJobRunRepository)(nil).CompleteRun), arg0, arg1, arg2, arg3)
+}
+
+// LastRun mocks base method.
+func (m *MockJobRunRepository) LastRun(arg0 string) (string, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastRun", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// LastRun indicates an expected call of LastRun.
+func (mr *MockJobRunRepositoryMockRecorder) LastRun(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastRun", reflect.TypeOf((*MockJobRunRepository)(nil).LastRun), arg0)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/domain (interfaces: ReversalRepository)
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/jonathanwamsley/banking/domain"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockReversalRepository is a mock of ReversalRepository interface.
+type MockReversalRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReversalRepositoryMockRecorder
+}
+
+// MockReversalRepositoryMockRecorder is the mock recorder for MockReversalRepository.
+type MockReversalRepositoryMockRecorder struct {
+	mock *MockReversalRepository
+}
+
+// NewMockReversalRepository creates a new mock instance.
+func NewMockReversalRepository(ctrl *gomock.Controller) *MockReversalRepository {
+	mock := &MockReversalRepository{ctrl: ctrl}
+	mock.recorder = &MockReversalRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReversalRepository) EXPECT() *MockReversalRepositoryMockRecorder {
+	return m.recorder
+}
+
+// SaveReversal mocks base method.
+func (m *MockReversalRepository) SaveReversal(arg0 domain.Reversal) (*domain.Reversal, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveReversal", arg0)
+	ret0, _ := ret[0].(*domain.Reversal)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// SaveReversal indicates an expected call of SaveReversal.
+func (mr *MockReversalRepositoryMockRecorder) SaveReversal(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveReversal", reflect.TypeOf((*MockReversalRepository)(nil).SaveReversal), arg0)
+}
@@ -0,0 +1,171 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/domain (interfaces: ScheduledPaymentRepository)
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/jonathanwamsley/banking/domain"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockScheduledPaymentRepository is a mock of ScheduledPaymentRepository interface.
+type MockScheduledPaymentRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduledPaymentRepositoryMockRecorder
+}
+
+// MockScheduledPaymentRepositoryMockRecorder is the mock recorder for MockScheduledPaymentRepository.
+type MockScheduledPaymentRepositoryMockRecorder struct {
+	mock *MockScheduledPaymentRepository
+}
+
+// NewMockScheduledPaymentRepository creates a new mock instance.
+func NewMockScheduledPaymentRepository(ctrl *gomock.Controller) *MockScheduledPaymentRepository {
+	mock := &MockScheduledPaymentRepository{ctrl: ctrl}
+	mock.recorder = &MockScheduledPaymentRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduledPaymentRepository) EXPECT() *MockScheduledPaymentRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimRun mocks base method.
+func (m *MockScheduledPaymentRepository) ClaimRun(arg0 domain.PaymentRun) (*domain.PaymentRun, bool, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimRun", arg0)
+	ret0, _ := ret[0].(*domain.PaymentRun)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(*errs.AppError)
+	return ret0, ret1, ret2
+}
+
+// ClaimRun indicates an expected call of ClaimRun.
+func (mr *MockScheduledPaymentRepositoryMockRecorder) ClaimRun(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimRun", reflect.TypeOf((*MockScheduledPaymentRepository)(nil).ClaimRun), arg0)
+}
+
+// DuePayments mocks base method.
+func (m *MockScheduledPaymentRepository) DuePayments(arg0 string) ([]domain.ScheduledPayment, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DuePayments", arg0)
+	ret0, _ := ret[0].([]domain.ScheduledPayment)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// DuePayments indicates an expected call of DuePayments.
+func (mr *MockScheduledPaymentRepositoryMockRecorder) DuePayments(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DuePayments", reflect.TypeOf((*MockScheduledPaymentRepository)(nil).DuePayments), arg0)
+}
+
+// FindPayment mocks base method.
+func (m *MockScheduledPaymentRepository) FindPayment(arg0, arg1 string) (*domain.ScheduledPayment, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPayment", arg0, arg1)
+	ret0, _ := ret[0].(*domain.ScheduledPayment)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// FindPayment indicates an expected call of FindPayment.
+func (mr *MockScheduledPaymentRepositoryMockRecorder) FindPayment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPayment", reflect.TypeOf((*MockScheduledPaymentRepository)(nil).FindPayment), arg0, arg1)
+}
+
+// FindPayments mocks base method.
+func (m *MockScheduledPaymentRepository) FindPayments(arg0 string) ([]domain.ScheduledPayment, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPayments", arg0)
+	ret0, _ := ret[0].([]domain.ScheduledPayment)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// FindPayments indicates an expected call of FindPayments.
+func (mr *MockScheduledPaymentRepositoryMockRecorder) FindPayments(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPayments", reflect.TypeOf((*MockScheduledPaymentRepository)(nil).FindPayments), arg0)
+}
+
+// FindRuns mocks base method.
+func (m *MockScheduledPaymentRepository) FindRuns(arg0 string) ([]domain.PaymentRun, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRuns", arg0)
+	ret0, _ := ret[0].([]domain.PaymentRun)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// FindRuns indicates an expected call of FindRuns.
+func (mr *MockScheduledPaymentRepositoryMockRecorder) FindRuns(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRuns", reflect.TypeOf((*MockScheduledPaymentRepository)(nil).FindRuns), arg0)
+}
+
+// FinishRun mocks base method.
+func (m *MockScheduledPaymentRepository) FinishRun(arg0 domain.PaymentRun, arg1, arg2 domain.ScheduledPayment) *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishRun", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// FinishRun indicates an expected call of FinishRun.
+func (mr *MockScheduledPaymentRepositoryMockRecorder) FinishRun(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishRun", reflect.TypeOf((*MockScheduledPaymentRepository)(nil).FinishRun), arg0, arg1, arg2)
+}
+
+// ReclaimRun mocks base method.
+func (m *MockScheduledPaymentRepository) ReclaimRun(arg0 domain.PaymentRun, arg1 string) (*domain.PaymentRun, bool, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReclaimRun", arg0, arg1)
+	ret0, _ := ret[0].(*domain.PaymentRun)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(*errs.AppError)
+	return ret0, ret1, ret2
+}
+
+// ReclaimRun indicates an expected call of ReclaimRun.
+func (mr *MockScheduledPaymentRepositoryMockRecorder) ReclaimRun(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReclaimRun", reflect.TypeOf((*MockScheduledPaymentRepository)(nil).ReclaimRun), arg0, arg1)
+}
+
+// SavePayment mocks base method.
+func (m *MockScheduledPaymentRepository) SavePayment(arg0 domain.ScheduledPayment) (*domain.ScheduledPayment, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePayment", arg0)
+	ret0, _ := ret[0].(*domain.ScheduledPayment)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// SavePayment indicates an expected call of SavePayment.
+func (mr *MockScheduledPaymentRepositoryMockRecorder) SavePayment(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePayment", reflect.TypeOf((*MockScheduledPaymentRepository)(nil).SavePayment), arg0)
+}
+
+// UpdatePayment mocks base method.
+func (m *MockScheduledPaymentRepository) UpdatePayment(arg0 domain.ScheduledPayment, arg1 int) *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePayment", arg0, arg1)
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// UpdatePayment indicates an expected call of UpdatePayment.
+func (mr *MockScheduledPaymentRepositoryMockRecorder) UpdatePayment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePayment", reflect.TypeOf((*MockScheduledPaymentRepository)(nil).UpdatePayment), arg0, arg1)
+}
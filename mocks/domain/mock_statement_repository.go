@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/domain (interfaces: StatementRepository)
+
+// Package domain is a generated GoMock package.
+package domain
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	domain "github.com/jonathanwamsley/banking/domain"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockStatementRepository is a mock of StatementRepository interface.
+type MockStatementRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatementRepositoryMockRecorder
+}
+
+// MockStatementRepositoryMockRecorder is the mock recorder for MockStatementRepository.
+type MockStatementRepositoryMockRecorder struct {
+	mock *MockStatementRepository
+}
+
+// NewMockStatementRepository creates a new mock instance.
+func NewMockStatementRepository(ctrl *gomock.Controller) *MockStatementRepository {
+	mock := &MockStatementRepository{ctrl: ctrl}
+	mock.recorder = &MockStatementRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatementRepository) EXPECT() *MockStatementRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindStatement mocks base method.
+func (m *MockStatementRepository) FindStatement(arg0, arg1 string) (*domain.StoredStatement, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindStatement", arg0, arg1)
+	ret0, _ := ret[0].(*domain.StoredStatement)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// FindStatement indicates an expected call of FindStatement.
+func (mr *MockStatementRepositoryMockRecorder) FindStatement(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindStatement", reflect.TypeOf((*MockStatementRepository)(nil).FindStatement), arg0, arg1)
+}
+
+// SaveStatement mocks base method.
+func (m *MockStatementRepository) SaveStatement(arg0 domain.StoredStatement) (bool, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveStatement", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// SaveStatement indicates an expected call of SaveStatement.
+func (mr *MockStatementRepositoryMockRecorder) SaveStatement(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveStatement", reflect.TypeOf((*MockStatementRepository)(nil).SaveStatement), arg0)
+}
+
+// StatementAccounts mocks base method.
+func (m *MockStatementRepository) StatementAccounts(arg0 string) ([]domain.Account, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatementAccounts", arg0)
+	ret0, _ := ret[0].([]domain.Account)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// StatementAccounts indicates an expected call of StatementAccounts.
+func (mr *MockStatementRepositoryMockRecorder) StatementAccounts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatementAccounts", reflect.TypeOf((*MockStatementRepository)(nil).StatementAccounts), arg0)
+}
+
+// StatementEntries mocks base method.
+func (m *MockStatementRepository) StatementEntries(arg0, arg1 string) ([]domain.StatementEntry, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatementEntries", arg0, arg1)
+	ret0, _ := ret[0].([]domain.StatementEntry)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// StatementEntries indicates an expected call of StatementEntries.
+func (mr *MockStatementRepositoryMockRecorder) StatementEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatementEntries", reflect.TypeOf((*MockStatementRepository)(nil).StatementEntries), arg0, arg1)
+}
@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/service (interfaces: BalanceService)
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/jonathanwamsley/banking/dto"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockBalanceService is a mock of BalanceService interface.
+type MockBalanceService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBalanceServiceMockRecorder
+}
+
+// MockBalanceServiceMockRecorder is the mock recorder for MockBalanceService.
+type MockBalanceServiceMockRecorder struct {
+	mock *MockBalanceService
+}
+
+// NewMockBalanceService creates a new mock instance.
+func NewMockBalanceService(ctrl *gomock.Controller) *MockBalanceService {
+	mock := &MockBalanceService{ctrl: ctrl}
+	mock.recorder = &MockBalanceServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBalanceService) EXPECT() *MockBalanceServiceMockRecorder {
+	return m.recorder
+}
+
+// CheckSnapshots mocks base method.
+func (m *MockBalanceService) CheckSnapshots() (*dto.SnapshotCheckResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckSnapshots")
+	ret0, _ := ret[0].(*dto.SnapshotCheckResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// CheckSnapshots indicates an expected call of CheckSnapshots.
+func (mr *MockBalanceServiceMockRecorder) CheckSnapshots() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckSnapshots", reflect.TypeOf((*MockBalanceService)(nil).CheckSnapshots))
+}
+
+// GetBalance mocks base method.
+func (m *MockBalanceService) GetBalance(arg0, arg1, arg2 string) (*dto.BalanceResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalance", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.BalanceResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// GetBalance indicates an expected call of GetBalance.
+func (mr *MockBalanceServiceMockRecorder) GetBalance(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockBalanceService)(nil).GetBalance), arg0, arg1, arg2)
+}
+
+// RunDue mocks base method.
+func (m *MockBalanceService) RunDue() *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunDue")
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// RunDue indicates an expected call of RunDue.
+func (mr *MockBalanceServiceMockRecorder) RunDue() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunDue", reflect.TypeOf((*MockBalanceService)(nil).RunDue))
+}
+
+// TakeSnapshots mocks base method.
+func (m *MockBalanceService) TakeSnapshots(arg0 string) (*dto.SnapshotRunResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeSnapshots", arg0)
+	ret0, _ := ret[0].(*dto.SnapshotRunResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// TakeSnapshots indicates an expected call of TakeSnapshots.
+func (mr *MockBalanceServiceMockRecorder) TakeSnapshots(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeSnapshots", reflect.TypeOf((*MockBalanceService)(nil).TakeSnapshots), arg0)
+}
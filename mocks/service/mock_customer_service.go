@@ -51,17 +51,17 @@ func (mr *MockCustomerServiceMockRecorder) CreateCustomer(arg0 interface{}) *gom
 }
 
 // DeleteCustomer mocks base method.
-func (m *MockCustomerService) DeleteCustomer(arg0 string) *errs.AppError {
+func (m *MockCustomerService) DeleteCustomer(arg0 string, arg1 int) *errs.AppError {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteCustomer", arg0)
+	ret := m.ctrl.Call(m, "DeleteCustomer", arg0, arg1)
 	ret0, _ := ret[0].(*errs.AppError)
 	return ret0
 }
 
 // DeleteCustomer indicates an expected call of DeleteCustomer.
-func (mr *MockCustomerServiceMockRecorder) DeleteCustomer(arg0 interface{}) *gomock.Call {
+func (mr *MockCustomerServiceMockRecorder) DeleteCustomer(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCustomer", reflect.TypeOf((*MockCustomerService)(nil).DeleteCustomer), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCustomer", reflect.TypeOf((*MockCustomerService)(nil).DeleteCustomer), arg0, arg1)
 }
 
 // GetAllCustomers mocks base method.
@@ -93,3 +93,48 @@ func (mr *MockCustomerServiceMockRecorder) GetCustomer(arg0 interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCustomer", reflect.TypeOf((*MockCustomerService)(nil).GetCustomer), arg0)
 }
+
+// PatchCustomer mocks base method.
+func (m *MockCustomerService) PatchCustomer(arg0 string, arg1 int, arg2 dto.CustomerPatch) (*dto.UpdateCustomerResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchCustomer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.UpdateCustomerResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// PatchCustomer indicates an expected call of PatchCustomer.
+func (mr *MockCustomerServiceMockRecorder) PatchCustomer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchCustomer", reflect.TypeOf((*MockCustomerService)(nil).PatchCustomer), arg0, arg1, arg2)
+}
+
+// UpdateCustomer mocks base method.
+func (m *MockCustomerService) UpdateCustomer(arg0 string, arg1 int, arg2 dto.CustomerRequest) (*dto.UpdateCustomerResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCustomer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.UpdateCustomerResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// UpdateCustomer indicates an expected call of UpdateCustomer.
+func (mr *MockCustomerServiceMockRecorder) UpdateCustomer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCustomer", reflect.TypeOf((*MockCustomerService)(nil).UpdateCustomer), arg0, arg1, arg2)
+}
+
+// UpdateCustomerStatus mocks base method.
+func (m *MockCustomerService) UpdateCustomerStatus(arg0 string, arg1 dto.UpdateStatusRequest) (*dto.CustomerResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCustomerStatus", arg0, arg1)
+	ret0, _ := ret[0].(*dto.CustomerResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// UpdateCustomerStatus indicates an expected call of UpdateCustomerStatus.
+func (mr *MockCustomerServiceMockRecorder) UpdateCustomerStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCustomerStatus", reflect.TypeOf((*MockCustomerService)(nil).UpdateCustomerStatus), arg0, arg1)
+}
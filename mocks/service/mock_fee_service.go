@@ -0,0 +1,139 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/service (interfaces: FeeService)
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/jonathanwamsley/banking/dto"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockFeeService is a mock of FeeService interface.
+type MockFeeService struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeeServiceMockRecorder
+}
+
+// MockFeeServiceMockRecorder is the mock recorder for MockFeeService.
+type MockFeeServiceMockRecorder struct {
+	mock *MockFeeService
+}
+
+// NewMockFeeService creates a new mock instance.
+func NewMockFeeService(ctrl *gomock.Controller) *MockFeeService {
+	mock := &MockFeeService{ctrl: ctrl}
+	mock.recorder = &MockFeeServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeeService) EXPECT() *MockFeeServiceMockRecorder {
+	return m.recorder
+}
+
+// ChargeMaintenanceFees mocks base method.
+func (m *MockFeeService) ChargeMaintenanceFees(arg0 string) (*dto.FeeRunResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChargeMaintenanceFees", arg0)
+	ret0, _ := ret[0].(*dto.FeeRunResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// ChargeMaintenanceFees indicates an expected call of ChargeMaintenanceFees.
+func (mr *MockFeeServiceMockRecorder) ChargeMaintenanceFees(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChargeMaintenanceFees", reflect.TypeOf((*MockFeeService)(nil).ChargeMaintenanceFees), arg0)
+}
+
+// CreateFeeWaiver mocks base method.
+func (m *MockFeeService) CreateFeeWaiver(arg0, arg1 string, arg2 dto.FeeWaiverRequest, arg3 string) (*dto.FeeWaiverResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFeeWaiver", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*dto.FeeWaiverResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// CreateFeeWaiver indicates an expected call of CreateFeeWaiver.
+func (mr *MockFeeServiceMockRecorder) CreateFeeWaiver(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFeeWaiver", reflect.TypeOf((*MockFeeService)(nil).CreateFeeWaiver), arg0, arg1, arg2, arg3)
+}
+
+// EndFeeWaiver mocks base method.
+func (m *MockFeeService) EndFeeWaiver(arg0, arg1, arg2 string) *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndFeeWaiver", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// EndFeeWaiver indicates an expected call of EndFeeWaiver.
+func (mr *MockFeeServiceMockRecorder) EndFeeWaiver(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndFeeWaiver", reflect.TypeOf((*MockFeeService)(nil).EndFeeWaiver), arg0, arg1, arg2)
+}
+
+// GetFeeSchedules mocks base method.
+func (m *MockFeeService) GetFeeSchedules() ([]dto.FeeScheduleResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeeSchedules")
+	ret0, _ := ret[0].([]dto.FeeScheduleResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// GetFeeSchedules indicates an expected call of GetFeeSchedules.
+func (mr *MockFeeServiceMockRecorder) GetFeeSchedules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeeSchedules", reflect.TypeOf((*MockFeeService)(nil).GetFeeSchedules))
+}
+
+// GetFeeWaivers mocks base method.
+func (m *MockFeeService) GetFeeWaivers(arg0, arg1 string) ([]dto.FeeWaiverResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeeWaivers", arg0, arg1)
+	ret0, _ := ret[0].([]dto.FeeWaiverResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// GetFeeWaivers indicates an expected call of GetFeeWaivers.
+func (mr *MockFeeServiceMockRecorder) GetFeeWaivers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeeWaivers", reflect.TypeOf((*MockFeeService)(nil).GetFeeWaivers), arg0, arg1)
+}
+
+// RunDue mocks base method.
+func (m *MockFeeService) RunDue() *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunDue")
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// RunDue indicates an expected call of RunDue.
+func (mr *MockFeeServiceMockRecorder) RunDue() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunDue", reflect.TypeOf((*MockFeeService)(nil).RunDue))
+}
+
+// SaveFeeSchedule mocks base method.
+func (m *MockFeeService) SaveFeeSchedule(arg0 string, arg1 dto.FeeScheduleRequest) (*dto.FeeScheduleResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFeeSchedule", arg0, arg1)
+	ret0, _ := ret[0].(*dto.FeeScheduleResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// SaveFeeSchedule indicates an expected call of SaveFeeSchedule.
+func (mr *MockFeeServiceMockRecorder) SaveFeeSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFeeSchedule", reflect.TypeOf((*MockFeeService)(nil).SaveFeeSchedule), arg0, arg1)
+}
@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/service (interfaces: InterestService)
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/jonathanwamsley/banking/dto"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockInterestService is a mock of InterestService interface.
+type MockInterestService struct {
+	ctrl     *gomock.Controller
+	recorder *MockInterestServiceMockRecorder
+}
+
+// MockInterestServiceMockRecorder is the mock recorder for MockInterestService.
+type MockInterestServiceMockRecorder struct {
+	mock *MockInterestService
+}
+
+// NewMockInterestService creates a new mock instance.
+func NewMockInterestService(ctrl *gomock.Controller) *MockInterestService {
+	mock := &MockInterestService{ctrl: ctrl}
+	mock.recorder = &MockInterestServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInterestService) EXPECT() *MockInterestServiceMockRecorder {
+	return m.recorder
+}
+
+// AccrueInterest mocks base method.
+func (m *MockInterestService) AccrueInterest(arg0 string) (*dto.InterestRunResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccrueInterest", arg0)
+	ret0, _ := ret[0].(*dto.InterestRunResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// AccrueInterest indicates an expected call of AccrueInterest.
+func (mr *MockInterestServiceMockRecorder) AccrueInterest(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccrueInterest", reflect.TypeOf((*MockInterestService)(nil).AccrueInterest), arg0)
+}
+
+// GetInterestProducts mocks base method.
+func (m *MockInterestService) GetInterestProducts() ([]dto.InterestProductResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInterestProducts")
+	ret0, _ := ret[0].([]dto.InterestProductResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// GetInterestProducts indicates an expected call of GetInterestProducts.
+func (mr *MockInterestServiceMockRecorder) GetInterestProducts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInterestProducts", reflect.TypeOf((*MockInterestService)(nil).GetInterestProducts))
+}
+
+// PostInterest mocks base method.
+func (m *MockInterestService) PostInterest(arg0 string) (*dto.InterestRunResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostInterest", arg0)
+	ret0, _ := ret[0].(*dto.InterestRunResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// PostInterest indicates an expected call of PostInterest.
+func (mr *MockInterestServiceMockRecorder) PostInterest(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostInterest", reflect.TypeOf((*MockInterestService)(nil).PostInterest), arg0)
+}
+
+// RunDue mocks base method.
+func (m *MockInterestService) RunDue() *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunDue")
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// RunDue indicates an expected call of RunDue.
+func (mr *MockInterestServiceMockRecorder) RunDue() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunDue", reflect.TypeOf((*MockInterestService)(nil).RunDue))
+}
+
+// SaveInterestProduct mocks base method.
+func (m *MockInterestService) SaveInterestProduct(arg0 string, arg1 dto.InterestProductRequest) (*dto.InterestProductResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveInterestProduct", arg0, arg1)
+	ret0, _ := ret[0].(*dto.InterestProductResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// SaveInterestProduct indicates an expected call of SaveInterestProduct.
+func (mr *MockInterestServiceMockRecorder) SaveInterestProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveInterestProduct", reflect.TypeOf((*MockInterestService)(nil).SaveInterestProduct), arg0, arg1)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/service (interfaces: ReversalService)
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/jonathanwamsley/banking/dto"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockReversalService is a mock of ReversalService interface.
+type MockReversalService struct {
+	ctrl     *gomock.Controller
+	recorder *MockReversalServiceMockRecorder
+}
+
+// MockReversalServiceMockRecorder is the mock recorder for MockReversalService.
+type MockReversalServiceMockRecorder struct {
+	mock *MockReversalService
+}
+
+// NewMockReversalService creates a new mock instance.
+func NewMockReversalService(ctrl *gomock.Controller) *MockReversalService {
+	mock := &MockReversalService{ctrl: ctrl}
+	mock.recorder = &MockReversalServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReversalService) EXPECT() *MockReversalServiceMockRecorder {
+	return m.recorder
+}
+
+// ReverseTransaction mocks base method.
+func (m *MockReversalService) ReverseTransaction(arg0, arg1, arg2 string, arg3 dto.ReverseTransactionRequest, arg4 string) (*dto.ReversalResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransaction", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*dto.ReversalResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// ReverseTransaction indicates an expected call of ReverseTransaction.
+func (mr *MockReversalServiceMockRecorder) ReverseTransaction(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransaction", reflect.TypeOf((*MockReversalService)(nil).ReverseTransaction), arg0, arg1, arg2, arg3, arg4)
+}
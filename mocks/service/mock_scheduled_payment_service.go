@@ -0,0 +1,141 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/service (interfaces: ScheduledPaymentService)
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/jonathanwamsley/banking/dto"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockScheduledPaymentService is a mock of ScheduledPaymentService interface.
+type MockScheduledPaymentService struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduledPaymentServiceMockRecorder
+}
+
+// MockScheduledPaymentServiceMockRecorder is the mock recorder for MockScheduledPaymentService.
+type MockScheduledPaymentServiceMockRecorder struct {
+	mock *MockScheduledPaymentService
+}
+
+// NewMockScheduledPaymentService creates a new mock instance.
+func NewMockScheduledPaymentService(ctrl *gomock.Controller) *MockScheduledPaymentService {
+	mock := &MockScheduledPaymentService{ctrl: ctrl}
+	mock.recorder = &MockScheduledPaymentServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduledPaymentService) EXPECT() *MockScheduledPaymentServiceMockRecorder {
+	return m.recorder
+}
+
+// CancelScheduledPayment mocks base method.
+func (m *MockScheduledPaymentService) CancelScheduledPayment(arg0, arg1 string, arg2 int) (*dto.ScheduledPaymentResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledPayment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.ScheduledPaymentResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// CancelScheduledPayment indicates an expected call of CancelScheduledPayment.
+func (mr *MockScheduledPaymentServiceMockRecorder) CancelScheduledPayment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledPayment", reflect.TypeOf((*MockScheduledPaymentService)(nil).CancelScheduledPayment), arg0, arg1, arg2)
+}
+
+// CreateScheduledPayment mocks base method.
+func (m *MockScheduledPaymentService) CreateScheduledPayment(arg0 dto.CreateScheduledPaymentRequest) (*dto.ScheduledPaymentResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledPayment", arg0)
+	ret0, _ := ret[0].(*dto.ScheduledPaymentResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// CreateScheduledPayment indicates an expected call of CreateScheduledPayment.
+func (mr *MockScheduledPaymentServiceMockRecorder) CreateScheduledPayment(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledPayment", reflect.TypeOf((*MockScheduledPaymentService)(nil).CreateScheduledPayment), arg0)
+}
+
+// GetScheduledPayment mocks base method.
+func (m *MockScheduledPaymentService) GetScheduledPayment(arg0, arg1 string) (*dto.ScheduledPaymentResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledPayment", arg0, arg1)
+	ret0, _ := ret[0].(*dto.ScheduledPaymentResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// GetScheduledPayment indicates an expected call of GetScheduledPayment.
+func (mr *MockScheduledPaymentServiceMockRecorder) GetScheduledPayment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledPayment", reflect.TypeOf((*MockScheduledPaymentService)(nil).GetScheduledPayment), arg0, arg1)
+}
+
+// GetScheduledPaymentRuns mocks base method.
+func (m *MockScheduledPaymentService) GetScheduledPaymentRuns(arg0, arg1 string) ([]dto.ScheduledPaymentRunResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledPaymentRuns", arg0, arg1)
+	ret0, _ := ret[0].([]dto.ScheduledPaymentRunResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// GetScheduledPaymentRuns indicates an expected call of GetScheduledPaymentRuns.
+func (mr *MockScheduledPaymentServiceMockRecorder) GetScheduledPaymentRuns(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledPaymentRuns", reflect.TypeOf((*MockScheduledPaymentService)(nil).GetScheduledPaymentRuns), arg0, arg1)
+}
+
+// GetScheduledPayments mocks base method.
+func (m *MockScheduledPaymentService) GetScheduledPayments(arg0 string) ([]dto.ScheduledPaymentResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledPayments", arg0)
+	ret0, _ := ret[0].([]dto.ScheduledPaymentResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// GetScheduledPayments indicates an expected call of GetScheduledPayments.
+func (mr *MockScheduledPaymentServiceMockRecorder) GetScheduledPayments(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledPayments", reflect.TypeOf((*MockScheduledPaymentService)(nil).GetScheduledPayments), arg0)
+}
+
+// RunDuePayments mocks base method.
+func (m *MockScheduledPaymentService) RunDuePayments() (*dto.ScheduledPaymentsRunResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunDuePayments")
+	ret0, _ := ret[0].(*dto.ScheduledPaymentsRunResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// RunDuePayments indicates an expected call of RunDuePayments.
+func (mr *MockScheduledPaymentServiceMockRecorder) RunDuePayments() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunDuePayments", reflect.TypeOf((*MockScheduledPaymentService)(nil).RunDuePayments))
+}
+
+// UpdateScheduledPayment mocks base method.
+func (m *MockScheduledPaymentService) UpdateScheduledPayment(arg0, arg1 string, arg2 dto.UpdateScheduledPaymentRequest) (*dto.ScheduledPaymentResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduledPayment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.ScheduledPaymentResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// UpdateScheduledPayment indicates an expected call of UpdateScheduledPayment.
+func (mr *MockScheduledPaymentServiceMockRecorder) UpdateScheduledPayment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduledPayment", reflect.TypeOf((*MockScheduledPaymentService)(nil).UpdateScheduledPayment), arg0, arg1, arg2)
+}
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jonathanwamsley/banking/service (interfaces: StatementService)
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/jonathanwamsley/banking/dto"
+	errs "github.com/jonathanwamsley/banking/errs"
+)
+
+// MockStatementService is a mock of StatementService interface.
+type MockStatementService struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatementServiceMockRecorder
+}
+
+// MockStatementServiceMockRecorder is the mock recorder for MockStatementService.
+type MockStatementServiceMockRecorder struct {
+	mock *MockStatementService
+}
+
+// NewMockStatementService creates a new mock instance.
+func NewMockStatementService(ctrl *gomock.Controller) *MockStatementService {
+	mock := &MockStatementService{ctrl: ctrl}
+	mock.recorder = &MockStatementServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatementService) EXPECT() *MockStatementServiceMockRecorder {
+	return m.recorder
+}
+
+// GenerateStatements mocks base method.
+func (m *MockStatementService) GenerateStatements(arg0 string) (*dto.StatementRunResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateStatements", arg0)
+	ret0, _ := ret[0].(*dto.StatementRunResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// GenerateStatements indicates an expected call of GenerateStatements.
+func (mr *MockStatementServiceMockRecorder) GenerateStatements(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateStatements", reflect.TypeOf((*MockStatementService)(nil).GenerateStatements), arg0)
+}
+
+// GetStatement mocks base method.
+func (m *MockStatementService) GetStatement(arg0, arg1, arg2 string) (*dto.StatementResponse, *errs.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatement", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.StatementResponse)
+	ret1, _ := ret[1].(*errs.AppError)
+	return ret0, ret1
+}
+
+// GetStatement indicates an expected call of GetStatement.
+func (mr *MockStatementServiceMockRecorder) GetStatement(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatement", reflect.TypeOf((*MockStatementService)(nil).GetStatement), arg0, arg1, arg2)
+}
+
+// RunDue mocks base method.
+func (m *MockStatementService) RunDue() *errs.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunDue")
+	ret0, _ := ret[0].(*errs.AppError)
+	return ret0
+}
+
+// RunDue indicates an expected call of RunDue.
+func (mr *MockStatementServiceMockRecorder) RunDue() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunDue", reflect.TypeOf((*MockStatementService)(nil).RunDue))
+}
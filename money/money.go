@@ -0,0 +1,339 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// currencies the bank can hold
+const (
+	USD = "USD"
+	EUR = "EUR"
+	GBP = "GBP"
+	JPY = "JPY"
+)
+
+// DefaultCurrency is used whenever an amount is read without a currency, like the accounts.amount column
+const DefaultCurrency = USD
+
+// minorDigits is the number of decimal places each currency is stored with
+var minorDigits = map[string]int{
+	USD: 2,
+	EUR: 2,
+	GBP: 2,
+	JPY: 0,
+}
+
+// ErrInvalidAmount is returned when an amount can not be read as an exact decimal
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// RoundingMode decides what happens to a fraction of a minor unit
+type RoundingMode int
+
+// the supported rounding rules
+//
+// HalfEven: rounds to the nearest minor unit, ties go to the even neighbour (bankers rounding)
+// HalfUp: rounds to the nearest minor unit, ties go away from zero
+// Down: drops the fraction, rounding towards zero
+const (
+	HalfEven RoundingMode = iota
+	HalfUp
+	Down
+)
+
+// Money is an exact amount held as minor units (cents for USD) plus its currency
+type Money struct {
+	minor    int64
+	currency string
+}
+
+// New returns an amount of minor units in a currency
+func New(minor int64, currency string) Money {
+	return Money{minor: minor, currency: normalize(currency)}
+}
+
+// FromMinor returns an amount of minor units in the default currency
+func FromMinor(minor int64) Money {
+	return New(minor, DefaultCurrency)
+}
+
+// Zero returns an empty amount in the default currency
+func Zero() Money {
+	return FromMinor(0)
+}
+
+// Parse reads a decimal string like "1250.50" in the default currency
+func Parse(s string) (Money, error) {
+	return ParseIn(s, DefaultCurrency)
+}
+
+// ParseIn reads a decimal string in the given currency.
+// More decimal places than the currency allows is an error, client amounts are never rounded silently.
+func ParseIn(s string, currency string) (Money, error) {
+	currency = normalize(currency)
+	digits := Digits(currency)
+
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > digits {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, s, digits)
+	}
+	frac += strings.Repeat("0", digits-len(frac))
+
+	var minor int64
+	if significant := strings.TrimLeft(whole+frac, "0"); significant != "" {
+		var err error
+		if minor, err = strconv.ParseInt(significant, 10, 64); err != nil {
+			return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidAmount, s)
+		}
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{minor: minor, currency: currency}, nil
+}
+
+// MustParse is like Parse but panics on a bad amount. It is meant for constants and tests.
+func MustParse(s string) Money {
+	m, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Digits returns the number of decimal places a currency is stored with
+func Digits(currency string) int {
+	if d, ok := minorDigits[normalize(currency)]; ok {
+		return d
+	}
+	return 2
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Currency returns the ISO code of the amount
+func (m Money) Currency() string {
+	return normalize(m.currency)
+}
+
+// IsZero checks for an empty amount
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// IsNegative checks for an amount below zero
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// IsPositive checks for an amount above zero
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+// Cmp returns -1, 0 or +1 when m is less than, equal to or greater than o
+func (m Money) Cmp(o Money) int {
+	m.mustMatch(o)
+	switch {
+	case m.minor < o.minor:
+		return -1
+	case m.minor > o.minor:
+		return 1
+	}
+	return 0
+}
+
+// LessThan checks m < o
+func (m Money) LessThan(o Money) bool {
+	return m.Cmp(o) < 0
+}
+
+// GreaterThan checks m > o
+func (m Money) GreaterThan(o Money) bool {
+	return m.Cmp(o) > 0
+}
+
+// Add returns m + o
+func (m Money) Add(o Money) Money {
+	m.mustMatch(o)
+	return Money{minor: m.minor + o.minor, currency: m.Currency()}
+}
+
+// Sub returns m - o
+func (m Money) Sub(o Money) Money {
+	m.mustMatch(o)
+	return Money{minor: m.minor - o.minor, currency: m.Currency()}
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.Currency()}
+}
+
+// Abs returns the amount without its sign
+func (m Money) Abs() Money {
+	if m.minor < 0 {
+		return m.Neg()
+	}
+	return Money{minor: m.minor, currency: m.Currency()}
+}
+
+// Times returns m multiplied by a whole number
+func (m Money) Times(n int64) Money {
+	return Money{minor: m.minor * n, currency: m.Currency()}
+}
+
+// Mul returns m multiplied by an exact rate, rounded back to minor units with the given mode
+func (m Money) Mul(rate *big.Rat, mode RoundingMode) Money {
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(m.minor), rate)
+	return Money{minor: Round(product, mode), currency: m.Currency()}
+}
+
+// Round turns a fraction of minor units into whole minor units
+func Round(r *big.Rat, mode RoundingMode) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 || mode == Down {
+		return quo.Int64()
+	}
+
+	// compare the remainder against half of the denominator
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	away := false
+	switch twice.Cmp(den) {
+	case 1:
+		away = true
+	case 0:
+		away = mode == HalfUp || quo.Bit(0) == 1
+	}
+	if away {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo.Int64()
+}
+
+// String formats the amount as a plain decimal like "-12.50", without the currency
+func (m Money) String() string {
+	digits := Digits(m.Currency())
+	minor := m.minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+	abs := uint64(minor)
+	if minor < 0 {
+		abs = uint64(-(minor + 1)) + 1
+	}
+	if digits == 0 {
+		return sign + strconv.FormatUint(abs, 10)
+	}
+	scale := uint64(math.Pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, abs/scale, digits, abs%scale)
+}
+
+// MarshalJSON writes the amount as a json string so clients never see a float
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON reads either a json string ("12.50") or a json number (12.50).
+// Numbers are read from their text, so they never pass through a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, s)
+		}
+		s = unquoted
+	}
+	parsed, err := ParseIn(s, m.Currency())
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads a decimal column, like accounts.amount, into the amount
+func (m *Money) Scan(src interface{}) error {
+	var parsed Money
+	var err error
+	switch v := src.(type) {
+	case []byte:
+		parsed, err = ParseIn(string(v), m.Currency())
+	case string:
+		parsed, err = ParseIn(v, m.Currency())
+	case int64:
+		parsed = New(v*int64(math.Pow10(Digits(m.Currency()))), m.Currency())
+	case float64:
+		parsed, err = ParseIn(strconv.FormatFloat(v, 'f', Digits(m.Currency()), 64), m.Currency())
+	case nil:
+		parsed = New(0, m.Currency())
+	default:
+		err = fmt.Errorf("money: can not scan %T", src)
+	}
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value writes the amount as a decimal string so the db never receives a float
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m Money) mustMatch(o Money) {
+	if m.Currency() != o.Currency() {
+		panic(fmt.Sprintf("money: currency mismatch %s and %s", m.Currency(), o.Currency()))
+	}
+}
+
+func normalize(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return strings.ToUpper(currency)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package money
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	m, err := Parse("6823.23")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 682323, m.Minor())
+	assert.EqualValues(t, USD, m.Currency())
+
+	m, err = Parse("-0.5")
+	assert.Nil(t, err)
+	assert.EqualValues(t, -50, m.Minor())
+
+	m, err = Parse("7000")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 700000, m.Minor())
+
+	m, err = Parse("10.100")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1010, m.Minor())
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{"", ".", "-", "abc", "1.2.3", "1e5", "10.005", "99999999999999999999"} {
+		_, err := Parse(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestParseInZeroDigitCurrency(t *testing.T) {
+	m, err := ParseIn("1500", JPY)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1500, m.Minor())
+	assert.EqualValues(t, "1500", m.String())
+
+	_, err = ParseIn("1500.5", JPY)
+	assert.NotNil(t, err)
+}
+
+func TestString(t *testing.T) {
+	assert.EqualValues(t, "0.00", Zero().String())
+	assert.EqualValues(t, "0.05", FromMinor(5).String())
+	assert.EqualValues(t, "-0.05", FromMinor(-5).String())
+	assert.EqualValues(t, "6823.23", FromMinor(682323).String())
+}
+
+func TestArithmetic(t *testing.T) {
+	a := MustParse("0.10")
+	b := MustParse("0.20")
+	assert.EqualValues(t, MustParse("0.30"), a.Add(b))
+	assert.EqualValues(t, MustParse("-0.10"), a.Sub(b))
+	assert.EqualValues(t, MustParse("0.10"), a.Sub(b).Abs())
+	assert.EqualValues(t, MustParse("0.60"), b.Times(3))
+	assert.True(t, a.LessThan(b))
+	assert.True(t, b.GreaterThan(a))
+	assert.EqualValues(t, 0, a.Cmp(MustParse("0.1")))
+}
+
+func TestCurrencyMismatchPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		New(100, USD).Add(New(100, EUR))
+	})
+}
+
+func TestMulRounding(t *testing.T) {
+	half := big.NewRat(1, 2)
+	assert.EqualValues(t, 2, FromMinor(5).Mul(half, HalfEven).Minor())             // 2.5 -> 2
+	assert.EqualValues(t, 4, FromMinor(7).Mul(half, HalfEven).Minor())             // 3.5 -> 4
+	assert.EqualValues(t, 3, FromMinor(5).Mul(half, HalfUp).Minor())               // 2.5 -> 3
+	assert.EqualValues(t, -3, FromMinor(-5).Mul(half, HalfUp).Minor())             // -2.5 -> -3
+	assert.EqualValues(t, -2, FromMinor(-5).Mul(half, HalfEven).Minor())           // -2.5 -> -2
+	assert.EqualValues(t, 2, FromMinor(5).Mul(half, Down).Minor())                 // 2.5 -> 2
+	assert.EqualValues(t, 2, FromMinor(5).Mul(big.NewRat(1, 3), HalfEven).Minor()) // 1.67 -> 2
+	assert.EqualValues(t, 1, FromMinor(4).Mul(big.NewRat(1, 3), HalfEven).Minor()) // 1.33 -> 1
+	assert.EqualValues(t, 2, FromMinor(5).Mul(big.NewRat(2, 5), HalfEven).Minor())
+}
+
+func TestJSON(t *testing.T) {
+	var body struct {
+		Amount Money `json:"amount"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(`{"amount":"10.50"}`), &body))
+	assert.EqualValues(t, 1050, body.Amount.Minor())
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"amount":0.1}`), &body))
+	assert.EqualValues(t, 10, body.Amount.Minor())
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"amount":"1.001"}`), &body))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"amount":true}`), &body))
+
+	out, err := json.Marshal(map[string]Money{"amount": MustParse("10.5")})
+	assert.Nil(t, err)
+	assert.EqualValues(t, `{"amount":"10.50"}`, string(out))
+}
+
+func TestScanAndValue(t *testing.T) {
+	var m Money
+	assert.Nil(t, m.Scan([]byte("3342.96")))
+	assert.EqualValues(t, 334296, m.Minor())
+
+	assert.Nil(t, m.Scan(int64(7000)))
+	assert.EqualValues(t, 700000, m.Minor())
+
+	assert.Nil(t, m.Scan(nil))
+	assert.True(t, m.IsZero())
+
+	assert.NotNil(t, m.Scan(true))
+
+	v, err := MustParse("5861.86").Value()
+	assert.Nil(t, err)
+	assert.EqualValues(t, "5861.86", v)
+}
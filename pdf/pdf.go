@@ -0,0 +1,152 @@
+// Package pdf writes simple PDF documents of text and lines with the standard fonts every PDF reader has,
+// so nothing has to be embedded. The output only depends on what was drawn, the same drawing gives the same bytes.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Font is one of the standard fonts of a PDF reader
+type Font string
+
+// the fonts a page can use. Courier is monospaced, so columns of numbers can be aligned with MonospaceWidth.
+const (
+	Helvetica     Font = "Helvetica"
+	HelveticaBold Font = "Helvetica-Bold"
+	Courier       Font = "Courier"
+)
+
+// the size of a US letter page in points, the origin is the bottom left corner
+const (
+	LetterWidth  = 612.0
+	LetterHeight = 792.0
+)
+
+// fonts are written once in this order and named F1, F2, F3 in the page resources
+var fonts = []Font{Helvetica, HelveticaBold, Courier}
+
+// Document is a PDF being drawn, one page after the other
+type Document struct {
+	pages []*Page
+}
+
+// Page holds the drawing operators of one page
+type Page struct {
+	content bytes.Buffer
+}
+
+// New returns an empty document
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage starts a new letter page at the end of the document
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Text writes text with its baseline starting at x, y. Characters outside latin-1 are written as a question mark.
+func (p *Page) Text(x float64, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", fontName(font), number(size), number(x), number(y), escape(text))
+}
+
+// TextRight writes monospaced text so that it ends at x
+func (p *Page) TextRight(x float64, y float64, size float64, text string) {
+	p.Text(x-MonospaceWidth(size, text), y, Courier, size, text)
+}
+
+// Line draws a thin line from x1, y1 to x2, y2
+func (p *Page) Line(x1 float64, y1 float64, x2 float64, y2 float64) {
+	fmt.Fprintf(&p.content, "0.5 w %s %s m %s %s l S\n", number(x1), number(y1), number(x2), number(y2))
+}
+
+// MonospaceWidth is the width of text written in Courier, every character is 600 thousandths of the size
+func MonospaceWidth(size float64, text string) float64 {
+	return float64(len([]rune(text))) * size * 0.6
+}
+
+// Bytes writes the document. A document without pages gets one empty page, a PDF needs at least one.
+func (d *Document) Bytes() []byte {
+	pages := d.pages
+	if len(pages) == 0 {
+		pages = []*Page{{}}
+	}
+
+	// objects are numbered from 1: the catalog, the page tree, the fonts, then each page and its content
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	firstPage := 3 + len(fonts)
+
+	out.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	var kids bytes.Buffer
+	for i := range pages {
+		fmt.Fprintf(&kids, "%d 0 R ", firstPage+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", bytes.TrimSpace(kids.Bytes()), len(pages)))
+	var resources bytes.Buffer
+	for i, f := range fonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f))
+		fmt.Fprintf(&resources, "/%s %d 0 R ", fontName(f), 3+i)
+	}
+	for i, p := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s>> >> /Contents %d 0 R >>",
+			number(LetterWidth), number(LetterHeight), resources.String(), firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// fontName is the resource name pages use for a font
+func fontName(f Font) string {
+	for i, known := range fonts {
+		if known == f {
+			return "F" + strconv.Itoa(i+1)
+		}
+	}
+	return "F1"
+}
+
+// number writes a coordinate or size with at most 2 decimals
+func number(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// escape encodes text as WinAnsi bytes in a PDF string, with the characters that end or escape a string escaped
+func escape(text string) string {
+	var b bytes.Buffer
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossReferencePointsAtEveryObject(t *testing.T) {
+	d := New()
+	d.AddPage().Text(72, 720, Helvetica, 12, "Statement")
+	d.AddPage().TextRight(540, 720, 9, "1,250.00")
+	out := d.Bytes()
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+
+	start := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if start == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(start[1]))
+	assert.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n0 10\n")))
+
+	// catalog, page tree, 3 fonts and a page and its content for each page
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	assert.Equal(t, 9, len(entries))
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
+
+func TestTextIsEscaped(t *testing.T) {
+	d := New()
+	d.AddPage().Text(0, 0, Courier, 10, `fee (waived) \ Zoë 😀`)
+	assert.Contains(t, string(d.Bytes()), `(fee \(waived\) \\ Zo\353 ?) Tj`)
+}
+
+func TestSameDrawingSameBytes(t *testing.T) {
+	draw := func() []byte {
+		d := New()
+		p := d.AddPage()
+		p.Text(72, 700.5, HelveticaBold, 14, "Account statement")
+		p.Line(72, 690, 540, 690)
+		return d.Bytes()
+	}
+	assert.Equal(t, draw(), draw())
+	assert.Contains(t, string(draw()), "BT /F2 14 Tf 72 700.5 Td")
+	assert.Equal(t, 54.0, MonospaceWidth(10, "123456789"))
+}
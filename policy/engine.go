@@ -0,0 +1,267 @@
+package policy
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Input is everything a policy can look at to decide on a request
+type Input struct {
+	Role  string
+	Route string
+	Token map[string]interface{}
+	Vars  map[string]string
+	Body  map[string]interface{}
+}
+
+// Decision explains why a request was allowed or denied
+type Decision struct {
+	Allowed bool
+	Reason  string
+}
+
+// Engine decides requests against a policy, and can reload it from its file while requests are decided
+type Engine struct {
+	mu      sync.RWMutex
+	policy  *Policy
+	path    string
+	modTime time.Time
+	// failed is the modification time of the last file that did not load, so it is reported once
+	failed time.Time
+}
+
+// NewEngine creates an Engine for a policy that is never reloaded
+func NewEngine(p *Policy) *Engine {
+	return &Engine{policy: p}
+}
+
+// NewFileEngine loads the policy file and creates an Engine that can reload it
+func NewFileEngine(path string) (*Engine, error) {
+	e := &Engine{path: path}
+	if _, err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload loads the policy file again if it changed since it was last loaded. A file that does not
+// load keeps the current policy in place, so a bad edit never locks everyone out.
+func (e *Engine) Reload() (bool, error) {
+	if e.path == "" {
+		return false, nil
+	}
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return false, err
+	}
+	e.mu.RLock()
+	unchanged := e.policy != nil && (info.ModTime().Equal(e.modTime) || info.ModTime().Equal(e.failed))
+	e.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	p, err := Load(e.path)
+	if err != nil {
+		e.mu.Lock()
+		e.failed = info.ModTime()
+		e.mu.Unlock()
+		return false, err
+	}
+	e.mu.Lock()
+	e.policy = p
+	e.modTime = info.ModTime()
+	e.mu.Unlock()
+	return true, nil
+}
+
+// Watch reloads the policy file every interval until stop is closed, it is meant to be run in its own goroutine.
+// onReload is called after every reload attempt that changed the policy or failed.
+func (e *Engine) Watch(interval time.Duration, stop <-chan struct{}, onReload func(reloaded bool, err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			reloaded, err := e.Reload()
+			if (reloaded || err != nil) && onReload != nil {
+				onReload(reloaded, err)
+			}
+		}
+	}
+}
+
+// Allows checks if the policy allows the request
+func (e *Engine) Allows(in Input) bool {
+	return e.Decide(in).Allowed
+}
+
+// Decide finds the first rule of the role, or of a role it inherits, that covers the route and whose conditions all hold
+func (e *Engine) Decide(in Input) Decision {
+	e.mu.RLock()
+	p := e.policy
+	e.mu.RUnlock()
+
+	if _, ok := p.Roles[in.Role]; !ok {
+		return Decision{Reason: fmt.Sprintf("unknown role %q", in.Role)}
+	}
+	failed := ""
+	for _, role := range p.roleChain(in.Role) {
+		for i, rule := range p.Roles[role].Rules {
+			if !rule.covers(in.Route) {
+				continue
+			}
+			if attr := rule.failedCondition(in); attr != "" {
+				failed = attr
+				continue
+			}
+			return Decision{Allowed: true, Reason: fmt.Sprintf("role %s rule %d", role, i+1)}
+		}
+	}
+	if failed != "" {
+		return Decision{Reason: fmt.Sprintf("condition on %s does not hold for %s", failed, in.Route)}
+	}
+	return Decision{Reason: fmt.Sprintf("role %s may not call %s", in.Role, in.Route)}
+}
+
+// roleChain returns the role followed by every role it inherits, each once
+func (p Policy) roleChain(role string) []string {
+	chain := []string{role}
+	seen := map[string]bool{role: true}
+	for i := 0; i < len(chain); i++ {
+		for _, parent := range p.Roles[chain[i]].Inherits {
+			if !seen[parent] {
+				seen[parent] = true
+				chain = append(chain, parent)
+			}
+		}
+	}
+	return chain
+}
+
+func (r Rule) covers(route string) bool {
+	for _, name := range r.Routes {
+		if name == route || name == ALL_ROUTES {
+			return true
+		}
+	}
+	return false
+}
+
+// failedCondition returns the attribute of the first condition that does not hold, or "" when they all hold
+func (r Rule) failedCondition(in Input) string {
+	for _, c := range r.When {
+		if !c.holds(in) {
+			return c.Attr
+		}
+	}
+	return ""
+}
+
+// holds evaluates the condition. A missing attribute never satisfies a condition, except ne.
+func (c Condition) holds(in Input) bool {
+	left, ok := in.lookup(c.Attr)
+	if c.Op == OP_EXISTS {
+		return ok
+	}
+	right := c.Value
+	rightOK := right != nil
+	if c.Ref != "" {
+		right, rightOK = in.lookup(c.Ref)
+	}
+	if !ok || !rightOK {
+		return c.Op == OP_NE && ok != rightOK
+	}
+
+	switch c.Op {
+	case OP_EQ:
+		return equal(left, right)
+	case OP_NE:
+		return !equal(left, right)
+	case OP_IN:
+		for _, item := range asList(right) {
+			if equal(left, item) {
+				return true
+			}
+		}
+		return false
+	}
+
+	l, lok := asNumber(left)
+	r, rok := asNumber(right)
+	if !lok || !rok {
+		return false
+	}
+	cmp := l.Cmp(r)
+	switch c.Op {
+	case OP_LT:
+		return cmp < 0
+	case OP_LTE:
+		return cmp <= 0
+	case OP_GT:
+		return cmp > 0
+	case OP_GTE:
+		return cmp >= 0
+	}
+	return false
+}
+
+// lookup reads an attribute like token.customer_id, route.account_id or body.amount
+func (in Input) lookup(attr string) (interface{}, bool) {
+	parts := strings.SplitN(attr, ".", 2)
+	if len(parts) != 2 {
+		return nil, false
+	}
+	switch parts[0] {
+	case ATTR_TOKEN:
+		v, ok := in.Token[parts[1]]
+		return v, ok && v != nil
+	case ATTR_ROUTE:
+		v, ok := in.Vars[parts[1]]
+		return v, ok
+	case ATTR_BODY:
+		v, ok := in.Body[parts[1]]
+		return v, ok && v != nil
+	}
+	return nil, false
+}
+
+// equal compares two values as numbers when both are numbers, and as text otherwise
+func equal(a interface{}, b interface{}) bool {
+	if an, ok := asNumber(a); ok {
+		if bn, ok := asNumber(b); ok {
+			return an.Cmp(bn) == 0
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// asNumber reads numbers and numeric strings exactly, so an amount like "9999.99" compares without rounding
+func asNumber(v interface{}) (*big.Rat, bool) {
+	switch v.(type) {
+	case []interface{}, []string, map[string]interface{}, bool, nil:
+		return nil, false
+	}
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(fmt.Sprint(v)))
+	return r, ok
+}
+
+func asList(v interface{}) []interface{} {
+	switch list := v.(type) {
+	case []interface{}:
+		return list
+	case []string:
+		items := make([]interface{}, 0, len(list))
+		for _, s := range list {
+			items = append(items, s)
+		}
+		return items
+	}
+	return []interface{}{v}
+}
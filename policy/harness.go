@@ -0,0 +1,44 @@
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Case is a request with the decision the policy is expected to make on it
+type Case struct {
+	Name  string                 `yaml:"name"`
+	Role  string                 `yaml:"role"`
+	Token map[string]interface{} `yaml:"token"`
+	Route string                 `yaml:"route"`
+	Vars  map[string]string      `yaml:"vars"`
+	Body  map[string]interface{} `yaml:"body"`
+	Allow bool                   `yaml:"allow"`
+}
+
+// LoadCases reads a yaml list of cases
+func LoadCases(path string) ([]Case, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cases []Case
+	if err = yaml.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cases, nil
+}
+
+// RunCases decides every case and describes each one that did not get its expected decision
+func RunCases(e *Engine, cases []Case) []string {
+	var failures []string
+	for _, c := range cases {
+		d := e.Decide(Input{Role: c.Role, Route: c.Route, Token: c.Token, Vars: c.Vars, Body: c.Body})
+		if d.Allowed != c.Allow {
+			failures = append(failures, fmt.Sprintf("%s: expected allow %v, got %v (%s)", c.Name, c.Allow, d.Allowed, d.Reason))
+		}
+	}
+	return failures
+}
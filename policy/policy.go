@@ -0,0 +1,177 @@
+// Package policy decides which roles may call which routes, and under which conditions.
+//
+// A policy file maps each role to rules. A rule names the routes it covers and the conditions that must all
+// hold for the request to be allowed. A request is allowed when any rule of its role covers the route and
+// all of the rule's conditions hold, everything else is denied.
+//
+//	roles:
+//	  customer:
+//	    rules:
+//	      - routes: [NewTransaction]
+//	        when:
+//	          - {attr: route.customer_id, op: eq, ref: token.customer_id}
+//	          - {attr: body.amount, op: lt, value: 10000}
+//
+// Conditions read attributes of the request: token.<claim>, route.<route variable> and body.<json field>.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ALL_ROUTES is the route name that covers every route
+const ALL_ROUTES = "*"
+
+// condition operators
+const (
+	OP_EQ     = "eq"
+	OP_NE     = "ne"
+	OP_LT     = "lt"
+	OP_LTE    = "lte"
+	OP_GT     = "gt"
+	OP_GTE    = "gte"
+	OP_IN     = "in"
+	OP_EXISTS = "exists"
+)
+
+// attribute namespaces
+const (
+	ATTR_TOKEN = "token"
+	ATTR_ROUTE = "route"
+	ATTR_BODY  = "body"
+)
+
+var operators = map[string]bool{OP_EQ: true, OP_NE: true, OP_LT: true, OP_LTE: true, OP_GT: true, OP_GTE: true, OP_IN: true, OP_EXISTS: true}
+
+// Policy holds the rules of every role
+type Policy struct {
+	Roles map[string]Role `json:"roles" yaml:"roles"`
+}
+
+// Role gets the rules of the roles it inherits as well as its own
+type Role struct {
+	Inherits []string `json:"inherits" yaml:"inherits"`
+	Rules    []Rule   `json:"rules" yaml:"rules"`
+}
+
+// Rule allows its routes when all of its conditions hold
+type Rule struct {
+	Routes []string    `json:"routes" yaml:"routes"`
+	When   []Condition `json:"when" yaml:"when"`
+}
+
+// Condition compares the attribute Attr with either a literal Value or the attribute Ref
+type Condition struct {
+	Attr  string      `json:"attr" yaml:"attr"`
+	Op    string      `json:"op" yaml:"op"`
+	Value interface{} `json:"value" yaml:"value"`
+	Ref   string      `json:"ref" yaml:"ref"`
+}
+
+// Load reads a policy file, .json files are read as json and anything else as yaml
+func Load(path string) (*Policy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data, filepath.Ext(path) == ".json")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return p, nil
+}
+
+// Parse reads and validates a policy
+func Parse(data []byte, isJSON bool) (*Policy, error) {
+	var p Policy
+	var err error
+	if isJSON {
+		err = json.Unmarshal(data, &p)
+	} else {
+		err = yaml.Unmarshal(data, &p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate makes sure every rule covers a route, every condition can be evaluated and inherited roles exist without cycles
+func (p Policy) Validate() error {
+	if len(p.Roles) == 0 {
+		return fmt.Errorf("policy has no roles")
+	}
+	for name, role := range p.Roles {
+		for _, parent := range role.Inherits {
+			if _, ok := p.Roles[parent]; !ok {
+				return fmt.Errorf("role %s inherits unknown role %s", name, parent)
+			}
+		}
+		if p.inheritsItself(name, name, map[string]bool{}) {
+			return fmt.Errorf("role %s inherits itself", name)
+		}
+		for i, rule := range role.Rules {
+			if len(rule.Routes) == 0 {
+				return fmt.Errorf("role %s rule %d has no routes", name, i+1)
+			}
+			for _, c := range rule.When {
+				if err := c.validate(); err != nil {
+					return fmt.Errorf("role %s rule %d: %v", name, i+1, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (p Policy) inheritsItself(start string, current string, seen map[string]bool) bool {
+	for _, parent := range p.Roles[current].Inherits {
+		if parent == start {
+			return true
+		}
+		if seen[parent] {
+			continue
+		}
+		seen[parent] = true
+		if p.inheritsItself(start, parent, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Condition) validate() error {
+	if !operators[c.Op] {
+		return fmt.Errorf("unknown operator %q", c.Op)
+	}
+	if !validAttr(c.Attr) {
+		return fmt.Errorf("attr %q must start with token., route. or body.", c.Attr)
+	}
+	if c.Op == OP_EXISTS {
+		return nil
+	}
+	if (c.Ref == "") == (c.Value == nil) {
+		return fmt.Errorf("condition on %s needs either a value or a ref", c.Attr)
+	}
+	if c.Ref != "" && !validAttr(c.Ref) {
+		return fmt.Errorf("ref %q must start with token., route. or body.", c.Ref)
+	}
+	return nil
+}
+
+func validAttr(attr string) bool {
+	parts := strings.SplitN(attr, ".", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return false
+	}
+	return parts[0] == ATTR_TOKEN || parts[0] == ATTR_ROUTE || parts[0] == ATTR_BODY
+}
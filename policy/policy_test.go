@@ -0,0 +1,147 @@
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyFileCases(t *testing.T) {
+	e, err := NewFileEngine("../resources/policy.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases, err := LoadCases("../resources/policy_cases.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotEmpty(t, cases)
+	for _, failure := range RunCases(e, cases) {
+		t.Error(failure)
+	}
+}
+
+func mustParse(t *testing.T, policy string) *Engine {
+	p, err := Parse([]byte(policy), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewEngine(p)
+}
+
+func TestConditionOperators(t *testing.T) {
+	e := mustParse(t, `
+roles:
+  r:
+    rules:
+      - routes: [Eq]
+        when: [{attr: body.amount, op: eq, value: 10}]
+      - routes: [Ne]
+        when: [{attr: body.type, op: ne, value: withdrawal}]
+      - routes: [Lte]
+        when: [{attr: body.amount, op: lte, value: "10.00"}]
+      - routes: [Gt]
+        when: [{attr: body.amount, op: gt, value: 10}]
+      - routes: [In]
+        when: [{attr: body.type, op: in, value: [deposit, withdrawal]}]
+      - routes: [Exists]
+        when: [{attr: token.sub, op: exists}]
+`)
+	body := func(amount interface{}) map[string]interface{} { return map[string]interface{}{"amount": amount} }
+
+	assert.True(t, e.Allows(Input{Role: "r", Route: "Eq", Body: body("10.00")}))
+	assert.False(t, e.Allows(Input{Role: "r", Route: "Eq", Body: body("10.01")}))
+	assert.True(t, e.Allows(Input{Role: "r", Route: "Ne", Body: map[string]interface{}{"type": "deposit"}}))
+	assert.False(t, e.Allows(Input{Role: "r", Route: "Ne", Body: map[string]interface{}{"type": "withdrawal"}}))
+	assert.True(t, e.Allows(Input{Role: "r", Route: "Lte", Body: body(10)}))
+	assert.False(t, e.Allows(Input{Role: "r", Route: "Lte", Body: body("10.001")}))
+	assert.True(t, e.Allows(Input{Role: "r", Route: "Gt", Body: body(10.5)}))
+	assert.False(t, e.Allows(Input{Role: "r", Route: "Gt", Body: body("ten")}))
+	assert.False(t, e.Allows(Input{Role: "r", Route: "Gt"}))
+	assert.True(t, e.Allows(Input{Role: "r", Route: "In", Body: map[string]interface{}{"type": "deposit"}}))
+	assert.False(t, e.Allows(Input{Role: "r", Route: "In", Body: map[string]interface{}{"type": "transfer"}}))
+	assert.True(t, e.Allows(Input{Role: "r", Route: "Exists", Token: map[string]interface{}{"sub": "2001"}}))
+	assert.False(t, e.Allows(Input{Role: "r", Route: "Exists"}))
+	assert.False(t, e.Allows(Input{Role: "r", Route: "Other"}))
+}
+
+func TestRoleInheritance(t *testing.T) {
+	e := mustParse(t, `
+roles:
+  base:
+    rules: [{routes: [GetCustomer]}]
+  middle:
+    inherits: [base]
+  top:
+    inherits: [middle]
+    rules: [{routes: [DeleteCustomer]}]
+`)
+
+	assert.True(t, e.Allows(Input{Role: "top", Route: "GetCustomer"}))
+	assert.True(t, e.Allows(Input{Role: "top", Route: "DeleteCustomer"}))
+	assert.False(t, e.Allows(Input{Role: "base", Route: "DeleteCustomer"}))
+}
+
+func TestParseRejectsInvalidPolicies(t *testing.T) {
+	invalid := map[string]string{
+		"no roles":           `roles: {}`,
+		"unknown parent":     `{roles: {a: {inherits: [b]}}}`,
+		"inheritance cycle":  `{roles: {a: {inherits: [b]}, b: {inherits: [a]}}}`,
+		"rule without route": `{roles: {a: {rules: [{when: [{attr: token.sub, op: exists}]}]}}}`,
+		"unknown operator":   `{roles: {a: {rules: [{routes: [X], when: [{attr: token.sub, op: like, value: a}]}]}}}`,
+		"unknown attr":       `{roles: {a: {rules: [{routes: [X], when: [{attr: header.sub, op: eq, value: a}]}]}}}`,
+		"value and ref":      `{roles: {a: {rules: [{routes: [X], when: [{attr: token.sub, op: eq, value: a, ref: route.customer_id}]}]}}}`,
+		"no value":           `{roles: {a: {rules: [{routes: [X], when: [{attr: token.sub, op: eq}]}]}}}`,
+	}
+	for name, policy := range invalid {
+		_, err := Parse([]byte(policy), false)
+		assert.NotNil(t, err, name)
+	}
+
+	_, err := Parse([]byte(`{"roles": {"admin": {"rules": [{"routes": ["*"]}]}}}`), true)
+	assert.Nil(t, err)
+}
+
+func TestReloadPicksUpChangesAndKeepsPolicyOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	ioutil.WriteFile(path, []byte(`{roles: {teller: {rules: [{routes: [GetCustomer]}]}}}`), 0600)
+	e, err := NewFileEngine(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, e.Allows(Input{Role: "teller", Route: "CreateCustomer"}))
+
+	reloaded, err := e.Reload()
+	assert.False(t, reloaded)
+	assert.Nil(t, err)
+
+	ioutil.WriteFile(path, []byte(`{roles: {teller: {rules: [{routes: [GetCustomer, CreateCustomer]}]}}}`), 0600)
+	touch(t, path, time.Minute)
+	reloaded, err = e.Reload()
+	assert.True(t, reloaded)
+	assert.Nil(t, err)
+	assert.True(t, e.Allows(Input{Role: "teller", Route: "CreateCustomer"}))
+
+	ioutil.WriteFile(path, []byte(`{roles: {teller: {inherits: [nobody]}}}`), 0600)
+	touch(t, path, 2*time.Minute)
+	reloaded, err = e.Reload()
+	assert.False(t, reloaded)
+	assert.NotNil(t, err)
+	assert.True(t, e.Allows(Input{Role: "teller", Route: "CreateCustomer"}))
+
+	_, err = e.Reload()
+	assert.Nil(t, err, "a file that failed is only reported once")
+}
+
+// touch moves the modification time forward, file systems with coarse timestamps would otherwise miss quick edits
+func touch(t *testing.T, path string, ahead time.Duration) {
+	at := time.Now().Add(ahead)
+	if err := os.Chtimes(path, at, at); err != nil {
+		t.Fatal(err)
+	}
+}
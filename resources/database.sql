@@ -1,3 +1,4 @@
+-- needs MySQL 8.0 or later: accounts are locked with FOR UPDATE OF and customers with FOR SHARE
 CREATE DATABASE banking;
 USE banking;
 
@@ -8,16 +9,45 @@ CREATE TABLE `customers` (
   `date_of_birth` date NOT NULL,
   `city` varchar(100) NOT NULL,
   `zipcode` varchar(10) NOT NULL,
-  `status` tinyint(1) NOT NULL DEFAULT '1',
+  `status` varchar(10) NOT NULL DEFAULT 'active',
+  `version` int(11) NOT NULL DEFAULT '1',
   PRIMARY KEY (`customer_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=2006 DEFAULT CHARSET=latin1;
 INSERT INTO `customers` VALUES
-	(2000,'Steve','1978-12-15','Delhi','110075',1),
-	(2001,'Arian','1988-05-21','Newburgh, NY','12550',1),
-	(2002,'Hadley','1988-04-30','Englewood, NJ','07631',1),
-	(2003,'Ben','1988-01-04','Manchester, NH','03102',0),
-	(2004,'Nina','1988-05-14','Clarkston, MI','48348',1),
-	(2005,'Osman','1988-11-08','Hyattsville, MD','20782',0);
+	(2000,'Steve','1978-12-15','Delhi','110075','active', 1),
+	(2001,'Arian','1988-05-21','Newburgh, NY','12550','active', 1),
+	(2002,'Hadley','1988-04-30','Englewood, NJ','07631','active', 1),
+	(2003,'Ben','1988-01-04','Manchester, NH','03102','dormant', 1),
+	(2004,'Nina','1988-05-14','Clarkston, MI','48348','active', 1),
+	(2005,'Osman','1988-11-08','Hyattsville, MD','20782','dormant', 1);
+
+DROP TABLE IF EXISTS `customer_audit`;
+CREATE TABLE `customer_audit` (
+  `audit_id` int(11) NOT NULL AUTO_INCREMENT,
+  `customer_id` int(11) NOT NULL,
+  `field` varchar(20) NOT NULL,
+  `old_value` varchar(100) NOT NULL,
+  `new_value` varchar(100) NOT NULL,
+  `changed_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`audit_id`),
+  KEY `customer_audit_customer` (`customer_id`, `changed_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- how accounts earn interest. apy is in percent, compounding is daily, monthly, quarterly or annually,
+-- day_count is actual/365, actual/360 or 30/360
+DROP TABLE IF EXISTS `interest_products`;
+CREATE TABLE `interest_products` (
+  `product_code` varchar(20) NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `apy` decimal(7,4) NOT NULL,
+  `compounding` varchar(10) NOT NULL,
+  `day_count` varchar(10) NOT NULL,
+  PRIMARY KEY (`product_code`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+INSERT INTO `interest_products` VALUES
+	('savings', 'Savings', 2.5000, 'daily', 'actual/365'),
+	('savings_bonus', 'Bonus savings', 3.1000, 'monthly', '30/360'),
+	('notice', 'Notice account', 3.7500, 'quarterly', 'actual/365');
 
 DROP TABLE IF EXISTS `accounts`;
 CREATE TABLE `accounts` (
@@ -26,26 +56,365 @@ CREATE TABLE `accounts` (
   `opening_date` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `account_type` varchar(10) NOT NULL,
   `amount` decimal(10,2) NOT NULL,
-  `status` tinyint(1) NOT NULL DEFAULT '1',
+  `status` varchar(10) NOT NULL DEFAULT 'active',
+  `version` int(11) NOT NULL DEFAULT '1',
+  `interest_product` varchar(20) DEFAULT NULL,
+  `overdraft_limit` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `overdraft_sweep` tinyint(1) NOT NULL DEFAULT '0',
   PRIMARY KEY (`account_id`),
   KEY `accounts_FK` (`customer_id`),
-  CONSTRAINT `accounts_FK` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`customer_id`)
+  KEY `accounts_interest_product` (`interest_product`),
+  CONSTRAINT `accounts_FK` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`customer_id`),
+  CONSTRAINT `accounts_interest_FK` FOREIGN KEY (`interest_product`) REFERENCES `interest_products` (`product_code`)
 ) ENGINE=InnoDB AUTO_INCREMENT=95471 DEFAULT CHARSET=latin1;
 INSERT INTO `accounts` VALUES
-	(95470,2000,'2020-08-22 10:20:06', 'saving', 6823.23, 1),
-	(95471,2002,'2020-08-09 10:27:22', 'checking', 3342.96, 1),
-  (95472,2001,'2020-08-09 10:35:22', 'saving', 7000, 1),
-  (95473,2001,'2020-08-09 10:38:22', 'checking', 5861.86, 1);
+	(95470,2000,'2020-08-22 10:20:06', 'saving', 6823.23, 'active', 1, 'savings', 0.00, 0),
+	(95471,2002,'2020-08-09 10:27:22', 'checking', 3342.96, 'active', 1, NULL, 0.00, 0),
+  (95472,2001,'2020-08-09 10:35:22', 'saving', 7000, 'active', 1, 'savings', 0.00, 0),
+  (95473,2001,'2020-08-09 10:38:22', 'checking', 5861.86, 'active', 1, NULL, 500.00, 1);
+
+
+-- login credentials, passwords are bcrypt hashes. Only customer users are linked to a customer.
+-- every seed user has the password Password1, change them before using this anywhere but a laptop
+DROP TABLE IF EXISTS `users`;
+CREATE TABLE `users` (
+  `username` varchar(20) NOT NULL,
+  `password` char(60) NOT NULL,
+  `role` varchar(20) NOT NULL,
+  `customer_id` int(11) DEFAULT NULL,
+  `failed_logins` int(11) NOT NULL DEFAULT '0',
+  `locked_until` datetime DEFAULT NULL,
+  `created_on` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `totp_secret` varchar(64) DEFAULT NULL,
+  `totp_enabled` tinyint(1) NOT NULL DEFAULT '0',
+  `totp_last_step` bigint(20) NOT NULL DEFAULT '0',
+  PRIMARY KEY (`username`),
+  KEY `users_FK` (`customer_id`),
+  CONSTRAINT `users_FK` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`customer_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+INSERT INTO `users` (`username`, `password`, `role`, `customer_id`, `created_on`) VALUES
+	('admin', '$2a$10$oGnLuSUor6pTf2gjRLIWD.j.qU64wsMb5pVGAdxNU8XCq.F7ZbmSO', 'admin', NULL, '2020-08-09 10:27:22'),
+	('teller', '$2a$10$/4bGCzuLaBR70nlGQ.FJuO.0zbM2RJI2KAlmHwNE6Zqr8itBlAkd2', 'teller', NULL, '2020-08-09 10:27:22'),
+	('auditor', '$2a$10$atLp0ipGhIbAwMMq1OWPn.uiju4ETQs.LMjGjtlDjStPHimhlX656', 'auditor', NULL, '2020-08-09 10:27:22'),
+	('2000', '$2a$10$uHkxhFXS3KJTmecJ9VWTWe/a8mTHgHzSDOaACKwieq7oGl2Io2kxe', 'customer', 2000, '2020-08-22 10:20:06'),
+	('2001', '$2a$10$ZrF.PlZKDQyhnsfnOBt0ROecC621pauMS9hMFo0dmv50ZASPKqCx2', 'customer', 2001, '2020-08-09 10:35:22');
+
+-- sha256 hashes of the mfa recovery codes, each works once
+DROP TABLE IF EXISTS `user_recovery_codes`;
+CREATE TABLE `user_recovery_codes` (
+  `username` varchar(20) NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `used_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`username`, `code_hash`),
+  CONSTRAINT `user_recovery_codes_FK` FOREIGN KEY (`username`) REFERENCES `users` (`username`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- a session is a login, refresh_id is the jti of the one refresh token of the session that still works.
+-- A session has no foreign key on its user, so the revoked sessions of a deleted user are kept until they expire
+-- and their access tokens stay refused.
+DROP TABLE IF EXISTS `sessions`;
+CREATE TABLE `sessions` (
+  `session_id` char(32) NOT NULL,
+  `username` varchar(20) NOT NULL,
+  `refresh_id` char(32) NOT NULL,
+  `user_agent` varchar(255) NOT NULL DEFAULT '',
+  `ip_address` varchar(45) NOT NULL DEFAULT '',
+  `created_at` datetime NOT NULL,
+  `last_used_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `revoked_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`session_id`),
+  KEY `sessions_user` (`username`, `expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
 
+-- the revocation list, a jti is kept until its token would have expired anyway
+DROP TABLE IF EXISTS `revoked_tokens`;
+CREATE TABLE `revoked_tokens` (
+  `jti` char(32) NOT NULL,
+  `expires_at` datetime NOT NULL,
+  PRIMARY KEY (`jti`),
+  KEY `revoked_tokens_expiry` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- api keys of batch jobs and partner services, only the sha256 of the secret is stored.
+-- scopes are the comma separated route names a key may call
+DROP TABLE IF EXISTS `api_keys`;
+CREATE TABLE `api_keys` (
+  `key_id` char(12) NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `secret_hash` char(64) NOT NULL,
+  `scopes` varchar(1000) NOT NULL,
+  `created_by` varchar(20) NOT NULL,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime DEFAULT NULL,
+  `last_used_at` datetime DEFAULT NULL,
+  `revoked_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`key_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
 
 DROP TABLE IF EXISTS `transactions`;
 CREATE TABLE `transactions` (
   `transaction_id` int(11) NOT NULL AUTO_INCREMENT,
   `account_id` int(11) NOT NULL,
   `amount` decimal(10,2) NOT NULL,
-  `transaction_type` varchar(10) NOT NULL,
+  `transaction_type` varchar(20) NOT NULL,
   `transaction_date` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `booking_date` date NOT NULL,
+  `value_date` date NOT NULL,
+  `balance` decimal(10,2) NOT NULL,
+  `related_transaction_id` int(11) DEFAULT NULL,
   PRIMARY KEY (`transaction_id`),
   KEY `transactions_FK` (`account_id`),
-  CONSTRAINT `transactions_FK` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`account_id`)
+  KEY `transactions_history` (`account_id`, `transaction_date`, `transaction_id`),
+  KEY `transactions_value_date` (`account_id`, `value_date`),
+  KEY `transactions_related_FK` (`related_transaction_id`),
+  CONSTRAINT `transactions_FK` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`account_id`),
+  CONSTRAINT `transactions_related_FK` FOREIGN KEY (`related_transaction_id`) REFERENCES `transactions` (`transaction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+DROP TABLE IF EXISTS `idempotency_keys`;
+CREATE TABLE `idempotency_keys` (
+  `idempotency_key` varchar(320) NOT NULL,
+  `request_fingerprint` char(64) NOT NULL,
+  `status_code` smallint NOT NULL DEFAULT '0',
+  `response_body` mediumtext NOT NULL,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `expires_at` datetime NOT NULL,
+  PRIMARY KEY (`idempotency_key`),
+  KEY `idempotency_keys_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+DROP TABLE IF EXISTS `journal_lines`;
+DROP TABLE IF EXISTS `journal_entries`;
+CREATE TABLE `journal_entries` (
+  `entry_id` int(11) NOT NULL AUTO_INCREMENT,
+  `transaction_id` int(11) DEFAULT NULL,
+  `description` varchar(100) NOT NULL,
+  `posted_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`entry_id`),
+  KEY `journal_entries_FK` (`transaction_id`),
+  CONSTRAINT `journal_entries_FK` FOREIGN KEY (`transaction_id`) REFERENCES `transactions` (`transaction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- ledger_account is a code from the chart of accounts: 1000 cash and clearing, 2000 customer deposits, 4000 fee income,
+-- 4100 interest income, 5000 interest expense.
+-- account_id is only set on customer deposit lines. It has no foreign key, so a closed account keeps its history.
+CREATE TABLE `journal_lines` (
+  `line_id` int(11) NOT NULL AUTO_INCREMENT,
+  `entry_id` int(11) NOT NULL,
+  `ledger_account` varchar(10) NOT NULL,
+  `account_id` int(11) DEFAULT NULL,
+  `debit` decimal(12,2) NOT NULL DEFAULT '0.00',
+  `credit` decimal(12,2) NOT NULL DEFAULT '0.00',
+  PRIMARY KEY (`line_id`),
+  KEY `journal_lines_FK` (`entry_id`),
+  KEY `journal_lines_account` (`ledger_account`, `account_id`),
+  CONSTRAINT `journal_lines_FK` FOREIGN KEY (`entry_id`) REFERENCES `journal_entries` (`entry_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- opening deposits of the seed accounts, so their balances are projections of the ledger
+INSERT INTO `journal_entries` VALUES
+	(1, NULL, 'opening deposit', '2020-08-22 10:20:06'),
+	(2, NULL, 'opening deposit', '2020-08-09 10:27:22'),
+	(3, NULL, 'opening deposit', '2020-08-09 10:35:22'),
+	(4, NULL, 'opening deposit', '2020-08-09 10:38:22');
+INSERT INTO `journal_lines` (`entry_id`, `ledger_account`, `account_id`, `debit`, `credit`) VALUES
+	(1, '1000', NULL, 6823.23, 0), (1, '2000', 95470, 0, 6823.23),
+	(2, '1000', NULL, 3342.96, 0), (2, '2000', 95471, 0, 3342.96),
+	(3, '1000', NULL, 7000, 0), (3, '2000', 95472, 0, 7000),
+	(4, '1000', NULL, 5861.86, 0), (4, '2000', 95473, 0, 5861.86);
+
+-- the interest an account earned each day, accrued has 8 decimal places and is only rounded to cents when posted.
+-- the primary key makes a date accrue once per account, however often the job runs
+DROP TABLE IF EXISTS `interest_accruals`;
+CREATE TABLE `interest_accruals` (
+  `account_id` int(11) NOT NULL,
+  `accrual_date` date NOT NULL,
+  `product_code` varchar(20) NOT NULL,
+  `balance` decimal(12,2) NOT NULL,
+  `annual_rate` decimal(16,12) NOT NULL,
+  `accrued` decimal(20,8) NOT NULL,
+  `posted_transaction_id` int(11) DEFAULT NULL,
+  PRIMARY KEY (`account_id`, `accrual_date`),
+  KEY `interest_accruals_unposted` (`account_id`, `posted_transaction_id`),
+  CONSTRAINT `interest_accruals_FK` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`account_id`),
+  CONSTRAINT `interest_accruals_txn_FK` FOREIGN KEY (`posted_transaction_id`) REFERENCES `transactions` (`transaction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- the fees of each account type. Leaving free_withdrawals NULL makes every withdrawal free.
+-- overdraft_rate is the annual interest in percent charged on a negative balance
+DROP TABLE IF EXISTS `fee_schedules`;
+CREATE TABLE `fee_schedules` (
+  `account_type` varchar(10) NOT NULL,
+  `minimum_balance` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `maintenance_fee` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `free_withdrawals` int(11) DEFAULT NULL,
+  `withdrawal_fee` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `overdraft_fee` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `overdraft_rate` decimal(7,4) NOT NULL DEFAULT '0.0000',
+  PRIMARY KEY (`account_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+INSERT INTO `fee_schedules` VALUES
+	('checking', 1500.00, 12.00, NULL, 0.00, 25.00, 18.0000),
+	('saving', 300.00, 5.00, 6, 10.00, 0.00, 0.0000);
+
+-- fee_type is maintenance, withdrawal, overdraft or all. Dates are inclusive, a waiver without ends_on lasts until it is ended
+DROP TABLE IF EXISTS `fee_waivers`;
+CREATE TABLE `fee_waivers` (
+  `waiver_id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` int(11) NOT NULL,
+  `fee_type` varchar(20) NOT NULL,
+  `reason` varchar(255) NOT NULL,
+  `starts_on` date NOT NULL,
+  `ends_on` date DEFAULT NULL,
+  `created_by` varchar(20) NOT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`waiver_id`),
+  KEY `fee_waivers_FK` (`account_id`),
+  CONSTRAINT `fee_waivers_FK` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`account_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- the maintenance fee decision of each account and month, the primary key charges a month once however often
+-- the job runs. fee is what was charged, 0 when the balance stayed above the minimum or the fee was waived
+DROP TABLE IF EXISTS `fee_assessments`;
+CREATE TABLE `fee_assessments` (
+  `account_id` int(11) NOT NULL,
+  `period` char(7) NOT NULL,
+  `lowest_balance` decimal(12,2) NOT NULL,
+  `fee` decimal(10,2) NOT NULL,
+  `waiver_id` int(11) DEFAULT NULL,
+  `transaction_id` int(11) DEFAULT NULL,
+  `assessed_at` datetime NOT NULL,
+  PRIMARY KEY (`account_id`, `period`),
+  CONSTRAINT `fee_assessments_FK` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`account_id`),
+  CONSTRAINT `fee_assessments_waiver_FK` FOREIGN KEY (`waiver_id`) REFERENCES `fee_waivers` (`waiver_id`),
+  CONSTRAINT `fee_assessments_txn_FK` FOREIGN KEY (`transaction_id`) REFERENCES `transactions` (`transaction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- funds reserved on an account, like a card authorization. Only active holds count against the available balance,
+-- a captured hold links to the withdrawal it was settled with and captured_amount is what that withdrawal took
+DROP TABLE IF EXISTS `holds`;
+CREATE TABLE `holds` (
+  `hold_id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` int(11) NOT NULL,
+  `amount` decimal(10,2) NOT NULL,
+  `captured_amount` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `status` varchar(10) NOT NULL,
+  `description` varchar(255) NOT NULL DEFAULT '',
+  `created_by` varchar(20) NOT NULL,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `closed_at` datetime DEFAULT NULL,
+  `transaction_id` int(11) DEFAULT NULL,
+  PRIMARY KEY (`hold_id`),
+  KEY `holds_account` (`account_id`, `status`),
+  KEY `holds_expiry` (`status`, `expires_at`),
+  CONSTRAINT `holds_FK` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`account_id`),
+  CONSTRAINT `holds_txn_FK` FOREIGN KEY (`transaction_id`) REFERENCES `transactions` (`transaction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- why and by whom a transaction was reversed. reversal_transaction_id is the reversal_out or reversal_in that was
+-- posted, the reversals of a transaction never add up to more than its amount
+DROP TABLE IF EXISTS `reversals`;
+CREATE TABLE `reversals` (
+  `reversal_id` int(11) NOT NULL AUTO_INCREMENT,
+  `transaction_id` int(11) NOT NULL,
+  `reversal_transaction_id` int(11) NOT NULL,
+  `account_id` int(11) NOT NULL,
+  `amount` decimal(10,2) NOT NULL,
+  `reason_code` varchar(20) NOT NULL,
+  `note` varchar(255) NOT NULL DEFAULT '',
+  `created_by` varchar(20) NOT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`reversal_id`),
+  KEY `reversals_transaction` (`transaction_id`),
+  UNIQUE KEY `reversals_reversal_txn` (`reversal_transaction_id`),
+  CONSTRAINT `reversals_FK` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`account_id`),
+  CONSTRAINT `reversals_txn_FK` FOREIGN KEY (`transaction_id`) REFERENCES `transactions` (`transaction_id`),
+  CONSTRAINT `reversals_reversal_txn_FK` FOREIGN KEY (`reversal_transaction_id`) REFERENCES `transactions` (`transaction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- standing orders of customers: a transfer to to_account_id, or a withdrawal when it is NULL, on every date of the
+-- schedule. next_run_date is NULL once the schedule ended, a declined payment is retried attempts days after it
+DROP TABLE IF EXISTS `scheduled_payment_runs`;
+DROP TABLE IF EXISTS `scheduled_payments`;
+CREATE TABLE `scheduled_payments` (
+  `payment_id` int(11) NOT NULL AUTO_INCREMENT,
+  `customer_id` int(11) NOT NULL,
+  `account_id` int(11) NOT NULL,
+  `to_account_id` int(11) DEFAULT NULL,
+  `amount` decimal(10,2) NOT NULL,
+  `description` varchar(255) NOT NULL DEFAULT '',
+  `frequency` varchar(20) NOT NULL,
+  `day_of_month` tinyint DEFAULT NULL,
+  `start_date` date NOT NULL,
+  `end_date` date DEFAULT NULL,
+  `next_run_date` date DEFAULT NULL,
+  `attempts` int(11) NOT NULL DEFAULT '0',
+  `retry_policy` varchar(20) NOT NULL,
+  `max_retries` int(11) NOT NULL,
+  `status` varchar(10) NOT NULL,
+  `created_at` datetime NOT NULL,
+  `version` int(11) NOT NULL DEFAULT '1',
+  PRIMARY KEY (`payment_id`),
+  KEY `scheduled_payments_customer` (`customer_id`),
+  KEY `scheduled_payments_due` (`status`, `next_run_date`),
+  CONSTRAINT `scheduled_payments_customer_FK` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`customer_id`),
+  CONSTRAINT `scheduled_payments_FK` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`account_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- every attempt at a scheduled payment. The unique key lets one scheduler claim an attempt, so a date is never paid
+-- twice. A run that stays running was interrupted and has to be checked against the transactions of the account
+CREATE TABLE `scheduled_payment_runs` (
+  `run_id` int(11) NOT NULL AUTO_INCREMENT,
+  `payment_id` int(11) NOT NULL,
+  `scheduled_date` date NOT NULL,
+  `attempt` int(11) NOT NULL,
+  `status` varchar(10) NOT NULL,
+  `reason` varchar(255) NOT NULL DEFAULT '',
+  `transaction_id` int(11) DEFAULT NULL,
+  `ran_at` datetime NOT NULL,
+  PRIMARY KEY (`run_id`),
+  UNIQUE KEY `scheduled_payment_runs_attempt` (`payment_id`, `scheduled_date`, `attempt`),
+  CONSTRAINT `scheduled_payment_runs_FK` FOREIGN KEY (`payment_id`) REFERENCES `scheduled_payments` (`payment_id`),
+  CONSTRAINT `scheduled_payment_runs_txn_FK` FOREIGN KEY (`transaction_id`) REFERENCES `transactions` (`transaction_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- the balance of each account at the end of each day, read from the ledger. A balance as of a date starts from the
+-- last snapshot before it, the primary key takes a date once per account however often the job runs
+DROP TABLE IF EXISTS `balance_snapshots`;
+
+CREATE TABLE `balance_snapshots` (
+  `account_id` int(11) NOT NULL,
+  `snapshot_date` date NOT NULL,
+  `balance` decimal(12,2) NOT NULL,
+  `taken_at` datetime NOT NULL,
+  PRIMARY KEY (`account_id`, `snapshot_date`),
+  CONSTRAINT `balance_snapshots_FK` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`account_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- the statement of each account for each month, generated by the statement job or when first asked for. A statement
+-- is only inserted, never updated: the unique key keeps one per month and checksum is the sha256 of the document
+DROP TABLE IF EXISTS `statements`;
+
+CREATE TABLE `statements` (
+  `statement_id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` int(11) NOT NULL,
+  `period` char(7) NOT NULL,
+  `document` mediumtext NOT NULL,
+  `checksum` char(64) NOT NULL,
+  `generated_at` datetime NOT NULL,
+  PRIMARY KEY (`statement_id`),
+  UNIQUE KEY `statements_period` (`account_id`, `period`),
+  CONSTRAINT `statements_FK` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`account_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=latin1;
+
+-- the dates each batch job completed, like the interest and fee jobs. Their schedulers catch up from the last one
+DROP TABLE IF EXISTS `job_runs`;
+CREATE TABLE `job_runs` (
+  `job` varchar(30) NOT NULL,
+  `run_date` date NOT NULL,
+  `accounts` int(11) NOT NULL,
+  `completed_at` datetime NOT NULL,
+  PRIMARY KEY (`job`, `run_date`)
 ) ENGINE=InnoDB DEFAULT CHARSET=latin1;
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jonathanwamsley/banking/calendar"
 	"github.com/jonathanwamsley/banking/domain"
 	"github.com/jonathanwamsley/banking/dto"
 	"github.com/jonathanwamsley/banking/errs"
@@ -15,26 +16,48 @@ const dbTSLayout = "2006-01-02 15:04:05"
 //
 // CreateAccount: creates a new account for a given customer and returns account id back on success
 // GetAccount: gets the user checking and savings account
-// DeleteAccount: deletes a user account
+// GetAccountByID: gets one account of the customer, with its version
+// DeleteAccount: deletes a user account, if it is still at the version the caller read
 // MakeTransaction: a customer creates a transation into an account and receive the new balance
+// Transfer: a customer moves money from their account to another account and receives both new balances
+// GetTransactionHistory: returns a filtered page of an account's transactions with the running balance
+// ExportTransactions: writes the transactions of an account between two dates to a TransactionWriter as they are read
+// UpdateAccountStatus: moves an account of a customer to a new status, like frozen or closed
+// UpdateOverdraft: sets the overdraft limit of an account of a customer and whether it sweeps from saving
+// mockgen -destination=mocks/service/mock_account_service.go -package=service github.com/jonathanwamsley/banking/service AccountService
 type AccountService interface {
 	CreateAccount(dto.CreateAccountRequest) (*dto.CreateAccountResponse, *errs.AppError)
 	GetAccount(id string) ([]dto.GetAccountResponse, *errs.AppError)
-	DeleteAccount(id string, accountType string) *errs.AppError
+	GetAccountByID(customerID string, accountID string) (*dto.GetAccountResponse, *errs.AppError)
+	DeleteAccount(id string, accountType string, version int) *errs.AppError
 	MakeTransaction(request dto.MakeTransactionRequest) (*dto.MakeTransactionResponse, *errs.AppError)
+	Transfer(request dto.TransferRequest) (*dto.TransferResponse, *errs.AppError)
+	GetTransactionHistory(request dto.TransactionHistoryRequest) (*dto.TransactionHistoryResponse, *errs.AppError)
+	ExportTransactions(request dto.TransactionExportRequest, out TransactionWriter) *errs.AppError
+	UpdateAccountStatus(customerID string, accountID string, req dto.UpdateStatusRequest) (*dto.GetAccountResponse, *errs.AppError)
+	UpdateOverdraft(customerID string, accountID string, req dto.UpdateOverdraftRequest) (*dto.GetAccountResponse, *errs.AppError)
 }
 
-// DefaultAccountService has methods that call dto and the domain
+// TransactionWriter writes an export in a format: Begin once the export is allowed, Write for every transaction
+// oldest first, then End
+type TransactionWriter interface {
+	Begin(dto.TransactionExport) error
+	Write(dto.TransactionExportItem) error
+	End() error
+}
+
+// DefaultAccountService has methods that call dto and the domain. Transactions are dated by the bank's calendar.
 type DefaultAccountService struct {
-	repo domain.AccountRepository
+	repo     domain.AccountRepository
+	calendar calendar.Calendar
 }
 
 // NewAccountService  is the entry point to the service to create a DefaultAccountService struct
-func NewAccountService(repository domain.AccountRepository) DefaultAccountService {
-	return DefaultAccountService{repository}
+func NewAccountService(repository domain.AccountRepository, cal calendar.Calendar) DefaultAccountService {
+	return DefaultAccountService{repo: repository, calendar: cal}
 }
 
-// CreateAccount manages the account dto and database interaction
+// CreateAccount manages the account dto and database interaction, the account is opened now by the bank's calendar
 func (s DefaultAccountService) CreateAccount(req dto.CreateAccountRequest) (*dto.CreateAccountResponse, *errs.AppError) {
 
 	accounts, _ := s.repo.ByID(req.CustomerID)
@@ -48,7 +71,7 @@ func (s DefaultAccountService) CreateAccount(req dto.CreateAccountRequest) (*dto
 		return nil, err
 	}
 
-	account := domain.NewAccount(req)
+	account := domain.NewAccount(req, s.calendar.Timestamp(time.Now()))
 	newAccount, err := s.repo.Save(account)
 	if err != nil {
 		return nil, err
@@ -70,23 +93,22 @@ func (s DefaultAccountService) GetAccount(id string) ([]dto.GetAccountResponse,
 	return response, nil
 }
 
-// DeleteAccount deletes an account using a customer_id and account_type
-func (s DefaultAccountService) DeleteAccount(id string, accountType string) *errs.AppError {
-	accounts, err := s.repo.ByID(id)
+// GetAccountByID returns one account of a customer
+func (s DefaultAccountService) GetAccountByID(customerID string, accountID string) (*dto.GetAccountResponse, *errs.AppError) {
+	account, err := s.repo.FindBy(accountID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	for _, a := range accounts {
-		if a.AccountType == accountType && a.Amount != 0 {
-			return errs.NewValidationError("Account must have a balance of 0 to close")
-		}
+	if account.CustomerID != customerID {
+		return nil, errs.NewNotFoundError("Account not found")
 	}
+	response := account.ToGetAccountResponseDTO()
+	return &response, nil
+}
 
-	err = s.repo.Delete(id, accountType)
-	if err != nil {
-		return err
-	}
-	return nil
+// DeleteAccount deletes an account using a customer_id and account_type, if it is at the version and has a balance of 0
+func (s DefaultAccountService) DeleteAccount(id string, accountType string, version int) *errs.AppError {
+	return s.repo.Delete(id, accountType, version)
 }
 
 // MakeTransaction makes a withdrawal or deposit to an account. It then returns the updated balance for the account
@@ -96,22 +118,16 @@ func (s DefaultAccountService) MakeTransaction(req dto.MakeTransactionRequest) (
 	if err != nil {
 		return nil, err
 	}
-	// server side validation for checking the available balance in the account
-	if req.IsTransactionTypeWithdrawal() {
-		account, err := s.repo.FindBy(req.AccountID)
-		if err != nil {
-			return nil, err
-		}
-		if !account.CanWithdraw(req.Amount) {
-			return nil, errs.NewValidationError("Insufficient balance in the account")
-		}
-	}
-	// if all is well, build the domain object & save the transaction
+	// the available balance is checked by the repository against the locked account row,
+	// checking it here first would let two withdrawals pass on the same balance
+	now := time.Now()
 	t := domain.Transaction{
 		AccountID:       req.AccountID,
 		Amount:          req.Amount,
 		TransactionType: req.TransactionType,
-		TransactionDate: time.Now().Format(dbTSLayout),
+		TransactionDate: s.calendar.Timestamp(now),
+		BookingDate:     s.calendar.BookingDate(now),
+		ExpectedVersion: req.Version,
 	}
 	transaction, appError := s.repo.SaveTransaction(t)
 	if appError != nil {
@@ -120,3 +136,168 @@ func (s DefaultAccountService) MakeTransaction(req dto.MakeTransactionRequest) (
 	response := transaction.ToDTO()
 	return &response, nil
 }
+
+// Transfer debits an account of the customer and credits the destination account, which may belong to anyone.
+// Both sides are saved together, so either both balances change or neither does.
+func (s DefaultAccountService) Transfer(req dto.TransferRequest) (*dto.TransferResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	from, err := s.repo.FindBy(req.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if from.CustomerID != req.CustomerID {
+		return nil, errs.NewNotFoundError("Account not found")
+	}
+	if _, err = s.repo.FindBy(req.ToAccountID); err != nil {
+		return nil, err
+	}
+
+	// a transfer after the cut-off, or on a day the bank is closed, is valued on the next business day
+	now := time.Now()
+	transfer, err := s.repo.SaveTransfer(domain.NewTransfer(req, s.calendar.Timestamp(now), s.calendar.BookingDate(now), s.calendar.ValueDate(now)))
+	if err != nil {
+		return nil, err
+	}
+	response := transfer.ToDTO()
+	return &response, nil
+}
+
+// GetTransactionHistory returns one page of transactions for an account the customer owns.
+// One extra row is fetched to know if there is a following page without counting the whole history.
+func (s DefaultAccountService) GetTransactionHistory(req dto.TransactionHistoryRequest) (*dto.TransactionHistoryResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	filter, err := domain.NewTransactionFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.repo.FindBy(req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.CustomerID != req.CustomerID {
+		return nil, errs.NewNotFoundError("Account not found")
+	}
+
+	filter.Limit = req.Limit + 1
+	transactions, err := s.repo.FindTransactions(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.TransactionHistoryResponse{
+		AccountID:    req.AccountID,
+		Transactions: make([]dto.TransactionHistoryItem, 0),
+	}
+	if len(transactions) > req.Limit {
+		transactions = transactions[:req.Limit]
+		response.HasMore = true
+		response.NextCursor = transactions[len(transactions)-1].Cursor().Encode()
+	}
+	for _, t := range transactions {
+		response.Transactions = append(response.Transactions, t.ToHistoryDTO())
+	}
+	return &response, nil
+}
+
+// ExportTransactions writes the transactions of an account the customer owns, oldest first. The rows are passed
+// to the writer as they are read from the db, so a long history is never held in memory. A missing from starts
+// at the day the account was opened and a missing to ends today. Once Begin was called, an error means the
+// export was cut short.
+func (s DefaultAccountService) ExportTransactions(req dto.TransactionExportRequest, out TransactionWriter) *errs.AppError {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	account, err := s.repo.FindBy(req.AccountID)
+	if err != nil {
+		return err
+	}
+	if account.CustomerID != req.CustomerID {
+		return errs.NewNotFoundError("Account not found")
+	}
+
+	now := time.Now()
+	if req.From == "" && len(account.OpeningDate) >= len(dto.DATE_LAYOUT) {
+		req.From = account.OpeningDate[:len(dto.DATE_LAYOUT)]
+	}
+	if req.To == "" {
+		req.To = calendar.FormatDate(s.calendar.Today(now))
+	}
+	filter, err := domain.NewTransactionFilter(dto.TransactionHistoryRequest{AccountID: req.AccountID, From: req.From, To: req.To, Sort: dto.SORT_ASC})
+	if err != nil {
+		return err
+	}
+
+	export := dto.TransactionExport{
+		AccountID:   account.AccountID,
+		AccountType: account.AccountType,
+		Currency:    account.Amount.Currency(),
+		From:        req.From,
+		To:          req.To,
+		Balance:     account.Amount,
+		ExportedAt:  s.calendar.Timestamp(now),
+	}
+	if err := out.Begin(export); err != nil {
+		return errs.NewUnexpectedError("Unexpected error while writing the export " + err.Error())
+	}
+	if err := s.repo.StreamTransactions(filter, func(t domain.Transaction) error { return out.Write(t.ToExportDTO()) }); err != nil {
+		return err
+	}
+	if err := out.End(); err != nil {
+		return errs.NewUnexpectedError("Unexpected error while writing the export " + err.Error())
+	}
+	return nil
+}
+
+// UpdateAccountStatus changes the status of an account the customer owns and returns the updated account
+func (s DefaultAccountService) UpdateAccountStatus(customerID string, accountID string, req dto.UpdateStatusRequest) (*dto.GetAccountResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	account, err := s.repo.FindBy(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.CustomerID != customerID {
+		return nil, errs.NewNotFoundError("Account not found")
+	}
+
+	if err = s.repo.UpdateStatus(accountID, req.Status, req.Version); err != nil {
+		return nil, err
+	}
+	// read back for the new version
+	if account, err = s.repo.FindBy(accountID); err != nil {
+		return nil, err
+	}
+	response := account.ToGetAccountResponseDTO()
+	return &response, nil
+}
+
+// UpdateOverdraft sets the overdraft of an account the customer owns and returns the updated account
+func (s DefaultAccountService) UpdateOverdraft(customerID string, accountID string, req dto.UpdateOverdraftRequest) (*dto.GetAccountResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	account, err := s.repo.FindBy(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.CustomerID != customerID {
+		return nil, errs.NewNotFoundError("Account not found")
+	}
+
+	if err = s.repo.UpdateOverdraft(accountID, domain.NewOverdraft(req), req.Version); err != nil {
+		return nil, err
+	}
+	// read back for the new version
+	if account, err = s.repo.FindBy(accountID); err != nil {
+		return nil, err
+	}
+	response := account.ToGetAccountResponseDTO()
+	return &response, nil
+}
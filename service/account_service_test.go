@@ -0,0 +1,376 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonathanwamsley/banking/calendar"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockAccountRepo *domain.MockAccountRepository
+var accountService AccountService
+
+func setupAccount(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockAccountRepo = domain.NewMockAccountRepository(ctrl)
+	accountService = NewAccountService(mockAccountRepo, calendar.Default())
+	return func() {
+		accountService = nil
+		defer ctrl.Finish()
+	}
+}
+
+func transferRequest() dto.TransferRequest {
+	return dto.TransferRequest{
+		FromAccountID: "95472",
+		ToAccountID:   "95470",
+		Amount:        money.MustParse("100.00"),
+		CustomerID:    "2001",
+	}
+}
+
+func TestTransferValidationError(t *testing.T) {
+	req := transferRequest()
+	req.ToAccountID = req.FromAccountID
+
+	resp, err := NewAccountService(nil, calendar.Default()).Transfer(req)
+	assert.Nil(t, resp)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 422, err.Code)
+}
+
+func TestTransferFromAnotherCustomersAccount(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	from := &realdomain.Account{AccountID: "95472", CustomerID: "2000", Amount: money.MustParse("7000.00")}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(from, nil)
+
+	resp, err := accountService.Transfer(transferRequest())
+	assert.Nil(t, resp)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 404, err.Code)
+}
+
+func TestTransferInsufficientBalance(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	from := &realdomain.Account{AccountID: "95472", CustomerID: "2001", Amount: money.MustParse("99.99")}
+	to := &realdomain.Account{AccountID: "95470", CustomerID: "2000", Amount: money.MustParse("6823.23")}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(from, nil)
+	mockAccountRepo.EXPECT().FindBy("95470").Return(to, nil)
+	mockAccountRepo.EXPECT().SaveTransfer(gomock.Any()).Return(nil, errs.NewValidationError("Insufficient balance in the account"))
+
+	resp, err := accountService.Transfer(transferRequest())
+	assert.Nil(t, resp)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Insufficient balance in the account", err.Message)
+}
+
+func TestTransferUnknownDestination(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	from := &realdomain.Account{AccountID: "95472", CustomerID: "2001", Amount: money.MustParse("7000.00")}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(from, nil)
+	mockAccountRepo.EXPECT().FindBy("95470").Return(nil, errs.NewNotFoundError("Account not found"))
+
+	resp, err := accountService.Transfer(transferRequest())
+	assert.Nil(t, resp)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 404, err.Code)
+}
+
+func TestTransferNoError(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	from := &realdomain.Account{AccountID: "95472", CustomerID: "2001", Amount: money.MustParse("7000.00")}
+	to := &realdomain.Account{AccountID: "95470", CustomerID: "2000", Amount: money.MustParse("6823.23")}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(from, nil)
+	mockAccountRepo.EXPECT().FindBy("95470").Return(to, nil)
+	mockAccountRepo.EXPECT().SaveTransfer(gomock.Any()).DoAndReturn(func(t realdomain.Transfer) (*realdomain.Transfer, *errs.AppError) {
+		t.Debit.TransactionID = "10"
+		t.Debit.Balance = money.MustParse("6900.00")
+		t.Credit.TransactionID = "11"
+		t.Credit.Balance = money.MustParse("6923.23")
+		return &t, nil
+	})
+
+	resp, err := accountService.Transfer(transferRequest())
+	assert.Nil(t, err)
+	assert.NotNil(t, resp)
+	assert.EqualValues(t, money.MustParse("100.00"), resp.Amount)
+	assert.EqualValues(t, "10", resp.From.TransactionID)
+	assert.EqualValues(t, "95472", resp.From.AccountID)
+	assert.EqualValues(t, money.MustParse("6900.00"), resp.From.NewBalance)
+	assert.EqualValues(t, "11", resp.To.TransactionID)
+	assert.EqualValues(t, "95470", resp.To.AccountID)
+	assert.EqualValues(t, money.MustParse("6923.23"), resp.To.NewBalance)
+}
+
+func TestMakeTransactionLeavesBalanceCheckToRepository(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	req := dto.MakeTransactionRequest{AccountID: "95472", Amount: money.MustParse("100.00"), TransactionType: dto.WITHDRAWAL}
+	// no FindBy is expected, the balance is only checked under the row lock inside SaveTransaction
+	mockAccountRepo.EXPECT().SaveTransaction(gomock.Any()).Return(nil, errs.NewValidationError("Insufficient balance in the account"))
+
+	resp, err := accountService.MakeTransaction(req)
+	assert.Nil(t, resp)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "Insufficient balance in the account", err.Message)
+}
+
+func TestMakeTransactionNoError(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	req := dto.MakeTransactionRequest{AccountID: "95472", Amount: money.MustParse("100.00"), TransactionType: dto.DEPOSIT}
+	mockAccountRepo.EXPECT().SaveTransaction(gomock.Any()).DoAndReturn(func(t realdomain.Transaction) (*realdomain.Transaction, *errs.AppError) {
+		t.TransactionID = "12"
+		t.Balance = money.MustParse("7100.00")
+		return &t, nil
+	})
+
+	resp, err := accountService.MakeTransaction(req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "12", resp.TransactionID)
+	assert.EqualValues(t, money.MustParse("7100.00"), resp.Amount)
+	assert.EqualValues(t, dto.DEPOSIT, resp.TransactionType)
+}
+
+func historyTransactions(n int) []realdomain.Transaction {
+	transactions := make([]realdomain.Transaction, 0)
+	for i := n; i > 0; i-- {
+		transactions = append(transactions, realdomain.Transaction{
+			TransactionID:   strconv.Itoa(i),
+			AccountID:       "95472",
+			Amount:          money.MustParse("10.00"),
+			TransactionType: realdomain.DEPOSIT,
+			TransactionDate: "2021-03-10 09:02:44",
+			Balance:         money.FromMinor(int64(700000 + i*1000)),
+		})
+	}
+	return transactions
+}
+
+func TestGetTransactionHistoryHasMore(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	req := dto.TransactionHistoryRequest{AccountID: "95472", CustomerID: "2001", Limit: 2, Sort: dto.SORT_DESC}
+	account := &realdomain.Account{AccountID: "95472", CustomerID: "2001"}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(account, nil)
+	mockAccountRepo.EXPECT().FindTransactions(gomock.Any()).DoAndReturn(func(f realdomain.TransactionFilter) ([]realdomain.Transaction, *errs.AppError) {
+		assert.Equal(t, 3, f.Limit)
+		assert.True(t, f.Descending)
+		return historyTransactions(3), nil
+	})
+
+	resp, err := accountService.GetTransactionHistory(req)
+	assert.Nil(t, err)
+	assert.True(t, resp.HasMore)
+	assert.Equal(t, 2, len(resp.Transactions))
+	assert.Equal(t, "3", resp.Transactions[0].TransactionID)
+	assert.Equal(t, money.MustParse("7030.00"), resp.Transactions[0].RunningBalance)
+	cursor, _ := realdomain.DecodeTransactionCursor(resp.NextCursor)
+	assert.Equal(t, "2", cursor.TransactionID)
+}
+
+func TestGetTransactionHistoryLastPage(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	req := dto.TransactionHistoryRequest{AccountID: "95472", CustomerID: "2001", Limit: 5, Sort: dto.SORT_ASC}
+	account := &realdomain.Account{AccountID: "95472", CustomerID: "2001"}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(account, nil)
+	mockAccountRepo.EXPECT().FindTransactions(gomock.Any()).Return(historyTransactions(2), nil)
+
+	resp, err := accountService.GetTransactionHistory(req)
+	assert.Nil(t, err)
+	assert.False(t, resp.HasMore)
+	assert.Equal(t, "", resp.NextCursor)
+	assert.Equal(t, 2, len(resp.Transactions))
+}
+
+func TestGetTransactionHistoryOtherCustomersAccount(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	req := dto.TransactionHistoryRequest{AccountID: "95472", CustomerID: "2000", Limit: 5, Sort: dto.SORT_ASC}
+	account := &realdomain.Account{AccountID: "95472", CustomerID: "2001"}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(account, nil)
+
+	resp, err := accountService.GetTransactionHistory(req)
+	assert.Nil(t, resp)
+	assert.EqualValues(t, 404, err.Code)
+}
+
+// recordingWriter keeps what an export wrote
+type recordingWriter struct {
+	export dto.TransactionExport
+	items  []dto.TransactionExportItem
+	ended  bool
+}
+
+func (w *recordingWriter) Begin(e dto.TransactionExport) error {
+	w.export = e
+	return nil
+}
+
+func (w *recordingWriter) Write(item dto.TransactionExportItem) error {
+	w.items = append(w.items, item)
+	return nil
+}
+
+func (w *recordingWriter) End() error {
+	w.ended = true
+	return nil
+}
+
+func TestExportTransactionsStreamsFromTheOpeningDate(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	req := dto.TransactionExportRequest{AccountID: "95472", CustomerID: "2001", To: "2021-03-31", Format: dto.EXPORT_OFX}
+	account := &realdomain.Account{AccountID: "95472", CustomerID: "2001", AccountType: "saving", OpeningDate: "2021-01-04 10:00:00", Amount: money.MustParse("7030.00")}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(account, nil)
+	mockAccountRepo.EXPECT().StreamTransactions(gomock.Any(), gomock.Any()).DoAndReturn(func(f realdomain.TransactionFilter, each func(realdomain.Transaction) error) *errs.AppError {
+		assert.Equal(t, 0, f.Limit)
+		assert.False(t, f.Descending)
+		assert.Equal(t, "2021-01-04 00:00:00", f.From)
+		assert.Equal(t, "2021-04-01 00:00:00", f.To)
+		for _, txn := range historyTransactions(2) {
+			if err := each(txn); err != nil {
+				return errs.NewUnexpectedError(err.Error())
+			}
+		}
+		return nil
+	})
+
+	out := &recordingWriter{}
+	assert.Nil(t, accountService.ExportTransactions(req, out))
+	assert.Equal(t, "2021-01-04", out.export.From)
+	assert.Equal(t, money.MustParse("7030.00"), out.export.Balance)
+	assert.Equal(t, 2, len(out.items))
+	assert.True(t, out.ended)
+}
+
+func TestExportTransactionsOtherCustomersAccountWritesNothing(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	req := dto.TransactionExportRequest{AccountID: "95472", CustomerID: "2000", Format: dto.EXPORT_QIF}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(&realdomain.Account{AccountID: "95472", CustomerID: "2001"}, nil)
+
+	out := &recordingWriter{}
+	err := accountService.ExportTransactions(req, out)
+	assert.EqualValues(t, 404, err.Code)
+	assert.Equal(t, "", out.export.AccountID)
+}
+
+func TestGetTransactionHistoryBadCursor(t *testing.T) {
+	req := dto.TransactionHistoryRequest{AccountID: "95472", CustomerID: "2001", Limit: 5, Sort: dto.SORT_ASC, Cursor: "???"}
+
+	resp, err := NewAccountService(nil, calendar.Default()).GetTransactionHistory(req)
+	assert.Nil(t, resp)
+	assert.EqualValues(t, "invalid cursor", err.Message)
+}
+
+func TestUpdateAccountStatusOtherCustomersAccount(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	account := &realdomain.Account{AccountID: "95472", CustomerID: "2001", Status: realdomain.STATUS_ACTIVE}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(account, nil)
+
+	resp, err := accountService.UpdateAccountStatus("2000", "95472", dto.UpdateStatusRequest{Status: realdomain.STATUS_FROZEN})
+	assert.Nil(t, resp)
+	assert.EqualValues(t, 404, err.Code)
+}
+
+func TestUpdateAccountStatusNoError(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	account := &realdomain.Account{AccountID: "95472", CustomerID: "2001", Status: realdomain.STATUS_ACTIVE}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(account, nil)
+	mockAccountRepo.EXPECT().UpdateStatus("95472", realdomain.STATUS_FROZEN, 0).Return(nil)
+	frozen := &realdomain.Account{AccountID: "95472", CustomerID: "2001", Status: realdomain.STATUS_FROZEN}
+	mockAccountRepo.EXPECT().FindBy("95472").Return(frozen, nil)
+
+	resp, err := accountService.UpdateAccountStatus("2001", "95472", dto.UpdateStatusRequest{Status: realdomain.STATUS_FROZEN})
+	assert.Nil(t, err)
+	assert.EqualValues(t, realdomain.STATUS_FROZEN, resp.Status)
+}
+
+func TestUpdateOverdraftRefusesNegativeLimit(t *testing.T) {
+	resp, err := NewAccountService(nil, calendar.Default()).UpdateOverdraft("2001", "95473", dto.UpdateOverdraftRequest{Limit: money.MustParse("-1.00")})
+	assert.Nil(t, resp)
+	assert.EqualValues(t, 422, err.Code)
+}
+
+func TestUpdateOverdraftNoError(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	account := &realdomain.Account{AccountID: "95473", CustomerID: "2001", AccountType: dto.CHECKING, Version: 3}
+	mockAccountRepo.EXPECT().FindBy("95473").Return(account, nil)
+	overdraft := realdomain.Overdraft{Limit: money.MustParse("500.00"), Sweep: true}
+	mockAccountRepo.EXPECT().UpdateOverdraft("95473", overdraft, 3).Return(nil)
+	updated := &realdomain.Account{AccountID: "95473", CustomerID: "2001", AccountType: dto.CHECKING, OverdraftLimit: overdraft.Limit, OverdraftSweep: true, Version: 4}
+	mockAccountRepo.EXPECT().FindBy("95473").Return(updated, nil)
+
+	resp, err := accountService.UpdateOverdraft("2001", "95473", dto.UpdateOverdraftRequest{Limit: overdraft.Limit, Sweep: true, Version: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, money.MustParse("500.00"), resp.OverdraftLimit)
+	assert.True(t, resp.OverdraftSweep)
+	assert.Equal(t, 4, resp.Version)
+}
+
+func TestMakeTransactionOnFrozenAccount(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	req := dto.MakeTransactionRequest{AccountID: "95472", Amount: money.MustParse("100.00"), TransactionType: dto.WITHDRAWAL}
+	mockAccountRepo.EXPECT().SaveTransaction(gomock.Any()).Return(nil, realdomain.CheckStatus(realdomain.SUBJECT_ACCOUNT, realdomain.STATUS_FROZEN, realdomain.OP_WITHDRAW))
+
+	resp, err := accountService.MakeTransaction(req)
+	assert.Nil(t, resp)
+	assert.EqualValues(t, 409, err.Code)
+	assert.EqualValues(t, "account_frozen", err.Reason)
+}
+
+func TestMakeTransactionPassesIfMatchVersion(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	req := dto.MakeTransactionRequest{AccountID: "95472", Amount: money.MustParse("100.00"), TransactionType: dto.DEPOSIT, Version: 7}
+	mockAccountRepo.EXPECT().SaveTransaction(gomock.Any()).DoAndReturn(func(t realdomain.Transaction) (*realdomain.Transaction, *errs.AppError) {
+		return nil, realdomain.CheckVersion(realdomain.SUBJECT_ACCOUNT, 8, t.ExpectedVersion)
+	})
+
+	resp, err := accountService.MakeTransaction(req)
+	assert.Nil(t, resp)
+	assert.EqualValues(t, 412, err.Code)
+}
+
+func TestDeleteAccountStaleVersion(t *testing.T) {
+	teardown := setupAccount(t)
+	defer teardown()
+
+	mockAccountRepo.EXPECT().Delete("2001", "saving", 1).Return(realdomain.CheckVersion(realdomain.SUBJECT_ACCOUNT, 2, 1))
+
+	err := accountService.DeleteAccount("2001", "saving", 1)
+	assert.EqualValues(t, 412, err.Code)
+}
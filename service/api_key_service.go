@@ -0,0 +1,105 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// APIKeyService is an interface that implements
+//
+// GetAPIKeys: returns every api key without its secret
+// CreateAPIKey: creates a key scoped to route names, the key is only returned this once
+// RevokeAPIKey: revokes a key, it is refused from the next request on
+// Authorize: checks a key may call a route and returns the claims of the request
+// mockgen -destination=mocks/service/mock_api_key_service.go -package=service github.com/jonathanwamsley/banking/service APIKeyService
+type APIKeyService interface {
+	GetAPIKeys() ([]dto.APIKeyResponse, *errs.AppError)
+	CreateAPIKey(req dto.CreateAPIKeyRequest, createdBy string) (*dto.CreateAPIKeyResponse, *errs.AppError)
+	RevokeAPIKey(keyID string) *errs.AppError
+	Authorize(key string, routeName string) (*domain.AccessClaims, *errs.AppError)
+}
+
+// DefaultAPIKeyService has methods that call dto and the domain
+type DefaultAPIKeyService struct {
+	repo    domain.APIKeyRepository
+	isRoute func(name string) bool
+	now     func() time.Time
+}
+
+// NewAPIKeyService is the entry point to the service to create a DefaultAPIKeyService struct.
+// isRoute tells if a scope names a route of the router.
+func NewAPIKeyService(repository domain.APIKeyRepository, isRoute func(name string) bool) DefaultAPIKeyService {
+	return DefaultAPIKeyService{repo: repository, isRoute: isRoute, now: time.Now}
+}
+
+// GetAPIKeys returns every api key
+func (s DefaultAPIKeyService) GetAPIKeys() ([]dto.APIKeyResponse, *errs.AppError) {
+	keys, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.APIKeyResponse, 0)
+	for _, k := range keys {
+		response = append(response, k.ToDTO())
+	}
+	return response, nil
+}
+
+// CreateAPIKey creates a key for the routes named in its scopes, a scope that is not a route is refused
+// so a typo does not quietly leave the key without access
+func (s DefaultAPIKeyService) CreateAPIKey(req dto.CreateAPIKeyRequest, createdBy string) (*dto.CreateAPIKeyResponse, *errs.AppError) {
+	now := s.now()
+	if err := req.Validate(now); err != nil {
+		return nil, err
+	}
+	for _, scope := range req.Scopes {
+		if !s.isRoute(scope) {
+			return nil, errs.NewValidationError("scope " + scope + " is not a route name")
+		}
+	}
+	k, key, genErr := domain.NewAPIKey(req, createdBy, now)
+	if genErr != nil {
+		logger.Error("Error while generating an api key " + genErr.Error())
+		return nil, errs.NewUnexpectedError("Unexpected error while generating an api key")
+	}
+	if err := s.repo.Save(k); err != nil {
+		return nil, err
+	}
+	return &dto.CreateAPIKeyResponse{APIKeyResponse: k.ToDTO(), Key: key}, nil
+}
+
+// RevokeAPIKey revokes a key
+func (s DefaultAPIKeyService) RevokeAPIKey(keyID string) *errs.AppError {
+	return s.repo.Revoke(keyID, s.now().Format(dbTSLayout))
+}
+
+// Authorize checks the key and its scopes. An unknown key gets the same 401 as a wrong secret.
+// The last use is recorded at most once a minute, and failing to record it does not fail the request.
+func (s DefaultAPIKeyService) Authorize(key string, routeName string) (*domain.AccessClaims, *errs.AppError) {
+	keyID, secret, ok := domain.ParseAPIKey(key)
+	if !ok {
+		return nil, errs.NewUnauthorizedError("invalid api key")
+	}
+	k, err := s.repo.ByID(keyID)
+	if err != nil {
+		if err.Code == http.StatusNotFound {
+			return nil, errs.NewUnauthorizedError("invalid api key")
+		}
+		return nil, err
+	}
+	now := s.now()
+	if err = k.Check(secret, routeName, now); err != nil {
+		return nil, err
+	}
+	if k.NeedsTouch(now) {
+		if err = s.repo.Touch(k.KeyID, now.Format(dbTSLayout)); err != nil {
+			logger.Error("unable to record the use of api key " + k.KeyID)
+		}
+	}
+	return k.AccessClaims(), nil
+}
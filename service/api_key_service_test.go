@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockKeyRepo *domain.MockAPIKeyRepository
+var keyService DefaultAPIKeyService
+
+var knownRoutes = map[string]bool{"GetCustomers": true, "NewTransaction": true}
+
+func setupAPIKeys(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockKeyRepo = domain.NewMockAPIKeyRepository(ctrl)
+	keyService = NewAPIKeyService(mockKeyRepo, func(name string) bool { return knownRoutes[name] })
+	keyService.now = func() time.Time { return loginNow }
+	return func() {
+		defer ctrl.Finish()
+	}
+}
+
+func TestCreateAPIKeyRefusesUnknownRoutes(t *testing.T) {
+	teardown := setupAPIKeys(t)
+	defer teardown()
+
+	_, err := keyService.CreateAPIKey(dto.CreateAPIKeyRequest{Name: "nightly", Scopes: []string{"GetCustomers", "GetCustomer"}}, "admin")
+
+	assert.EqualValues(t, 422, err.Code)
+}
+
+func TestCreatedAPIKeyAuthorizesItsScopes(t *testing.T) {
+	teardown := setupAPIKeys(t)
+	defer teardown()
+	var saved realdomain.APIKey
+	mockKeyRepo.EXPECT().Save(gomock.Any()).DoAndReturn(func(k realdomain.APIKey) *errs.AppError {
+		saved = k
+		return nil
+	})
+
+	created, err := keyService.CreateAPIKey(dto.CreateAPIKeyRequest{Name: "nightly", Scopes: []string{"GetCustomers"}}, "admin")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", created.CreatedBy)
+
+	mockKeyRepo.EXPECT().ByID(saved.KeyID).Return(&saved, nil).Times(2)
+	mockKeyRepo.EXPECT().Touch(saved.KeyID, "2021-03-10 09:00:00").Return(nil)
+	claims, err := keyService.Authorize(created.Key, "GetCustomers")
+	assert.Nil(t, err)
+	assert.Equal(t, realdomain.ROLE_SERVICE, claims.Role)
+	assert.Equal(t, "apikey:"+saved.KeyID, claims.Subject)
+
+	_, err = keyService.Authorize(created.Key, "NewTransaction")
+	assert.EqualValues(t, 403, err.Code)
+}
+
+func TestAuthorizeUnknownAPIKeyLooksLikeWrongSecret(t *testing.T) {
+	teardown := setupAPIKeys(t)
+	defer teardown()
+	mockKeyRepo.EXPECT().ByID("0a1b2c3d4e5f").Return(nil, errs.NewNotFoundError("API key not found"))
+
+	_, err := keyService.Authorize("bk_0a1b2c3d4e5f_00ff", "GetCustomers")
+
+	assert.EqualValues(t, 401, err.Code)
+	assert.Equal(t, "invalid api key", err.Message)
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// the reasons a login is refused with
+const (
+	REASON_INVALID_CREDENTIALS = "invalid_credentials"
+	REASON_USER_LOCKED         = "user_locked"
+)
+
+// AuthService is an interface that implements
+//
+// Login: checks the credentials of a user, starts a session and returns a signed access token and refresh token
+// Refresh: trades the current refresh token of a session for a new access token and refresh token
+type AuthService interface {
+	Login(dto.LoginRequest) (*dto.LoginResponse, *errs.AppError)
+	Refresh(dto.RefreshRequest) (*dto.LoginResponse, *errs.AppError)
+}
+
+// DefaultAuthService has methods that call dto and the domain
+type DefaultAuthService struct {
+	repo     domain.UserRepository
+	sessions domain.SessionRepository
+	issuer   domain.TokenIssuer
+	lockout  domain.LoginLockout
+	now      func() time.Time
+}
+
+// NewAuthService is the entry point to the service to create a DefaultAuthService struct
+func NewAuthService(repository domain.UserRepository, sessions domain.SessionRepository, issuer domain.TokenIssuer, lockout domain.LoginLockout) DefaultAuthService {
+	return DefaultAuthService{repo: repository, sessions: sessions, issuer: issuer, lockout: lockout, now: time.Now}
+}
+
+// unknownUser is compared against when the username does not exist, so a missing user takes as long
+// to refuse as a wrong password and usernames cannot be found by timing logins
+var unknownUser struct {
+	once sync.Once
+	user domain.User
+}
+
+func unknownUserHash() domain.User {
+	unknownUser.once.Do(func() {
+		hash, _ := domain.HashPassword("Unknown user password")
+		unknownUser.user = domain.User{PasswordHash: hash}
+	})
+	return unknownUser.user
+}
+
+// Login returns tokens for valid credentials. A wrong password counts towards the lockout, and a locked user
+// is refused until the lock expires, even with the right password. A user with mfa also needs a one time password,
+// without one the login is refused with reason mfa_required and a wrong one counts towards the lockout.
+func (s DefaultAuthService) Login(req dto.LoginRequest) (*dto.LoginResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	refused := errs.NewUnauthorizedError("invalid username or password").WithReason(REASON_INVALID_CREDENTIALS)
+
+	u, err := s.repo.ByUsername(req.Username)
+	if err != nil {
+		if err.Code != http.StatusNotFound {
+			return nil, err
+		}
+		unknownUserHash().CheckPassword(req.Password)
+		return nil, refused
+	}
+
+	now := s.now()
+	if u.IsLocked(now) {
+		return nil, userLocked()
+	}
+	if !u.CheckPassword(req.Password) {
+		if err = recordFailure(s.repo, s.lockout, u.Username, now); err != nil {
+			return nil, err
+		}
+		return nil, refused
+	}
+	if u.TOTPEnabled {
+		if err = checkSecondFactor(s.repo, u, req.OTP, now); err != nil {
+			if err.Reason == REASON_INVALID_OTP {
+				if recordErr := recordFailure(s.repo, s.lockout, u.Username, now); recordErr != nil {
+					return nil, recordErr
+				}
+			}
+			return nil, err
+		}
+	}
+	if u.FailedLogins > 0 || u.LockedUntil.Valid {
+		if err = s.repo.ResetFailedLogins(u.Username); err != nil {
+			return nil, err
+		}
+	}
+
+	sessionID, idErr := domain.NewTokenID()
+	if idErr != nil {
+		logger.Error("Error while creating a session id " + idErr.Error())
+		return nil, errs.NewUnexpectedError("Unexpected error while starting a session")
+	}
+	tokens, err := s.issue(u, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	session := domain.NewSession(sessionID, u.Username, tokens.RefreshID, req.UserAgent, req.IPAddress, now, tokens.RefreshTTL)
+	if err = s.sessions.Save(session); err != nil {
+		return nil, err
+	}
+	return loginResponse(tokens, sessionID), nil
+}
+
+// Refresh rotates the refresh token of a session. The claims are read from the user again, so a changed role
+// or a new account shows up without logging in again. Every refresh token works once, a token that was already
+// traded in revokes its whole session, since either the client or whoever stole the token is replaying it.
+func (s DefaultAuthService) Refresh(req dto.RefreshRequest) (*dto.LoginResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	claims, parseErr := s.issuer.ParseRefreshToken(req.RefreshToken)
+	if parseErr != nil || claims.SessionID == "" || claims.ID == "" {
+		return nil, errs.NewUnauthorizedError("invalid refresh token").WithReason(domain.REASON_INVALID_REFRESH_TOKEN)
+	}
+
+	u, err := s.repo.ByUsername(claims.Username)
+	if err != nil {
+		if err.Code == http.StatusNotFound {
+			return nil, errs.NewUnauthorizedError("invalid refresh token").WithReason(domain.REASON_INVALID_REFRESH_TOKEN)
+		}
+		return nil, err
+	}
+	now := s.now()
+	if u.IsLocked(now) {
+		return nil, userLocked()
+	}
+
+	tokens, err := s.issue(u, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.sessions.Rotate(claims.SessionID, claims.ID, tokens.RefreshID, now); err != nil {
+		return nil, err
+	}
+	return loginResponse(tokens, claims.SessionID), nil
+}
+
+// issue signs the tokens of a user for a session
+func (s DefaultAuthService) issue(u *domain.User, sessionID string) (*domain.TokenPair, *errs.AppError) {
+	claims := u.AccessClaims()
+	claims.SessionID = sessionID
+	tokens, err := s.issuer.Issue(claims)
+	if err != nil {
+		logger.Error("Error while signing tokens " + err.Error())
+		return nil, errs.NewUnexpectedError("Unexpected error while signing tokens")
+	}
+	return tokens, nil
+}
+
+func loginResponse(tokens *domain.TokenPair, sessionID string) *dto.LoginResponse {
+	return &dto.LoginResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tokens.AccessTTL / time.Second),
+		SessionID:    sessionID,
+	}
+}
+
+// recordFailure counts a failed login, locking the user for the lockout duration once it has too many
+func recordFailure(repo domain.UserRepository, lockout domain.LoginLockout, username string, now time.Time) *errs.AppError {
+	lockedUntil := now.Add(lockout.Duration).Format(dbTSLayout)
+	return repo.RecordFailedLogin(username, lockout.MaxAttempts, lockedUntil)
+}
+
+func userLocked() *errs.AppError {
+	return errs.NewUnauthorizedError("user is locked after too many failed logins, try again later").WithReason(REASON_USER_LOCKED)
+}
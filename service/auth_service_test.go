@@ -0,0 +1,208 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/mock/gomock"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockUserRepo *domain.MockUserRepository
+var mockSessionRepo *domain.MockSessionRepository
+var authService DefaultAuthService
+var authIssuer realdomain.TokenIssuer
+
+var loginNow = time.Date(2021, 3, 10, 9, 0, 0, 0, time.Local)
+
+func setupAuth(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockUserRepo = domain.NewMockUserRepository(ctrl)
+	mockSessionRepo = domain.NewMockSessionRepository(ctrl)
+	authIssuer = realdomain.NewHMACTokenIssuer([]byte("hmacSampleSecret"), "banking", "", 15*time.Minute, 24*time.Hour)
+	authService = NewAuthService(mockUserRepo, mockSessionRepo, authIssuer, realdomain.LoginLockout{MaxAttempts: 5, Duration: 15 * time.Minute})
+	authService.now = func() time.Time { return loginNow }
+	return func() {
+		defer ctrl.Finish()
+	}
+}
+
+func customerUser(t *testing.T) *realdomain.User {
+	hash, err := realdomain.HashPassword("Password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &realdomain.User{
+		Username:     "arian",
+		PasswordHash: hash,
+		Role:         realdomain.ROLE_CUSTOMER,
+		CustomerID:   sql.NullString{String: "2001", Valid: true},
+		Accounts:     []string{"95472", "95473"},
+	}
+}
+
+func TestLoginIssuesAccessAndRefreshTokens(t *testing.T) {
+	teardown := setupAuth(t)
+	defer teardown()
+	mockUserRepo.EXPECT().ByUsername("arian").Return(customerUser(t), nil)
+	var saved realdomain.Session
+	mockSessionRepo.EXPECT().Save(gomock.Any()).DoAndReturn(func(s realdomain.Session) *errs.AppError {
+		saved = s
+		return nil
+	})
+
+	resp, err := authService.Login(dto.LoginRequest{Username: "arian", Password: "Password1", UserAgent: "curl/7.68.0", IPAddress: "10.0.0.7"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer", resp.TokenType)
+	assert.EqualValues(t, 900, resp.ExpiresIn)
+	claims := &realdomain.AccessClaims{}
+	_, parseErr := jwt.NewParser(jwt.WithoutClaimsValidation()).ParseWithClaims(resp.AccessToken, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("hmacSampleSecret"), nil
+	})
+	assert.Nil(t, parseErr)
+	assert.Equal(t, "2001", claims.CustomerID)
+	assert.Equal(t, []string{"95472", "95473"}, claims.Accounts)
+	assert.Equal(t, "arian", claims.Subject)
+	assert.Equal(t, realdomain.TOKEN_USE_ACCESS, claims.TokenUse)
+	assert.NotEqual(t, resp.AccessToken, resp.RefreshToken)
+	assert.Equal(t, resp.SessionID, claims.SessionID)
+	assert.NotEmpty(t, claims.ID)
+	assert.Equal(t, resp.SessionID, saved.SessionID)
+	assert.Equal(t, "2021-03-11 09:00:00", saved.ExpiresAt)
+	assert.Equal(t, "10.0.0.7", saved.IPAddress)
+	refresh, _ := authIssuer.ParseRefreshToken(resp.RefreshToken)
+	assert.Equal(t, refresh.ID, saved.RefreshID)
+}
+
+func TestLoginWrongPasswordCountsTowardsLockout(t *testing.T) {
+	teardown := setupAuth(t)
+	defer teardown()
+	mockUserRepo.EXPECT().ByUsername("arian").Return(customerUser(t), nil)
+	mockUserRepo.EXPECT().RecordFailedLogin("arian", 5, "2021-03-10 09:15:00").Return(nil)
+
+	resp, err := authService.Login(dto.LoginRequest{Username: "arian", Password: "Password2"})
+
+	assert.Nil(t, resp)
+	assert.EqualValues(t, 401, err.Code)
+	assert.EqualValues(t, REASON_INVALID_CREDENTIALS, err.Reason)
+}
+
+func TestLoginUnknownUserLooksLikeWrongPassword(t *testing.T) {
+	teardown := setupAuth(t)
+	defer teardown()
+	mockUserRepo.EXPECT().ByUsername("nobody").Return(nil, errs.NewNotFoundError("User not found"))
+
+	_, err := authService.Login(dto.LoginRequest{Username: "nobody", Password: "Password1"})
+
+	assert.EqualValues(t, 401, err.Code)
+	assert.EqualValues(t, REASON_INVALID_CREDENTIALS, err.Reason)
+}
+
+func TestLoginLockedUserIsRefusedWithRightPassword(t *testing.T) {
+	teardown := setupAuth(t)
+	defer teardown()
+	u := customerUser(t)
+	u.LockedUntil = sql.NullString{String: "2021-03-10 09:10:00", Valid: true}
+	mockUserRepo.EXPECT().ByUsername("arian").Return(u, nil)
+
+	_, err := authService.Login(dto.LoginRequest{Username: "arian", Password: "Password1"})
+
+	assert.EqualValues(t, 401, err.Code)
+	assert.EqualValues(t, REASON_USER_LOCKED, err.Reason)
+}
+
+func TestLoginAfterLockExpiresResetsFailedLogins(t *testing.T) {
+	teardown := setupAuth(t)
+	defer teardown()
+	u := customerUser(t)
+	u.FailedLogins = 0
+	u.LockedUntil = sql.NullString{String: "2021-03-10 08:59:00", Valid: true}
+	mockUserRepo.EXPECT().ByUsername("arian").Return(u, nil)
+	mockUserRepo.EXPECT().ResetFailedLogins("arian").Return(nil)
+	mockSessionRepo.EXPECT().Save(gomock.Any()).Return(nil)
+
+	resp, err := authService.Login(dto.LoginRequest{Username: "arian", Password: "Password1"})
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+}
+
+func TestLoginWithMFANeedsAOneTimePassword(t *testing.T) {
+	teardown := setupAuth(t)
+	defer teardown()
+	u := customerUser(t)
+	u.TOTPEnabled = true
+	u.TOTPSecret = sql.NullString{String: testTOTPSecret, Valid: true}
+	mockUserRepo.EXPECT().ByUsername("arian").Return(u, nil).Times(2)
+	mockUserRepo.EXPECT().RecordFailedLogin("arian", 5, "2021-03-10 09:15:00").Return(nil)
+
+	_, err := authService.Login(dto.LoginRequest{Username: "arian", Password: "Password1"})
+	assert.EqualValues(t, REASON_MFA_REQUIRED, err.Reason)
+
+	_, err = authService.Login(dto.LoginRequest{Username: "arian", Password: "Password1", OTP: "000000"})
+	assert.EqualValues(t, REASON_INVALID_OTP, err.Reason)
+}
+
+// loginTokens issues the tokens of a login to session s1 without going through Login
+func loginTokens(t *testing.T) *realdomain.TokenPair {
+	claims := customerUser(t).AccessClaims()
+	claims.SessionID = "s1"
+	tokens, err := authIssuer.Issue(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+func TestRefreshRotatesTheRefreshToken(t *testing.T) {
+	teardown := setupAuth(t)
+	defer teardown()
+	tokens := loginTokens(t)
+	mockUserRepo.EXPECT().ByUsername("arian").Return(customerUser(t), nil)
+	var rotatedTo string
+	mockSessionRepo.EXPECT().Rotate("s1", tokens.RefreshID, gomock.Any(), loginNow).DoAndReturn(
+		func(sessionID string, used string, next string, now time.Time) *errs.AppError {
+			rotatedTo = next
+			return nil
+		})
+
+	resp, err := authService.Refresh(dto.RefreshRequest{RefreshToken: tokens.RefreshToken})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "s1", resp.SessionID)
+	refresh, _ := authIssuer.ParseRefreshToken(resp.RefreshToken)
+	assert.Equal(t, rotatedTo, refresh.ID)
+	assert.NotEqual(t, tokens.RefreshID, refresh.ID)
+}
+
+func TestRefreshWithAReusedTokenIsRefused(t *testing.T) {
+	teardown := setupAuth(t)
+	defer teardown()
+	tokens := loginTokens(t)
+	mockUserRepo.EXPECT().ByUsername("arian").Return(customerUser(t), nil)
+	reused := errs.NewUnauthorizedError("refresh token was already used, the session is revoked").WithReason(realdomain.REASON_REFRESH_TOKEN_REUSED)
+	mockSessionRepo.EXPECT().Rotate("s1", tokens.RefreshID, gomock.Any(), loginNow).Return(reused)
+
+	resp, err := authService.Refresh(dto.RefreshRequest{RefreshToken: tokens.RefreshToken})
+
+	assert.Nil(t, resp)
+	assert.EqualValues(t, realdomain.REASON_REFRESH_TOKEN_REUSED, err.Reason)
+}
+
+func TestRefreshWithAnAccessTokenIsRefused(t *testing.T) {
+	teardown := setupAuth(t)
+	defer teardown()
+	tokens := loginTokens(t)
+
+	_, err := authService.Refresh(dto.RefreshRequest{RefreshToken: tokens.AccessToken})
+
+	assert.EqualValues(t, 401, err.Code)
+	assert.EqualValues(t, realdomain.REASON_INVALID_REFRESH_TOKEN, err.Reason)
+}
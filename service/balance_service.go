@@ -0,0 +1,185 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// MAX_SNAPSHOT_DAYS is how many dates RunDue takes snapshots of at a time. A long backfill continues from the
+// last completed date on the next run.
+const MAX_SNAPSHOT_DAYS = 31
+
+// BalanceService is an interface that implements
+//
+// GetBalance: returns the balance of an account of a customer at the end of a date
+// TakeSnapshots: stores the end of day balance of every open account for a date that has ended, a date is only
+// taken once per account
+// CheckSnapshots: compares the last snapshot of every account, moved by the ledger since, with its balance
+// RunDue: takes the snapshots of the dates since the last completed run
+// mockgen -destination=mocks/service/mock_balance_service.go -package=service github.com/jonathanwamsley/banking/service BalanceService
+type BalanceService interface {
+	GetBalance(customerID string, accountID string, asOf string) (*dto.BalanceResponse, *errs.AppError)
+	TakeSnapshots(date string) (*dto.SnapshotRunResponse, *errs.AppError)
+	CheckSnapshots() (*dto.SnapshotCheckResponse, *errs.AppError)
+	RunDue() *errs.AppError
+}
+
+// DefaultBalanceService has methods that call dto and the domain. Days start and end in the bank's time zone.
+type DefaultBalanceService struct {
+	repo     domain.BalanceRepository
+	accounts domain.AccountRepository
+	jobs     domain.JobRunRepository
+	calendar calendar.Calendar
+	now      func() time.Time
+}
+
+// NewBalanceService is the entry point to the service to create a DefaultBalanceService struct
+func NewBalanceService(repository domain.BalanceRepository, accounts domain.AccountRepository, jobs domain.JobRunRepository, cal calendar.Calendar) DefaultBalanceService {
+	return DefaultBalanceService{repo: repository, accounts: accounts, jobs: jobs, calendar: cal, now: time.Now}
+}
+
+// GetBalance returns the balance of an account the customer owns at the end of asOf, today when it is empty.
+// The balance of today is the balance so far.
+func (s DefaultBalanceService) GetBalance(customerID string, accountID string, asOf string) (*dto.BalanceResponse, *errs.AppError) {
+	today := s.calendar.Today(s.now())
+	if asOf == "" {
+		asOf = calendar.FormatDate(today)
+	}
+	day, err := domain.ParseRunDate(asOf, s.calendar.Location())
+	if err != nil {
+		return nil, errs.NewValidationError("as_of should be a date like 2021-03-31")
+	}
+	if day.After(today) {
+		return nil, errs.NewValidationError("as_of can not be in the future")
+	}
+
+	account, err := s.accounts.FindBy(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.CustomerID != customerID {
+		return nil, errs.NewNotFoundError("Account not found")
+	}
+	if !account.OpenedBy(day) {
+		return nil, errs.NewValidationError("the account was not open on " + asOf)
+	}
+
+	balance, err := s.repo.BalanceAsOf(accountID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	response := balance.ToDTO()
+	return &response, nil
+}
+
+// TakeSnapshots stores the balance of every open account at the end of date. Accounts that already have a
+// snapshot of the date are left out.
+func (s DefaultBalanceService) TakeSnapshots(date string) (*dto.SnapshotRunResponse, *errs.AppError) {
+	day, err := domain.ParseRunDate(date, s.calendar.Location())
+	if err != nil {
+		return nil, err
+	}
+	if !day.Before(s.calendar.Today(s.now())) {
+		return nil, errs.NewValidationError("a snapshot can only be taken of a day that has ended")
+	}
+	candidates, err := s.repo.SnapshotCandidates(date)
+	if err != nil {
+		return nil, err
+	}
+
+	takenAt := s.calendar.Timestamp(s.now())
+	response := dto.SnapshotRunResponse{Date: date}
+	for _, c := range candidates {
+		saved, err := s.repo.SaveSnapshot(c.Snapshot(takenAt))
+		if err != nil {
+			logger.Error("unable to take the balance snapshot of account " + c.AccountID + ": " + err.Message)
+			response.Failed++
+			continue
+		}
+		if !saved {
+			response.Skipped++
+			continue
+		}
+		response.Accounts++
+	}
+	if response.Failed == 0 {
+		if err = s.jobs.CompleteRun(domain.JOB_BALANCE_SNAPSHOTS, date, response.Accounts, takenAt); err != nil {
+			return nil, err
+		}
+	}
+	return &response, nil
+}
+
+// CheckSnapshots proves the snapshots add up: the last snapshot of every account, moved by the ledger lines
+// posted since, has to come to the account balance. Snapshots that do not are reported, not treated as an error.
+func (s DefaultBalanceService) CheckSnapshots() (*dto.SnapshotCheckResponse, *errs.AppError) {
+	last, err := s.jobs.LastRun(domain.JOB_BALANCE_SNAPSHOTS)
+	if err != nil {
+		return nil, err
+	}
+	comparisons, err := s.repo.CompareSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	response := dto.SnapshotCheckResponse{
+		Consistent:       true,
+		AccountsChecked:  len(comparisons),
+		LastSnapshotDate: last,
+		Mismatches:       make([]dto.SnapshotMismatch, 0),
+	}
+	for _, c := range comparisons {
+		if !c.IsConsistent() {
+			response.Consistent = false
+			response.Mismatches = append(response.Mismatches, c.ToDTO())
+		}
+	}
+	return &response, nil
+}
+
+// RunDue takes the snapshots of the dates after the last completed run up to yesterday, at most
+// MAX_SNAPSHOT_DAYS of them. A job that never ran starts from the day the first account was opened, so the
+// whole history is backfilled over as many runs as it takes. It stops at the first date that fails, the next
+// run starts again from it.
+func (s DefaultBalanceService) RunDue() *errs.AppError {
+	yesterday := s.calendar.Today(s.now()).AddDate(0, 0, -1)
+	last, err := s.jobs.LastRun(domain.JOB_BALANCE_SNAPSHOTS)
+	if err != nil {
+		return err
+	}
+	if last == "" {
+		if last, err = s.repo.FirstOpeningDate(); err != nil || last == "" {
+			return err
+		}
+		first, err := domain.ParseRunDate(last, s.calendar.Location())
+		if err != nil {
+			return err
+		}
+		last = domain.FormatRunDate(first.AddDate(0, 0, -1))
+	}
+	lastDay, err := domain.ParseRunDate(last, s.calendar.Location())
+	if err != nil {
+		return err
+	}
+
+	days := 0
+	for day := lastDay.AddDate(0, 0, 1); !day.After(yesterday) && days < MAX_SNAPSHOT_DAYS; day = day.AddDate(0, 0, 1) {
+		response, err := s.TakeSnapshots(domain.FormatRunDate(day))
+		if err != nil {
+			return err
+		}
+		if response.Failed > 0 {
+			return errs.NewUnexpectedError(fmt.Sprintf("balance snapshots failed for %d accounts on %s", response.Failed, response.Date))
+		}
+		days++
+	}
+	if next := lastDay.AddDate(0, 0, days+1); !next.After(yesterday) {
+		logger.Info(fmt.Sprintf("took %d days of balance snapshots, the next run continues from %s", days, domain.FormatRunDate(next)))
+	}
+	return nil
+}
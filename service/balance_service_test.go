@@ -0,0 +1,146 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonathanwamsley/banking/calendar"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockBalanceRepo *domain.MockBalanceRepository
+var balanceService DefaultBalanceService
+
+func setupBalance(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockBalanceRepo = domain.NewMockBalanceRepository(ctrl)
+	mockAccountRepo = domain.NewMockAccountRepository(ctrl)
+	mockJobRepo = domain.NewMockJobRunRepository(ctrl)
+	balanceService = NewBalanceService(mockBalanceRepo, mockAccountRepo, mockJobRepo, calendar.Default())
+	balanceService.now = func() time.Time { return time.Date(2021, time.April, 2, 6, 0, 0, 0, time.Local) }
+	return func() {
+		defer ctrl.Finish()
+	}
+}
+
+func TestGetBalanceAsOfAPastDate(t *testing.T) {
+	teardown := setupBalance(t)
+	defer teardown()
+
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&realdomain.Account{AccountID: "95470", CustomerID: "2000", OpeningDate: "2021-01-04 10:00:00"}, nil)
+	mockBalanceRepo.EXPECT().BalanceAsOf("95470", "2021-03-31").Return(&realdomain.EndOfDayBalance{
+		AccountID:       "95470",
+		Date:            "2021-03-31",
+		SnapshotDate:    "2021-03-30",
+		SnapshotBalance: money.MustParse("6823.23"),
+		Movement:        money.MustParse("-250.00"),
+	}, nil)
+
+	resp, err := balanceService.GetBalance("2000", "95470", "2021-03-31")
+	assert.Nil(t, err)
+	assert.Equal(t, money.MustParse("6573.23"), resp.Balance)
+	assert.Equal(t, "2021-03-30", resp.SnapshotDate)
+}
+
+func TestGetBalanceRefusesDatesOutsideTheAccountsLife(t *testing.T) {
+	teardown := setupBalance(t)
+	defer teardown()
+
+	_, err := balanceService.GetBalance("2000", "95470", "2021-04-03")
+	assert.Equal(t, "as_of can not be in the future", err.Message)
+	_, err = balanceService.GetBalance("2000", "95470", "31/03/2021")
+	assert.EqualValues(t, 422, err.Code)
+
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&realdomain.Account{AccountID: "95470", CustomerID: "2000", OpeningDate: "2021-01-04 10:00:00"}, nil).Times(2)
+	_, err = balanceService.GetBalance("2000", "95470", "2021-01-03")
+	assert.Equal(t, "the account was not open on 2021-01-03", err.Message)
+	_, err = balanceService.GetBalance("2001", "95470", "2021-03-31")
+	assert.EqualValues(t, 404, err.Code)
+}
+
+func TestTakeSnapshotsIsOnlyCompletedWithoutFailures(t *testing.T) {
+	teardown := setupBalance(t)
+	defer teardown()
+
+	candidates := []realdomain.EndOfDayBalance{
+		{AccountID: "95470", Date: "2021-03-31", SnapshotDate: "2021-03-30", SnapshotBalance: money.MustParse("100.00"), Movement: money.MustParse("20.00")},
+		{AccountID: "95471", Date: "2021-03-31", SnapshotBalance: money.Zero(), Movement: money.MustParse("50.00")},
+	}
+	mockBalanceRepo.EXPECT().SnapshotCandidates("2021-03-31").Return(candidates, nil).Times(2)
+	mockBalanceRepo.EXPECT().SaveSnapshot(gomock.Any()).DoAndReturn(func(snapshot realdomain.BalanceSnapshot) (bool, *errs.AppError) {
+		if snapshot.AccountID == "95471" {
+			return false, errs.NewUnexpectedError("Unexpected database error")
+		}
+		assert.Equal(t, money.MustParse("120.00"), snapshot.Balance)
+		return true, nil
+	}).Times(2)
+
+	resp, err := balanceService.TakeSnapshots("2021-03-31")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.Accounts)
+	assert.Equal(t, 1, resp.Failed)
+
+	// the rerun finds the first account already taken
+	mockBalanceRepo.EXPECT().SaveSnapshot(gomock.Any()).DoAndReturn(func(snapshot realdomain.BalanceSnapshot) (bool, *errs.AppError) {
+		return snapshot.AccountID == "95471", nil
+	}).Times(2)
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_BALANCE_SNAPSHOTS, "2021-03-31", 1, "2021-04-02 06:00:00").Return(nil)
+	resp, err = balanceService.TakeSnapshots("2021-03-31")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.Skipped)
+	assert.Equal(t, 0, resp.Failed)
+}
+
+func TestRunDueBackfillsFromTheFirstAccountInChunks(t *testing.T) {
+	teardown := setupBalance(t)
+	defer teardown()
+
+	var dates []string
+	mockJobRepo.EXPECT().LastRun(realdomain.JOB_BALANCE_SNAPSHOTS).Return("", nil)
+	mockBalanceRepo.EXPECT().FirstOpeningDate().Return("2021-01-04", nil)
+	mockBalanceRepo.EXPECT().SnapshotCandidates(gomock.Any()).DoAndReturn(func(date string) ([]realdomain.EndOfDayBalance, *errs.AppError) {
+		dates = append(dates, date)
+		return nil, nil
+	}).Times(MAX_SNAPSHOT_DAYS)
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_BALANCE_SNAPSHOTS, gomock.Any(), 0, gomock.Any()).Return(nil).Times(MAX_SNAPSHOT_DAYS)
+
+	assert.Nil(t, balanceService.RunDue())
+	assert.Equal(t, "2021-01-04", dates[0])
+	assert.Equal(t, "2021-02-03", dates[MAX_SNAPSHOT_DAYS-1])
+
+	// the next run continues after the last completed date and stops at yesterday
+	dates = nil
+	mockJobRepo.EXPECT().LastRun(realdomain.JOB_BALANCE_SNAPSHOTS).Return("2021-03-29", nil)
+	mockBalanceRepo.EXPECT().SnapshotCandidates(gomock.Any()).DoAndReturn(func(date string) ([]realdomain.EndOfDayBalance, *errs.AppError) {
+		dates = append(dates, date)
+		return nil, nil
+	}).Times(3)
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_BALANCE_SNAPSHOTS, gomock.Any(), 0, gomock.Any()).Return(nil).Times(3)
+
+	assert.Nil(t, balanceService.RunDue())
+	assert.Equal(t, []string{"2021-03-30", "2021-03-31", "2021-04-01"}, dates)
+}
+
+func TestCheckSnapshotsReportsMismatches(t *testing.T) {
+	teardown := setupBalance(t)
+	defer teardown()
+
+	mockJobRepo.EXPECT().LastRun(realdomain.JOB_BALANCE_SNAPSHOTS).Return("2021-04-01", nil)
+	mockBalanceRepo.EXPECT().CompareSnapshots().Return([]realdomain.SnapshotComparison{
+		{AccountID: "95470", AccountBalance: money.MustParse("6573.23"), SnapshotDate: "2021-04-01", SnapshotBalance: money.MustParse("6823.23"), Movement: money.MustParse("-250.00")},
+		{AccountID: "95471", AccountBalance: money.MustParse("500.00"), SnapshotDate: "2021-04-01", SnapshotBalance: money.MustParse("450.00"), Movement: money.Zero()},
+	}, nil)
+
+	resp, err := balanceService.CheckSnapshots()
+	assert.Nil(t, err)
+	assert.False(t, resp.Consistent)
+	assert.Equal(t, 2, resp.AccountsChecked)
+	assert.Equal(t, 1, len(resp.Mismatches))
+	assert.Equal(t, "95471", resp.Mismatches[0].AccountID)
+	assert.Equal(t, money.MustParse("450.00"), resp.Mismatches[0].ExpectedBalance)
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"time"
+
+	"github.com/jonathanwamsley/banking/calendar"
 	"github.com/jonathanwamsley/banking/domain"
 	"github.com/jonathanwamsley/banking/dto"
 	"github.com/jonathanwamsley/banking/errs"
@@ -12,23 +15,32 @@ import (
 // CreateCustomer: inserts a new customer into the db
 // GetCustomer: returns a customer by id
 // DeleteCustomer: Removes a customer by id from the db
+// UpdateCustomer: replaces all the editable fields of a customer
+// PatchCustomer: changes only the fields sent in a json merge patch
+// UpdateCustomerStatus: moves a customer to a new status, like frozen or closed
+//
+// DeleteCustomer, UpdateCustomer and PatchCustomer take the version the caller read, or domain.ANY_VERSION
 //
 // go:generate mockgen -destination=../mocks/service/mockCustomerService.go -package=service github.com/jonathanwamsley/banking/service CustomerService
 type CustomerService interface {
 	GetAllCustomers() ([]dto.CustomerResponse, *errs.AppError)
 	CreateCustomer(dto.CustomerRequest) (*dto.CustomerResponse, *errs.AppError)
 	GetCustomer(string) (*dto.CustomerResponse, *errs.AppError)
-	DeleteCustomer(string) *errs.AppError
+	DeleteCustomer(id string, version int) *errs.AppError
+	UpdateCustomer(id string, version int, req dto.CustomerRequest) (*dto.UpdateCustomerResponse, *errs.AppError)
+	PatchCustomer(id string, version int, patch dto.CustomerPatch) (*dto.UpdateCustomerResponse, *errs.AppError)
+	UpdateCustomerStatus(id string, req dto.UpdateStatusRequest) (*dto.CustomerResponse, *errs.AppError)
 }
 
 // DefaultCustomerService has methods that call upon dto and domain
 type DefaultCustomerService struct {
-	repo domain.CustomerRepository
+	repo     domain.CustomerRepository
+	calendar calendar.Calendar
 }
 
 // NewCustomerService is the entry point to the service to create a DefaultCustomerService struct
-func NewCustomerService(repository domain.CustomerRepository) DefaultCustomerService {
-	return DefaultCustomerService{repository}
+func NewCustomerService(repository domain.CustomerRepository, cal calendar.Calendar) DefaultCustomerService {
+	return DefaultCustomerService{repo: repository, calendar: cal}
 }
 
 // GetAllCustomers returns all the customers as dto response
@@ -73,16 +85,69 @@ func (s DefaultCustomerService) GetCustomer(id string) (*dto.CustomerResponse, *
 	return &response, nil
 }
 
-// DeleteCustomer first makes sure an id exists, then it removes the customer by id
-func (s DefaultCustomerService) DeleteCustomer(id string) *errs.AppError {
-	_, err := s.repo.ByID(id)
+// DeleteCustomer removes the customer by id, if it is still at the version the caller read
+func (s DefaultCustomerService) DeleteCustomer(id string, version int) *errs.AppError {
+	return s.repo.Delete(id, version)
+}
+
+// UpdateCustomerStatus changes the status of a customer and returns the updated customer
+func (s DefaultCustomerService) UpdateCustomerStatus(id string, req dto.UpdateStatusRequest) (*dto.CustomerResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateStatus(id, req.Status, req.Version); err != nil {
+		return nil, err
+	}
+	return s.GetCustomer(id)
+}
+
+// UpdateCustomer validates the full set of fields and saves them over the customer
+func (s DefaultCustomerService) UpdateCustomer(id string, version int, req dto.CustomerRequest) (*dto.UpdateCustomerResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	c, err := s.current(id, version)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return s.update(c.UpdatedCustomer(req.Trimmed()))
+}
 
-	err = s.repo.Delete(id)
+// PatchCustomer merges the patch into the current customer, validates the result and saves it
+func (s DefaultCustomerService) PatchCustomer(id string, version int, patch dto.CustomerPatch) (*dto.UpdateCustomerResponse, *errs.AppError) {
+	c, err := s.current(id, version)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	req, err := patch.ApplyTo(c.ToRequest())
+	if err != nil {
+		return nil, err
+	}
+	return s.update(c.UpdatedCustomer(req))
+}
+
+// current reads the customer an update starts from. The repository only saves the update if the customer
+// is still at this version, so a change made in between is never overwritten.
+func (s DefaultCustomerService) current(id string, version int) (*domain.Customer, *errs.AppError) {
+	c, err := s.repo.ByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = domain.CheckVersion(domain.SUBJECT_CUSTOMER, c.Version, version); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// update saves c and audits its changes as made now by the bank's calendar
+func (s DefaultCustomerService) update(c domain.Customer) (*dto.UpdateCustomerResponse, *errs.AppError) {
+	changes, err := s.repo.Update(c, s.calendar.Timestamp(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) > 0 {
+		c.Version++
+	}
+	response := c.ToUpdateDTO(changes)
+	return &response, nil
 }
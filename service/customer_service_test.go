@@ -2,8 +2,10 @@ package service
 
 import (
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/jonathanwamsley/banking/calendar"
 	realdomain "github.com/jonathanwamsley/banking/domain"
 	"github.com/jonathanwamsley/banking/dto"
 	"github.com/jonathanwamsley/banking/errs"
@@ -17,7 +19,7 @@ var service CustomerService
 func setup(t *testing.T) func() {
 	ctrl := gomock.NewController(t)
 	mockRepo = domain.NewMockCustomerRepository(ctrl)
-	service = NewCustomerService(mockRepo)
+	service = NewCustomerService(mockRepo, calendar.Default())
 	return func() {
 		service = nil
 		defer ctrl.Finish()
@@ -32,7 +34,7 @@ func TestCreateCustomerValidationError(t *testing.T) {
 		DateofBirth: "",
 	}
 
-	service := NewCustomerService(nil)
+	service := NewCustomerService(nil, calendar.Default())
 
 	resp, err := service.CreateCustomer(request)
 	assert.Nil(t, resp)
@@ -47,7 +49,7 @@ func TestCreateCustomerServerError(t *testing.T) {
 		Name:        "jon doe",
 		City:        "city",
 		Zipcode:     "123321",
-		DateofBirth: "11/11/2000",
+		DateofBirth: "2000-11-11",
 	}
 	customer := realdomain.Customer{
 		ID:          "",
@@ -55,7 +57,8 @@ func TestCreateCustomerServerError(t *testing.T) {
 		City:        req.City,
 		Zipcode:     req.Zipcode,
 		DateofBirth: req.DateofBirth,
-		Status:      "1",
+		Status:      "active",
+		Version:     1,
 	}
 
 	mockRepo.EXPECT().Save(customer).Return(nil, errs.NewUnexpectedError("unexpected database error"))
@@ -73,7 +76,7 @@ func TestCreateCustomerNoError(t *testing.T) {
 		Name:        "jon doe",
 		City:        "city",
 		Zipcode:     "123321",
-		DateofBirth: "11/11/2000",
+		DateofBirth: "2000-11-11",
 	}
 	customer := realdomain.Customer{
 		ID:          "",
@@ -81,7 +84,8 @@ func TestCreateCustomerNoError(t *testing.T) {
 		City:        req.City,
 		Zipcode:     req.Zipcode,
 		DateofBirth: req.DateofBirth,
-		Status:      "1",
+		Status:      "active",
+		Version:     1,
 	}
 
 	customerOut := customer
@@ -96,8 +100,8 @@ func TestCreateCustomerNoError(t *testing.T) {
 	assert.EqualValues(t, "jon doe", customerResponse.Name)
 	assert.EqualValues(t, "city", customerResponse.City)
 	assert.EqualValues(t, "123321", customerResponse.Zipcode)
-	assert.EqualValues(t, "11/11/2000", customerResponse.DateofBirth)
-	assert.EqualValues(t, "active", customerResponse.Status) // To Dto changes 1 to active
+	assert.EqualValues(t, "2000-11-11", customerResponse.DateofBirth)
+	assert.EqualValues(t, "active", customerResponse.Status)
 }
 
 func TestGetAllCustomersNoError(t *testing.T) {
@@ -109,13 +113,13 @@ func TestGetAllCustomersNoError(t *testing.T) {
 	// 		Name: "jon doe",
 	// 		City: "city",
 	// 		Zipcode: "123321",
-	// 		DateofBirth: "11/11/2000",
+	// 		DateofBirth: "2000-11-11",
 	// 	},
 	// 	{
 	// 		Name: "jon smith",
 	// 		City: "city2",
 	// 		Zipcode: "123456",
-	// 		DateofBirth: "12/12/2012",
+	// 		DateofBirth: "2012-12-12",
 	// 	},
 	// }
 
@@ -125,16 +129,16 @@ func TestGetAllCustomersNoError(t *testing.T) {
 			Name:        "jon doe",
 			City:        "city",
 			Zipcode:     "123321",
-			DateofBirth: "11/11/2000",
-			Status:      "1",
+			DateofBirth: "2000-11-11",
+			Status:      "active",
 		},
 		{
 			ID:          "1235",
 			Name:        "jon smith",
 			City:        "city2",
 			Zipcode:     "123456",
-			DateofBirth: "12/12/2012",
-			Status:      "1",
+			DateofBirth: "2012-12-12",
+			Status:      "active",
 		},
 	}
 
@@ -149,14 +153,14 @@ func TestGetAllCustomersNoError(t *testing.T) {
 	assert.EqualValues(t, customersResponse[0].Name, "jon doe")
 	assert.EqualValues(t, customersResponse[0].City, "city")
 	assert.EqualValues(t, customersResponse[0].Zipcode, "123321")
-	assert.EqualValues(t, customersResponse[0].DateofBirth, "11/11/2000")
+	assert.EqualValues(t, customersResponse[0].DateofBirth, "2000-11-11")
 	assert.EqualValues(t, customersResponse[0].Status, "active")
 
 	assert.EqualValues(t, customersResponse[1].ID, "1235")
 	assert.EqualValues(t, customersResponse[1].Name, "jon smith")
 	assert.EqualValues(t, customersResponse[1].City, "city2")
 	assert.EqualValues(t, customersResponse[1].Zipcode, "123456")
-	assert.EqualValues(t, customersResponse[1].DateofBirth, "12/12/2012")
+	assert.EqualValues(t, customersResponse[1].DateofBirth, "2012-12-12")
 	assert.EqualValues(t, customersResponse[1].Status, "active")
 }
 
@@ -181,8 +185,8 @@ func TestGetCustomerNoError(t *testing.T) {
 		Name:        "jon doe",
 		City:        "city",
 		Zipcode:     "123321",
-		DateofBirth: "11/11/2000",
-		Status:      "1",
+		DateofBirth: "2000-11-11",
+		Status:      "active",
 	}
 
 	mockRepo.EXPECT().ByID("1234").Return(customer, nil)
@@ -194,8 +198,8 @@ func TestGetCustomerNoError(t *testing.T) {
 	assert.EqualValues(t, "jon doe", c.Name)
 	assert.EqualValues(t, "city", c.City)
 	assert.EqualValues(t, "123321", c.Zipcode)
-	assert.EqualValues(t, "11/11/2000", c.DateofBirth)
-	assert.EqualValues(t, "active", c.Status) // dto transformation
+	assert.EqualValues(t, "2000-11-11", c.DateofBirth)
+	assert.EqualValues(t, "active", c.Status)
 }
 
 func TestGetCustomerSeverError(t *testing.T) {
@@ -224,19 +228,9 @@ func TestDeleteCustomerServerError(t *testing.T) {
 	teardown := setup(t)
 	defer teardown()
 
-	customer := &realdomain.Customer{
-		ID:          "1234",
-		Name:        "jon doe",
-		City:        "city",
-		Zipcode:     "123321",
-		DateofBirth: "11/11/2000",
-		Status:      "1",
-	}
-
-	mockRepo.EXPECT().ByID("1234").Return(customer, nil)
-	mockRepo.EXPECT().Delete("1234").Return(errs.NewUnexpectedError("unexpected database error"))
+	mockRepo.EXPECT().Delete("1234", realdomain.ANY_VERSION).Return(errs.NewUnexpectedError("unexpected database error"))
 
-	err := service.DeleteCustomer("1234")
+	err := service.DeleteCustomer("1234", realdomain.ANY_VERSION)
 	assert.NotNil(t, err)
 }
 
@@ -244,19 +238,9 @@ func TestDeleteCustomerNoError(t *testing.T) {
 	teardown := setup(t)
 	defer teardown()
 
-	customer := &realdomain.Customer{
-		ID:          "1234",
-		Name:        "jon doe",
-		City:        "city",
-		Zipcode:     "123321",
-		DateofBirth: "11/11/2000",
-		Status:      "1",
-	}
-
-	mockRepo.EXPECT().ByID("1234").Return(customer, nil)
-	mockRepo.EXPECT().Delete("1234").Return(nil)
+	mockRepo.EXPECT().Delete("1234", realdomain.ANY_VERSION).Return(nil)
 
-	err := service.DeleteCustomer("1234")
+	err := service.DeleteCustomer("1234", realdomain.ANY_VERSION)
 	assert.Nil(t, err)
 }
 
@@ -264,6 +248,133 @@ func TestNewCustomerService(t *testing.T) {
 	teardown := setup(t)
 	defer teardown()
 
-	customerService := NewCustomerService(mockRepo)
+	customerService := NewCustomerService(mockRepo, calendar.Default())
 	assert.NotNil(t, customerService)
 }
+
+func TestUpdateCustomerStatusNotAllowed(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	mockRepo.EXPECT().UpdateStatus("2003", realdomain.STATUS_FROZEN, 0).
+		Return(realdomain.CheckTransition(realdomain.SUBJECT_CUSTOMER, realdomain.STATUS_DORMANT, realdomain.STATUS_FROZEN))
+
+	resp, err := service.UpdateCustomerStatus("2003", dto.UpdateStatusRequest{Status: realdomain.STATUS_FROZEN})
+	assert.Nil(t, resp)
+	assert.EqualValues(t, 409, err.Code)
+	assert.EqualValues(t, "invalid_status_transition", err.Reason)
+}
+
+func TestUpdateCustomerStatusNoError(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	customer := &realdomain.Customer{ID: "2003", Name: "Ben", Status: realdomain.STATUS_ACTIVE}
+	mockRepo.EXPECT().UpdateStatus("2003", realdomain.STATUS_ACTIVE, 0).Return(nil)
+	mockRepo.EXPECT().ByID("2003").Return(customer, nil)
+
+	resp, err := service.UpdateCustomerStatus("2003", dto.UpdateStatusRequest{Status: realdomain.STATUS_ACTIVE})
+	assert.Nil(t, err)
+	assert.EqualValues(t, realdomain.STATUS_ACTIVE, resp.Status)
+}
+
+func arian() *realdomain.Customer {
+	return &realdomain.Customer{ID: "2001", Name: "Arian", City: "Newburgh, NY", Zipcode: "12550", DateofBirth: "1988-05-21", Status: "active"}
+}
+
+func TestUpdateCustomerValidationError(t *testing.T) {
+	resp, err := NewCustomerService(nil, calendar.Default()).UpdateCustomer("2001", realdomain.ANY_VERSION, dto.CustomerRequest{Name: "Arian"})
+	assert.Nil(t, resp)
+	assert.EqualValues(t, 422, err.Code)
+}
+
+func TestUpdateCustomerNotFound(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	mockRepo.EXPECT().ByID("2001").Return(nil, errs.NewNotFoundError("Customer not found"))
+
+	req := dto.CustomerRequest{Name: "Arian", City: "Beacon, NY", Zipcode: "12508", DateofBirth: "1988-05-21"}
+	resp, err := service.UpdateCustomer("2001", realdomain.ANY_VERSION, req)
+	assert.Nil(t, resp)
+	assert.EqualValues(t, 404, err.Code)
+}
+
+func TestUpdateCustomerNoError(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	mockRepo.EXPECT().ByID("2001").Return(arian(), nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(c realdomain.Customer, changedAt string) ([]realdomain.FieldChange, *errs.AppError) {
+		_, err := time.ParseInLocation("2006-01-02 15:04:05", changedAt, time.Local)
+		assert.Nil(t, err)
+		return arian().Changes(c), nil
+	})
+
+	req := dto.CustomerRequest{Name: "Arian", City: " Beacon, NY", Zipcode: "12508", DateofBirth: "1988-05-21"}
+	resp, err := service.UpdateCustomer("2001", realdomain.ANY_VERSION, req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "Beacon, NY", resp.City)
+	assert.EqualValues(t, "active", resp.Status)
+	assert.EqualValues(t, []string{"city", "zipcode"}, resp.ChangedFields)
+}
+
+func TestPatchCustomerNoError(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	mockRepo.EXPECT().ByID("2001").Return(arian(), nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(c realdomain.Customer, changedAt string) ([]realdomain.FieldChange, *errs.AppError) {
+		assert.EqualValues(t, "Newburgh, NY", c.City)
+		return arian().Changes(c), nil
+	})
+
+	resp, err := service.PatchCustomer("2001", realdomain.ANY_VERSION, dto.CustomerPatch{"full_name": []byte(`"Arian Smith"`)})
+	assert.Nil(t, err)
+	assert.EqualValues(t, "Arian Smith", resp.Name)
+	assert.EqualValues(t, "12550", resp.Zipcode)
+	assert.EqualValues(t, []string{"full_name"}, resp.ChangedFields)
+}
+
+func TestPatchCustomerInvalidPatch(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	mockRepo.EXPECT().ByID("2001").Return(arian(), nil)
+
+	resp, err := service.PatchCustomer("2001", realdomain.ANY_VERSION, dto.CustomerPatch{"zipcode": []byte(`""`)})
+	assert.Nil(t, resp)
+	assert.EqualValues(t, "invalid zipcode", err.Message)
+}
+
+func TestUpdateCustomerStaleVersion(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	customer := arian()
+	customer.Version = 4
+	mockRepo.EXPECT().ByID("2001").Return(customer, nil)
+
+	req := dto.CustomerRequest{Name: "Arian", City: "Beacon, NY", Zipcode: "12508", DateofBirth: "1988-05-21"}
+	resp, err := service.UpdateCustomer("2001", 3, req)
+	assert.Nil(t, resp)
+	assert.EqualValues(t, 412, err.Code)
+}
+
+func TestPatchCustomerBumpsVersion(t *testing.T) {
+	teardown := setup(t)
+	defer teardown()
+
+	customer := arian()
+	customer.Version = 4
+	mockRepo.EXPECT().ByID("2001").Return(customer, nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(c realdomain.Customer, changedAt string) ([]realdomain.FieldChange, *errs.AppError) {
+		// the repository only saves the update if the customer is still at the version that was read
+		assert.Equal(t, 4, c.Version)
+		return []realdomain.FieldChange{{Field: "city", OldValue: "Newburgh, NY", NewValue: "Beacon, NY"}}, nil
+	})
+
+	resp, err := service.PatchCustomer("2001", 4, dto.CustomerPatch{"city": []byte(`"Beacon, NY"`)})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, resp.Version)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// FeeService is an interface that implements
+//
+// GetFeeSchedules: returns the fee schedule of every account type
+// SaveFeeSchedule: creates or replaces the fee schedule of an account type
+// GetFeeWaivers: returns the fee waivers of an account of the customer
+// CreateFeeWaiver: waives a fee of an account of the customer
+// EndFeeWaiver: ends a fee waiver today
+// ChargeMaintenanceFees: charges the maintenance fee of a month to the accounts that went below their minimum
+// RunDue: charges the maintenance fees of last month if that was not done yet
+// mockgen -destination=mocks/service/mock_fee_service.go -package=service github.com/jonathanwamsley/banking/service FeeService
+type FeeService interface {
+	GetFeeSchedules() ([]dto.FeeScheduleResponse, *errs.AppError)
+	SaveFeeSchedule(accountType string, req dto.FeeScheduleRequest) (*dto.FeeScheduleResponse, *errs.AppError)
+	GetFeeWaivers(customerID string, accountID string) ([]dto.FeeWaiverResponse, *errs.AppError)
+	CreateFeeWaiver(customerID string, accountID string, req dto.FeeWaiverRequest, createdBy string) (*dto.FeeWaiverResponse, *errs.AppError)
+	EndFeeWaiver(customerID string, accountID string, waiverID string) *errs.AppError
+	ChargeMaintenanceFees(period string) (*dto.FeeRunResponse, *errs.AppError)
+	RunDue() *errs.AppError
+}
+
+// DefaultFeeService has methods that call dto and the domain. Months start and end in the bank's time zone,
+// and maintenance fees are charged on the first business day of the month.
+type DefaultFeeService struct {
+	repo     domain.FeeRepository
+	accounts domain.AccountRepository
+	jobs     domain.JobRunRepository
+	calendar calendar.Calendar
+	now      func() time.Time
+}
+
+// NewFeeService is the entry point to the service to create a DefaultFeeService struct
+func NewFeeService(repository domain.FeeRepository, accounts domain.AccountRepository, jobs domain.JobRunRepository, cal calendar.Calendar) DefaultFeeService {
+	return DefaultFeeService{repo: repository, accounts: accounts, jobs: jobs, calendar: cal, now: time.Now}
+}
+
+// GetFeeSchedules returns every fee schedule
+func (s DefaultFeeService) GetFeeSchedules() ([]dto.FeeScheduleResponse, *errs.AppError) {
+	schedules, err := s.repo.FindSchedules()
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.FeeScheduleResponse, 0)
+	for _, schedule := range schedules {
+		response = append(response, schedule.ToDTO())
+	}
+	return response, nil
+}
+
+// SaveFeeSchedule validates and stores the fee schedule of an account type
+func (s DefaultFeeService) SaveFeeSchedule(accountType string, req dto.FeeScheduleRequest) (*dto.FeeScheduleResponse, *errs.AppError) {
+	schedule := domain.NewFeeSchedule(accountType, req)
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveSchedule(schedule); err != nil {
+		return nil, err
+	}
+	response := schedule.ToDTO()
+	return &response, nil
+}
+
+// GetFeeWaivers returns the waivers of an account the customer owns
+func (s DefaultFeeService) GetFeeWaivers(customerID string, accountID string) ([]dto.FeeWaiverResponse, *errs.AppError) {
+	if err := s.checkOwner(customerID, accountID); err != nil {
+		return nil, err
+	}
+	waivers, err := s.repo.FindWaivers(accountID)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.FeeWaiverResponse, 0)
+	for _, w := range waivers {
+		response = append(response, w.ToDTO())
+	}
+	return response, nil
+}
+
+// CreateFeeWaiver waives a fee of an account the customer owns
+func (s DefaultFeeService) CreateFeeWaiver(customerID string, accountID string, req dto.FeeWaiverRequest, createdBy string) (*dto.FeeWaiverResponse, *errs.AppError) {
+	w := domain.NewFeeWaiver(accountID, req, createdBy, s.now().In(s.calendar.Location()))
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkOwner(customerID, accountID); err != nil {
+		return nil, err
+	}
+	saved, err := s.repo.SaveWaiver(w)
+	if err != nil {
+		return nil, err
+	}
+	response := saved.ToDTO()
+	return &response, nil
+}
+
+// EndFeeWaiver ends a waiver today, fees of today are still waived
+func (s DefaultFeeService) EndFeeWaiver(customerID string, accountID string, waiverID string) *errs.AppError {
+	if err := s.checkOwner(customerID, accountID); err != nil {
+		return err
+	}
+	return s.repo.EndWaiver(accountID, waiverID, s.calendar.BookingDate(s.now()))
+}
+
+// ChargeMaintenanceFees assesses every account with a maintenance fee for a month that has ended.
+// Accounts already assessed for the month are skipped, so a rerun only charges what a failed run missed.
+func (s DefaultFeeService) ChargeMaintenanceFees(period string) (*dto.FeeRunResponse, *errs.AppError) {
+	_, lastDay, err := domain.ParseFeePeriod(period, s.calendar.Location())
+	if err != nil {
+		return nil, err
+	}
+	if !lastDay.Before(s.calendar.Today(s.now())) {
+		return nil, errs.NewValidationError("maintenance fees can only be charged for a month that has ended")
+	}
+	schedules, err := s.repo.FindSchedules()
+	if err != nil {
+		return nil, err
+	}
+	byType := make(map[string]domain.FeeSchedule, len(schedules))
+	for _, schedule := range schedules {
+		byType[schedule.AccountType] = schedule
+	}
+	candidates, err := s.repo.MaintenanceCandidates(period)
+	if err != nil {
+		return nil, err
+	}
+
+	assessedAt := s.calendar.Timestamp(s.now())
+	lastDate := domain.FormatRunDate(lastDay)
+	response := dto.FeeRunResponse{Job: domain.JOB_MAINTENANCE_FEES, Period: period, Total: money.Zero()}
+	for _, c := range candidates {
+		schedule := byType[c.AccountType]
+		var waivers []domain.FeeWaiver
+		if schedule.MaintenanceFeeFor(c.LowestBalance).IsPositive() {
+			if waivers, err = s.repo.FindWaivers(c.AccountID); err != nil {
+				logger.Error("unable to read the fee waivers of account " + c.AccountID + ": " + err.Message)
+				response.Failed++
+				continue
+			}
+		}
+		assessment := domain.NewFeeAssessment(c, schedule, period, lastDate, waivers, assessedAt)
+		t, saved, err := s.repo.ChargeMaintenanceFee(assessment)
+		switch {
+		case err != nil:
+			logger.Error("unable to charge the maintenance fee of account " + c.AccountID + ": " + err.Message)
+			response.Failed++
+		case !saved:
+			response.Skipped++
+		case t != nil:
+			response.Charged++
+			response.Total = response.Total.Add(t.Amount)
+		case assessment.WaiverID.Valid:
+			response.Waived++
+		default:
+			response.Assessed++
+		}
+	}
+	if response.Failed == 0 {
+		if err = s.jobs.CompleteRun(domain.JOB_MAINTENANCE_FEES, lastDate, response.Charged, assessedAt); err != nil {
+			return nil, err
+		}
+	}
+	return &response, nil
+}
+
+// RunDue charges last month's maintenance fees once, on the first business day of the month. A month the
+// scheduler missed entirely is run by hand.
+func (s DefaultFeeService) RunDue() *errs.AppError {
+	today := s.calendar.Today(s.now())
+	if !s.calendar.IsBusinessDay(today) {
+		return nil
+	}
+	firstOfMonth := today.AddDate(0, 0, 1-today.Day())
+	lastMonth := firstOfMonth.AddDate(0, -1, 0)
+	last, err := s.jobs.LastRun(domain.JOB_MAINTENANCE_FEES)
+	if err != nil {
+		return err
+	}
+	if last >= domain.FormatRunDate(firstOfMonth.AddDate(0, 0, -1)) {
+		return nil
+	}
+	response, err := s.ChargeMaintenanceFees(domain.FormatFeePeriod(lastMonth))
+	if err != nil {
+		return err
+	}
+	if response.Failed > 0 {
+		return errs.NewUnexpectedError("maintenance fees failed for some accounts of " + response.Period)
+	}
+	return nil
+}
+
+// checkOwner checks the account exists and belongs to the customer
+func (s DefaultFeeService) checkOwner(customerID string, accountID string) *errs.AppError {
+	account, err := s.accounts.FindBy(accountID)
+	if err != nil {
+		return err
+	}
+	if account.CustomerID != customerID {
+		return errs.NewNotFoundError("Account not found")
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonathanwamsley/banking/calendar"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+var savingFees = realdomain.FeeSchedule{
+	AccountType:     dto.SAVING,
+	MinimumBalance:  money.MustParse("300.00"),
+	MaintenanceFee:  money.MustParse("5.00"),
+	FreeWithdrawals: sql.NullInt64{Int64: 6, Valid: true},
+	WithdrawalFee:   money.MustParse("10.00"),
+}
+
+var mockFeeRepo *domain.MockFeeRepository
+var feeService DefaultFeeService
+
+// setupFee runs at the first second of March, right after February ended
+func setupFee(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockFeeRepo = domain.NewMockFeeRepository(ctrl)
+	mockAccountRepo = domain.NewMockAccountRepository(ctrl)
+	mockJobRepo = domain.NewMockJobRunRepository(ctrl)
+	feeService = NewFeeService(mockFeeRepo, mockAccountRepo, mockJobRepo, calendar.Default())
+	feeService.now = func() time.Time { return time.Date(2021, time.March, 1, 0, 0, 1, 0, time.Local) }
+	return func() {
+		defer ctrl.Finish()
+	}
+}
+
+func TestChargeMaintenanceFees(t *testing.T) {
+	teardown := setupFee(t)
+	defer teardown()
+
+	candidates := []realdomain.MaintenanceCandidate{
+		{AccountID: "1", AccountType: dto.SAVING, LowestBalance: money.MustParse("120.00")},
+		{AccountID: "2", AccountType: dto.SAVING, LowestBalance: money.MustParse("300.00")},
+		{AccountID: "3", AccountType: dto.SAVING, LowestBalance: money.MustParse("10.00")},
+		{AccountID: "4", AccountType: dto.SAVING, LowestBalance: money.MustParse("0.00")},
+	}
+	waiver := realdomain.FeeWaiver{WaiverID: "7", FeeType: realdomain.FEE_ALL, StartsOn: "2021-02-28"}
+	mockFeeRepo.EXPECT().FindSchedules().Return([]realdomain.FeeSchedule{savingFees}, nil)
+	mockFeeRepo.EXPECT().MaintenanceCandidates("2021-02").Return(candidates, nil)
+	mockFeeRepo.EXPECT().FindWaivers("1").Return(nil, nil)
+	mockFeeRepo.EXPECT().FindWaivers("3").Return([]realdomain.FeeWaiver{waiver}, nil)
+	mockFeeRepo.EXPECT().FindWaivers("4").Return(nil, nil)
+	mockFeeRepo.EXPECT().ChargeMaintenanceFee(gomock.Any()).DoAndReturn(func(a realdomain.FeeAssessment) (*realdomain.Transaction, bool, *errs.AppError) {
+		switch a.AccountID {
+		case "1":
+			assert.Equal(t, money.MustParse("5.00"), a.Fee)
+			return &realdomain.Transaction{TransactionID: "9", Amount: a.Fee}, true, nil
+		case "3":
+			assert.Equal(t, "7", a.WaiverID.String)
+			return nil, true, nil
+		case "4":
+			// charged last time the job ran
+			return nil, false, nil
+		}
+		assert.True(t, a.Fee.IsZero())
+		return nil, true, nil
+	}).Times(4)
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_MAINTENANCE_FEES, "2021-02-28", 1, "2021-03-01 00:00:01").Return(nil)
+
+	resp, err := feeService.ChargeMaintenanceFees("2021-02")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.Charged)
+	assert.Equal(t, 1, resp.Waived)
+	assert.Equal(t, 1, resp.Assessed)
+	assert.Equal(t, 1, resp.Skipped)
+	assert.Equal(t, money.MustParse("5.00"), resp.Total)
+}
+
+func TestChargeMaintenanceFeesRefusesTheCurrentMonth(t *testing.T) {
+	teardown := setupFee(t)
+	defer teardown()
+
+	_, err := feeService.ChargeMaintenanceFees("2021-03")
+	assert.EqualValues(t, "maintenance fees can only be charged for a month that has ended", err.Message)
+}
+
+func TestFeeRunDueSkipsAMonthAlreadyCharged(t *testing.T) {
+	teardown := setupFee(t)
+	defer teardown()
+
+	mockJobRepo.EXPECT().LastRun(realdomain.JOB_MAINTENANCE_FEES).Return("2021-02-28", nil)
+	assert.Nil(t, feeService.RunDue())
+}
+
+func TestFeeRunDueChargesLastMonth(t *testing.T) {
+	teardown := setupFee(t)
+	defer teardown()
+
+	mockJobRepo.EXPECT().LastRun(realdomain.JOB_MAINTENANCE_FEES).Return("2021-01-31", nil)
+	mockFeeRepo.EXPECT().FindSchedules().Return([]realdomain.FeeSchedule{savingFees}, nil)
+	mockFeeRepo.EXPECT().MaintenanceCandidates("2021-02").Return(nil, nil)
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_MAINTENANCE_FEES, "2021-02-28", 0, gomock.Any()).Return(nil)
+	assert.Nil(t, feeService.RunDue())
+}
+
+func TestCreateFeeWaiverOfAnotherCustomersAccount(t *testing.T) {
+	teardown := setupFee(t)
+	defer teardown()
+
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&realdomain.Account{AccountID: "95470", CustomerID: "2000"}, nil)
+	_, err := feeService.CreateFeeWaiver("2001", "95470", dto.FeeWaiverRequest{FeeType: realdomain.FEE_MAINTENANCE, Reason: "goodwill"}, "teller")
+	assert.EqualValues(t, 404, err.Code)
+}
+
+func TestCreateFeeWaiverStartsToday(t *testing.T) {
+	teardown := setupFee(t)
+	defer teardown()
+
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&realdomain.Account{AccountID: "95470", CustomerID: "2000"}, nil)
+	mockFeeRepo.EXPECT().SaveWaiver(gomock.Any()).DoAndReturn(func(w realdomain.FeeWaiver) (*realdomain.FeeWaiver, *errs.AppError) {
+		w.WaiverID = "3"
+		return &w, nil
+	})
+	resp, err := feeService.CreateFeeWaiver("2000", "95470", dto.FeeWaiverRequest{FeeType: realdomain.FEE_WITHDRAWAL, Reason: "goodwill"}, "teller")
+	assert.Nil(t, err)
+	assert.Equal(t, "2021-03-01", resp.StartsOn)
+	assert.Equal(t, "teller", resp.CreatedBy)
+}
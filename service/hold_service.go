@@ -0,0 +1,133 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// HoldService is an interface that implements
+//
+// GetHolds: returns the holds of an account of the customer
+// CreateHold: reserves an amount of an account of the customer until the hold is captured, voided or expires
+// CaptureHold: settles a hold of an account of the customer as a withdrawal, in full or in part
+// VoidHold: releases a hold of an account of the customer
+// ExpireHolds: releases every hold that expired
+// mockgen -destination=mocks/service/mock_hold_service.go -package=service github.com/jonathanwamsley/banking/service HoldService
+type HoldService interface {
+	GetHolds(customerID string, accountID string) ([]dto.HoldResponse, *errs.AppError)
+	CreateHold(customerID string, accountID string, req dto.CreateHoldRequest, createdBy string) (*dto.HoldResponse, *errs.AppError)
+	CaptureHold(customerID string, accountID string, holdID string, req dto.CaptureHoldRequest) (*dto.HoldResponse, *errs.AppError)
+	VoidHold(customerID string, accountID string, holdID string) (*dto.HoldResponse, *errs.AppError)
+	ExpireHolds() *errs.AppError
+}
+
+// DefaultHoldService has methods that call dto and the domain
+type DefaultHoldService struct {
+	repo     domain.HoldRepository
+	accounts domain.AccountRepository
+	ttl      time.Duration
+	calendar calendar.Calendar
+	now      func() time.Time
+}
+
+// NewHoldService is the entry point to the service to create a DefaultHoldService struct, holds expire ttl after
+// they are created
+func NewHoldService(repository domain.HoldRepository, accounts domain.AccountRepository, ttl time.Duration, cal calendar.Calendar) DefaultHoldService {
+	return DefaultHoldService{repo: repository, accounts: accounts, ttl: ttl, calendar: cal, now: time.Now}
+}
+
+// GetHolds returns the holds of an account the customer owns
+func (s DefaultHoldService) GetHolds(customerID string, accountID string) ([]dto.HoldResponse, *errs.AppError) {
+	if err := s.checkOwner(customerID, accountID); err != nil {
+		return nil, err
+	}
+	holds, err := s.repo.FindHolds(accountID)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.HoldResponse, 0)
+	for _, h := range holds {
+		response = append(response, h.ToDTO())
+	}
+	return response, nil
+}
+
+// CreateHold reserves an amount of an account the customer owns, the funds are checked by the repository
+// against the locked account row
+func (s DefaultHoldService) CreateHold(customerID string, accountID string, req dto.CreateHoldRequest, createdBy string) (*dto.HoldResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkOwner(customerID, accountID); err != nil {
+		return nil, err
+	}
+	saved, err := s.repo.SaveHold(domain.NewHold(accountID, req, createdBy, s.bankNow(), s.ttl))
+	if err != nil {
+		return nil, err
+	}
+	response := saved.ToDTO()
+	return &response, nil
+}
+
+// CaptureHold settles a hold of an account the customer owns, the response has the withdrawal it was settled with
+func (s DefaultHoldService) CaptureHold(customerID string, accountID string, holdID string, req dto.CaptureHoldRequest) (*dto.HoldResponse, *errs.AppError) {
+	if err := s.checkOwner(customerID, accountID); err != nil {
+		return nil, err
+	}
+	h, t, err := s.repo.CaptureHold(accountID, holdID, req.Amount, s.bankNow())
+	if err != nil {
+		return nil, err
+	}
+	response := h.ToDTO()
+	transaction := t.ToDTO()
+	response.Transaction = &transaction
+	return &response, nil
+}
+
+// VoidHold releases a hold of an account the customer owns
+func (s DefaultHoldService) VoidHold(customerID string, accountID string, holdID string) (*dto.HoldResponse, *errs.AppError) {
+	if err := s.checkOwner(customerID, accountID); err != nil {
+		return nil, err
+	}
+	h, err := s.repo.VoidHold(accountID, holdID, s.bankNow())
+	if err != nil {
+		return nil, err
+	}
+	response := h.ToDTO()
+	return &response, nil
+}
+
+// ExpireHolds releases the holds that are past their expiry
+func (s DefaultHoldService) ExpireHolds() *errs.AppError {
+	expired, err := s.repo.ExpireHolds(s.bankNow())
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		logger.Info("expired " + strconv.Itoa(expired) + " holds")
+	}
+	return nil
+}
+
+// checkOwner checks the account exists and belongs to the customer
+func (s DefaultHoldService) checkOwner(customerID string, accountID string) *errs.AppError {
+	account, err := s.accounts.FindBy(accountID)
+	if err != nil {
+		return err
+	}
+	if account.CustomerID != customerID {
+		return errs.NewNotFoundError("Account not found")
+	}
+	return nil
+}
+
+// bankNow is now in the bank's time zone, the hold timestamps and the capture are written on its wall clock
+func (s DefaultHoldService) bankNow() time.Time {
+	return s.now().In(s.calendar.Location())
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonathanwamsley/banking/calendar"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockHoldRepo *domain.MockHoldRepository
+var holdService DefaultHoldService
+
+// setupHold runs at noon on the 10th of March with holds that last a day
+func setupHold(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockHoldRepo = domain.NewMockHoldRepository(ctrl)
+	mockAccountRepo = domain.NewMockAccountRepository(ctrl)
+	holdService = NewHoldService(mockHoldRepo, mockAccountRepo, 24*time.Hour, calendar.Default())
+	holdService.now = func() time.Time { return time.Date(2021, time.March, 10, 12, 0, 0, 0, time.Local) }
+	return func() {
+		defer ctrl.Finish()
+	}
+}
+
+func TestCreateHoldExpiresAfterTheTTL(t *testing.T) {
+	teardown := setupHold(t)
+	defer teardown()
+
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&realdomain.Account{AccountID: "95470", CustomerID: "2000"}, nil)
+	mockHoldRepo.EXPECT().SaveHold(gomock.Any()).DoAndReturn(func(h realdomain.Hold) (*realdomain.Hold, *errs.AppError) {
+		h.HoldID = "4"
+		return &h, nil
+	})
+	resp, err := holdService.CreateHold("2000", "95470", dto.CreateHoldRequest{Amount: money.MustParse("60.00"), Description: "fuel"}, "teller")
+	assert.Nil(t, err)
+	assert.Equal(t, "4", resp.HoldID)
+	assert.Equal(t, realdomain.HOLD_ACTIVE, resp.Status)
+	assert.Equal(t, "2021-03-11 12:00:00", resp.ExpiresAt)
+	assert.Equal(t, "teller", resp.CreatedBy)
+}
+
+func TestCreateHoldRejectsZeroAmount(t *testing.T) {
+	teardown := setupHold(t)
+	defer teardown()
+
+	_, err := holdService.CreateHold("2000", "95470", dto.CreateHoldRequest{Amount: money.Zero()}, "teller")
+	assert.EqualValues(t, 422, err.Code)
+}
+
+func TestCaptureHoldOfAnotherCustomersAccount(t *testing.T) {
+	teardown := setupHold(t)
+	defer teardown()
+
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&realdomain.Account{AccountID: "95470", CustomerID: "2000"}, nil)
+	_, err := holdService.CaptureHold("2001", "95470", "4", dto.CaptureHoldRequest{})
+	assert.EqualValues(t, 404, err.Code)
+}
+
+func TestCaptureHoldReturnsTheWithdrawal(t *testing.T) {
+	teardown := setupHold(t)
+	defer teardown()
+
+	partial := money.MustParse("45.00")
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&realdomain.Account{AccountID: "95470", CustomerID: "2000"}, nil)
+	mockHoldRepo.EXPECT().CaptureHold("95470", "4", &partial, holdService.now()).Return(
+		&realdomain.Hold{HoldID: "4", AccountID: "95470", Amount: money.MustParse("60.00"), CapturedAmount: partial,
+			Status: realdomain.HOLD_CAPTURED, TransactionID: sql.NullString{String: "12", Valid: true}},
+		&realdomain.Transaction{TransactionID: "12", AccountID: "95470", Amount: partial, TransactionType: realdomain.WITHDRAWAL, Balance: money.MustParse("55.00")},
+		nil)
+	resp, err := holdService.CaptureHold("2000", "95470", "4", dto.CaptureHoldRequest{Amount: &partial})
+	assert.Nil(t, err)
+	assert.Equal(t, realdomain.HOLD_CAPTURED, resp.Status)
+	assert.Equal(t, "12", resp.TransactionID)
+	assert.Equal(t, money.MustParse("55.00"), resp.Transaction.Amount)
+}
+
+func TestExpireHolds(t *testing.T) {
+	teardown := setupHold(t)
+	defer teardown()
+
+	mockHoldRepo.EXPECT().ExpireHolds(holdService.now()).Return(3, nil)
+	assert.Nil(t, holdService.ExpireHolds())
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+	"github.com/jonathanwamsley/banking/money"
+)
+
+// MAX_CATCH_UP_DAYS is how many missed accrual days RunDue accrues after the scheduler was down,
+// older days have to be run by hand
+const MAX_CATCH_UP_DAYS = 31
+
+// InterestService is an interface that implements
+//
+// GetInterestProducts: returns every interest product
+// SaveInterestProduct: creates or replaces an interest product
+// AccrueInterest: accrues one day of interest for every account that earns it or is charged it on a negative
+// balance, a date is only accrued once
+// PostInterest: pays the accruals of every posting period that ended before a date and charges the overdraft
+// interest of the month before
+// RunDue: accrues every day up to yesterday that was not accrued yet and posts what is due today
+// mockgen -destination=mocks/service/mock_interest_service.go -package=service github.com/jonathanwamsley/banking/service InterestService
+type InterestService interface {
+	GetInterestProducts() ([]dto.InterestProductResponse, *errs.AppError)
+	SaveInterestProduct(productCode string, req dto.InterestProductRequest) (*dto.InterestProductResponse, *errs.AppError)
+	AccrueInterest(date string) (*dto.InterestRunResponse, *errs.AppError)
+	PostInterest(date string) (*dto.InterestRunResponse, *errs.AppError)
+	RunDue() *errs.AppError
+}
+
+// DefaultInterestService has methods that call dto and the domain. Days start and end in the bank's time zone,
+// and interest is only posted on business days.
+type DefaultInterestService struct {
+	repo     domain.InterestRepository
+	jobs     domain.JobRunRepository
+	calendar calendar.Calendar
+	now      func() time.Time
+}
+
+// NewInterestService is the entry point to the service to create a DefaultInterestService struct
+func NewInterestService(repository domain.InterestRepository, jobs domain.JobRunRepository, cal calendar.Calendar) DefaultInterestService {
+	return DefaultInterestService{repo: repository, jobs: jobs, calendar: cal, now: time.Now}
+}
+
+// GetInterestProducts returns every interest product
+func (s DefaultInterestService) GetInterestProducts() ([]dto.InterestProductResponse, *errs.AppError) {
+	products, err := s.repo.FindProducts()
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.InterestProductResponse, 0)
+	for _, p := range products {
+		response = append(response, p.ToDTO())
+	}
+	return response, nil
+}
+
+// SaveInterestProduct validates and stores a product
+func (s DefaultInterestService) SaveInterestProduct(productCode string, req dto.InterestProductRequest) (*dto.InterestProductResponse, *errs.AppError) {
+	p := domain.NewInterestProduct(productCode, req)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveProduct(p); err != nil {
+		return nil, err
+	}
+	response := p.ToDTO()
+	return &response, nil
+}
+
+// AccrueInterest accrues interest on the balances at the end of date. A negative balance on a schedule with an
+// overdraft rate accrues overdraft interest instead of earning any. Accounts that already have an accrual for
+// the date are skipped.
+func (s DefaultInterestService) AccrueInterest(date string) (*dto.InterestRunResponse, *errs.AppError) {
+	day, err := domain.ParseRunDate(date, s.calendar.Location())
+	if err != nil {
+		return nil, err
+	}
+	if !day.Before(s.calendar.Today(s.now())) {
+		return nil, errs.NewValidationError("interest can only be accrued for a day that has ended")
+	}
+	products, err := s.products()
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := s.repo.AccrualCandidates(date)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.InterestRunResponse{Job: domain.JOB_INTEREST_ACCRUAL, Date: date, Total: money.Zero(), OverdraftTotal: money.Zero()}
+	for _, c := range candidates {
+		var accrual domain.InterestAccrual
+		switch p, ok := products[c.ProductCode]; {
+		case c.HasOverdraftInterest():
+			accrual = domain.AccrueOverdraft(c, day)
+		case c.ProductCode == "":
+			// on a schedule with an overdraft rate, but not overdrawn
+			continue
+		case !ok:
+			logger.Error("account " + c.AccountID + " has unknown interest product " + c.ProductCode)
+			response.Failed++
+			continue
+		default:
+			accrual = p.Accrue(c, day)
+		}
+		saved, err := s.repo.SaveAccrual(accrual)
+		if err != nil {
+			logger.Error("unable to accrue interest for account " + c.AccountID + ": " + err.Message)
+			response.Failed++
+			continue
+		}
+		if !saved {
+			response.Skipped++
+			continue
+		}
+		response.Accounts++
+	}
+	if response.Failed == 0 {
+		if err = s.jobs.CompleteRun(domain.JOB_INTEREST_ACCRUAL, date, response.Accounts, s.calendar.Timestamp(s.now())); err != nil {
+			return nil, err
+		}
+	}
+	return &response, nil
+}
+
+// PostInterest pays every product's accruals up to the end of its last posting period before date, and charges
+// overdraft interest up to the end of the month before. Accruals that are already posted are not found again,
+// so a rerun posts nothing twice.
+func (s DefaultInterestService) PostInterest(date string) (*dto.InterestRunResponse, *errs.AppError) {
+	day, err := domain.ParseRunDate(date, s.calendar.Location())
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.repo.FindProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	postedAt := s.calendar.Timestamp(s.now())
+	response := dto.InterestRunResponse{Job: domain.JOB_INTEREST_POSTING, Date: date, Total: money.Zero(), OverdraftTotal: money.Zero()}
+	for _, p := range products {
+		if err = s.postProduct(&response, p.ProductCode, domain.FormatRunDate(p.PostingPeriodEnd(day)), postedAt); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.postProduct(&response, domain.OVERDRAFT_PRODUCT, domain.FormatRunDate(domain.OverdraftPeriodEnd(day)), postedAt); err != nil {
+		return nil, err
+	}
+	if response.Failed == 0 {
+		if err = s.jobs.CompleteRun(domain.JOB_INTEREST_POSTING, date, response.Accounts, postedAt); err != nil {
+			return nil, err
+		}
+	}
+	return &response, nil
+}
+
+// postProduct posts the accruals of one product up to and including through and counts them in the response
+func (s DefaultInterestService) postProduct(response *dto.InterestRunResponse, productCode string, through string, postedAt string) *errs.AppError {
+	accounts, err := s.repo.PostableAccounts(productCode, through)
+	if err != nil {
+		return err
+	}
+	for _, accountID := range accounts {
+		t, err := s.repo.PostInterest(accountID, productCode, through, postedAt)
+		if err != nil {
+			logger.Error("unable to post interest to account " + accountID + ": " + err.Message)
+			response.Failed++
+			continue
+		}
+		if t == nil {
+			response.Skipped++
+			continue
+		}
+		response.Accounts++
+		if t.TransactionType == domain.OVERDRAFT_INTEREST {
+			response.OverdraftTotal = response.OverdraftTotal.Add(t.Amount)
+		} else {
+			response.Total = response.Total.Add(t.Amount)
+		}
+	}
+	return nil
+}
+
+// RunDue catches up on the accrual days since the last completed run, at most MAX_CATCH_UP_DAYS of them,
+// and then posts once a business day. It stops at the first day that fails so the days stay accrued in order.
+// Periods that end on a day the bank is closed are posted on the next business day.
+func (s DefaultInterestService) RunDue() *errs.AppError {
+	now := s.calendar.Today(s.now())
+	yesterday := now.AddDate(0, 0, -1)
+	start := yesterday
+	last, err := s.jobs.LastRun(domain.JOB_INTEREST_ACCRUAL)
+	if err != nil {
+		return err
+	}
+	if last != "" {
+		lastDay, err := domain.ParseRunDate(last, s.calendar.Location())
+		if err != nil {
+			return err
+		}
+		start = lastDay.AddDate(0, 0, 1)
+		if earliest := now.AddDate(0, 0, -MAX_CATCH_UP_DAYS); start.Before(earliest) {
+			logger.Error("interest accrual missed more than " + domain.FormatRunDate(earliest) + ", run the older days by hand")
+			start = earliest
+		}
+	}
+	for day := start; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+		response, err := s.AccrueInterest(domain.FormatRunDate(day))
+		if err != nil {
+			return err
+		}
+		if response.Failed > 0 {
+			return errs.NewUnexpectedError("interest accrual failed for some accounts on " + response.Date)
+		}
+	}
+
+	lastPosting, err := s.jobs.LastRun(domain.JOB_INTEREST_POSTING)
+	if err != nil {
+		return err
+	}
+	if date := domain.FormatRunDate(now); lastPosting != date && s.calendar.IsBusinessDay(now) {
+		if _, err = s.PostInterest(date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// products returns the interest products by code
+func (s DefaultInterestService) products() (map[string]domain.InterestProduct, *errs.AppError) {
+	products, err := s.repo.FindProducts()
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]domain.InterestProduct, len(products))
+	for _, p := range products {
+		byCode[p.ProductCode] = p
+	}
+	return byCode, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonathanwamsley/banking/calendar"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+var savings = realdomain.InterestProduct{ProductCode: "savings", APY: "3.65", Compounding: realdomain.COMPOUND_MONTHLY, DayCount: realdomain.DAY_COUNT_ACT_365}
+
+var mockInterestRepo *domain.MockInterestRepository
+var mockJobRepo *domain.MockJobRunRepository
+var interestService DefaultInterestService
+
+func setupInterest(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockInterestRepo = domain.NewMockInterestRepository(ctrl)
+	mockJobRepo = domain.NewMockJobRunRepository(ctrl)
+	interestService = NewInterestService(mockInterestRepo, mockJobRepo, calendar.Default())
+	interestService.now = func() time.Time { return time.Date(2021, time.March, 10, 6, 0, 0, 0, time.Local) }
+	return func() {
+		defer ctrl.Finish()
+	}
+}
+
+func TestSaveInterestProductRefusesUnknownCompounding(t *testing.T) {
+	teardown := setupInterest(t)
+	defer teardown()
+
+	_, err := interestService.SaveInterestProduct("savings", dto.InterestProductRequest{APY: "2.5", Compounding: "hourly", DayCount: realdomain.DAY_COUNT_ACT_365})
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 422, err.Code)
+}
+
+func TestAccrueInterestSkipsAccountsAlreadyAccrued(t *testing.T) {
+	teardown := setupInterest(t)
+	defer teardown()
+
+	candidates := []realdomain.AccrualCandidate{
+		{AccountID: "95470", ProductCode: "savings", Balance: money.MustParse("1000.00"), UnpostedInterest: "0"},
+		{AccountID: "95471", ProductCode: "savings", Balance: money.MustParse("50.00"), UnpostedInterest: "0"},
+	}
+	mockInterestRepo.EXPECT().FindProducts().Return([]realdomain.InterestProduct{savings}, nil)
+	mockInterestRepo.EXPECT().AccrualCandidates("2021-03-09").Return(candidates, nil)
+	mockInterestRepo.EXPECT().SaveAccrual(gomock.Any()).DoAndReturn(func(a realdomain.InterestAccrual) (bool, *errs.AppError) {
+		assert.Equal(t, "2021-03-09", a.AccrualDate)
+		return a.AccountID == "95470", nil
+	}).Times(2)
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_INTEREST_ACCRUAL, "2021-03-09", 1, "2021-03-10 06:00:00").Return(nil)
+
+	resp, err := interestService.AccrueInterest("2021-03-09")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.Accounts)
+	assert.Equal(t, 1, resp.Skipped)
+	assert.Equal(t, 0, resp.Failed)
+}
+
+func TestAccrueInterestRefusesADayThatHasNotEnded(t *testing.T) {
+	teardown := setupInterest(t)
+	defer teardown()
+
+	_, err := interestService.AccrueInterest("2021-03-10")
+	assert.EqualValues(t, "interest can only be accrued for a day that has ended", err.Message)
+}
+
+func TestAccrueInterestFailureIsNotRecordedAsComplete(t *testing.T) {
+	teardown := setupInterest(t)
+	defer teardown()
+
+	mockInterestRepo.EXPECT().FindProducts().Return([]realdomain.InterestProduct{savings}, nil)
+	mockInterestRepo.EXPECT().AccrualCandidates("2021-03-09").Return([]realdomain.AccrualCandidate{{AccountID: "95470", ProductCode: "gone"}}, nil)
+
+	resp, err := interestService.AccrueInterest("2021-03-09")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.Failed)
+}
+
+func TestPostInterestPaysThePreviousMonth(t *testing.T) {
+	teardown := setupInterest(t)
+	defer teardown()
+
+	mockInterestRepo.EXPECT().FindProducts().Return([]realdomain.InterestProduct{savings}, nil)
+	mockInterestRepo.EXPECT().PostableAccounts("savings", "2021-02-28").Return([]string{"95470", "95471"}, nil)
+	mockInterestRepo.EXPECT().PostInterest("95470", "savings", "2021-02-28", "2021-03-10 06:00:00").Return(&realdomain.Transaction{TransactionID: "9", Amount: money.MustParse("2.80")}, nil)
+	// less than a cent is left for the next month
+	mockInterestRepo.EXPECT().PostInterest("95471", "savings", "2021-02-28", "2021-03-10 06:00:00").Return(nil, nil)
+	mockInterestRepo.EXPECT().PostableAccounts(realdomain.OVERDRAFT_PRODUCT, "2021-02-28").Return(nil, nil)
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_INTEREST_POSTING, "2021-03-10", 1, "2021-03-10 06:00:00").Return(nil)
+
+	resp, err := interestService.PostInterest("2021-03-10")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.Accounts)
+	assert.Equal(t, 1, resp.Skipped)
+	assert.Equal(t, money.MustParse("2.80"), resp.Total)
+}
+
+func TestAccrueInterestChargesOverdrawnAccounts(t *testing.T) {
+	teardown := setupInterest(t)
+	defer teardown()
+
+	candidates := []realdomain.AccrualCandidate{
+		{AccountID: "95473", OverdraftRate: "18.0000", Balance: money.MustParse("-365.00"), UnpostedInterest: "0"},
+		// on an overdraft schedule but in credit, nothing to accrue
+		{AccountID: "95471", OverdraftRate: "18.0000", Balance: money.MustParse("20.00"), UnpostedInterest: "0"},
+	}
+	mockInterestRepo.EXPECT().FindProducts().Return([]realdomain.InterestProduct{savings}, nil)
+	mockInterestRepo.EXPECT().AccrualCandidates("2021-03-09").Return(candidates, nil)
+	mockInterestRepo.EXPECT().SaveAccrual(gomock.Any()).DoAndReturn(func(a realdomain.InterestAccrual) (bool, *errs.AppError) {
+		assert.Equal(t, "95473", a.AccountID)
+		assert.Equal(t, realdomain.OVERDRAFT_PRODUCT, a.ProductCode)
+		assert.Equal(t, "-0.18000000", a.Accrued)
+		return true, nil
+	})
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_INTEREST_ACCRUAL, "2021-03-09", 1, "2021-03-10 06:00:00").Return(nil)
+
+	resp, err := interestService.AccrueInterest("2021-03-09")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.Accounts)
+}
+
+func TestPostInterestChargesOverdraftInterestMonthly(t *testing.T) {
+	teardown := setupInterest(t)
+	defer teardown()
+
+	mockInterestRepo.EXPECT().FindProducts().Return(nil, nil)
+	mockInterestRepo.EXPECT().PostableAccounts(realdomain.OVERDRAFT_PRODUCT, "2021-02-28").Return([]string{"95473"}, nil)
+	mockInterestRepo.EXPECT().PostInterest("95473", realdomain.OVERDRAFT_PRODUCT, "2021-02-28", "2021-03-10 06:00:00").
+		Return(&realdomain.Transaction{TransactionID: "9", Amount: money.MustParse("5.04"), TransactionType: realdomain.OVERDRAFT_INTEREST}, nil)
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_INTEREST_POSTING, "2021-03-10", 1, "2021-03-10 06:00:00").Return(nil)
+
+	resp, err := interestService.PostInterest("2021-03-10")
+	assert.Nil(t, err)
+	assert.True(t, resp.Total.IsZero())
+	assert.Equal(t, money.MustParse("5.04"), resp.OverdraftTotal)
+}
+
+func TestRunDueCatchesUpMissedDays(t *testing.T) {
+	teardown := setupInterest(t)
+	defer teardown()
+
+	mockJobRepo.EXPECT().LastRun(realdomain.JOB_INTEREST_ACCRUAL).Return("2021-03-07", nil)
+	mockInterestRepo.EXPECT().FindProducts().Return([]realdomain.InterestProduct{savings}, nil).Times(2)
+	mockInterestRepo.EXPECT().AccrualCandidates("2021-03-08").Return(nil, nil)
+	mockInterestRepo.EXPECT().AccrualCandidates("2021-03-09").Return(nil, nil)
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_INTEREST_ACCRUAL, gomock.Any(), 0, gomock.Any()).Return(nil).Times(2)
+	mockJobRepo.EXPECT().LastRun(realdomain.JOB_INTEREST_POSTING).Return("2021-03-10", nil)
+
+	assert.Nil(t, interestService.RunDue())
+}
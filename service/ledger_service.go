@@ -0,0 +1,34 @@
+package service
+
+import (
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// LedgerService is an interface that implements
+//
+// CheckLedger: proves the books balance, total debits equal total credits and every account matches the ledger
+type LedgerService interface {
+	CheckLedger() (*dto.LedgerCheckResponse, *errs.AppError)
+}
+
+// DefaultLedgerService has methods that call dto and the domain
+type DefaultLedgerService struct {
+	repo domain.LedgerRepository
+}
+
+// NewLedgerService is the entry point to the service to create a DefaultLedgerService struct
+func NewLedgerService(repository domain.LedgerRepository) DefaultLedgerService {
+	return DefaultLedgerService{repository}
+}
+
+// CheckLedger runs the ledger invariants. A ledger that does not balance is reported, not treated as an error.
+func (s DefaultLedgerService) CheckLedger() (*dto.LedgerCheckResponse, *errs.AppError) {
+	check, err := s.repo.CheckInvariants()
+	if err != nil {
+		return nil, err
+	}
+	response := check.ToDTO()
+	return &response, nil
+}
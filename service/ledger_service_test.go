@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLedgerOutOfBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := domain.NewMockLedgerRepository(ctrl)
+
+	check := &realdomain.LedgerCheck{
+		TotalDebits:       money.MustParse("100.00"),
+		TotalCredits:      money.MustParse("100.00"),
+		UnbalancedEntries: []string{"12"},
+	}
+	repo.EXPECT().CheckInvariants().Return(check, nil)
+
+	resp, err := NewLedgerService(repo).CheckLedger()
+	assert.Nil(t, err)
+	assert.False(t, resp.Balanced)
+	assert.Equal(t, []string{"12"}, resp.UnbalancedEntries)
+}
+
+func TestCheckLedgerServerError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := domain.NewMockLedgerRepository(ctrl)
+	repo.EXPECT().CheckInvariants().Return(nil, errs.NewUnexpectedError("Unexpected database error"))
+
+	resp, err := NewLedgerService(repo).CheckLedger()
+	assert.Nil(t, resp)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// the reasons a second factor is refused with
+const (
+	REASON_MFA_REQUIRED     = "mfa_required"
+	REASON_INVALID_OTP      = "invalid_otp"
+	REASON_MFA_NOT_ENROLLED = "mfa_not_enrolled"
+	REASON_MFA_ENABLED      = "mfa_already_enabled"
+)
+
+// MFAService is an interface that implements
+//
+// Enroll: starts totp enrollment for a user and returns the secret and its provisioning uri
+// Confirm: turns mfa on once the user sends a code of the new secret, and returns the recovery codes
+// StepUp: checks a one time password of a user before a high value request
+// Reset: turns mfa off for a user that lost their authenticator and recovery codes
+//
+// mockgen -destination=mocks/service/mock_mfa_service.go -package=service github.com/jonathanwamsley/banking/service MFAService
+type MFAService interface {
+	Enroll(username string) (*dto.MFAEnrollmentResponse, *errs.AppError)
+	Confirm(username string, req dto.MFAConfirmRequest) (*dto.RecoveryCodesResponse, *errs.AppError)
+	StepUp(username string, otp string) *errs.AppError
+	Reset(username string) *errs.AppError
+}
+
+// DefaultMFAService has methods that call dto and the domain
+type DefaultMFAService struct {
+	repo    domain.UserRepository
+	issuer  string
+	lockout domain.LoginLockout
+	now     func() time.Time
+}
+
+// NewMFAService is the entry point to the service to create a DefaultMFAService struct.
+// The issuer is the name authenticator apps show next to the codes, and the lockout is the one of logins,
+// wrong step-up codes count towards it.
+func NewMFAService(repository domain.UserRepository, issuer string, lockout domain.LoginLockout) DefaultMFAService {
+	return DefaultMFAService{repo: repository, issuer: issuer, lockout: lockout, now: time.Now}
+}
+
+// Enroll stores a new secret for the user. Enrolling again before confirming replaces the secret.
+func (s DefaultMFAService) Enroll(username string) (*dto.MFAEnrollmentResponse, *errs.AppError) {
+	u, err := s.repo.ByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, errs.NewConflictError("mfa is already on").WithReason(REASON_MFA_ENABLED)
+	}
+	secret, genErr := domain.NewTOTPSecret()
+	if genErr != nil {
+		logger.Error("Error while generating a totp secret " + genErr.Error())
+		return nil, errs.NewUnexpectedError("Unexpected error while generating the secret")
+	}
+	if err = s.repo.SetTOTPSecret(username, secret); err != nil {
+		return nil, err
+	}
+	return &dto.MFAEnrollmentResponse{Secret: secret, ProvisioningURI: domain.TOTPProvisioningURI(s.issuer, username, secret)}, nil
+}
+
+// Confirm checks a code of the enrolled secret, turns mfa on and returns new recovery codes
+func (s DefaultMFAService) Confirm(username string, req dto.MFAConfirmRequest) (*dto.RecoveryCodesResponse, *errs.AppError) {
+	u, err := s.repo.ByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, errs.NewConflictError("mfa is already on").WithReason(REASON_MFA_ENABLED)
+	}
+	if !u.TOTPSecret.Valid {
+		return nil, errs.NewConflictError("start mfa enrollment first").WithReason(REASON_MFA_NOT_ENROLLED)
+	}
+	step, ok := domain.MatchTOTP(u.TOTPSecret.String, req.OTP, s.now())
+	if !ok {
+		return nil, errs.NewValidationError("the code does not match, check the authenticator app's clock").WithReason(REASON_INVALID_OTP)
+	}
+	codes, hashes, genErr := domain.NewRecoveryCodes(domain.RECOVERY_CODE_COUNT)
+	if genErr != nil {
+		logger.Error("Error while generating recovery codes " + genErr.Error())
+		return nil, errs.NewUnexpectedError("Unexpected error while generating recovery codes")
+	}
+	if err = s.repo.EnableTOTP(username, step, hashes); err != nil {
+		return nil, err
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// StepUp checks a one time password before a high value request. A user without mfa can not step up, and a
+// wrong code counts as a failed login, so a stolen access token can not be used to guess codes.
+func (s DefaultMFAService) StepUp(username string, otp string) *errs.AppError {
+	notEnrolled := errs.NewForbiddenError("this request needs mfa, turn it on with POST /auth/mfa").WithReason(REASON_MFA_NOT_ENROLLED)
+	if username == "" {
+		return notEnrolled
+	}
+	u, err := s.repo.ByUsername(username)
+	if err != nil {
+		if err.Code == http.StatusNotFound {
+			return notEnrolled
+		}
+		return err
+	}
+	if !u.TOTPEnabled {
+		return notEnrolled
+	}
+	now := s.now()
+	if u.IsLocked(now) {
+		return userLocked()
+	}
+	if err = checkSecondFactor(s.repo, u, otp, now); err != nil {
+		if err.Reason == REASON_INVALID_OTP {
+			if recordErr := recordFailure(s.repo, s.lockout, u.Username, now); recordErr != nil {
+				return recordErr
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// Reset turns mfa off and removes the recovery codes
+func (s DefaultMFAService) Reset(username string) *errs.AppError {
+	if _, err := s.repo.ByUsername(username); err != nil {
+		return err
+	}
+	return s.repo.DisableTOTP(username)
+}
+
+// checkSecondFactor accepts a current totp code that was not used before, or an unused recovery code
+func checkSecondFactor(repo domain.UserRepository, u *domain.User, code string, now time.Time) *errs.AppError {
+	if code == "" {
+		return errs.NewUnauthorizedError("a one time password is required").WithReason(REASON_MFA_REQUIRED)
+	}
+	invalid := errs.NewUnauthorizedError("invalid one time password").WithReason(REASON_INVALID_OTP)
+
+	if domain.IsRecoveryCode(code) {
+		used, err := repo.UseRecoveryCode(u.Username, domain.HashRecoveryCode(code), now.Format(dbTSLayout))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return invalid
+		}
+		return nil
+	}
+
+	step, ok := domain.MatchTOTP(u.TOTPSecret.String, code, now)
+	if !ok {
+		return invalid
+	}
+	// a code can only be used once, even within its period, so a code read over someone's shoulder is useless
+	used, err := repo.UseTOTPStep(u.Username, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return invalid
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// the RFC 6238 test secret, base32 encoded
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+var mfaService DefaultMFAService
+
+func setupMFA(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockUserRepo = domain.NewMockUserRepository(ctrl)
+	mfaService = NewMFAService(mockUserRepo, "Banking", realdomain.LoginLockout{MaxAttempts: 5, Duration: 15 * time.Minute})
+	mfaService.now = func() time.Time { return loginNow }
+	return func() {
+		defer ctrl.Finish()
+	}
+}
+
+func mfaUser() *realdomain.User {
+	return &realdomain.User{
+		Username:    "2001",
+		Role:        realdomain.ROLE_CUSTOMER,
+		TOTPSecret:  sql.NullString{String: testTOTPSecret, Valid: true},
+		TOTPEnabled: true,
+	}
+}
+
+func currentCode(t *testing.T) string {
+	code, err := realdomain.TOTPCode(testTOTPSecret, realdomain.TOTPStep(loginNow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestConfirmMFATurnsItOnWithRecoveryCodes(t *testing.T) {
+	teardown := setupMFA(t)
+	defer teardown()
+	pending := mfaUser()
+	pending.TOTPEnabled = false
+	mockUserRepo.EXPECT().ByUsername("2001").Return(pending, nil)
+	mockUserRepo.EXPECT().EnableTOTP("2001", realdomain.TOTPStep(loginNow), gomock.Len(realdomain.RECOVERY_CODE_COUNT)).Return(nil)
+
+	resp, err := mfaService.Confirm("2001", dto.MFAConfirmRequest{OTP: currentCode(t)})
+
+	assert.Nil(t, err)
+	assert.Len(t, resp.RecoveryCodes, realdomain.RECOVERY_CODE_COUNT)
+}
+
+func TestConfirmMFAWithoutEnrollment(t *testing.T) {
+	teardown := setupMFA(t)
+	defer teardown()
+	mockUserRepo.EXPECT().ByUsername("2001").Return(&realdomain.User{Username: "2001"}, nil)
+
+	_, err := mfaService.Confirm("2001", dto.MFAConfirmRequest{OTP: "123456"})
+
+	assert.EqualValues(t, REASON_MFA_NOT_ENROLLED, err.Reason)
+}
+
+func TestStepUpRefusesAReusedCode(t *testing.T) {
+	teardown := setupMFA(t)
+	defer teardown()
+	mockUserRepo.EXPECT().ByUsername("2001").Return(mfaUser(), nil).Times(2)
+	gomock.InOrder(
+		mockUserRepo.EXPECT().UseTOTPStep("2001", realdomain.TOTPStep(loginNow)).Return(true, nil),
+		mockUserRepo.EXPECT().UseTOTPStep("2001", realdomain.TOTPStep(loginNow)).Return(false, nil),
+	)
+	mockUserRepo.EXPECT().RecordFailedLogin("2001", 5, "2021-03-10 09:15:00").Return(nil)
+
+	assert.Nil(t, mfaService.StepUp("2001", currentCode(t)))
+	err := mfaService.StepUp("2001", currentCode(t))
+	assert.EqualValues(t, 401, err.Code)
+	assert.EqualValues(t, REASON_INVALID_OTP, err.Reason)
+}
+
+func TestStepUpLocksAfterTooManyWrongCodes(t *testing.T) {
+	teardown := setupMFA(t)
+	defer teardown()
+	failures := 0
+	mockUserRepo.EXPECT().ByUsername("2001").DoAndReturn(func(string) (*realdomain.User, *errs.AppError) {
+		u := mfaUser()
+		u.FailedLogins = failures
+		if failures >= 5 {
+			u.LockedUntil = sql.NullString{String: "2021-03-10 09:15:00", Valid: true}
+		}
+		return u, nil
+	}).Times(6)
+	mockUserRepo.EXPECT().UseRecoveryCode("2001", gomock.Any(), "2021-03-10 09:00:00").Return(false, nil).Times(5)
+	mockUserRepo.EXPECT().RecordFailedLogin("2001", 5, "2021-03-10 09:15:00").DoAndReturn(func(string, int, string) *errs.AppError {
+		failures++
+		return nil
+	}).Times(5)
+
+	for i := 0; i < 5; i++ {
+		assert.EqualValues(t, REASON_INVALID_OTP, mfaService.StepUp("2001", "abcde-fghij").Reason)
+	}
+	err := mfaService.StepUp("2001", "abcde-fghij")
+	assert.EqualValues(t, 401, err.Code)
+	assert.EqualValues(t, REASON_USER_LOCKED, err.Reason)
+}
+
+func TestStepUpAcceptsARecoveryCode(t *testing.T) {
+	teardown := setupMFA(t)
+	defer teardown()
+	mockUserRepo.EXPECT().ByUsername("2001").Return(mfaUser(), nil)
+	mockUserRepo.EXPECT().UseRecoveryCode("2001", realdomain.HashRecoveryCode("abcde-fghij"), "2021-03-10 09:00:00").Return(true, nil)
+
+	assert.Nil(t, mfaService.StepUp("2001", "ABCDE-FGHIJ"))
+}
+
+func TestStepUpNeedsMFA(t *testing.T) {
+	teardown := setupMFA(t)
+	defer teardown()
+	mockUserRepo.EXPECT().ByUsername("admin").Return(nil, errs.NewNotFoundError("User not found"))
+	mockUserRepo.EXPECT().ByUsername("2001").Return(mfaUser(), nil)
+
+	assert.EqualValues(t, REASON_MFA_NOT_ENROLLED, mfaService.StepUp("admin", "123456").Reason)
+	assert.EqualValues(t, REASON_MFA_REQUIRED, mfaService.StepUp("2001", "").Reason)
+}
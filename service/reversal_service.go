@@ -0,0 +1,52 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// ReversalService is an interface that implements
+//
+// ReverseTransaction: posts a compensating transaction for all or part of a transaction of an account of the customer
+// mockgen -destination=mocks/service/mock_reversal_service.go -package=service github.com/jonathanwamsley/banking/service ReversalService
+type ReversalService interface {
+	ReverseTransaction(customerID string, accountID string, transactionID string, req dto.ReverseTransactionRequest, createdBy string) (*dto.ReversalResponse, *errs.AppError)
+}
+
+// DefaultReversalService has methods that call dto and the domain
+type DefaultReversalService struct {
+	repo     domain.ReversalRepository
+	accounts domain.AccountRepository
+	calendar calendar.Calendar
+	now      func() time.Time
+}
+
+// NewReversalService is the entry point to the service to create a DefaultReversalService struct
+func NewReversalService(repository domain.ReversalRepository, accounts domain.AccountRepository, cal calendar.Calendar) DefaultReversalService {
+	return DefaultReversalService{repo: repository, accounts: accounts, calendar: cal, now: time.Now}
+}
+
+// ReverseTransaction reverses a transaction of an account the customer owns. What was already reversed is
+// checked by the repository against the locked account row.
+func (s DefaultReversalService) ReverseTransaction(customerID string, accountID string, transactionID string, req dto.ReverseTransactionRequest, createdBy string) (*dto.ReversalResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	account, err := s.accounts.FindBy(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.CustomerID != customerID {
+		return nil, errs.NewNotFoundError("Account not found")
+	}
+	saved, err := s.repo.SaveReversal(domain.NewReversal(accountID, transactionID, req, createdBy, s.now().In(s.calendar.Location())))
+	if err != nil {
+		return nil, err
+	}
+	response := saved.ToDTO()
+	return &response, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonathanwamsley/banking/calendar"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockReversalRepo *domain.MockReversalRepository
+var reversalService DefaultReversalService
+
+func setupReversal(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockReversalRepo = domain.NewMockReversalRepository(ctrl)
+	mockAccountRepo = domain.NewMockAccountRepository(ctrl)
+	reversalService = NewReversalService(mockReversalRepo, mockAccountRepo, calendar.Default())
+	reversalService.now = func() time.Time { return time.Date(2021, time.March, 10, 9, 0, 0, 0, time.Local) }
+	return func() {
+		defer ctrl.Finish()
+	}
+}
+
+func TestReverseTransactionNeedsAReasonCode(t *testing.T) {
+	teardown := setupReversal(t)
+	defer teardown()
+
+	_, err := reversalService.ReverseTransaction("2000", "95470", "12", dto.ReverseTransactionRequest{ReasonCode: "oops"}, "admin")
+	assert.Equal(t, "invalid_reason_code", err.Reason)
+}
+
+func TestReverseTransactionOfAnotherCustomersAccount(t *testing.T) {
+	teardown := setupReversal(t)
+	defer teardown()
+
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&realdomain.Account{AccountID: "95470", CustomerID: "2000"}, nil)
+	_, err := reversalService.ReverseTransaction("2001", "95470", "12", dto.ReverseTransactionRequest{ReasonCode: dto.REVERSAL_DUPLICATE}, "admin")
+	assert.EqualValues(t, 404, err.Code)
+}
+
+func TestReverseTransactionInPart(t *testing.T) {
+	teardown := setupReversal(t)
+	defer teardown()
+
+	partial := money.MustParse("40.00")
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&realdomain.Account{AccountID: "95470", CustomerID: "2000"}, nil)
+	mockReversalRepo.EXPECT().SaveReversal(gomock.Any()).DoAndReturn(func(r realdomain.Reversal) (*realdomain.Reversal, *errs.AppError) {
+		assert.Equal(t, "12", r.TransactionID)
+		assert.Equal(t, &partial, r.Requested)
+		assert.Equal(t, "2021-03-10 09:00:00", r.CreatedAt)
+		r.ReversalID = "3"
+		r.Amount = *r.Requested
+		r.Transaction = realdomain.Transaction{TransactionID: "13", TransactionType: realdomain.REVERSAL_OUT, Balance: money.MustParse("60.00")}
+		r.ReversalTransactionID = "13"
+		r.ReversibleAmount = money.MustParse("60.00")
+		return &r, nil
+	})
+	resp, err := reversalService.ReverseTransaction("2000", "95470", "12", dto.ReverseTransactionRequest{Amount: &partial, ReasonCode: dto.REVERSAL_POSTING_ERROR}, "admin")
+	assert.Nil(t, err)
+	assert.Equal(t, "13", resp.ReversalTransactionID)
+	assert.Equal(t, realdomain.REVERSAL_OUT, resp.TransactionType)
+	assert.Equal(t, money.MustParse("60.00"), resp.ReversibleAmount)
+	assert.Equal(t, "admin", resp.CreatedBy)
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// ScheduledPaymentService is an interface that implements
+//
+// GetScheduledPayments: returns the scheduled payments of a customer
+// GetScheduledPayment: returns a scheduled payment of a customer, with its version
+// CreateScheduledPayment: schedules a one-off or recurring payment from an account of the customer
+// UpdateScheduledPayment: changes, suspends or resumes a scheduled payment of the customer
+// CancelScheduledPayment: ends a scheduled payment of the customer
+// GetScheduledPaymentRuns: returns the run history of a scheduled payment of the customer
+// RunDuePayments: makes every scheduled payment that is due today
+// mockgen -destination=mocks/service/mock_scheduled_payment_service.go -package=service github.com/jonathanwamsley/banking/service ScheduledPaymentService
+type ScheduledPaymentService interface {
+	GetScheduledPayments(customerID string) ([]dto.ScheduledPaymentResponse, *errs.AppError)
+	GetScheduledPayment(customerID string, paymentID string) (*dto.ScheduledPaymentResponse, *errs.AppError)
+	CreateScheduledPayment(req dto.CreateScheduledPaymentRequest) (*dto.ScheduledPaymentResponse, *errs.AppError)
+	UpdateScheduledPayment(customerID string, paymentID string, req dto.UpdateScheduledPaymentRequest) (*dto.ScheduledPaymentResponse, *errs.AppError)
+	CancelScheduledPayment(customerID string, paymentID string, version int) (*dto.ScheduledPaymentResponse, *errs.AppError)
+	GetScheduledPaymentRuns(customerID string, paymentID string) ([]dto.ScheduledPaymentRunResponse, *errs.AppError)
+	RunDuePayments() (*dto.ScheduledPaymentsRunResponse, *errs.AppError)
+}
+
+// DefaultScheduledPaymentService has methods that call dto and the domain. Payments are made through the
+// AccountService, so they are checked and posted like the transfers and withdrawals of the customer. They are
+// only made on business days, a payment due on a day the bank is closed is made on the next business day.
+type DefaultScheduledPaymentService struct {
+	repo     domain.ScheduledPaymentRepository
+	accounts domain.AccountRepository
+	payer    AccountService
+	calendar calendar.Calendar
+	now      func() time.Time
+}
+
+// NewScheduledPaymentService is the entry point to the service to create a DefaultScheduledPaymentService struct
+func NewScheduledPaymentService(repository domain.ScheduledPaymentRepository, accounts domain.AccountRepository, payer AccountService, cal calendar.Calendar) DefaultScheduledPaymentService {
+	return DefaultScheduledPaymentService{repo: repository, accounts: accounts, payer: payer, calendar: cal, now: time.Now}
+}
+
+// GetScheduledPayments returns the scheduled payments of a customer
+func (s DefaultScheduledPaymentService) GetScheduledPayments(customerID string) ([]dto.ScheduledPaymentResponse, *errs.AppError) {
+	payments, err := s.repo.FindPayments(customerID)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.ScheduledPaymentResponse, 0)
+	for _, p := range payments {
+		response = append(response, p.ToDTO())
+	}
+	return response, nil
+}
+
+// GetScheduledPayment returns a scheduled payment of a customer
+func (s DefaultScheduledPaymentService) GetScheduledPayment(customerID string, paymentID string) (*dto.ScheduledPaymentResponse, *errs.AppError) {
+	p, err := s.repo.FindPayment(customerID, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	response := p.ToDTO()
+	return &response, nil
+}
+
+// CreateScheduledPayment schedules a payment from an account the customer owns. The destination account only
+// has to exist, like with a transfer, and the funds are checked each time the payment runs.
+func (s DefaultScheduledPaymentService) CreateScheduledPayment(req dto.CreateScheduledPaymentRequest) (*dto.ScheduledPaymentResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	p, err := domain.NewScheduledPayment(req, s.now().In(s.calendar.Location()))
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.accounts.FindBy(req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.CustomerID != req.CustomerID {
+		return nil, errs.NewNotFoundError("Account not found")
+	}
+	if req.ToAccountID != "" {
+		if _, err = s.accounts.FindBy(req.ToAccountID); err != nil {
+			return nil, err
+		}
+	}
+	saved, err := s.repo.SavePayment(p)
+	if err != nil {
+		return nil, err
+	}
+	response := saved.ToDTO()
+	return &response, nil
+}
+
+// UpdateScheduledPayment changes a scheduled payment of the customer, if it is still at the version the caller read
+func (s DefaultScheduledPaymentService) UpdateScheduledPayment(customerID string, paymentID string, req dto.UpdateScheduledPaymentRequest) (*dto.ScheduledPaymentResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	p, err := s.repo.FindPayment(customerID, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if err = domain.CheckVersion(domain.SUBJECT_SCHEDULED_PAYMENT, p.Version, req.Version); err != nil {
+		return nil, err
+	}
+	updated, err := p.Apply(req, s.now().In(s.calendar.Location()))
+	if err != nil {
+		return nil, err
+	}
+	return s.save(updated, p.Version)
+}
+
+// CancelScheduledPayment ends a scheduled payment of the customer, if it is still at the version the caller read
+func (s DefaultScheduledPaymentService) CancelScheduledPayment(customerID string, paymentID string, version int) (*dto.ScheduledPaymentResponse, *errs.AppError) {
+	p, err := s.repo.FindPayment(customerID, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if err = domain.CheckVersion(domain.SUBJECT_SCHEDULED_PAYMENT, p.Version, version); err != nil {
+		return nil, err
+	}
+	cancelled, err := p.Cancel()
+	if err != nil {
+		return nil, err
+	}
+	return s.save(cancelled, p.Version)
+}
+
+// GetScheduledPaymentRuns returns the runs of a scheduled payment of the customer
+func (s DefaultScheduledPaymentService) GetScheduledPaymentRuns(customerID string, paymentID string) ([]dto.ScheduledPaymentRunResponse, *errs.AppError) {
+	if _, err := s.repo.FindPayment(customerID, paymentID); err != nil {
+		return nil, err
+	}
+	runs, err := s.repo.FindRuns(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.ScheduledPaymentRunResponse, 0)
+	for _, r := range runs {
+		response = append(response, r.ToDTO())
+	}
+	return response, nil
+}
+
+// RunDuePayments attempts every payment that is due today once, nothing is paid on a day the bank is closed.
+// A payment that missed several dates, because the server was down, catches up one date per run. An attempt
+// another scheduler claimed is skipped, unless it stayed running for domain.STALE_RUN_AFTER: it is then settled as
+// interrupted without paying again, since it can not be known whether the money moved. The failure of one payment
+// never stops the others.
+func (s DefaultScheduledPaymentService) RunDuePayments() (*dto.ScheduledPaymentsRunResponse, *errs.AppError) {
+	now := s.now()
+	today := s.calendar.BookingDate(now)
+	if !s.calendar.IsBusinessDay(now) {
+		return &dto.ScheduledPaymentsRunResponse{Date: today}, nil
+	}
+	due, err := s.repo.DuePayments(today)
+	if err != nil {
+		return nil, err
+	}
+	result := dto.ScheduledPaymentsRunResponse{Date: today}
+	for _, p := range due {
+		run, claimed, err := s.repo.ClaimRun(p.NewPaymentRun(now))
+		if err != nil {
+			logger.Error("scheduled payment " + p.PaymentID + " was not run: " + err.Message)
+			result.Failed++
+			continue
+		}
+		if !claimed {
+			s.reclaimStaleRun(p, now, &result)
+			continue
+		}
+
+		transactionID, payErr := s.pay(p)
+		next := p.Settle(run, transactionID, payErr)
+		if err = s.repo.FinishRun(*run, p, next); err != nil {
+			logger.Error(fmt.Sprintf("run %s of scheduled payment %s was not recorded: %s", run.RunID, p.PaymentID, err.Message))
+		}
+		switch run.Status {
+		case domain.RUN_SUCCEEDED:
+			result.Succeeded++
+		case domain.RUN_DECLINED:
+			result.Declined++
+		default:
+			result.Failed++
+		}
+	}
+	if len(due) > 0 {
+		logger.Info(fmt.Sprintf("scheduled payments of %s: %d succeeded, %d declined, %d failed, %d skipped",
+			today, result.Succeeded, result.Declined, result.Failed, result.Skipped))
+	}
+	return &result, nil
+}
+
+// reclaimStaleRun settles the run of a payment another scheduler claimed when it stayed running too long, and
+// counts it as failed. A run that is not stale is still being made and is skipped.
+func (s DefaultScheduledPaymentService) reclaimStaleRun(p domain.ScheduledPayment, now time.Time, result *dto.ScheduledPaymentsRunResponse) {
+	staleBefore := domain.StaleRunBefore(now)
+	run, reclaimed, err := s.repo.ReclaimRun(p.NewPaymentRun(now), staleBefore)
+	if err != nil {
+		logger.Error("stale run of scheduled payment " + p.PaymentID + " was not reclaimed: " + err.Message)
+		result.Failed++
+		return
+	}
+	if !reclaimed {
+		result.Skipped++
+		return
+	}
+	logger.Error(fmt.Sprintf("run %s of scheduled payment %s stayed running since before %s, it is suspended for the customer to check",
+		run.RunID, p.PaymentID, staleBefore))
+	next := p.Interrupt(run)
+	if err = s.repo.FinishRun(*run, p, next); err != nil {
+		logger.Error(fmt.Sprintf("run %s of scheduled payment %s was not recorded: %s", run.RunID, p.PaymentID, err.Message))
+	}
+	result.Failed++
+}
+
+// pay makes a scheduled payment as a transfer, or as a withdrawal when it has no destination, and returns the
+// transaction that took the money from the account
+func (s DefaultScheduledPaymentService) pay(p domain.ScheduledPayment) (string, *errs.AppError) {
+	if p.ToAccountID.Valid {
+		transfer, err := s.payer.Transfer(dto.TransferRequest{
+			FromAccountID: p.AccountID,
+			ToAccountID:   p.ToAccountID.String,
+			Amount:        p.Amount,
+			CustomerID:    p.CustomerID,
+		})
+		if err != nil {
+			return "", err
+		}
+		return transfer.From.TransactionID, nil
+	}
+	withdrawal, err := s.payer.MakeTransaction(dto.MakeTransactionRequest{
+		AccountID:       p.AccountID,
+		Amount:          p.Amount,
+		TransactionType: dto.WITHDRAWAL,
+		CustomerID:      p.CustomerID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return withdrawal.TransactionID, nil
+}
+
+// save stores a changed scheduled payment that was read at version
+func (s DefaultScheduledPaymentService) save(p domain.ScheduledPayment, version int) (*dto.ScheduledPaymentResponse, *errs.AppError) {
+	if err := s.repo.UpdatePayment(p, version); err != nil {
+		return nil, err
+	}
+	p.Version = version + 1
+	response := p.ToDTO()
+	return &response, nil
+}
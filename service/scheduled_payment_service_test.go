@@ -0,0 +1,183 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonathanwamsley/banking/calendar"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockPaymentRepo *domain.MockScheduledPaymentRepository
+var paymentService DefaultScheduledPaymentService
+
+// setupScheduledPayment pays through a real account service, so the payments take the same path as the
+// transactions and transfers of the account routes
+func setupScheduledPayment(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockPaymentRepo = domain.NewMockScheduledPaymentRepository(ctrl)
+	mockAccountRepo = domain.NewMockAccountRepository(ctrl)
+	paymentService = NewScheduledPaymentService(mockPaymentRepo, mockAccountRepo, NewAccountService(mockAccountRepo, calendar.Default()), calendar.Default())
+	paymentService.now = func() time.Time { return time.Date(2021, time.March, 10, 9, 0, 0, 0, time.Local) }
+	return func() {
+		defer ctrl.Finish()
+	}
+}
+
+func dueWithdrawal() realdomain.ScheduledPayment {
+	return realdomain.ScheduledPayment{
+		PaymentID:   "4",
+		CustomerID:  "2001",
+		AccountID:   "95472",
+		Amount:      money.MustParse("250.00"),
+		Frequency:   dto.FREQUENCY_WEEKLY,
+		StartDate:   "2021-03-03",
+		NextRunDate: sql.NullString{String: "2021-03-10", Valid: true},
+		RetryPolicy: dto.RETRY_RETRY,
+		MaxRetries:  3,
+		Status:      realdomain.PAYMENT_ACTIVE,
+		Version:     1,
+	}
+}
+
+func TestCreateScheduledPaymentFromAnotherCustomersAccount(t *testing.T) {
+	teardown := setupScheduledPayment(t)
+	defer teardown()
+
+	mockAccountRepo.EXPECT().FindBy("95472").Return(&realdomain.Account{AccountID: "95472", CustomerID: "2000"}, nil)
+	_, err := paymentService.CreateScheduledPayment(dto.CreateScheduledPaymentRequest{
+		CustomerID: "2001", AccountID: "95472", Amount: money.MustParse("250.00"), Frequency: dto.FREQUENCY_ONCE,
+	})
+	assert.EqualValues(t, 404, err.Code)
+}
+
+func TestCreateScheduledPayment(t *testing.T) {
+	teardown := setupScheduledPayment(t)
+	defer teardown()
+
+	mockAccountRepo.EXPECT().FindBy("95472").Return(&realdomain.Account{AccountID: "95472", CustomerID: "2001"}, nil)
+	mockAccountRepo.EXPECT().FindBy("95473").Return(&realdomain.Account{AccountID: "95473", CustomerID: "2002"}, nil)
+	mockPaymentRepo.EXPECT().SavePayment(gomock.Any()).DoAndReturn(func(p realdomain.ScheduledPayment) (*realdomain.ScheduledPayment, *errs.AppError) {
+		p.PaymentID = "4"
+		return &p, nil
+	})
+	resp, err := paymentService.CreateScheduledPayment(dto.CreateScheduledPaymentRequest{
+		CustomerID: "2001", AccountID: "95472", ToAccountID: "95473", Amount: money.MustParse("250.00"), Frequency: dto.FREQUENCY_END_OF_MONTH,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "4", resp.PaymentID)
+	assert.Equal(t, "2021-03-31", resp.NextRunDate)
+	assert.Equal(t, 1, resp.Version)
+}
+
+func TestUpdateScheduledPaymentWithAStaleVersion(t *testing.T) {
+	teardown := setupScheduledPayment(t)
+	defer teardown()
+
+	p := dueWithdrawal()
+	p.Version = 3
+	mockPaymentRepo.EXPECT().FindPayment("2001", "4").Return(&p, nil)
+	_, err := paymentService.UpdateScheduledPayment("2001", "4", dto.UpdateScheduledPaymentRequest{Amount: money.MustParse("10.00"), Status: "suspended", Version: 2})
+	assert.Equal(t, "version_mismatch", err.Reason)
+}
+
+func TestRunDuePaymentsRetriesADecline(t *testing.T) {
+	teardown := setupScheduledPayment(t)
+	defer teardown()
+
+	p := dueWithdrawal()
+	mockPaymentRepo.EXPECT().DuePayments("2021-03-10").Return([]realdomain.ScheduledPayment{p}, nil)
+	mockPaymentRepo.EXPECT().ClaimRun(gomock.Any()).DoAndReturn(func(r realdomain.PaymentRun) (*realdomain.PaymentRun, bool, *errs.AppError) {
+		assert.Equal(t, "2021-03-10", r.ScheduledDate)
+		assert.Equal(t, 1, r.Attempt)
+		r.RunID = "7"
+		return &r, true, nil
+	})
+	mockAccountRepo.EXPECT().SaveTransaction(gomock.Any()).DoAndReturn(func(txn realdomain.Transaction) (*realdomain.Transaction, *errs.AppError) {
+		return nil, errs.NewValidationError("Insufficient funds").WithReason(realdomain.REASON_INSUFFICIENT_FUNDS)
+	})
+	mockPaymentRepo.EXPECT().FinishRun(gomock.Any(), p, gomock.Any()).DoAndReturn(func(r realdomain.PaymentRun, ran realdomain.ScheduledPayment, next realdomain.ScheduledPayment) *errs.AppError {
+		assert.Equal(t, realdomain.RUN_DECLINED, r.Status)
+		assert.Equal(t, 1, next.Attempts)
+		assert.Equal(t, "2021-03-10", next.NextRunDate.String)
+		return nil
+	})
+
+	result, err := paymentService.RunDuePayments()
+	assert.Nil(t, err)
+	assert.Equal(t, dto.ScheduledPaymentsRunResponse{Date: "2021-03-10", Declined: 1}, *result)
+}
+
+func TestRunDuePaymentsPaysAndSkipsClaimedRuns(t *testing.T) {
+	teardown := setupScheduledPayment(t)
+	defer teardown()
+
+	paid, claimed := dueWithdrawal(), dueWithdrawal()
+	claimed.PaymentID = "5"
+	mockPaymentRepo.EXPECT().DuePayments("2021-03-10").Return([]realdomain.ScheduledPayment{paid, claimed}, nil)
+	mockPaymentRepo.EXPECT().ClaimRun(gomock.Any()).DoAndReturn(func(r realdomain.PaymentRun) (*realdomain.PaymentRun, bool, *errs.AppError) {
+		if r.PaymentID == "5" {
+			return nil, false, nil
+		}
+		r.RunID = "7"
+		return &r, true, nil
+	}).Times(2)
+	mockPaymentRepo.EXPECT().ReclaimRun(gomock.Any(), "2021-03-10 08:45:00").Return(nil, false, nil)
+	mockAccountRepo.EXPECT().SaveTransaction(gomock.Any()).DoAndReturn(func(txn realdomain.Transaction) (*realdomain.Transaction, *errs.AppError) {
+		assert.Equal(t, realdomain.WITHDRAWAL, txn.TransactionType)
+		txn.TransactionID = "42"
+		return &txn, nil
+	})
+	mockPaymentRepo.EXPECT().FinishRun(gomock.Any(), paid, gomock.Any()).DoAndReturn(func(r realdomain.PaymentRun, ran realdomain.ScheduledPayment, next realdomain.ScheduledPayment) *errs.AppError {
+		assert.Equal(t, "42", r.TransactionID.String)
+		assert.Equal(t, "2021-03-17", next.NextRunDate.String)
+		return nil
+	})
+
+	result, err := paymentService.RunDuePayments()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, 1, result.Skipped)
+}
+
+func TestRunDuePaymentsSettlesAStaleRunWithoutPaying(t *testing.T) {
+	teardown := setupScheduledPayment(t)
+	defer teardown()
+
+	p := dueWithdrawal()
+	mockPaymentRepo.EXPECT().DuePayments("2021-03-10").Return([]realdomain.ScheduledPayment{p}, nil)
+	mockPaymentRepo.EXPECT().ClaimRun(gomock.Any()).Return(nil, false, nil)
+	mockPaymentRepo.EXPECT().ReclaimRun(gomock.Any(), "2021-03-10 08:45:00").DoAndReturn(func(r realdomain.PaymentRun, staleBefore string) (*realdomain.PaymentRun, bool, *errs.AppError) {
+		r.RunID = "7"
+		return &r, true, nil
+	})
+	// no SaveTransaction is expected, the stale run may already have paid
+	mockPaymentRepo.EXPECT().FinishRun(gomock.Any(), p, gomock.Any()).DoAndReturn(func(r realdomain.PaymentRun, ran realdomain.ScheduledPayment, next realdomain.ScheduledPayment) *errs.AppError {
+		assert.Equal(t, "7", r.RunID)
+		assert.Equal(t, realdomain.REASON_RUN_INTERRUPTED, r.Reason)
+		assert.Equal(t, realdomain.PAYMENT_SUSPENDED, next.Status)
+		return nil
+	})
+
+	result, err := paymentService.RunDuePayments()
+	assert.Nil(t, err)
+	assert.Equal(t, dto.ScheduledPaymentsRunResponse{Date: "2021-03-10", Failed: 1}, *result)
+}
+
+func TestRunDuePaymentsWaitsForABusinessDay(t *testing.T) {
+	teardown := setupScheduledPayment(t)
+	defer teardown()
+
+	// a saturday, the repository is not asked for due payments
+	paymentService.now = func() time.Time { return time.Date(2021, time.March, 13, 9, 0, 0, 0, time.Local) }
+	result, err := paymentService.RunDuePayments()
+	assert.Nil(t, err)
+	assert.Equal(t, dto.ScheduledPaymentsRunResponse{Date: "2021-03-13"}, *result)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// SessionService is an interface that implements
+//
+// GetSessions: returns the active sessions of a user
+// RevokeSession: revokes a session of a user, or any session when anyUser is set
+// RevokeToken: puts a single token on the revocation list, and revokes the session of a refresh token
+type SessionService interface {
+	GetSessions(username string, currentSessionID string) ([]dto.SessionResponse, *errs.AppError)
+	RevokeSession(sessionID string, username string, anyUser bool) *errs.AppError
+	RevokeToken(dto.RevokeTokenRequest) *errs.AppError
+}
+
+// DefaultSessionService has methods that call dto and the domain
+type DefaultSessionService struct {
+	repo   domain.SessionRepository
+	issuer domain.TokenIssuer
+	now    func() time.Time
+}
+
+// NewSessionService is the entry point to the service to create a DefaultSessionService struct
+func NewSessionService(repository domain.SessionRepository, issuer domain.TokenIssuer) DefaultSessionService {
+	return DefaultSessionService{repo: repository, issuer: issuer, now: time.Now}
+}
+
+// GetSessions returns the sessions of a user that can still be refreshed
+func (s DefaultSessionService) GetSessions(username string, currentSessionID string) ([]dto.SessionResponse, *errs.AppError) {
+	sessions, err := s.repo.FindActive(username, s.now().Format(dbTSLayout))
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.SessionResponse, 0)
+	for _, session := range sessions {
+		response = append(response, session.ToDTO(currentSessionID))
+	}
+	return response, nil
+}
+
+// RevokeSession revokes a session, its access tokens are refused from the next request on. A session of
+// another user is reported as not found unless anyUser is set, so session ids of other users cannot be probed.
+func (s DefaultSessionService) RevokeSession(sessionID string, username string, anyUser bool) *errs.AppError {
+	session, err := s.repo.ByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if !anyUser && session.Username != username {
+		return errs.NewNotFoundError("Session not found")
+	}
+	return s.repo.Revoke(sessionID, s.now().Format(dbTSLayout))
+}
+
+// RevokeToken revokes a token signed by this api. The jti stays on the revocation list until the token expires,
+// and a refresh token also revokes its session, since everything issued from it is as compromised as it is.
+func (s DefaultSessionService) RevokeToken(req dto.RevokeTokenRequest) *errs.AppError {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	claims, parseErr := s.issuer.ParseToken(req.Token)
+	if parseErr != nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return errs.NewValidationError("token was not issued by this api")
+	}
+	if !claims.ExpiresAt.Time.After(s.now()) {
+		return nil
+	}
+	if err := s.repo.RevokeToken(claims.ID, claims.ExpiresAt.Time.Local().Format(dbTSLayout)); err != nil {
+		return err
+	}
+	if claims.TokenUse == domain.TOKEN_USE_REFRESH && claims.SessionID != "" {
+		return s.repo.Revoke(claims.SessionID, s.now().Format(dbTSLayout))
+	}
+	return nil
+}
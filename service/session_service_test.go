@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+var sessionService DefaultSessionService
+
+func setupSessions(t *testing.T) func() {
+	teardown := setupAuth(t)
+	sessionService = NewSessionService(mockSessionRepo, authIssuer)
+	sessionService.now = func() time.Time { return loginNow }
+	return teardown
+}
+
+func TestGetSessionsMarksTheCurrentSession(t *testing.T) {
+	teardown := setupSessions(t)
+	defer teardown()
+	mockSessionRepo.EXPECT().FindActive("arian", "2021-03-10 09:00:00").Return([]realdomain.Session{
+		{SessionID: "s1", Username: "arian"},
+		{SessionID: "s2", Username: "arian"},
+	}, nil)
+
+	sessions, err := sessionService.GetSessions("arian", "s2")
+
+	assert.Nil(t, err)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+}
+
+func TestRevokeSessionOfAnotherUserIsNotFound(t *testing.T) {
+	teardown := setupSessions(t)
+	defer teardown()
+	mockSessionRepo.EXPECT().ByID("s1").Return(&realdomain.Session{SessionID: "s1", Username: "rob"}, nil)
+
+	err := sessionService.RevokeSession("s1", "arian", false)
+
+	assert.EqualValues(t, 404, err.Code)
+}
+
+func TestAdminRevokesAnySession(t *testing.T) {
+	teardown := setupSessions(t)
+	defer teardown()
+	mockSessionRepo.EXPECT().ByID("s1").Return(&realdomain.Session{SessionID: "s1", Username: "rob"}, nil)
+	mockSessionRepo.EXPECT().Revoke("s1", "2021-03-10 09:00:00").Return(nil)
+
+	err := sessionService.RevokeSession("s1", "admin", true)
+
+	assert.Nil(t, err)
+}
+
+func TestRevokeRefreshTokenRevokesItsSession(t *testing.T) {
+	teardown := setupSessions(t)
+	defer teardown()
+	sessionService.now = time.Now
+	tokens := loginTokens(t)
+	refresh, _ := authIssuer.ParseRefreshToken(tokens.RefreshToken)
+	mockSessionRepo.EXPECT().RevokeToken(tokens.RefreshID, refresh.ExpiresAt.Time.Format(dbTSLayout)).Return(nil)
+	mockSessionRepo.EXPECT().Revoke("s1", gomock.Any()).Return(nil)
+
+	err := sessionService.RevokeToken(dto.RevokeTokenRequest{Token: tokens.RefreshToken})
+
+	assert.Nil(t, err)
+}
+
+func TestRevokeTokenOfAnotherIssuerIsRefused(t *testing.T) {
+	teardown := setupSessions(t)
+	defer teardown()
+	other := realdomain.NewHMACTokenIssuer([]byte("otherSecret"), "banking", "", time.Minute, time.Hour)
+	tokens, _ := other.Issue(realdomain.AccessClaims{Username: "arian"})
+
+	err := sessionService.RevokeToken(dto.RevokeTokenRequest{Token: tokens.AccessToken})
+
+	assert.EqualValues(t, 422, err.Code)
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jonathanwamsley/banking/calendar"
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/logger"
+)
+
+// StatementService is an interface that implements
+//
+// GetStatement: returns the statement of an account of a customer for a month that has ended, generating it
+// when the monthly job has not yet
+// GenerateStatements: generates and stores the statement of every account for a month that has ended, an account
+// only gets one statement per month
+// RunDue: generates last month's statements when they were not yet
+// mockgen -destination=mocks/service/mock_statement_service.go -package=service github.com/jonathanwamsley/banking/service StatementService
+type StatementService interface {
+	GetStatement(customerID string, accountID string, period string) (*dto.StatementResponse, *errs.AppError)
+	GenerateStatements(period string) (*dto.StatementRunResponse, *errs.AppError)
+	RunDue() *errs.AppError
+}
+
+// DefaultStatementService has methods that call dto and the domain. Months start and end in the bank's time zone.
+type DefaultStatementService struct {
+	repo      domain.StatementRepository
+	accounts  domain.AccountRepository
+	customers domain.CustomerRepository
+	balances  domain.BalanceRepository
+	jobs      domain.JobRunRepository
+	calendar  calendar.Calendar
+	now       func() time.Time
+}
+
+// NewStatementService is the entry point to the service to create a DefaultStatementService struct
+func NewStatementService(repository domain.StatementRepository, accounts domain.AccountRepository, customers domain.CustomerRepository,
+	balances domain.BalanceRepository, jobs domain.JobRunRepository, cal calendar.Calendar) DefaultStatementService {
+	return DefaultStatementService{repo: repository, accounts: accounts, customers: customers, balances: balances, jobs: jobs, calendar: cal, now: time.Now}
+}
+
+// GetStatement returns the stored statement of an account the customer owns. A statement the monthly job has
+// not generated yet is generated and stored first, so asking for it again returns the same document. A stored
+// statement that no longer matches its checksum is refused.
+func (s DefaultStatementService) GetStatement(customerID string, accountID string, period string) (*dto.StatementResponse, *errs.AppError) {
+	end, err := s.endedPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.accounts.FindBy(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.CustomerID != customerID {
+		return nil, errs.NewNotFoundError("Account not found")
+	}
+	if !account.OpenedBy(end) {
+		return nil, errs.NewValidationError("the account was not open in " + period)
+	}
+
+	stored, err := s.repo.FindStatement(accountID, period)
+	if err != nil && err.Code == http.StatusNotFound {
+		if _, err = s.generate(*account, period); err != nil {
+			return nil, err
+		}
+		stored, err = s.repo.FindStatement(accountID, period)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stored.Open()
+}
+
+// GenerateStatements stores the statement of the month of every account that needs one. Accounts that already
+// have one are left out.
+func (s DefaultStatementService) GenerateStatements(period string) (*dto.StatementRunResponse, *errs.AppError) {
+	end, err := s.endedPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.repo.StatementAccounts(period)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.StatementRunResponse{Period: period}
+	for _, a := range accounts {
+		saved, err := s.generate(a, period)
+		if err != nil {
+			logger.Error("unable to generate the " + period + " statement of account " + a.AccountID + ": " + err.Message)
+			response.Failed++
+			continue
+		}
+		if saved {
+			response.Statements++
+		}
+	}
+	if response.Failed == 0 {
+		if err = s.jobs.CompleteRun(domain.JOB_STATEMENTS, domain.FormatRunDate(end), response.Statements, s.calendar.Timestamp(s.now())); err != nil {
+			return nil, err
+		}
+	}
+	return &response, nil
+}
+
+// RunDue generates last month's statements once the month has ended, unless the job already completed them
+func (s DefaultStatementService) RunDue() *errs.AppError {
+	today := s.calendar.Today(s.now())
+	lastMonthEnd := today.AddDate(0, 0, -today.Day())
+	last, err := s.jobs.LastRun(domain.JOB_STATEMENTS)
+	if err != nil {
+		return err
+	}
+	if last >= domain.FormatRunDate(lastMonthEnd) {
+		return nil
+	}
+	response, err := s.GenerateStatements(domain.FormatFeePeriod(lastMonthEnd))
+	if err != nil {
+		return err
+	}
+	if response.Failed > 0 {
+		return errs.NewUnexpectedError("statements failed for some accounts of " + response.Period)
+	}
+	return nil
+}
+
+// generate builds the statement of an account from the balance at the end of the month before and the ledger
+// entries of the month, and stores it. false is returned when the account already had a statement of the month.
+func (s DefaultStatementService) generate(account domain.Account, period string) (bool, *errs.AppError) {
+	start, _, err := domain.ParseFeePeriod(period, s.calendar.Location())
+	if err != nil {
+		return false, err
+	}
+	customer, err := s.customers.ByID(account.CustomerID)
+	if err != nil {
+		return false, err
+	}
+	opening, err := s.balances.BalanceAsOf(account.AccountID, domain.FormatRunDate(start.AddDate(0, 0, -1)))
+	if err != nil {
+		return false, err
+	}
+	entries, err := s.repo.StatementEntries(account.AccountID, period)
+	if err != nil {
+		return false, err
+	}
+
+	statement := domain.Statement{
+		Account:     account,
+		Customer:    *customer,
+		Period:      period,
+		Opening:     opening.Balance(),
+		Entries:     entries,
+		GeneratedAt: s.calendar.Timestamp(s.now()),
+	}
+	stored, err := domain.NewStoredStatement(statement.ToDTO())
+	if err != nil {
+		return false, err
+	}
+	return s.repo.SaveStatement(stored)
+}
+
+// endedPeriod reads a month and checks it has ended, it returns its last day
+func (s DefaultStatementService) endedPeriod(period string) (time.Time, *errs.AppError) {
+	_, end, err := domain.ParseFeePeriod(period, s.calendar.Location())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !end.Before(s.calendar.Today(s.now())) {
+		return time.Time{}, errs.NewValidationError("a statement can only be generated of a month that has ended")
+	}
+	return end, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonathanwamsley/banking/calendar"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/jonathanwamsley/banking/money"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockStatementRepo *domain.MockStatementRepository
+var statementService DefaultStatementService
+
+func setupStatement(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockStatementRepo = domain.NewMockStatementRepository(ctrl)
+	mockAccountRepo = domain.NewMockAccountRepository(ctrl)
+	mockRepo = domain.NewMockCustomerRepository(ctrl)
+	mockBalanceRepo = domain.NewMockBalanceRepository(ctrl)
+	mockJobRepo = domain.NewMockJobRunRepository(ctrl)
+	statementService = NewStatementService(mockStatementRepo, mockAccountRepo, mockRepo, mockBalanceRepo, mockJobRepo, calendar.Default())
+	statementService.now = func() time.Time { return time.Date(2021, time.April, 2, 6, 0, 0, 0, time.Local) }
+	return func() {
+		defer ctrl.Finish()
+	}
+}
+
+var statementAccount = realdomain.Account{AccountID: "95470", CustomerID: "2000", OpeningDate: "2021-01-04 10:00:00", AccountType: "checking"}
+
+// expectGenerate expects the statement of March to be built and stored, and returns what was stored
+func expectGenerate(stored *realdomain.StoredStatement) {
+	mockRepo.EXPECT().ByID("2000").Return(&realdomain.Customer{ID: "2000", Name: "Steve", City: "Delhi", Zipcode: "110075"}, nil)
+	mockBalanceRepo.EXPECT().BalanceAsOf("95470", "2021-02-28").Return(&realdomain.EndOfDayBalance{
+		AccountID: "95470", Date: "2021-02-28", SnapshotBalance: money.MustParse("100.00"), Movement: money.MustParse("0.00"),
+	}, nil)
+	mockStatementRepo.EXPECT().StatementEntries("95470", "2021-03").Return([]realdomain.StatementEntry{
+		{TransactionID: "1", TransactionType: "deposit", Description: "deposit", PostedAt: "2021-03-02 10:00:00", ValueDate: "2021-03-02", Amount: money.MustParse("50.00")},
+		{TransactionID: "2", TransactionType: "fee", Description: "fee", PostedAt: "2021-03-31 23:00:00", ValueDate: "2021-03-31", Amount: money.MustParse("-5.00")},
+	}, nil)
+	mockStatementRepo.EXPECT().SaveStatement(gomock.Any()).DoAndReturn(func(s realdomain.StoredStatement) (bool, *errs.AppError) {
+		*stored = s
+		return true, nil
+	})
+}
+
+func TestGetStatementGeneratesAMissingStatementOnce(t *testing.T) {
+	teardown := setupStatement(t)
+	defer teardown()
+
+	var stored realdomain.StoredStatement
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&statementAccount, nil)
+	gomock.InOrder(
+		mockStatementRepo.EXPECT().FindStatement("95470", "2021-03").Return(nil, errs.NewNotFoundError("Statement not found")),
+		mockStatementRepo.EXPECT().FindStatement("95470", "2021-03").DoAndReturn(func(string, string) (*realdomain.StoredStatement, *errs.AppError) {
+			return &stored, nil
+		}),
+	)
+	expectGenerate(&stored)
+
+	resp, err := statementService.GetStatement("2000", "95470", "2021-03")
+	assert.Nil(t, err)
+	assert.Equal(t, "Steve", resp.Customer.Name)
+	assert.Equal(t, money.MustParse("100.00"), resp.OpeningBalance)
+	assert.Equal(t, money.MustParse("145.00"), resp.ClosingBalance)
+	assert.Equal(t, money.MustParse("5.00"), resp.FeesCharged)
+	assert.Equal(t, stored.Checksum, resp.Checksum)
+	assert.Len(t, resp.Checksum, 64)
+}
+
+func TestGetStatementRefusesATamperedStatement(t *testing.T) {
+	teardown := setupStatement(t)
+	defer teardown()
+
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&statementAccount, nil)
+	mockStatementRepo.EXPECT().FindStatement("95470", "2021-03").Return(&realdomain.StoredStatement{
+		StatementID: "1", AccountID: "95470", Period: "2021-03", Document: `{"account_id":"95470"}`, Checksum: "0000",
+	}, nil)
+
+	_, err := statementService.GetStatement("2000", "95470", "2021-03")
+	assert.EqualValues(t, 500, err.Code)
+	assert.Equal(t, "statement_checksum_mismatch", err.Reason)
+}
+
+func TestGetStatementRefusesMonthsThatHaveNotEndedAndOtherCustomers(t *testing.T) {
+	teardown := setupStatement(t)
+	defer teardown()
+
+	_, err := statementService.GetStatement("2000", "95470", "2021-04")
+	assert.Equal(t, "a statement can only be generated of a month that has ended", err.Message)
+	_, err = statementService.GetStatement("2000", "95470", "2021-3")
+	assert.EqualValues(t, 422, err.Code)
+
+	mockAccountRepo.EXPECT().FindBy("95470").Return(&statementAccount, nil).Times(2)
+	_, err = statementService.GetStatement("2001", "95470", "2021-03")
+	assert.EqualValues(t, 404, err.Code)
+	_, err = statementService.GetStatement("2000", "95470", "2020-12")
+	assert.Equal(t, "the account was not open in 2020-12", err.Message)
+}
+
+func TestStatementsRunDueGeneratesLastMonthOnce(t *testing.T) {
+	teardown := setupStatement(t)
+	defer teardown()
+
+	var stored realdomain.StoredStatement
+	gomock.InOrder(
+		mockJobRepo.EXPECT().LastRun(realdomain.JOB_STATEMENTS).Return("2021-02-28", nil),
+		mockJobRepo.EXPECT().LastRun(realdomain.JOB_STATEMENTS).Return("2021-03-31", nil),
+	)
+	mockStatementRepo.EXPECT().StatementAccounts("2021-03").Return([]realdomain.Account{statementAccount}, nil)
+	expectGenerate(&stored)
+	mockJobRepo.EXPECT().CompleteRun(realdomain.JOB_STATEMENTS, "2021-03-31", 1, gomock.Any()).Return(nil)
+
+	assert.Nil(t, statementService.RunDue())
+	assert.Equal(t, "2021-03", stored.Period)
+	assert.Nil(t, statementService.RunDue())
+}
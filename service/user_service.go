@@ -0,0 +1,128 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+)
+
+// UserService is an interface that implements
+//
+// GetAllUsers: returns every user without their password hashes
+// GetUser: returns a user by username
+// CreateUser: checks the password policy and stores a user with a bcrypt hash of the password
+// UpdateUser: changes the role and customer of a user, and the password when one is sent
+// DeleteUser: removes a user
+//
+// A new password and a deleted user revoke every session of the user, so nobody stays logged in with the old password.
+// UnlockUser: clears the failed logins of a user that was locked out
+type UserService interface {
+	GetAllUsers() ([]dto.UserResponse, *errs.AppError)
+	GetUser(username string) (*dto.UserResponse, *errs.AppError)
+	CreateUser(dto.CreateUserRequest) (*dto.UserResponse, *errs.AppError)
+	UpdateUser(username string, req dto.UpdateUserRequest) (*dto.UserResponse, *errs.AppError)
+	DeleteUser(username string) *errs.AppError
+	UnlockUser(username string) (*dto.UserResponse, *errs.AppError)
+}
+
+// DefaultUserService has methods that call dto and the domain
+type DefaultUserService struct {
+	repo     domain.UserRepository
+	sessions domain.SessionRepository
+}
+
+// NewUserService is the entry point to the service to create a DefaultUserService struct
+func NewUserService(repository domain.UserRepository, sessions domain.SessionRepository) DefaultUserService {
+	return DefaultUserService{repo: repository, sessions: sessions}
+}
+
+// GetAllUsers returns every user
+func (s DefaultUserService) GetAllUsers() ([]dto.UserResponse, *errs.AppError) {
+	users, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.UserResponse, 0)
+	for _, u := range users {
+		response = append(response, u.ToDTO())
+	}
+	return response, nil
+}
+
+// GetUser returns a user by username
+func (s DefaultUserService) GetUser(username string) (*dto.UserResponse, *errs.AppError) {
+	u, err := s.repo.ByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	response := u.ToDTO()
+	return &response, nil
+}
+
+// CreateUser validates the request and stores the new user
+func (s DefaultUserService) CreateUser(req dto.CreateUserRequest) (*dto.UserResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	u, err := domain.NewUser(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.Save(*u); err != nil {
+		return nil, err
+	}
+	response := u.ToDTO()
+	return &response, nil
+}
+
+// UpdateUser replaces the role and customer of a user, and hashes a new password when one is sent
+func (s DefaultUserService) UpdateUser(username string, req dto.UpdateUserRequest) (*dto.UserResponse, *errs.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	current, err := s.repo.ByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := current.Updated(req)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.Update(*updated); err != nil {
+		return nil, err
+	}
+	if req.Password != "" {
+		if err = s.sessions.RevokeAll(username, time.Now().Format(dbTSLayout)); err != nil {
+			return nil, err
+		}
+	}
+	response := updated.ToDTO()
+	return &response, nil
+}
+
+// DeleteUser revokes the sessions of a user and removes it. The revoked sessions are kept until they expire,
+// so the access tokens they issued stay refused.
+func (s DefaultUserService) DeleteUser(username string) *errs.AppError {
+	if err := s.sessions.RevokeAll(username, time.Now().Format(dbTSLayout)); err != nil {
+		return err
+	}
+	return s.repo.Delete(username)
+}
+
+// UnlockUser clears the failed logins and the lock of a user
+func (s DefaultUserService) UnlockUser(username string) (*dto.UserResponse, *errs.AppError) {
+	u, err := s.repo.ByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.ResetFailedLogins(username); err != nil {
+		return nil, err
+	}
+	u.FailedLogins = 0
+	u.LockedUntil.Valid = false
+	u.LockedUntil.String = ""
+	response := u.ToDTO()
+	return &response, nil
+}
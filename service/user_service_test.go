@@ -0,0 +1,117 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	realdomain "github.com/jonathanwamsley/banking/domain"
+	"github.com/jonathanwamsley/banking/dto"
+	"github.com/jonathanwamsley/banking/errs"
+	"github.com/jonathanwamsley/banking/mocks/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var userService UserService
+
+func setupUser(t *testing.T) func() {
+	ctrl := gomock.NewController(t)
+	mockUserRepo = domain.NewMockUserRepository(ctrl)
+	mockSessionRepo = domain.NewMockSessionRepository(ctrl)
+	userService = NewUserService(mockUserRepo, mockSessionRepo)
+	return func() {
+		userService = nil
+		defer ctrl.Finish()
+	}
+}
+
+func TestCreateUserHashesPassword(t *testing.T) {
+	teardown := setupUser(t)
+	defer teardown()
+	var saved realdomain.User
+	mockUserRepo.EXPECT().Save(gomock.Any()).DoAndReturn(func(u realdomain.User) *errs.AppError {
+		saved = u
+		return nil
+	})
+
+	resp, err := userService.CreateUser(dto.CreateUserRequest{Username: "arian", Password: "Password1", Role: "customer", CustomerID: "2001"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "2001", resp.CustomerID)
+	assert.NotEqual(t, "Password1", saved.PasswordHash)
+	assert.True(t, saved.CheckPassword("Password1"))
+}
+
+func TestCreateUserRejectsWeakPasswordAndBadRoles(t *testing.T) {
+	service := NewUserService(nil, nil)
+
+	_, err := service.CreateUser(dto.CreateUserRequest{Username: "arian", Password: "password1", Role: "customer", CustomerID: "2001"})
+	assert.EqualValues(t, "weak_password", err.Reason)
+
+	_, err = service.CreateUser(dto.CreateUserRequest{Username: "arian", Password: "Password1", Role: "customer"})
+	assert.EqualValues(t, "a customer user needs a customer_id", err.Message)
+
+	_, err = service.CreateUser(dto.CreateUserRequest{Username: "teller1", Password: "Password1", Role: "teller", CustomerID: "2001"})
+	assert.EqualValues(t, "only a customer user can have a customer_id", err.Message)
+
+	_, err = service.CreateUser(dto.CreateUserRequest{Username: "root", Password: "Password1", Role: "root"})
+	assert.EqualValues(t, 422, err.Code)
+}
+
+func TestUpdateUserKeepsPasswordWhenNoneIsSent(t *testing.T) {
+	teardown := setupUser(t)
+	defer teardown()
+	current := &realdomain.User{Username: "arian", PasswordHash: "$2a$10$hash", Role: "customer", CustomerID: sql.NullString{String: "2001", Valid: true}}
+	mockUserRepo.EXPECT().ByUsername("arian").Return(current, nil)
+	mockUserRepo.EXPECT().Update(realdomain.User{Username: "arian", PasswordHash: "$2a$10$hash", Role: "teller"}).Return(nil)
+
+	resp, err := userService.UpdateUser("arian", dto.UpdateUserRequest{Role: "teller"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "teller", resp.Role)
+	assert.Equal(t, "", resp.CustomerID)
+}
+
+func TestUpdateUserWithNewPasswordRevokesSessions(t *testing.T) {
+	teardown := setupUser(t)
+	defer teardown()
+	mockUserRepo.EXPECT().ByUsername("arian").Return(&realdomain.User{Username: "arian", PasswordHash: "$2a$10$hash", Role: "teller"}, nil)
+	mockUserRepo.EXPECT().Update(gomock.Any()).Return(nil)
+	mockSessionRepo.EXPECT().RevokeAll("arian", gomock.Any()).Return(nil)
+
+	_, err := userService.UpdateUser("arian", dto.UpdateUserRequest{Role: "teller", Password: "Password2"})
+
+	assert.Nil(t, err)
+}
+
+func TestDeleteUserRevokesSessionsFirst(t *testing.T) {
+	teardown := setupUser(t)
+	defer teardown()
+	gomock.InOrder(
+		mockSessionRepo.EXPECT().RevokeAll("arian", gomock.Any()).Return(nil),
+		mockUserRepo.EXPECT().Delete("arian").Return(nil),
+	)
+
+	assert.Nil(t, userService.DeleteUser("arian"))
+}
+
+func TestDeleteUserIsNotDeletedWhenSessionsCannotBeRevoked(t *testing.T) {
+	teardown := setupUser(t)
+	defer teardown()
+	mockSessionRepo.EXPECT().RevokeAll("arian", gomock.Any()).Return(errs.NewUnexpectedError("Unexpected database error"))
+
+	assert.EqualValues(t, 500, userService.DeleteUser("arian").Code)
+}
+
+func TestUnlockUserClearsLock(t *testing.T) {
+	teardown := setupUser(t)
+	defer teardown()
+	locked := &realdomain.User{Username: "arian", Role: "customer", FailedLogins: 0, LockedUntil: sql.NullString{String: "2021-03-10 09:15:00", Valid: true}}
+	mockUserRepo.EXPECT().ByUsername("arian").Return(locked, nil)
+	mockUserRepo.EXPECT().ResetFailedLogins("arian").Return(nil)
+
+	resp, err := userService.UnlockUser("arian")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", resp.LockedUntil)
+}